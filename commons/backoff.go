@@ -0,0 +1,14 @@
+package commons
+
+import "time"
+
+// Backoff returns the delay before retrying after the given number of failed
+// attempts: base after the first one, doubling with each further one, and at
+// most max.
+func Backoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
@@ -1,38 +1,44 @@
 package commons
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // DBTask represents the database model for tasks
 type DBTask struct {
-	ID          string    `db:"id" json:"id"`
-	Title       string    `db:"title" json:"title"`
-	Description string    `db:"description" json:"description"`
-	Status      string    `db:"status" json:"status"`
-	Priority    int       `db:"priority" json:"priority"`
-	DueDate     time.Time `db:"due_date" json:"due_date"`
-	CreatorID   string    `db:"creator_id" json:"creator_id"`
-	AssigneeID  *string   `db:"assignee_id" json:"assignee_id,omitempty"`
-	EmailSent   bool      `db:"email_sent" json:"email_sent"`
-	InAppSent   bool      `db:"in_app_sent" json:"in_app_sent"`
-	Deleted     bool      `db:"deleted" json:"deleted"`
-	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
-	Events      []TaskSystemEvent `db:"events" json:"events,omitempty"`
+	ID           string            `db:"id" json:"id"`
+	Title        string            `db:"title" json:"title"`
+	Description  string            `db:"description" json:"description"`
+	Status       string            `db:"status" json:"status"`
+	Priority     int               `db:"priority" json:"priority"`
+	DueDate      time.Time         `db:"due_date" json:"due_date"`
+	CreatorID    string            `db:"creator_id" json:"creator_id"`
+	AssigneeID   *string           `db:"assignee_id" json:"assignee_id,omitempty"`
+	ProjectID    *string           `db:"project_id" json:"project_id,omitempty"`
+	SeriesID     *string           `db:"series_id" json:"series_id,omitempty"`
+	OccurrenceAt *time.Time        `db:"occurrence_at" json:"occurrence_at,omitempty"`
+	EmailSent    bool              `db:"email_sent" json:"email_sent"`
+	InAppSent    bool              `db:"in_app_sent" json:"in_app_sent"`
+	Deleted      bool              `db:"deleted" json:"deleted"`
+	DeletedAt    *time.Time        `db:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time         `db:"updated_at" json:"updated_at"`
+	Events       []TaskSystemEvent `db:"events" json:"events,omitempty"`
 }
 
 // DBUser represents the database model for users
 type DBUser struct {
-	ID             string    `db:"id" json:"id"`
-	Handle         string    `db:"handle" json:"handle"`
-	Email          string    `db:"email" json:"email"`
-	HashedPassword string    `db:"password_hash" json:"-"`
-	Salt           string    `db:"salt" json:"-"`
-	Status         string    `db:"status" json:"status"`
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+	ID              string     `db:"id" json:"id"`
+	Handle          string     `db:"handle" json:"handle"`
+	Email           string     `db:"email" json:"email"`
+	HashedPassword  string     `db:"password_hash" json:"-"`
+	Salt            string     `db:"salt" json:"-"`
+	Status          string     `db:"status" json:"status"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+	PhoneNumber     *string    `db:"phone_number" json:"phone_number,omitempty"`
+	PhoneVerifiedAt *time.Time `db:"phone_verified_at" json:"phone_verified_at,omitempty"`
 }
 
 // DBTaskSystemEvent represents the database model for task system events
@@ -58,6 +64,23 @@ type DBPasswordResetToken struct {
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
+// DBRefreshToken represents the database model for refresh tokens
+type DBRefreshToken struct {
+	ID              string     `db:"id" json:"id"`
+	UserID          string     `db:"user_id" json:"user_id"`
+	FamilyID        string     `db:"family_id" json:"family_id"`
+	TokenHash       string     `db:"token_hash" json:"-"`
+	AccessTokenID   *string    `db:"access_token_id" json:"access_token_id"`
+	AccessExpiresAt *time.Time `db:"access_expires_at" json:"access_expires_at"`
+	UserAgent       string     `db:"user_agent" json:"user_agent"`
+	IPAddress       string     `db:"ip_address" json:"ip_address"`
+	ExpiresAt       time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt       *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokedReason   *string    `db:"revoked_reason" json:"revoked_reason,omitempty"`
+	ReplacedBy      *string    `db:"replaced_by" json:"replaced_by,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
 // DBInAppNotification represents the database model for in-app notifications
 type DBInAppNotification struct {
 	ID          string     `db:"id" json:"id"`
@@ -72,24 +95,71 @@ type DBInAppNotification struct {
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 }
 
+// DBProject represents the database model for projects
+type DBProject struct {
+	ID          string     `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Description string     `db:"description" json:"description"`
+	CreatorID   string     `db:"creator_id" json:"creator_id"`
+	Deleted     bool       `db:"deleted" json:"deleted"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// DBProjectMember represents the database model for project memberships
+type DBProjectMember struct {
+	ProjectID string    `db:"project_id" json:"project_id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	Role      string    `db:"role" json:"role"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// DBTaskComment represents the database model for task comments
+type DBTaskComment struct {
+	ID        string     `db:"id" json:"id"`
+	TaskID    string     `db:"task_id" json:"task_id"`
+	AuthorID  string     `db:"author_id" json:"author_id"`
+	ParentID  *string    `db:"parent_id" json:"parent_id,omitempty"`
+	Body      string     `db:"body" json:"body"`
+	Edited    bool       `db:"edited" json:"edited"`
+	Deleted   bool       `db:"deleted" json:"deleted"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// DBTaskCommentEdit represents the database model for the edit history of a comment
+type DBTaskCommentEdit struct {
+	ID           string    `db:"id" json:"id"`
+	CommentID    string    `db:"comment_id" json:"comment_id"`
+	PreviousBody string    `db:"previous_body" json:"previous_body"`
+	EditedBy     string    `db:"edited_by" json:"edited_by"`
+	EditedAt     time.Time `db:"edited_at" json:"edited_at"`
+}
+
 // ToTask converts a DBTask to a domain Task
 func (dt *DBTask) ToTask() Task {
 	task := Task{
-		ID:          dt.ID,
-		Title:       dt.Title,
-		Description: dt.Description,
-		Status:      dt.Status,
-		Priority:    dt.Priority,
-		DueDate:     dt.DueDate,
-		CreatorID:   dt.CreatorID,
-		AssigneeID:  dt.AssigneeID,
-		EmailSent:   dt.EmailSent,
-		InAppSent:   dt.InAppSent,
-		Deleted:     dt.Deleted,
-		DeletedAt:   dt.DeletedAt,
-		CreatedAt:   dt.CreatedAt,
-		UpdatedAt:   dt.UpdatedAt,
-		Events:      dt.Events,
+		ID:           dt.ID,
+		Title:        dt.Title,
+		Description:  dt.Description,
+		Status:       dt.Status,
+		Priority:     dt.Priority,
+		DueDate:      dt.DueDate,
+		CreatorID:    dt.CreatorID,
+		AssigneeID:   dt.AssigneeID,
+		ProjectID:    dt.ProjectID,
+		SeriesID:     dt.SeriesID,
+		OccurrenceAt: dt.OccurrenceAt,
+		EmailSent:    dt.EmailSent,
+		InAppSent:    dt.InAppSent,
+		Deleted:      dt.Deleted,
+		DeletedAt:    dt.DeletedAt,
+		CreatedAt:    dt.CreatedAt,
+		UpdatedAt:    dt.UpdatedAt,
+		Events:       dt.Events,
 	}
 	return task
 }
@@ -104,6 +174,9 @@ func (dt *DBTask) FromTask(t Task) {
 	dt.DueDate = t.DueDate
 	dt.CreatorID = t.CreatorID
 	dt.AssigneeID = t.AssigneeID
+	dt.ProjectID = t.ProjectID
+	dt.SeriesID = t.SeriesID
+	dt.OccurrenceAt = t.OccurrenceAt
 	dt.EmailSent = t.EmailSent
 	dt.InAppSent = t.InAppSent
 	dt.Deleted = t.Deleted
@@ -115,16 +188,21 @@ func (dt *DBTask) FromTask(t Task) {
 
 // ToUser converts a DBUser to a domain User
 func (du *DBUser) ToUser() User {
-	return User{
-		ID:             du.ID,
-		Handle:         du.Handle,
-		Email:          du.Email,
-		HashedPassword: du.HashedPassword,
-		Salt:           du.Salt,
-		Status:         du.Status,
-		CreatedAt:      du.CreatedAt,
-		UpdatedAt:      du.UpdatedAt,
+	user := User{
+		ID:              du.ID,
+		Handle:          du.Handle,
+		Email:           du.Email,
+		HashedPassword:  du.HashedPassword,
+		Salt:            du.Salt,
+		Status:          du.Status,
+		CreatedAt:       du.CreatedAt,
+		UpdatedAt:       du.UpdatedAt,
+		PhoneVerifiedAt: du.PhoneVerifiedAt,
 	}
+	if du.PhoneNumber != nil {
+		user.PhoneNumber = *du.PhoneNumber
+	}
+	return user
 }
 
 // FromUser converts a domain User to a DBUser
@@ -137,6 +215,10 @@ func (du *DBUser) FromUser(u User) {
 	du.Status = u.Status
 	du.CreatedAt = u.CreatedAt
 	du.UpdatedAt = u.UpdatedAt
+	if u.PhoneNumber != "" {
+		du.PhoneNumber = &u.PhoneNumber
+	}
+	du.PhoneVerifiedAt = u.PhoneVerifiedAt
 }
 
 // ToTaskSystemEvent converts a DBTaskSystemEvent to a domain TaskSystemEvent
@@ -218,3 +300,270 @@ func (d *DBInAppNotification) FromInAppNotification(n InAppNotification) {
 	d.UpdatedAt = n.UpdatedAt
 	d.CreatedAt = n.CreatedAt
 }
+
+// ToTaskComment converts a DBTaskComment to a domain TaskComment
+func (d *DBTaskComment) ToTaskComment() TaskComment {
+	return TaskComment{
+		ID:        d.ID,
+		TaskID:    d.TaskID,
+		AuthorID:  d.AuthorID,
+		ParentID:  d.ParentID,
+		Body:      d.Body,
+		Edited:    d.Edited,
+		Deleted:   d.Deleted,
+		DeletedAt: d.DeletedAt,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+// FromTaskComment converts a domain TaskComment to a DBTaskComment
+func (d *DBTaskComment) FromTaskComment(c TaskComment) {
+	d.ID = c.ID
+	d.TaskID = c.TaskID
+	d.AuthorID = c.AuthorID
+	d.ParentID = c.ParentID
+	d.Body = c.Body
+	d.Edited = c.Edited
+	d.Deleted = c.Deleted
+	d.DeletedAt = c.DeletedAt
+	d.CreatedAt = c.CreatedAt
+	d.UpdatedAt = c.UpdatedAt
+}
+
+// ToTaskCommentEdit converts a DBTaskCommentEdit to a domain TaskCommentEdit
+func (d *DBTaskCommentEdit) ToTaskCommentEdit() TaskCommentEdit {
+	return TaskCommentEdit{
+		ID:           d.ID,
+		CommentID:    d.CommentID,
+		PreviousBody: d.PreviousBody,
+		EditedBy:     d.EditedBy,
+		EditedAt:     d.EditedAt,
+	}
+}
+
+// ToProject converts a DBProject to a domain Project
+func (dp *DBProject) ToProject() Project {
+	return Project{
+		ID:          dp.ID,
+		Name:        dp.Name,
+		Description: dp.Description,
+		CreatorID:   dp.CreatorID,
+		Deleted:     dp.Deleted,
+		DeletedAt:   dp.DeletedAt,
+		CreatedAt:   dp.CreatedAt,
+		UpdatedAt:   dp.UpdatedAt,
+	}
+}
+
+// FromProject converts a domain Project to a DBProject
+func (dp *DBProject) FromProject(p Project) {
+	dp.ID = p.ID
+	dp.Name = p.Name
+	dp.Description = p.Description
+	dp.CreatorID = p.CreatorID
+	dp.Deleted = p.Deleted
+	dp.DeletedAt = p.DeletedAt
+	dp.CreatedAt = p.CreatedAt
+	dp.UpdatedAt = p.UpdatedAt
+}
+
+// ToProjectMember converts a DBProjectMember to a domain ProjectMember
+func (dm *DBProjectMember) ToProjectMember() ProjectMember {
+	return ProjectMember{
+		ProjectID: dm.ProjectID,
+		UserID:    dm.UserID,
+		Role:      dm.Role,
+		CreatedAt: dm.CreatedAt,
+		UpdatedAt: dm.UpdatedAt,
+	}
+}
+
+// ToRefreshToken converts a DBRefreshToken to a domain RefreshToken
+func (dr *DBRefreshToken) ToRefreshToken() RefreshToken {
+	token := RefreshToken{
+		ID:         dr.ID,
+		UserID:     dr.UserID,
+		FamilyID:   dr.FamilyID,
+		TokenHash:  dr.TokenHash,
+		UserAgent:  dr.UserAgent,
+		IPAddress:  dr.IPAddress,
+		ExpiresAt:  dr.ExpiresAt,
+		RevokedAt:  dr.RevokedAt,
+		ReplacedBy: dr.ReplacedBy,
+		CreatedAt:  dr.CreatedAt,
+	}
+	if dr.AccessTokenID != nil {
+		token.AccessTokenID = *dr.AccessTokenID
+	}
+	if dr.AccessExpiresAt != nil {
+		token.AccessExpiresAt = *dr.AccessExpiresAt
+	}
+	if dr.RevokedReason != nil {
+		token.RevokedReason = *dr.RevokedReason
+	}
+	return token
+}
+
+// DBEmail represents the database model for emails
+type DBEmail struct {
+	ID                string     `db:"id" json:"id"`
+	TaskID            *string    `db:"task_id" json:"task_id,omitempty"`
+	CorrelationID     string     `db:"correlation_id" json:"correlation_id"`
+	RecipientID       *string    `db:"recipient_id" json:"recipient_id,omitempty"`
+	Recipient         string     `db:"recipient" json:"recipient"`
+	Template          string     `db:"template" json:"template"`
+	Payload           string     `db:"payload" json:"payload"`
+	Status            string     `db:"status" json:"status"`
+	Attempts          int        `db:"attempts" json:"attempts"`
+	LastError         *string    `db:"last_error" json:"last_error,omitempty"`
+	ProviderMessageID *string    `db:"provider_message_id" json:"provider_message_id,omitempty"`
+	SentAt            *time.Time `db:"sent_at" json:"sent_at,omitempty"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// ToEmail converts a DBEmail to a domain Email
+func (de *DBEmail) ToEmail() Email {
+	email := Email{
+		ID:            de.ID,
+		TaskID:        de.TaskID,
+		CorrelationID: de.CorrelationID,
+		RecipientID:   de.RecipientID,
+		Recipient:     de.Recipient,
+		Template:      de.Template,
+		Payload:       json.RawMessage(de.Payload),
+		Status:        de.Status,
+		Attempts:      de.Attempts,
+		SentAt:        de.SentAt,
+		CreatedAt:     de.CreatedAt,
+		UpdatedAt:     de.UpdatedAt,
+	}
+	if de.LastError != nil {
+		email.LastError = *de.LastError
+	}
+	if de.ProviderMessageID != nil {
+		email.ProviderMessageID = *de.ProviderMessageID
+	}
+	return email
+}
+
+// DBOutboxEvent represents the database model for outbox events
+type DBOutboxEvent struct {
+	ID            string     `db:"id" json:"id"`
+	AggregateType string     `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   string     `db:"aggregate_id" json:"aggregate_id"`
+	CorrelationID string     `db:"correlation_id" json:"correlation_id"`
+	EventType     string     `db:"event_type" json:"event_type"`
+	Payload       string     `db:"payload" json:"payload"`
+	TraceContext  string     `db:"trace_context" json:"trace_context"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	LastError     *string    `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	PublishedAt   *time.Time `db:"published_at" json:"published_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ToOutboxEvent converts a DBOutboxEvent to a domain OutboxEvent
+func (de *DBOutboxEvent) ToOutboxEvent() OutboxEvent {
+	event := OutboxEvent{
+		ID:            de.ID,
+		AggregateType: de.AggregateType,
+		AggregateID:   de.AggregateID,
+		CorrelationID: de.CorrelationID,
+		EventType:     de.EventType,
+		Payload:       json.RawMessage(de.Payload),
+		Status:        de.Status,
+		Attempts:      de.Attempts,
+		NextAttemptAt: de.NextAttemptAt,
+		PublishedAt:   de.PublishedAt,
+		CreatedAt:     de.CreatedAt,
+	}
+	if de.LastError != nil {
+		event.LastError = *de.LastError
+	}
+	// A trace context that cannot be read only loses the link to the trace.
+	_ = json.Unmarshal([]byte(de.TraceContext), &event.TraceContext)
+	return event
+}
+
+// DBTaskSeries represents the database model for recurring task series
+type DBTaskSeries struct {
+	ID               string     `db:"id" json:"id"`
+	Rule             string     `db:"rrule" json:"rrule"`
+	Timezone         string     `db:"timezone" json:"timezone"`
+	StartsAt         time.Time  `db:"starts_at" json:"starts_at"`
+	LeadTimeSeconds  int        `db:"lead_time_seconds" json:"lead_time_seconds"`
+	Title            string     `db:"title" json:"title"`
+	Description      *string    `db:"description" json:"description"`
+	Priority         int        `db:"priority" json:"priority"`
+	CreatorID        string     `db:"creator_id" json:"creator_id"`
+	AssigneeID       *string    `db:"assignee_id" json:"assignee_id,omitempty"`
+	ProjectID        *string    `db:"project_id" json:"project_id,omitempty"`
+	OccurrenceCount  int        `db:"occurrence_count" json:"occurrence_count"`
+	NextOccurrenceAt *time.Time `db:"next_occurrence_at" json:"next_occurrence_at,omitempty"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// ToTaskSeries converts a DBTaskSeries to a domain TaskSeries
+func (ds *DBTaskSeries) ToTaskSeries() TaskSeries {
+	series := TaskSeries{
+		ID:               ds.ID,
+		Rule:             ds.Rule,
+		Timezone:         ds.Timezone,
+		StartsAt:         ds.StartsAt,
+		LeadTime:         time.Duration(ds.LeadTimeSeconds) * time.Second,
+		Title:            ds.Title,
+		Priority:         ds.Priority,
+		CreatorID:        ds.CreatorID,
+		AssigneeID:       ds.AssigneeID,
+		ProjectID:        ds.ProjectID,
+		OccurrenceCount:  ds.OccurrenceCount,
+		NextOccurrenceAt: ds.NextOccurrenceAt,
+		CreatedAt:        ds.CreatedAt,
+		UpdatedAt:        ds.UpdatedAt,
+	}
+	if ds.Description != nil {
+		series.Description = *ds.Description
+	}
+	return series
+}
+
+// DBWebhookDelivery represents the database model for webhook deliveries
+type DBWebhookDelivery struct {
+	ID             string     `db:"id" json:"id"`
+	WebhookID      string     `db:"webhook_id" json:"webhook_id"`
+	EventID        string     `db:"event_id" json:"event_id"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	Payload        string     `db:"payload" json:"payload"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	ResponseStatus *int       `db:"response_status" json:"response_status,omitempty"`
+	LastError      *string    `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ToWebhookDelivery converts a DBWebhookDelivery to a domain WebhookDelivery
+func (dd *DBWebhookDelivery) ToWebhookDelivery() WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:             dd.ID,
+		WebhookID:      dd.WebhookID,
+		EventID:        dd.EventID,
+		EventType:      dd.EventType,
+		Payload:        json.RawMessage(dd.Payload),
+		Status:         dd.Status,
+		Attempts:       dd.Attempts,
+		ResponseStatus: dd.ResponseStatus,
+		NextAttemptAt:  dd.NextAttemptAt,
+		DeliveredAt:    dd.DeliveredAt,
+		CreatedAt:      dd.CreatedAt,
+	}
+	if dd.LastError != nil {
+		delivery.LastError = *dd.LastError
+	}
+	return delivery
+}
@@ -28,4 +28,12 @@ var (
 	ErrEmailTaken = NewError("EMAIL_TAKEN", "Email already taken")
 
 	ErrInvalidCredentials = NewError("INVALID_CREDENTIALS", "Invalid credentials")
+
+	ErrTokenReused = NewError("TOKEN_REUSED", "Refresh token was already used")
+
+	ErrInvalidRecurrence = NewError("INVALID_RECURRENCE", "Invalid recurrence rule")
+
+	ErrInvalidVerificationCode = NewError("INVALID_VERIFICATION_CODE", "Invalid or expired verification code")
+
+	ErrWebhookURLNotAllowed = NewError("WEBHOOK_URL_NOT_ALLOWED", "Webhook URL does not resolve to a public address")
 )
@@ -1,65 +1,216 @@
 package commons
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Logger writes leveled, structured log records. Arguments after the message
+// are key/value pairs, as in log/slog. The Context variants also record the
+// request ID, correlation ID and user ID stored in ctx (see WithRequestID,
+// WithCorrelationID and WithUserID) and the current trace and span IDs.
 type Logger interface {
-	Printf(format string, v ...interface{})
-	Error(v ...interface{})
-	Errorf(format string, v ...interface{})
-	Info(v ...interface{})
-	Infof(format string, v ...interface{})
-	Debug(v ...interface{})
-	Debugf(format string, v ...interface{})
-	Warn(v ...interface{})
-	Warnf(format string, v ...interface{})
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+	// With returns a logger that adds args to every record.
+	With(args ...any) Logger
 }
 
+// Values of LOG_FORMAT.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logger is the logger of the repositories and database setup of this
+// package.
+var logger = DefaultLogger()
+
 type AppLogger struct {
-	logger *log.Logger
+	// logger is nil for the logger returned by DefaultLogger, which uses
+	// whatever slog.Default is when it logs.
+	logger *slog.Logger
+}
+
+// NewLogger returns a logger writing to stdout whose records carry the name
+// of service. The level (debug, info, warn or error) comes from LOG_LEVEL,
+// info by default, and the format (text or json) from LOG_FORMAT, text by
+// default.
+func NewLogger(service string) *AppLogger {
+	return NewLoggerWithOutput(os.Stdout, service)
 }
 
-func NewLogger(prefix string) *AppLogger {
-	return &AppLogger{
-		logger: log.New(os.Stdout, prefix, log.LstdFlags|log.Lshortfile),
+// NewLoggerWithOutput is NewLogger writing to w.
+func NewLoggerWithOutput(w io.Writer, service string) *AppLogger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(GetEnv("LOG_LEVEL", "info"))); err != nil {
+		level = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       level,
+		ReplaceAttr: shortenSource,
+	}
+
+	var handler slog.Handler
+	if strings.ToLower(GetEnv("LOG_FORMAT", LogFormatText)) == LogFormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	logger := slog.New(contextHandler{handler})
+	if service != "" {
+		logger = logger.With("service", service)
 	}
+	return &AppLogger{logger: logger}
+}
+
+// SetDefaultLogger makes l the logger of DefaultLogger and of the log and
+// log/slog packages.
+func SetDefaultLogger(l *AppLogger) {
+	slog.SetDefault(l.current())
+}
+
+// DefaultLogger returns the logger set by SetDefaultLogger, for code without
+// a logger of its own such as repositories. It may be stored before
+// SetDefaultLogger is called.
+func DefaultLogger() Logger {
+	return &AppLogger{}
+}
+
+func (l *AppLogger) current() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
+func (l *AppLogger) Debug(msg string, args ...any) {
+	l.log(context.Background(), slog.LevelDebug, msg, args)
+}
+
+func (l *AppLogger) Info(msg string, args ...any) {
+	l.log(context.Background(), slog.LevelInfo, msg, args)
 }
 
-func (l *AppLogger) Printf(format string, v ...interface{}) {
-	l.logger.Printf(format, v...)
+func (l *AppLogger) Warn(msg string, args ...any) {
+	l.log(context.Background(), slog.LevelWarn, msg, args)
 }
 
-func (l *AppLogger) Error(v ...interface{}) {
-	l.logger.Printf("[ERROR] %s", fmt.Sprint(v...))
+func (l *AppLogger) Error(msg string, args ...any) {
+	l.log(context.Background(), slog.LevelError, msg, args)
 }
 
-func (l *AppLogger) Errorf(format string, v ...interface{}) {
-	l.logger.Printf("[ERROR] "+format, v...)
+func (l *AppLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelDebug, msg, args)
 }
 
-func (l *AppLogger) Info(v ...interface{}) {
-	l.logger.Printf("[INFO] %s", fmt.Sprint(v...))
+func (l *AppLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelInfo, msg, args)
 }
 
-func (l *AppLogger) Infof(format string, v ...interface{}) {
-	l.logger.Printf("[INFO] "+format, v...)
+func (l *AppLogger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelWarn, msg, args)
 }
 
-func (l *AppLogger) Debug(v ...interface{}) {
-	l.logger.Printf("[DEBUG] %s", fmt.Sprint(v...))
+func (l *AppLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelError, msg, args)
 }
 
-func (l *AppLogger) Debugf(format string, v ...interface{}) {
-	l.logger.Printf("[DEBUG] "+format, v...)
+func (l *AppLogger) With(args ...any) Logger {
+	return &AppLogger{logger: l.current().With(args...)}
+}
+
+// log records the caller of the Logger method as the source, rather than
+// this file.
+func (l *AppLogger) log(ctx context.Context, level slog.Level, msg string, args []any) {
+	logger := l.current()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	record.Add(args...)
+	_ = logger.Handler().Handle(ctx, record)
+}
+
+// shortenSource logs the source of records as file:line.
+func shortenSource(_ []string, attr slog.Attr) slog.Attr {
+	if source, ok := attr.Value.Any().(*slog.Source); ok && attr.Key == slog.SourceKey {
+		attr.Value = slog.StringValue(filepath.Base(source.File) + ":" + strconv.Itoa(source.Line))
+	}
+	return attr
+}
+
+type logContextKey string
+
+const (
+	requestIDLogKey     logContextKey = "request_id"
+	correlationIDLogKey logContextKey = "correlation_id"
+	userIDLogKey        logContextKey = "user_id"
+)
+
+// WithRequestID returns ctx with the ID of the request being served, for the
+// log records written with it.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDLogKey, requestID)
+}
+
+// WithCorrelationID returns ctx with the correlation ID of the task system
+// events being worked on, for the log records written with it.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDLogKey, correlationID)
+}
+
+// WithUserID returns ctx with the ID of the user acting, for the log records
+// written with it.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDLogKey, userID)
+}
+
+// contextHandler adds the IDs stored in the context of a record to it.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	for _, key := range []logContextKey{requestIDLogKey, correlationIDLogKey, userIDLogKey} {
+		if value, ok := ctx.Value(key).(string); ok && value != "" {
+			record.AddAttrs(slog.String(string(key), value))
+		}
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
 }
 
-func (l *AppLogger) Warn(v ...interface{}) {
-	l.logger.Printf("[WARN] %s", fmt.Sprint(v...))
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
 }
 
-func (l *AppLogger) Warnf(format string, v ...interface{}) {
-	l.logger.Printf("[WARN] "+format, v...)
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
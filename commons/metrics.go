@@ -0,0 +1,45 @@
+package commons
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler serves the metrics of the service, Go runtime and process
+// metrics included, in the Prometheus text format.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// StartMetricsServer serves MetricsHandler at /metrics on addr, for services
+// without an HTTP server of their own. The caller shuts the server down.
+func StartMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		logger.Info("Serving metrics", "address", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to serve metrics", "error", err)
+		}
+	}()
+
+	return server
+}
+
+// registerDBStats exports the connection pool stats of db (open, in use and
+// idle connections, waits) as go_sql_* metrics labeled with the database
+// name.
+func registerDBStats(db *sql.DB) {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, GetEnv("DB_NAME", "tasks")))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if err != nil && !errors.As(err, &alreadyRegistered) {
+		logger.Error("Failed to register database metrics", "error", err)
+	}
+}
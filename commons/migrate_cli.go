@@ -0,0 +1,87 @@
+package commons
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `usage: <service> migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [steps]    roll back the last applied migration(s), default 1
+  status          list migrations and whether they are applied
+  goto <version>  migrate up or down to the given version (0 rolls back everything)`
+
+// IsMigrateCommand reports whether the process was started as "<service> migrate ...".
+func IsMigrateCommand(args []string) bool {
+	return len(args) > 1 && args[1] == "migrate"
+}
+
+// RunMigrateCLI runs the migrate sub-command shared by every service binary.
+// args are the arguments following "migrate".
+func RunMigrateCLI(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", migrateUsage)
+	}
+
+	db, err := GetConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	return runMigrateCommand(context.Background(), migrator, args, os.Stdout)
+}
+
+func runMigrateCommand(ctx context.Context, migrator *Migrator, args []string, out io.Writer) error {
+	switch args[0] {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid steps %q: %w", args[1], err)
+			}
+			steps = n
+		}
+		return migrator.Down(ctx, steps)
+	case "goto":
+		if len(args) < 2 {
+			return fmt.Errorf("goto requires a version\n%s", migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+		return migrator.Goto(ctx, version)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+}
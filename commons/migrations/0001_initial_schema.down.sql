@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS task_system_events;
+DROP TABLE IF EXISTS in_app_notifications;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	handle VARCHAR(50) UNIQUE NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	salt VARCHAR(32) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'active',
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	token TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	used BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_password_reset_tokens_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+	id TEXT PRIMARY KEY,
+	creator_id TEXT NOT NULL,
+	assignee_id TEXT,
+	title TEXT NOT NULL,
+	description TEXT,
+	status TEXT NOT NULL,
+	priority INTEGER NOT NULL DEFAULT 0,
+	email_sent BOOLEAN NOT NULL DEFAULT FALSE,
+	in_app_sent BOOLEAN NOT NULL DEFAULT FALSE,
+	due_date TIMESTAMP,
+	deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_tasks_creator FOREIGN KEY (creator_id)
+		REFERENCES users(id) ON DELETE RESTRICT,
+	CONSTRAINT fk_tasks_assignee FOREIGN KEY (assignee_id)
+		REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS in_app_notifications (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT,
+	is_read BOOLEAN NOT NULL DEFAULT FALSE,
+	read_at TIMESTAMP,
+	deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_notifications_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS task_system_events (
+	id TEXT PRIMARY KEY,
+	task_id TEXT NOT NULL,
+	correlation_id TEXT NOT NULL,
+	origin TEXT NOT NULL,
+	action TEXT NOT NULL,
+	message TEXT NOT NULL,
+	json_data TEXT,
+	emit_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_task_system_events_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_handle ON users(handle);
+CREATE INDEX IF NOT EXISTS idx_users_status ON users(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
+CREATE INDEX IF NOT EXISTS idx_tasks_creator ON tasks(creator_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee ON tasks(assignee_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_is_read ON in_app_notifications(is_read);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON in_app_notifications(user_id);
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_tasks_created_at_id ON tasks(created_at, id) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_tasks_updated_at_id ON tasks(updated_at, id) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_tasks_priority_id ON tasks(priority, id) WHERE deleted = false;
+CREATE INDEX IF NOT EXISTS idx_task_system_events_task ON task_system_events(task_id, created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS task_comment_mentions;
+DROP TABLE IF EXISTS task_comment_edits;
+DROP TABLE IF EXISTS task_comments;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS task_comments (
+	id TEXT PRIMARY KEY,
+	task_id TEXT NOT NULL,
+	author_id TEXT NOT NULL,
+	parent_id TEXT,
+	body TEXT NOT NULL,
+	edited BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_task_comments_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE,
+	CONSTRAINT fk_task_comments_author FOREIGN KEY (author_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_task_comments_parent FOREIGN KEY (parent_id)
+		REFERENCES task_comments(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS task_comment_edits (
+	id TEXT PRIMARY KEY,
+	comment_id TEXT NOT NULL,
+	previous_body TEXT NOT NULL,
+	edited_by TEXT NOT NULL,
+	edited_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_task_comment_edits_comment FOREIGN KEY (comment_id)
+		REFERENCES task_comments(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS task_comment_mentions (
+	comment_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (comment_id, user_id),
+	CONSTRAINT fk_task_comment_mentions_comment FOREIGN KEY (comment_id)
+		REFERENCES task_comments(id) ON DELETE CASCADE,
+	CONSTRAINT fk_task_comment_mentions_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task ON task_comments(task_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_task_comments_parent ON task_comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_task_comment_edits_comment ON task_comment_edits(comment_id, edited_at);
+CREATE INDEX IF NOT EXISTS idx_task_comment_mentions_user ON task_comment_mentions(user_id);
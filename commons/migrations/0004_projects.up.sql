@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS projects (
+	id TEXT PRIMARY KEY,
+	name VARCHAR(200) NOT NULL,
+	description TEXT,
+	creator_id TEXT NOT NULL,
+	deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_projects_creator FOREIGN KEY (creator_id)
+		REFERENCES users(id) ON DELETE RESTRICT
+);
+
+CREATE TABLE IF NOT EXISTS project_members (
+	project_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (project_id, user_id),
+	CONSTRAINT chk_project_members_role CHECK (role IN ('owner', 'editor', 'viewer')),
+	CONSTRAINT fk_project_members_project FOREIGN KEY (project_id)
+		REFERENCES projects(id) ON DELETE CASCADE,
+	CONSTRAINT fk_project_members_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user ON project_members(user_id);
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS project_id TEXT
+	CONSTRAINT fk_tasks_project REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_project ON tasks(project_id) WHERE deleted = false;
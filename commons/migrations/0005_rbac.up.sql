@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS roles (
+	id VARCHAR(50) PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+	id VARCHAR(100) PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role_id VARCHAR(50) NOT NULL,
+	permission_id VARCHAR(100) NOT NULL,
+	PRIMARY KEY (role_id, permission_id),
+	CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id)
+		REFERENCES roles(id) ON DELETE CASCADE,
+	CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_id)
+		REFERENCES permissions(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+	user_id TEXT NOT NULL,
+	role_id VARCHAR(50) NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, role_id),
+	CONSTRAINT fk_user_roles_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_user_roles_role FOREIGN KEY (role_id)
+		REFERENCES roles(id) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (id, description) VALUES
+	('task:read', 'Read tasks the user has access to'),
+	('task:create', 'Create tasks'),
+	('task:update', 'Update tasks the user has access to'),
+	('task:delete', 'Delete tasks the user has access to'),
+	('events:read-all', 'Read the system events of every task'),
+	('admin', 'Full access, including role management')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO roles (id, description) VALUES
+	('admin', 'Administrators'),
+	('member', 'Regular users')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+	('admin', 'task:read'),
+	('admin', 'task:create'),
+	('admin', 'task:update'),
+	('admin', 'task:delete'),
+	('admin', 'events:read-all'),
+	('admin', 'admin'),
+	('member', 'task:read'),
+	('member', 'task:create'),
+	('member', 'task:update'),
+	('member', 'task:delete')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id, created_at)
+SELECT id, 'member', NOW() FROM users
+ON CONFLICT (user_id, role_id) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role ON user_roles(role_id);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	family_id TEXT NOT NULL,
+	token_hash TEXT UNIQUE NOT NULL,
+	access_token_id TEXT,
+	access_expires_at TIMESTAMP,
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address TEXT NOT NULL DEFAULT '',
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	revoked_reason VARCHAR(50),
+	replaced_by TEXT,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_refresh_tokens_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_active ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS revoked_access_tokens (
+	jti TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_access_tokens_expires ON revoked_access_tokens(expires_at);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS emails (
+	id TEXT PRIMARY KEY,
+	task_id TEXT,
+	correlation_id TEXT NOT NULL,
+	recipient_id TEXT,
+	recipient TEXT NOT NULL,
+	template VARCHAR(50) NOT NULL,
+	payload TEXT NOT NULL DEFAULT '{}',
+	status VARCHAR(20) NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'sent', 'failed', 'bounced')),
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	provider_message_id TEXT,
+	sent_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_emails_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE,
+	CONSTRAINT uq_emails_delivery UNIQUE (correlation_id, template, recipient)
+);
+
+CREATE INDEX IF NOT EXISTS idx_emails_task ON emails(task_id, created_at);
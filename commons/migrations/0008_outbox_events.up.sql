@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+	id TEXT PRIMARY KEY,
+	aggregate_type VARCHAR(50) NOT NULL,
+	aggregate_id TEXT NOT NULL,
+	correlation_id TEXT NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	payload TEXT NOT NULL DEFAULT '{}',
+	status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'published', 'failed')),
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT,
+	next_attempt_at TIMESTAMP NOT NULL,
+	published_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id, created_at);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS task_series (
+	id TEXT PRIMARY KEY,
+	rrule TEXT NOT NULL,
+	timezone TEXT NOT NULL,
+	starts_at TIMESTAMP NOT NULL,
+	lead_time_seconds INT NOT NULL DEFAULT 0,
+	title TEXT NOT NULL,
+	description TEXT,
+	priority INTEGER NOT NULL DEFAULT 0,
+	creator_id TEXT NOT NULL,
+	assignee_id TEXT,
+	project_id TEXT,
+	occurrence_count INT NOT NULL DEFAULT 0,
+	next_occurrence_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_task_series_creator FOREIGN KEY (creator_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_task_series_assignee FOREIGN KEY (assignee_id)
+		REFERENCES users(id) ON DELETE SET NULL,
+	CONSTRAINT fk_task_series_project FOREIGN KEY (project_id)
+		REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_series_next ON task_series(next_occurrence_at) WHERE next_occurrence_at IS NOT NULL;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS series_id TEXT
+	CONSTRAINT fk_tasks_series REFERENCES task_series(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP;
+
+-- An occurrence is materialized at most once, even if its task was deleted.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_series_occurrence ON tasks(series_id, occurrence_at) WHERE series_id IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS task_reminders;
+DROP TABLE IF EXISTS reminder_lead_times;
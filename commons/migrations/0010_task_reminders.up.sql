@@ -0,0 +1,27 @@
+-- Lead times before the due date at which a user is reminded of their tasks.
+-- A lead time of 0 reminds when the task becomes overdue. Users without rows
+-- get the defaults of the notification service.
+CREATE TABLE IF NOT EXISTS reminder_lead_times (
+	user_id TEXT NOT NULL,
+	lead_time_minutes INT NOT NULL CHECK (lead_time_minutes >= 0),
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, lead_time_minutes),
+	CONSTRAINT fk_reminder_lead_times_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Reminders that were sent. The primary key makes sure a reminder is sent
+-- once, however many notification services run; a new due date re-arms it.
+CREATE TABLE IF NOT EXISTS task_reminders (
+	task_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	lead_time_minutes INT NOT NULL,
+	due_date TIMESTAMP NOT NULL,
+	correlation_id TEXT NOT NULL,
+	sent_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (task_id, user_id, lead_time_minutes, due_date),
+	CONSTRAINT fk_task_reminders_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE,
+	CONSTRAINT fk_task_reminders_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
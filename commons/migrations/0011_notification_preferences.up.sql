@@ -0,0 +1,22 @@
+-- Per event and channel choices of a user. Missing rows mean enabled.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	user_id TEXT NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	channel VARCHAR(20) NOT NULL,
+	enabled BOOLEAN NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, event_type, channel),
+	CONSTRAINT fk_notification_preferences_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS notification_settings (
+	user_id TEXT PRIMARY KEY,
+	delivery VARCHAR(20) NOT NULL DEFAULT 'immediate' CHECK (delivery IN ('immediate', 'digest')),
+	quiet_hours_start VARCHAR(5),
+	quiet_hours_end VARCHAR(5),
+	quiet_hours_timezone TEXT,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_notification_settings_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
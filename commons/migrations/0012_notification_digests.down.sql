@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_undigested;
+ALTER TABLE in_app_notifications DROP COLUMN IF EXISTS digest_id;
+DROP TABLE IF EXISTS digest_items;
+DROP TABLE IF EXISTS notification_digests;
+ALTER TABLE notification_settings DROP COLUMN IF EXISTS digest_frequency;
@@ -0,0 +1,42 @@
+ALTER TABLE notification_settings
+	ADD COLUMN IF NOT EXISTS digest_frequency VARCHAR(20) NOT NULL DEFAULT 'daily' CHECK (digest_frequency IN ('daily', 'weekly'));
+
+-- One row per user and digest period, claimed before the digest is sent.
+CREATE TABLE IF NOT EXISTS notification_digests (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	frequency VARCHAR(20) NOT NULL,
+	period_start TIMESTAMP NOT NULL,
+	period_end TIMESTAMP NOT NULL,
+	item_count INTEGER NOT NULL,
+	correlation_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	UNIQUE (user_id, period_end),
+	CONSTRAINT fk_notification_digests_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Notifications held back for the next digest of a user.
+CREATE TABLE IF NOT EXISTS digest_items (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	task_id TEXT,
+	event_type VARCHAR(50) NOT NULL,
+	title TEXT NOT NULL,
+	message TEXT NOT NULL DEFAULT '',
+	digest_id TEXT,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_digest_items_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_digest_items_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE,
+	CONSTRAINT fk_digest_items_digest FOREIGN KEY (digest_id)
+		REFERENCES notification_digests(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_digest_items_pending ON digest_items(user_id, created_at) WHERE digest_id IS NULL;
+
+ALTER TABLE in_app_notifications
+	ADD COLUMN IF NOT EXISTS digest_id TEXT REFERENCES notification_digests(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_undigested ON in_app_notifications(user_id, created_at) WHERE digest_id IS NULL AND is_read = FALSE AND deleted = FALSE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS sms_messages;
+DROP TABLE IF EXISTS phone_verifications;
+ALTER TABLE users
+	DROP COLUMN IF EXISTS phone_verified_at,
+	DROP COLUMN IF EXISTS phone_number;
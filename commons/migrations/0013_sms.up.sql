@@ -0,0 +1,35 @@
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS phone_number VARCHAR(20),
+	ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP;
+
+-- The pending verification code of a user's phone number, hashed.
+CREATE TABLE IF NOT EXISTS phone_verifications (
+	user_id TEXT PRIMARY KEY,
+	phone_number VARCHAR(20) NOT NULL,
+	code_hash TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_phone_verifications_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Every SMS sent or attempted, also used to rate limit them per recipient.
+CREATE TABLE IF NOT EXISTS sms_messages (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	task_id TEXT,
+	correlation_id TEXT NOT NULL,
+	phone_number VARCHAR(20) NOT NULL,
+	body TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'sent', 'failed', 'rate_limited')),
+	provider_message_id TEXT,
+	last_error TEXT,
+	created_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP,
+	UNIQUE (correlation_id, user_id),
+	CONSTRAINT fk_sms_messages_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_messages_user_created ON sms_messages(user_id, created_at);
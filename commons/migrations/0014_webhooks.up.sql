@@ -0,0 +1,60 @@
+-- Endpoints that get task events posted to them. Webhooks with a project get
+-- the events of the project's tasks, the others those of the tasks their
+-- owner created or is assigned to.
+CREATE TABLE IF NOT EXISTS webhooks (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	project_id TEXT,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT[] NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_webhooks_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_webhooks_project FOREIGN KEY (project_id)
+		REFERENCES projects(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks(user_id);
+CREATE INDEX IF NOT EXISTS idx_webhooks_project ON webhooks(project_id) WHERE project_id IS NOT NULL;
+
+-- One event to be posted to one webhook. The payload is stored as sent, so
+-- redeliveries send the same body.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id TEXT PRIMARY KEY,
+	webhook_id TEXT NOT NULL,
+	event_id TEXT NOT NULL,
+	event_type VARCHAR(100) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+	attempts INT NOT NULL DEFAULT 0,
+	response_status INT,
+	last_error TEXT,
+	next_attempt_at TIMESTAMP NOT NULL,
+	delivered_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL,
+	UNIQUE (webhook_id, event_id),
+	CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id)
+		REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+
+-- Every attempt to post a delivery, with the response it got.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+	id TEXT PRIMARY KEY,
+	delivery_id TEXT NOT NULL,
+	attempt INT NOT NULL,
+	response_status INT,
+	response_body TEXT,
+	error TEXT,
+	duration_ms INT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_webhook_delivery_attempts_delivery FOREIGN KEY (delivery_id)
+		REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_system_events_stream ON task_system_events;
+DROP TRIGGER IF EXISTS in_app_notifications_stream ON in_app_notifications;
+DROP FUNCTION IF EXISTS notify_stream_event();
@@ -0,0 +1,19 @@
+-- Announce new in-app notifications and task system events on the
+-- stream_events channel, so the gateway can push them to connected clients.
+-- Only the table and row ID are sent; listeners load the row themselves.
+CREATE OR REPLACE FUNCTION notify_stream_event() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('stream_events', json_build_object('table', TG_TABLE_NAME, 'id', NEW.id)::text);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS in_app_notifications_stream ON in_app_notifications;
+CREATE TRIGGER in_app_notifications_stream
+	AFTER INSERT ON in_app_notifications
+	FOR EACH ROW EXECUTE FUNCTION notify_stream_event();
+
+DROP TRIGGER IF EXISTS task_system_events_stream ON task_system_events;
+CREATE TRIGGER task_system_events_stream
+	AFTER INSERT ON task_system_events
+	FOR EACH ROW EXECUTE FUNCTION notify_stream_event();
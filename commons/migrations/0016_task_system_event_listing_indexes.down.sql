@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_task_system_events_correlation;
+DROP INDEX IF EXISTS idx_task_system_events_emit_at_id;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_task_system_events_emit_at_id ON task_system_events(emit_at, id);
+CREATE INDEX IF NOT EXISTS idx_task_system_events_correlation ON task_system_events(correlation_id);
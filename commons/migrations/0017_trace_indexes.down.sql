@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_task_system_events_created_at;
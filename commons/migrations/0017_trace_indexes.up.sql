@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_task_system_events_created_at ON task_system_events(created_at);
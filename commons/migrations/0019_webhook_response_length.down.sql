@@ -0,0 +1,2 @@
+ALTER TABLE webhook_delivery_attempts ADD COLUMN IF NOT EXISTS response_body TEXT;
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS response_length;
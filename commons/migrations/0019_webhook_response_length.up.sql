@@ -0,0 +1,4 @@
+-- Response bodies of webhook endpoints are no longer kept, only their length.
+ALTER TABLE webhook_delivery_attempts ADD COLUMN IF NOT EXISTS response_length INT;
+UPDATE webhook_delivery_attempts SET response_length = octet_length(response_body) WHERE response_body IS NOT NULL;
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS response_body;
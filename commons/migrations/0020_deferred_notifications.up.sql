@@ -0,0 +1,22 @@
+-- Email and SMS notifications held back during the quiet hours of their
+-- recipient, one row per recipient and channel, sent once deliver_at passes.
+CREATE TABLE IF NOT EXISTS deferred_notifications (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	channel VARCHAR(20) NOT NULL,
+	event_type VARCHAR(50) NOT NULL,
+	task_id TEXT,
+	correlation_id TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	message TEXT NOT NULL DEFAULT '',
+	link TEXT NOT NULL DEFAULT '',
+	task_audience BOOLEAN NOT NULL DEFAULT FALSE,
+	deliver_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	CONSTRAINT fk_deferred_notifications_user FOREIGN KEY (user_id)
+		REFERENCES users(id) ON DELETE CASCADE,
+	CONSTRAINT fk_deferred_notifications_task FOREIGN KEY (task_id)
+		REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_deferred_notifications_due ON deferred_notifications(deliver_at);
@@ -0,0 +1,2 @@
+-- The redacted verification codes cannot be restored.
+SELECT 1;
@@ -0,0 +1,5 @@
+-- Phone verification SMS were stored with their code in plaintext; the
+-- codes are only stored hashed now.
+UPDATE sms_messages
+SET body = '[phone verification code]'
+WHERE task_id IS NULL AND body LIKE 'Your verification code is %';
@@ -0,0 +1,9 @@
+DELETE FROM permissions WHERE id IN (
+	'project:read',
+	'project:write',
+	'comment:read',
+	'comment:write',
+	'notification:read',
+	'notification:settings',
+	'webhook:manage'
+);
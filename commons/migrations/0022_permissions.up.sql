@@ -0,0 +1,26 @@
+INSERT INTO permissions (id, description) VALUES
+	('project:read', 'Read projects the user is a member of'),
+	('project:write', 'Create projects and manage projects the user owns'),
+	('comment:read', 'Read the comments of tasks the user has access to'),
+	('comment:write', 'Comment on tasks the user has access to'),
+	('notification:read', 'Read and stream the user''s own notifications'),
+	('notification:settings', 'Manage the user''s notification preferences, reminders and phone number'),
+	('webhook:manage', 'Manage the user''s webhooks')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id) VALUES
+	('admin', 'project:read'),
+	('admin', 'project:write'),
+	('admin', 'comment:read'),
+	('admin', 'comment:write'),
+	('admin', 'notification:read'),
+	('admin', 'notification:settings'),
+	('admin', 'webhook:manage'),
+	('member', 'project:read'),
+	('member', 'project:write'),
+	('member', 'comment:read'),
+	('member', 'comment:write'),
+	('member', 'notification:read'),
+	('member', 'notification:settings'),
+	('member', 'webhook:manage')
+ON CONFLICT (role_id, permission_id) DO NOTHING;
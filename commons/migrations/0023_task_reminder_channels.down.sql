@@ -0,0 +1,5 @@
+DELETE FROM task_reminders WHERE channel <> 'IN_APP';
+
+ALTER TABLE task_reminders DROP CONSTRAINT IF EXISTS task_reminders_pkey;
+ALTER TABLE task_reminders ADD PRIMARY KEY (task_id, user_id, lead_time_minutes, due_date);
+ALTER TABLE task_reminders DROP COLUMN IF EXISTS channel;
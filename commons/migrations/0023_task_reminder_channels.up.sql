@@ -0,0 +1,15 @@
+-- Reminders are claimed per channel, so a channel that fails is retried
+-- without sending the others again. Reminders sent before count as sent on
+-- every channel.
+ALTER TABLE task_reminders ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'IN_APP';
+
+ALTER TABLE task_reminders DROP CONSTRAINT IF EXISTS task_reminders_pkey;
+ALTER TABLE task_reminders ADD PRIMARY KEY (task_id, user_id, lead_time_minutes, due_date, channel);
+
+INSERT INTO task_reminders (task_id, user_id, lead_time_minutes, due_date, correlation_id, sent_at, channel)
+SELECT r.task_id, r.user_id, r.lead_time_minutes, r.due_date, r.correlation_id, r.sent_at, c.channel
+FROM task_reminders r, (VALUES ('EMAIL'), ('SMS')) AS c(channel)
+WHERE r.channel = 'IN_APP'
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE task_reminders ALTER COLUMN channel DROP DEFAULT;
@@ -0,0 +1,8 @@
+-- Of digests of both frequencies for the same period end, only one is kept.
+DELETE FROM notification_digests a
+USING notification_digests b
+WHERE a.user_id = b.user_id AND a.period_end = b.period_end AND a.id > b.id;
+
+ALTER TABLE notification_digests DROP CONSTRAINT IF EXISTS notification_digests_user_id_frequency_period_end_key;
+ALTER TABLE notification_digests ADD CONSTRAINT notification_digests_user_id_period_end_key
+	UNIQUE (user_id, period_end);
@@ -0,0 +1,5 @@
+-- A user switching between daily and weekly digests may get one of each for
+-- periods ending at the same time.
+ALTER TABLE notification_digests DROP CONSTRAINT IF EXISTS notification_digests_user_id_period_end_key;
+ALTER TABLE notification_digests ADD CONSTRAINT notification_digests_user_id_frequency_period_end_key
+	UNIQUE (user_id, frequency, period_end);
@@ -0,0 +1,82 @@
+package commons
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0010_add_index.up.sql":     {Data: []byte("CREATE INDEX;")},
+		"migrations/0002_add_column.up.sql":    {Data: []byte("ALTER TABLE;")},
+		"migrations/0002_add_column.down.sql":  {Data: []byte("ALTER TABLE DROP;")},
+		"migrations/0001_initial.up.sql":       {Data: []byte("CREATE TABLE;")},
+		"migrations/0001_initial.down.sql":     {Data: []byte("DROP TABLE;")},
+		"migrations/archive/0003_old.up.sql":   {Data: []byte("SELECT 1;")},
+		"migrations/archive/0003_old.down.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := LoadMigrations(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "initial", Up: "CREATE TABLE;", Down: "DROP TABLE;"},
+		{Version: 2, Name: "add_column", Up: "ALTER TABLE;", Down: "ALTER TABLE DROP;"},
+		{Version: 10, Name: "add_index", Up: "CREATE INDEX;"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{"duplicate version", []string{"0001_initial.up.sql", "0001_other.up.sql"}, "conflicting names"},
+		{"duplicate version with another padding", []string{"0001_initial.up.sql", "1_initial.up.sql"}, "more than one up script"},
+		{"missing up script", []string{"0001_initial.up.sql", "0002_add_column.down.sql"}, "no up script"},
+		{"down script without a matching up", []string{"0001_initial.up.sql", "0001_other.down.sql"}, "conflicting names"},
+		{"invalid file name", []string{"0001_initial.up.sql", "0002-add-column.up.sql"}, "invalid migration file name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["migrations/"+name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+
+			_, err := LoadMigrations(fsys, "migrations")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadMigrations() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("LoadMigrations failed: %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Fatalf("migration %d_%s has version %d, want %d", migration.Version, migration.Name, migration.Version, i+1)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package commons
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -13,24 +14,184 @@ type User struct {
 	Status         string    `json:"status"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+	// PhoneNumber is in E.164 format; SMS are only sent to it once
+	// PhoneVerifiedAt is set.
+	PhoneNumber     string     `json:"phone_number,omitempty"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty"`
+}
+
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+
+	// DefaultUserRole is granted to every user on sign-up.
+	DefaultUserRole = RoleMember
+)
+
+const (
+	PermissionTaskRead      = "task:read"
+	PermissionTaskCreate    = "task:create"
+	PermissionTaskUpdate    = "task:update"
+	PermissionTaskDelete    = "task:delete"
+	PermissionEventsReadAll = "events:read-all"
+
+	PermissionProjectRead  = "project:read"
+	PermissionProjectWrite = "project:write"
+	PermissionCommentRead  = "comment:read"
+	PermissionCommentWrite = "comment:write"
+	// PermissionNotificationRead covers the user's own in-app notifications
+	// and event stream; PermissionNotificationSettings their notification
+	// preferences, reminder lead times and phone number.
+	PermissionNotificationRead     = "notification:read"
+	PermissionNotificationSettings = "notification:settings"
+	PermissionWebhookManage        = "webhook:manage"
+	// PermissionAdmin implies every other permission.
+	PermissionAdmin = "admin"
+)
+
+type Role struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// HasPermission reports whether permissions grant required, either directly
+// or through the admin permission.
+func HasPermission(permissions []string, required string) bool {
+	for _, permission := range permissions {
+		if permission == required || permission == PermissionAdmin {
+			return true
+		}
+	}
+	return false
 }
 
 type Task struct {
-	ID          string            `json:"id"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	Status      string            `json:"status"`
-	Priority    int              `json:"priority"`
-	DueDate     time.Time         `json:"due_date"`
-	CreatorID   string            `json:"creator_id"`
-	AssigneeID  *string           `json:"assignee_id,omitempty"`
-	EmailSent   bool              `json:"email_sent"`
-	InAppSent   bool              `json:"in_app_sent"`
-	Deleted     bool              `json:"deleted"`
-	DeletedAt   *time.Time        `json:"deleted_at,omitempty"`
-	CreatedAt   time.Time         `json:"created_at"`
-	UpdatedAt   time.Time         `json:"updated_at"`
-	Events      []TaskSystemEvent `json:"events,omitempty"`
+	ID           string            `json:"id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	Status       string            `json:"status"`
+	Priority     int               `json:"priority"`
+	DueDate      time.Time         `json:"due_date"`
+	CreatorID    string            `json:"creator_id"`
+	AssigneeID   *string           `json:"assignee_id,omitempty"`
+	ProjectID    *string           `json:"project_id,omitempty"`
+	SeriesID     *string           `json:"series_id,omitempty"`
+	OccurrenceAt *time.Time        `json:"occurrence_at,omitempty"`
+	EmailSent    bool              `json:"email_sent"`
+	InAppSent    bool              `json:"in_app_sent"`
+	Deleted      bool              `json:"deleted"`
+	DeletedAt    *time.Time        `json:"deleted_at,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	Events       []TaskSystemEvent `json:"events,omitempty"`
+}
+
+// TaskSeries is the schedule of a recurring task. Its occurrences are tasks
+// created from the series' title, description, priority, assignee and
+// project once their window opens, LeadTime before they are due, or as soon
+// as the previous occurrence is done. Occurrences carry the series ID and the
+// due date the series scheduled them for (OccurrenceAt). NextOccurrenceAt is
+// nil once the series has ended.
+type TaskSeries struct {
+	ID               string        `json:"id"`
+	Rule             string        `json:"rrule"`
+	Timezone         string        `json:"timezone"`
+	StartsAt         time.Time     `json:"starts_at"`
+	LeadTime         time.Duration `json:"-"`
+	Title            string        `json:"title"`
+	Description      string        `json:"description"`
+	Priority         int           `json:"priority"`
+	CreatorID        string        `json:"creator_id"`
+	AssigneeID       *string       `json:"assignee_id,omitempty"`
+	ProjectID        *string       `json:"project_id,omitempty"`
+	OccurrenceCount  int           `json:"occurrence_count"`
+	NextOccurrenceAt *time.Time    `json:"next_occurrence_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+}
+
+const (
+	TaskSortCreatedAt = "created_at"
+	TaskSortUpdatedAt = "updated_at"
+	TaskSortDueDate   = "due_date"
+	TaskSortPriority  = "priority"
+	TaskSortTitle     = "title"
+
+	SortAsc  = "asc"
+	SortDesc = "desc"
+
+	DefaultTaskPageSize = 50
+	MaxTaskPageSize     = 200
+)
+
+// TaskQuery describes a filtered, sorted and paginated task listing.
+// Zero values mean "no filter".
+type TaskQuery struct {
+	VisibleTo   string
+	Statuses    []string
+	MinPriority int
+	MaxPriority int
+	DueAfter    *time.Time
+	DueBefore   *time.Time
+	AssigneeID  string
+	CreatorID   string
+	ProjectID   string
+	Search      string
+	SortBy      string
+	SortDir     string
+	Cursor      string
+	Limit       int
+}
+
+type TaskPage struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+const (
+	ProjectRoleOwner  = "owner"
+	ProjectRoleEditor = "editor"
+	ProjectRoleViewer = "viewer"
+)
+
+var projectRoleRanks = map[string]int{
+	ProjectRoleViewer: 1,
+	ProjectRoleEditor: 2,
+	ProjectRoleOwner:  3,
+}
+
+// IsValidProjectRole reports whether role is one of the project roles.
+func IsValidProjectRole(role string) bool {
+	_, ok := projectRoleRanks[role]
+	return ok
+}
+
+// ProjectRoleAtLeast reports whether role grants at least the permissions of
+// required (owner > editor > viewer).
+func ProjectRoleAtLeast(role, required string) bool {
+	return projectRoleRanks[role] > 0 && projectRoleRanks[role] >= projectRoleRanks[required]
+}
+
+type Project struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatorID   string     `json:"creator_id"`
+	Deleted     bool       `json:"deleted"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Role is the role of the user the project was listed for, if any.
+	Role string `json:"role,omitempty"`
+}
+
+type ProjectMember struct {
+	ProjectID string    `json:"project_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type TaskSystemEvent struct {
@@ -45,6 +206,33 @@ type TaskSystemEvent struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+const (
+	DefaultTaskSystemEventPageSize = 50
+	MaxTaskSystemEventPageSize     = 200
+)
+
+// TaskSystemEventQuery describes a filtered and paginated listing of the
+// system events that have been emitted, newest first unless SortDir is asc.
+// Zero values mean "no filter"; an empty VisibleTo lists the events of all
+// tasks.
+type TaskSystemEventQuery struct {
+	VisibleTo     string
+	TaskID        string
+	CorrelationID string
+	Origin        string
+	ActionPrefix  string
+	EmittedAfter  *time.Time
+	EmittedBefore *time.Time
+	SortDir       string
+	Cursor        string
+	Limit         int
+}
+
+type TaskSystemEventPage struct {
+	Events     []TaskSystemEvent `json:"events"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
 type Notification struct {
 	ID        string                 `json:"id"`
 	UserID    string                 `json:"user_id"`
@@ -57,6 +245,17 @@ type Notification struct {
 	UpdatedAt time.Time             `json:"updated_at"`
 }
 
+// StreamEventsChannel is the Postgres NOTIFY channel on which new in-app
+// notifications and task system events are announced, with a JSON payload
+// of their table and ID (StreamEventNotice).
+const StreamEventsChannel = "stream_events"
+
+// StreamEventNotice is the payload of a notification on StreamEventsChannel.
+type StreamEventNotice struct {
+	Table string `json:"table"`
+	ID    string `json:"id"`
+}
+
 type InAppNotification struct {
 	ID          string      `json:"id"`
 	UserID      string      `json:"user_id"`
@@ -79,10 +278,393 @@ type PasswordResetToken struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+const (
+	TokenRevokedRotated       = "rotated"
+	TokenRevokedSignOut       = "sign_out"
+	TokenRevokedByUser        = "revoked_by_user"
+	TokenRevokedReuseDetected = "reuse_detected"
+)
+
+// RefreshToken is one link of a refresh token family. Every refresh rotates
+// the token: the old link is revoked and replaced by a new one in the same
+// family. A family corresponds to one signed-in session.
+type RefreshToken struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	FamilyID        string     `json:"family_id"`
+	TokenHash       string     `json:"-"`
+	AccessTokenID   string     `json:"access_token_id"`
+	AccessExpiresAt time.Time  `json:"access_expires_at"`
+	UserAgent       string     `json:"user_agent"`
+	IPAddress       string     `json:"ip_address"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason   string     `json:"revoked_reason,omitempty"`
+	ReplacedBy      *string    `json:"replaced_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Session summarizes an active refresh token family.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type TaskComment struct {
+	ID               string     `json:"id"`
+	TaskID           string     `json:"task_id"`
+	AuthorID         string     `json:"author_id"`
+	ParentID         *string    `json:"parent_id,omitempty"`
+	Body             string     `json:"body"`
+	Edited           bool       `json:"edited"`
+	Deleted          bool       `json:"deleted"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	MentionedUserIDs []string   `json:"mentioned_user_ids,omitempty"`
+}
+
+type TaskCommentEdit struct {
+	ID           string    `json:"id"`
+	CommentID    string    `json:"comment_id"`
+	PreviousBody string    `json:"previous_body"`
+	EditedBy     string    `json:"edited_by"`
+	EditedAt     time.Time `json:"edited_at"`
+}
+
+const (
+	NotificationEventTaskCreated       = "task.created"
+	NotificationEventTaskMentioned     = "task.mentioned"
+	NotificationEventTaskAssigned      = "task.assigned"
+	NotificationEventTaskDueSoon       = "task.due-soon"
+	NotificationEventTaskStatusChanged = "task.status-changed"
+	NotificationEventPasswordReset     = "auth.password-reset"
+	// NotificationEventPhoneVerification sends a verification code to a
+	// phone number that is not verified yet.
+	NotificationEventPhoneVerification = "auth.phone-verification"
+)
+
+// Types of the messages the notification service puts on the email queue.
+// Messages without a type are task notifications.
+const (
+	EmailMessageTypeTaskNotification = "task_notification"
+	EmailMessageTypePasswordReset    = "password_reset"
+	EmailMessageTypeDigest           = "digest"
+)
+
+// DigestEmail is the content of a digest email message.
+type DigestEmail struct {
+	Frequency   string        `json:"frequency"`
+	PeriodStart time.Time     `json:"periodStart"`
+	PeriodEnd   time.Time     `json:"periodEnd"`
+	Entries     []DigestEntry `json:"entries"`
+	// More is how many notifications did not fit into the email.
+	More int `json:"more,omitempty"`
+}
+
+// DigestEntry is one notification listed in a digest email.
+type DigestEntry struct {
+	TaskID    string    `json:"taskId,omitempty"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const (
+	EmailStatusQueued  = "queued"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+	EmailStatusBounced = "bounced"
+)
+
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusFailed    = "failed"
+)
+
+const OutboxAggregateTask = "task"
+
+// Domain events of tasks, as written to the outbox.
+const (
+	TaskEventCreated = "task.created"
+	TaskEventUpdated = "task.updated"
+	TaskEventDeleted = "task.deleted"
+)
+
+// OutboxEvent is a domain event stored in the same transaction as the change
+// it describes and published afterwards by the outbox relay, so the event is
+// never lost once the change is committed.
+type OutboxEvent struct {
+	ID            string          `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	CorrelationID string          `json:"correlation_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	// TraceContext is the W3C trace context of the request that raised the
+	// event, so that publishing it continues the same trace.
+	TraceContext  map[string]string `json:"trace_context,omitempty"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	LastError     string            `json:"last_error,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	PublishedAt   *time.Time        `json:"published_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TaskEventPayload is the payload of task outbox events. Events without a
+// notification event or types are only recorded as system events when
+// published. The channels of events users have preferences for are chosen
+// by the notification service, so those need no types.
+type TaskEventPayload struct {
+	NotificationTypes []string `json:"notification_types,omitempty"`
+	NotificationEvent string   `json:"notification_event,omitempty"`
+	// RecipientIDs narrows the notification down to some users; without
+	// them, the task's creator and assignee are notified.
+	RecipientIDs []string `json:"recipient_ids,omitempty"`
+}
+
+// Email is one email to one recipient, as recorded by the email service.
+// Payload is the JSON data the template was rendered with.
+type Email struct {
+	ID                string          `json:"id"`
+	TaskID            *string         `json:"task_id,omitempty"`
+	CorrelationID     string          `json:"correlation_id"`
+	RecipientID       *string         `json:"recipient_id,omitempty"`
+	Recipient         string          `json:"recipient"`
+	Template          string          `json:"template"`
+	Payload           json.RawMessage `json:"payload" swaggertype:"object"`
+	Status            string          `json:"status"`
+	Attempts          int             `json:"attempts"`
+	LastError         string          `json:"last_error,omitempty"`
+	ProviderMessageID string          `json:"provider_message_id,omitempty"`
+	SentAt            *time.Time      `json:"sent_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+const (
+	SmsStatusQueued      = "queued"
+	SmsStatusSent        = "sent"
+	SmsStatusFailed      = "failed"
+	SmsStatusRateLimited = "rate_limited"
+)
+
+// SmsMessage is one SMS to one recipient, as recorded by the notification
+// service.
+type SmsMessage struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"user_id"`
+	TaskID            *string    `json:"task_id,omitempty"`
+	CorrelationID     string     `json:"correlation_id"`
+	PhoneNumber       string     `json:"phone_number"`
+	// Body is what was sent, except for phone verification codes, whose
+	// body is redacted.
+	Body              string     `json:"body"`
+	Status            string     `json:"status"`
+	ProviderMessageID string     `json:"provider_message_id,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+}
+
+// PhoneVerification is the pending verification of a user's phone number.
+// Only a hash of the code is stored.
+type PhoneVerification struct {
+	UserID      string    `json:"user_id"`
+	PhoneNumber string    `json:"phone_number"`
+	CodeHash    string    `json:"-"`
+	Attempts    int       `json:"attempts"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Events webhooks can subscribe to. The task events are the domain events
+// of the outbox; WebhookEventNotificationSent is posted once a notification
+// about a task went out on some channels.
+const (
+	WebhookEventTaskCreated      = TaskEventCreated
+	WebhookEventTaskUpdated      = TaskEventUpdated
+	WebhookEventTaskDeleted      = TaskEventDeleted
+	WebhookEventTaskAssigned     = NotificationEventTaskAssigned
+	WebhookEventNotificationSent = "notification.sent"
+)
+
+// WebhookEvents are all events webhooks can subscribe to.
+var WebhookEvents = []string{
+	WebhookEventTaskCreated,
+	WebhookEventTaskUpdated,
+	WebhookEventTaskDeleted,
+	WebhookEventTaskAssigned,
+	WebhookEventNotificationSent,
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an endpoint events are posted to, signed with its secret.
+// Webhooks with a ProjectID get the events of the project's tasks, the
+// others those of the tasks their owner (UserID) created or is assigned to
+// and the notifications sent to them.
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	ProjectID *string   `json:"project_id,omitempty"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookEvent is an event to be posted to the webhooks subscribed to it.
+// TaskID, ProjectID and UserIDs select the webhooks; Data is the body of
+// the event.
+type WebhookEvent struct {
+	ID        string
+	Type      string
+	TaskID    string
+	ProjectID *string
+	UserIDs   []string
+	Data      any
+	CreatedAt time.Time
+}
+
+// WebhookPayload is the JSON body posted to webhooks.
+type WebhookPayload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookTaskData is the data of task events: the task as it was when the
+// event was published, with its system events.
+type WebhookTaskData struct {
+	Task Task `json:"task"`
+}
+
+// WebhookNotificationData is the data of notification.sent events.
+type WebhookNotificationData struct {
+	TaskID        string   `json:"task_id"`
+	CorrelationID string   `json:"correlation_id"`
+	Event         string   `json:"event"`
+	Channels      []string `json:"channels"`
+	RecipientIDs  []string `json:"recipient_ids"`
+}
+
+// WebhookDelivery is one event posted, or to be posted, to one webhook.
+type WebhookDelivery struct {
+	ID             string                   `json:"id"`
+	WebhookID      string                   `json:"webhook_id"`
+	EventID        string                   `json:"event_id"`
+	EventType      string                   `json:"event_type"`
+	Payload        json.RawMessage          `json:"payload" swaggertype:"object"`
+	Status         string                   `json:"status"`
+	Attempts       int                      `json:"attempts"`
+	ResponseStatus *int                     `json:"response_status,omitempty"`
+	LastError      string                   `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time                `json:"next_attempt_at"`
+	DeliveredAt    *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                `json:"created_at"`
+	AttemptLog     []WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookDeliveryAttempt is one attempt to post a delivery. ResponseStatus
+// and ResponseLength, the length of the response body, are nil if no
+// response was received.
+type WebhookDeliveryAttempt struct {
+	ID             string    `json:"id"`
+	DeliveryID     string    `json:"delivery_id"`
+	Attempt        int       `json:"attempt"`
+	ResponseStatus *int      `json:"response_status,omitempty"`
+	ResponseLength *int      `json:"response_length,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int       `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TaskReminder is a reminder sent to a user on Channel LeadTimeMinutes
+// before DueDate, or once the task is overdue if LeadTimeMinutes is 0.
+type TaskReminder struct {
+	TaskID          string    `json:"task_id"`
+	UserID          string    `json:"user_id"`
+	LeadTimeMinutes int       `json:"lead_time_minutes"`
+	DueDate         time.Time `json:"due_date"`
+	Channel         string    `json:"channel"`
+	CorrelationID   string    `json:"correlation_id"`
+	SentAt          time.Time `json:"sent_at"`
+}
+
+// NotificationDigest is one digest email sent to a user, summing up the
+// notifications of a period.
+type NotificationDigest struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	Frequency     string    `json:"frequency"`
+	PeriodStart   time.Time `json:"period_start"`
+	PeriodEnd     time.Time `json:"period_end"`
+	ItemCount     int       `json:"item_count"`
+	CorrelationID string    `json:"correlation_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DigestItem is a notification held back for the next digest of a user.
+type DigestItem struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	TaskID    *string   `json:"task_id,omitempty"`
+	Event     string    `json:"event"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeferredNotification is a notification held back on one channel during
+// the quiet hours of its recipient and sent at DeliverAt, when they end.
+type DeferredNotification struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	Channel       string    `json:"channel"`
+	Event         string    `json:"event"`
+	TaskID        *string   `json:"task_id,omitempty"`
+	CorrelationID string    `json:"correlation_id"`
+	Title         string    `json:"title"`
+	Message       string    `json:"message"`
+	Link          string    `json:"link"`
+	TaskAudience  bool      `json:"task_audience"`
+	DeliverAt     time.Time `json:"deliver_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const (
+	// MaxReminderLeadTimes is how many lead times a user may choose.
+	MaxReminderLeadTimes       = 5
+	MaxReminderLeadTimeMinutes = 7 * 24 * 60
+)
+
+// DefaultReminderLeadTimes are the lead times, in minutes, of users who have
+// not chosen any: a day before the due date and when the task is overdue.
+var DefaultReminderLeadTimes = []int{24 * 60, 0}
+
 type GRPCEvent struct {
 	TaskId        string
 	CorrelationId string
 	Types         []string
+	Event         string
+	RecipientIDs  []string
+	Title         string
+	Message       string
+	Link          string
 }
 
 type Error struct {
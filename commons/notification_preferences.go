@@ -0,0 +1,175 @@
+package commons
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	NotificationChannelInApp = "IN_APP"
+	NotificationChannelEmail = "EMAIL"
+	// NotificationChannelSMS only reaches users with a verified phone
+	// number, and is off unless they turn it on.
+	NotificationChannelSMS = "SMS"
+)
+
+const (
+	NotificationDeliveryImmediate = "immediate"
+	// NotificationDeliveryDigest holds back everything but in-app
+	// notifications, which are summed up in digest emails instead.
+	NotificationDeliveryDigest = "digest"
+)
+
+const (
+	NotificationDigestDaily  = "daily"
+	NotificationDigestWeekly = "weekly"
+)
+
+// NotificationEventChannels are the channels each notification event can be
+// sent on, and so the events and channels users have preferences for.
+var NotificationEventChannels = map[string][]string{
+	NotificationEventTaskCreated:       {NotificationChannelInApp, NotificationChannelEmail},
+	NotificationEventTaskAssigned:      {NotificationChannelInApp, NotificationChannelEmail, NotificationChannelSMS},
+	NotificationEventTaskStatusChanged: {NotificationChannelInApp, NotificationChannelEmail},
+	NotificationEventTaskDueSoon:       {NotificationChannelInApp, NotificationChannelEmail, NotificationChannelSMS},
+	NotificationEventTaskMentioned:     {NotificationChannelInApp, NotificationChannelSMS},
+}
+
+// QuietHours is a daily time span, e.g. 22:00 to 07:00, in which a user gets
+// nothing but in-app notifications.
+type QuietHours struct {
+	Start    string `json:"start" example:"22:00"`
+	End      string `json:"end" example:"07:00"`
+	Timezone string `json:"timezone" example:"Europe/Berlin"`
+}
+
+// Validate checks that the times are HH:MM and the time zone is known.
+func (q QuietHours) Validate() error {
+	if _, err := time.Parse("15:04", q.Start); err != nil {
+		return fmt.Errorf("start must be HH:MM")
+	}
+	if _, err := time.Parse("15:04", q.End); err != nil {
+		return fmt.Errorf("end must be HH:MM")
+	}
+	if _, err := time.LoadLocation(q.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", q.Timezone)
+	}
+	return nil
+}
+
+// Contains reports whether t falls into the quiet hours. Quiet hours that
+// start and end at the same time are empty.
+func (q QuietHours) Contains(t time.Time) bool {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return false
+	}
+	location, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return false
+	}
+
+	t = t.In(location)
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+// EndAfter returns the first time after t at which the quiet hours end.
+func (q QuietHours) EndAfter(t time.Time) time.Time {
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return t
+	}
+	location, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return t
+	}
+
+	t = t.In(location)
+	next := time.Date(t.Year(), t.Month(), t.Day(), end.Hour(), end.Minute(), 0, 0, location)
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, end.Hour(), end.Minute(), 0, 0, location)
+	}
+	return next
+}
+
+// NotificationPreferences are the choices of a user about which
+// notifications they get on which channel, and when.
+type NotificationPreferences struct {
+	UserID string `json:"-"`
+	// Events holds, per event and channel, whether the user gets it.
+	Events     map[string]map[string]bool `json:"events"`
+	QuietHours *QuietHours                `json:"quiet_hours,omitempty"`
+	Delivery   string                     `json:"delivery"`
+	// DigestFrequency is how often digest emails are sent in digest mode.
+	DigestFrequency string `json:"digest_frequency"`
+}
+
+// DefaultNotificationPreferences enable every event on every channel but
+// SMS, sent immediately; in digest mode, digests are sent daily.
+func DefaultNotificationPreferences(userID string) NotificationPreferences {
+	events := make(map[string]map[string]bool, len(NotificationEventChannels))
+	for event, channels := range NotificationEventChannels {
+		events[event] = make(map[string]bool, len(channels))
+		for _, channel := range channels {
+			events[event][channel] = channel != NotificationChannelSMS
+		}
+	}
+
+	return NotificationPreferences{
+		UserID:          userID,
+		Events:          events,
+		Delivery:        NotificationDeliveryImmediate,
+		DigestFrequency: NotificationDigestDaily,
+	}
+}
+
+// Allows reports whether the user gets event on channel right now. In-app
+// notifications are only subject to the per-event choice; other channels are
+// also held back in digest mode and during quiet hours (see Digests and
+// Defers).
+func (p NotificationPreferences) Allows(event string, channel string, now time.Time) bool {
+	if !p.Events[event][channel] {
+		return false
+	}
+	if channel == NotificationChannelInApp {
+		return true
+	}
+	if p.Delivery == NotificationDeliveryDigest {
+		return false
+	}
+	return p.QuietHours == nil || !p.QuietHours.Contains(now)
+}
+
+// Digests reports whether the user gets event on channel in their digest
+// instead. Events the user also gets in-app are left out, as their unread
+// in-app notifications are in the digest already.
+func (p NotificationPreferences) Digests(event string, channel string) bool {
+	return p.Delivery == NotificationDeliveryDigest &&
+		channel != NotificationChannelInApp &&
+		p.Events[event][channel] &&
+		!p.Events[event][NotificationChannelInApp]
+}
+
+// Defers reports whether the user gets event on channel once their quiet
+// hours are over rather than now, and when they are over.
+func (p NotificationPreferences) Defers(event string, channel string, now time.Time) (time.Time, bool) {
+	if !p.Events[event][channel] ||
+		channel == NotificationChannelInApp ||
+		p.Delivery == NotificationDeliveryDigest ||
+		p.QuietHours == nil ||
+		!p.QuietHours.Contains(now) {
+		return time.Time{}, false
+	}
+	return p.QuietHours.EndAfter(now), true
+}
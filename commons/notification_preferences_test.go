@@ -0,0 +1,126 @@
+package commons
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	utc := func(hour, minute int) time.Time {
+		return time.Date(2025, 1, 10, hour, minute, 0, 0, time.UTC)
+	}
+	night := QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}
+	day := QuietHours{Start: "09:00", End: "17:00", Timezone: "UTC"}
+	empty := QuietHours{Start: "22:00", End: "22:00", Timezone: "UTC"}
+	newYork := QuietHours{Start: "22:00", End: "07:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name  string
+		quiet QuietHours
+		t     time.Time
+		want  bool
+	}{
+		{"before a window over midnight", night, utc(20, 59), false},
+		{"at the start of a window over midnight", night, utc(21, 0), true},
+		{"before midnight", night, utc(22, 30), true},
+		{"after midnight", night, utc(3, 0), true},
+		{"just before the end of a window over midnight", night, utc(5, 59), true},
+		{"at the end of a window over midnight", night, utc(6, 0), false},
+		{"before a window within the day", day, utc(8, 59), false},
+		{"at the start of a window within the day", day, utc(9, 0), true},
+		{"at the end of a window within the day", day, utc(17, 0), false},
+		{"empty window at its start", empty, utc(22, 0), false},
+		{"empty window at another time", empty, utc(3, 0), false},
+		{"local evening in another time zone", newYork, utc(3, 0), true},
+		{"local morning in another time zone", newYork, utc(12, 0), false},
+		{"t given in another time zone", night, time.Date(2025, 1, 10, 23, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)), true},
+		{"unknown time zone", QuietHours{Start: "22:00", End: "07:00", Timezone: "Nowhere/City"}, utc(23, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quiet.Contains(tt.t); got != tt.want {
+				t.Fatalf("Contains(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEndAfter(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	local := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, berlin)
+	}
+	night := QuietHours{Start: "22:00", End: "07:00", Timezone: "Europe/Berlin"}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"before midnight", local(1, 10, 23), local(1, 11, 7)},
+		{"after midnight", local(1, 11, 3), local(1, 11, 7)},
+		{"at the end", local(1, 11, 7), local(1, 12, 7)},
+		{"across the start of summer time", local(3, 29, 23), local(3, 30, 7)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := night.EndAfter(tt.t); !got.Equal(tt.want) {
+				t.Fatalf("EndAfter(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationPreferencesAllowsAndDefers(t *testing.T) {
+	quiet := &QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"}
+	during := time.Date(2025, 1, 10, 23, 0, 0, 0, time.UTC)
+	outside := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	quietEnd := time.Date(2025, 1, 11, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		delivery   string
+		quiet      *QuietHours
+		channel    string
+		disabled   bool
+		now        time.Time
+		wantAllows bool
+		wantDefers bool
+	}{
+		{"immediate email", NotificationDeliveryImmediate, nil, NotificationChannelEmail, false, during, true, false},
+		{"email outside quiet hours", NotificationDeliveryImmediate, quiet, NotificationChannelEmail, false, outside, true, false},
+		{"email during quiet hours", NotificationDeliveryImmediate, quiet, NotificationChannelEmail, false, during, false, true},
+		{"in-app during quiet hours", NotificationDeliveryImmediate, quiet, NotificationChannelInApp, false, during, true, false},
+		{"disabled email during quiet hours", NotificationDeliveryImmediate, quiet, NotificationChannelEmail, true, during, false, false},
+		{"digest email", NotificationDeliveryDigest, nil, NotificationChannelEmail, false, outside, false, false},
+		{"digest email during quiet hours", NotificationDeliveryDigest, quiet, NotificationChannelEmail, false, during, false, false},
+		{"digest in-app during quiet hours", NotificationDeliveryDigest, quiet, NotificationChannelInApp, false, during, true, false},
+		{"disabled in-app", NotificationDeliveryImmediate, nil, NotificationChannelInApp, true, outside, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferences := DefaultNotificationPreferences("user")
+			preferences.Delivery = tt.delivery
+			preferences.QuietHours = tt.quiet
+			preferences.Events[NotificationEventTaskAssigned][tt.channel] = !tt.disabled
+
+			if got := preferences.Allows(NotificationEventTaskAssigned, tt.channel, tt.now); got != tt.wantAllows {
+				t.Fatalf("Allows() = %v, want %v", got, tt.wantAllows)
+			}
+
+			deliverAt, defers := preferences.Defers(NotificationEventTaskAssigned, tt.channel, tt.now)
+			if defers != tt.wantDefers {
+				t.Fatalf("Defers() = %v, want %v", defers, tt.wantDefers)
+			}
+			if defers && !deliverAt.Equal(quietEnd) {
+				t.Fatalf("Defers() delivers at %v, want %v", deliverAt, quietEnd)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DeferredNotificationRepositoryInterface interface {
+	Add(ctx context.Context, notification DeferredNotification) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]DeferredNotification, error)
+	Postpone(ctx context.Context, id string, deliverAt time.Time) error
+	Delete(ctx context.Context, id string) error
+}
+
+type PostgresDeferredNotificationRepository struct {
+	DB DBTX
+}
+
+func NewPostgresDeferredNotificationRepository(db *sql.DB) *PostgresDeferredNotificationRepository {
+	return &PostgresDeferredNotificationRepository{DB: db}
+}
+
+// Add holds a notification back until its DeliverAt.
+func (r *PostgresDeferredNotificationRepository) Add(ctx context.Context, notification DeferredNotification) error {
+	if notification.ID == "" {
+		notification.ID = uuid.New().String()
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO deferred_notifications (id, user_id, channel, event_type, task_id, correlation_id, title, message, link, task_audience, deliver_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		notification.ID,
+		notification.UserID,
+		notification.Channel,
+		notification.Event,
+		notification.TaskID,
+		notification.CorrelationID,
+		notification.Title,
+		notification.Message,
+		notification.Link,
+		notification.TaskAudience,
+		notification.DeliverAt.UTC(),
+		notification.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// ClaimDue returns up to limit notifications that are due, oldest first, and
+// pushes their delivery lease into the future. Concurrent schedulers skip
+// each other's rows, and a notification whose scheduler dies before
+// deleting it becomes due again once the lease expires.
+func (r *PostgresDeferredNotificationRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]DeferredNotification, error) {
+	now := time.Now().UTC()
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE deferred_notifications
+		SET deliver_at = $1
+		WHERE id IN (
+			SELECT id FROM deferred_notifications
+			WHERE deliver_at <= $2
+			ORDER BY deliver_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, channel, event_type, task_id, correlation_id, title, message, link, task_audience, deliver_at, created_at
+	`, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []DeferredNotification
+	for rows.Next() {
+		var notification DeferredNotification
+		var taskID sql.NullString
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Channel,
+			&notification.Event,
+			&taskID,
+			&notification.CorrelationID,
+			&notification.Title,
+			&notification.Message,
+			&notification.Link,
+			&notification.TaskAudience,
+			&notification.DeliverAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if taskID.Valid {
+			notification.TaskID = &taskID.String
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// Postpone moves a notification to deliverAt, e.g. when its recipient
+// changed their quiet hours in the meantime.
+func (r *PostgresDeferredNotificationRepository) Postpone(ctx context.Context, id string, deliverAt time.Time) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE deferred_notifications SET deliver_at = $1 WHERE id = $2
+	`, deliverAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// Delete removes a notification once it is sent or no longer wanted.
+func (r *PostgresDeferredNotificationRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM deferred_notifications WHERE id = $1
+	`, id)
+	return err
+}
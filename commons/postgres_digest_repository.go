@@ -0,0 +1,191 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type DigestRepositoryInterface interface {
+	AddItem(ctx context.Context, item DigestItem) error
+	ListUsers(ctx context.Context, frequency string) ([]string, error)
+	ListPendingItems(ctx context.Context, userID string) ([]DigestItem, error)
+	ListUnreadNotifications(ctx context.Context, userID string) ([]InAppNotification, error)
+	Claim(ctx context.Context, digest NotificationDigest) (bool, error)
+	MarkIncluded(ctx context.Context, digestID string, itemIDs []string, notificationIDs []string) error
+}
+
+type PostgresDigestRepository struct {
+	DB DBTX
+}
+
+func NewPostgresDigestRepository(db *sql.DB) *PostgresDigestRepository {
+	return &PostgresDigestRepository{DB: db}
+}
+
+// AddItem holds a notification back for the next digest of its user.
+func (r *PostgresDigestRepository) AddItem(ctx context.Context, item DigestItem) error {
+	if item.ID == "" {
+		item.ID = uuid.New().String()
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now()
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO digest_items (id, user_id, task_id, event_type, title, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, item.ID, item.UserID, item.TaskID, item.Event, item.Title, item.Message, item.CreatedAt)
+	return err
+}
+
+// ListUsers returns the users in digest mode who get digests at the given
+// frequency.
+func (r *PostgresDigestRepository) ListUsers(ctx context.Context, frequency string) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT user_id FROM notification_settings
+		WHERE delivery = $1 AND digest_frequency = $2
+		ORDER BY user_id
+	`, NotificationDeliveryDigest, frequency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ListPendingItems returns the items of a user not yet in a digest, oldest
+// first.
+func (r *PostgresDigestRepository) ListPendingItems(ctx context.Context, userID string) ([]DigestItem, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, user_id, task_id, event_type, title, message, created_at
+		FROM digest_items
+		WHERE user_id = $1 AND digest_id IS NULL
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []DigestItem
+	for rows.Next() {
+		var item DigestItem
+		var taskID sql.NullString
+		if err := rows.Scan(&item.ID, &item.UserID, &taskID, &item.Event, &item.Title, &item.Message, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		if taskID.Valid {
+			item.TaskID = &taskID.String
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// ListUnreadNotifications returns the unread in-app notifications of a user
+// not yet in a digest, oldest first.
+func (r *PostgresDigestRepository) ListUnreadNotifications(ctx context.Context, userID string) ([]InAppNotification, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, user_id, title, description, created_at, updated_at
+		FROM in_app_notifications
+		WHERE user_id = $1 AND digest_id IS NULL AND is_read = FALSE AND deleted = FALSE
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []InAppNotification
+	for rows.Next() {
+		var notification InAppNotification
+		var description sql.NullString
+		if err := rows.Scan(&notification.ID, &notification.UserID, &notification.Title, &description, &notification.CreatedAt, &notification.UpdatedAt); err != nil {
+			return nil, err
+		}
+		notification.Description = description.String
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+// Claim records a digest and reports whether this call recorded it. A user
+// gets one digest per frequency and period, even with several schedulers
+// running. Its items are counted once they are marked included.
+func (r *PostgresDigestRepository) Claim(ctx context.Context, digest NotificationDigest) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO notification_digests (id, user_id, frequency, period_start, period_end, item_count, correlation_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6, $7)
+		ON CONFLICT (user_id, frequency, period_end) DO NOTHING
+	`,
+		digest.ID,
+		digest.UserID,
+		digest.Frequency,
+		digest.PeriodStart.UTC(),
+		digest.PeriodEnd.UTC(),
+		digest.CorrelationID,
+		digest.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// MarkIncluded marks items and in-app notifications as included in a
+// digest, so they are not sent again, and counts them on the digest.
+func (r *PostgresDigestRepository) MarkIncluded(ctx context.Context, digestID string, itemIDs []string, notificationIDs []string) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(itemIDs) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE digest_items SET digest_id = $1 WHERE id = ANY($2)
+		`, digestID, pq.Array(itemIDs))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(notificationIDs) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE in_app_notifications SET digest_id = $1 WHERE id = ANY($2)
+		`, digestID, pq.Array(notificationIDs))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE notification_digests SET item_count = $2 WHERE id = $1
+	`, digestID, len(itemIDs)+len(notificationIDs))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,144 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailRepositoryInterface interface {
+	Queue(ctx context.Context, email Email) (Email, error)
+	MarkSent(ctx context.Context, id string, providerMessageID string) error
+	MarkFailed(ctx context.Context, id string, status string, lastError string) error
+	GetByTaskID(ctx context.Context, taskID string) ([]Email, error)
+}
+
+type PostgresEmailRepository struct {
+	DB DBTX
+}
+
+func NewPostgresEmailRepository(db *sql.DB) *PostgresEmailRepository {
+	return &PostgresEmailRepository{DB: db}
+}
+
+const emailColumns = `id, task_id, correlation_id, recipient_id, recipient, template, payload, status, attempts,
+	last_error, provider_message_id, sent_at, created_at, updated_at`
+
+func scanEmail(scanner interface{ Scan(dest ...any) error }) (Email, error) {
+	var dbEmail DBEmail
+	err := scanner.Scan(
+		&dbEmail.ID,
+		&dbEmail.TaskID,
+		&dbEmail.CorrelationID,
+		&dbEmail.RecipientID,
+		&dbEmail.Recipient,
+		&dbEmail.Template,
+		&dbEmail.Payload,
+		&dbEmail.Status,
+		&dbEmail.Attempts,
+		&dbEmail.LastError,
+		&dbEmail.ProviderMessageID,
+		&dbEmail.SentAt,
+		&dbEmail.CreatedAt,
+		&dbEmail.UpdatedAt,
+	)
+	if err != nil {
+		return Email{}, err
+	}
+	return dbEmail.ToEmail(), nil
+}
+
+// Queue records email as queued and returns it. If the same email (same
+// correlation ID, template and recipient) was recorded before, e.g. because
+// the queue message is redelivered, the existing record is returned instead,
+// so callers can skip emails that were already sent.
+func (r *PostgresEmailRepository) Queue(ctx context.Context, email Email) (Email, error) {
+	if email.ID == "" {
+		email.ID = uuid.New().String()
+	}
+	payload := string(email.Payload)
+	if payload == "" {
+		payload = "{}"
+	}
+	now := time.Now()
+
+	row := r.DB.QueryRowContext(ctx, `
+		INSERT INTO emails (id, task_id, correlation_id, recipient_id, recipient, template, payload, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		ON CONFLICT (correlation_id, template, recipient) DO UPDATE SET updated_at = emails.updated_at
+		RETURNING `+emailColumns,
+		email.ID,
+		email.TaskID,
+		email.CorrelationID,
+		email.RecipientID,
+		email.Recipient,
+		email.Template,
+		payload,
+		EmailStatusQueued,
+		now,
+	)
+
+	queued, err := scanEmail(row)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to queue email", "error", err)
+		return Email{}, err
+	}
+	return queued, nil
+}
+
+// MarkSent records a successful delivery attempt.
+func (r *PostgresEmailRepository) MarkSent(ctx context.Context, id string, providerMessageID string) error {
+	now := time.Now()
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE emails
+		SET status = $1, attempts = attempts + 1, provider_message_id = $2, last_error = NULL, sent_at = $3, updated_at = $3
+		WHERE id = $4
+	`, EmailStatusSent, providerMessageID, now, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// MarkFailed records a failed delivery attempt. status is EmailStatusFailed
+// for errors worth retrying and EmailStatusBounced when the recipient was
+// rejected.
+func (r *PostgresEmailRepository) MarkFailed(ctx context.Context, id string, status string, lastError string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE emails
+		SET status = $1, attempts = attempts + 1, last_error = $2, updated_at = $3
+		WHERE id = $4
+	`, status, lastError, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+func (r *PostgresEmailRepository) GetByTaskID(ctx context.Context, taskID string) ([]Email, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+emailColumns+`
+		FROM emails
+		WHERE task_id = $1
+		ORDER BY created_at ASC, recipient ASC
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []Email{}
+	for rows.Next() {
+		email, err := scanEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, rows.Err()
+}
@@ -1,34 +1,34 @@
 package commons
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type InAppNotificationRepositoryInterface interface {
-	GetAll() ([]InAppNotification, error)
-	GetByID(id string) (InAppNotification, error)
-	GetByUserID(userID string) ([]InAppNotification, error)
-	Create(inAppNotification InAppNotification) (InAppNotification, error)
-	UpdateOnRead(id string, isRead bool) error
-	Update(inAppNotification InAppNotification) error
-	Delete(id string) error
-	HardDelete(id string) error
+	GetAll(ctx context.Context) ([]InAppNotification, error)
+	GetByID(ctx context.Context, id string) (InAppNotification, error)
+	GetByUserID(ctx context.Context, userID string) ([]InAppNotification, error)
+	Create(ctx context.Context, inAppNotification InAppNotification) (InAppNotification, error)
+	UpdateOnRead(ctx context.Context, id string, isRead bool) error
+	Update(ctx context.Context, inAppNotification InAppNotification) error
+	Delete(ctx context.Context, id string) error
+	HardDelete(ctx context.Context, id string) error
 }
 
 type PostgresInAppNotificationRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewPostgresInAppNotificationRepository(db *sql.DB) *PostgresInAppNotificationRepository {
 	return &PostgresInAppNotificationRepository{DB: db}
 }
 
-func (r *PostgresInAppNotificationRepository) GetAll() ([]InAppNotification, error) {
-	rows, err := r.DB.Query(`
+func (r *PostgresInAppNotificationRepository) GetAll(ctx context.Context) ([]InAppNotification, error) {
+	rows, err := r.DB.QueryContext(ctx, `
 		SELECT id, user_id, title, description, is_read, read_at, created_at, updated_at, deleted, deleted_at
 		FROM in_app_notifications
 	`)
@@ -68,11 +68,11 @@ func (r *PostgresInAppNotificationRepository) GetAll() ([]InAppNotification, err
 	return inAppNotifications, nil
 }
 
-func (r *PostgresInAppNotificationRepository) GetByID(id string) (InAppNotification, error) {
+func (r *PostgresInAppNotificationRepository) GetByID(ctx context.Context, id string) (InAppNotification, error) {
 	var dbNotification DBInAppNotification
 	var readAt sql.NullTime
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT id, user_id, title, description, is_read, read_at, created_at, updated_at, deleted, deleted_at
 		FROM in_app_notifications WHERE id = $1
 	`, id).Scan(
@@ -99,8 +99,8 @@ func (r *PostgresInAppNotificationRepository) GetByID(id string) (InAppNotificat
 	return dbNotification.ToInAppNotification(), nil
 }
 
-func (r *PostgresInAppNotificationRepository) GetByUserID(userID string) ([]InAppNotification, error) {
-	rows, err := r.DB.Query(`
+func (r *PostgresInAppNotificationRepository) GetByUserID(ctx context.Context, userID string) ([]InAppNotification, error) {
+	rows, err := r.DB.QueryContext(ctx, `
 		SELECT id, user_id, title, description, is_read, read_at, created_at, updated_at, deleted, deleted_at
 		FROM in_app_notifications
 		WHERE user_id = $1 AND deleted = false
@@ -142,7 +142,7 @@ func (r *PostgresInAppNotificationRepository) GetByUserID(userID string) ([]InAp
 	return inAppNotifications, nil
 }
 
-func (r *PostgresInAppNotificationRepository) Create(notification InAppNotification) (InAppNotification, error) {
+func (r *PostgresInAppNotificationRepository) Create(ctx context.Context, notification InAppNotification) (InAppNotification, error) {
 	dbNotification := &DBInAppNotification{}
 	dbNotification.FromInAppNotification(notification)
 
@@ -154,7 +154,7 @@ func (r *PostgresInAppNotificationRepository) Create(notification InAppNotificat
 	dbNotification.CreatedAt = now
 	dbNotification.UpdatedAt = now
 
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		INSERT INTO in_app_notifications (id, user_id, title, description, is_read, read_at, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`,
@@ -175,7 +175,7 @@ func (r *PostgresInAppNotificationRepository) Create(notification InAppNotificat
 	return dbNotification.ToInAppNotification(), nil
 }
 
-func (r *PostgresInAppNotificationRepository) UpdateOnRead(id string, isRead bool) error {
+func (r *PostgresInAppNotificationRepository) UpdateOnRead(ctx context.Context, id string, isRead bool) error {
 	now := time.Now()
 
 	var readAt *time.Time
@@ -185,7 +185,7 @@ func (r *PostgresInAppNotificationRepository) UpdateOnRead(id string, isRead boo
 		readAt = nil
 	}
 
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE in_app_notifications 
 		SET is_read = $1, read_at = $2, updated_at = $3
 		WHERE id = $4
@@ -195,16 +195,16 @@ func (r *PostgresInAppNotificationRepository) UpdateOnRead(id string, isRead boo
 		now,
 		id,
 	)
-	log.Println("InAppNotification updated ON READ successfully")
+	logger.DebugContext(ctx, "In-app notification marked as read", "notification_id", id)
 	return err
 }
 
-func (r *PostgresInAppNotificationRepository) Update(notification InAppNotification) error {
+func (r *PostgresInAppNotificationRepository) Update(ctx context.Context, notification InAppNotification) error {
 	dbNotification := &DBInAppNotification{}
 	dbNotification.FromInAppNotification(notification)
 	dbNotification.UpdatedAt = time.Now()
 	
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE in_app_notifications 
 		SET title = $1, description = $2, is_read = $3, read_at = $4, updated_at = $5
 		WHERE id = $6
@@ -216,12 +216,12 @@ func (r *PostgresInAppNotificationRepository) Update(notification InAppNotificat
 		dbNotification.UpdatedAt,
 		dbNotification.ID,
 	)
-	log.Println("InAppNotification updated successfully")
+	logger.DebugContext(ctx, "In-app notification updated", "notification_id", notification.ID)
 	return err
 }
 
-func (r *PostgresInAppNotificationRepository) Delete(id string) error {
-	_, err := r.DB.Exec(`
+func (r *PostgresInAppNotificationRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE in_app_notifications 
 		SET deleted = $1, deleted_at = $2, updated_at = $3 
 		WHERE id = $4
@@ -231,11 +231,11 @@ func (r *PostgresInAppNotificationRepository) Delete(id string) error {
 		time.Now(),
 		id,
 	)
-	log.Println("InAppNotification soft deleted successfully")
+	logger.DebugContext(ctx, "In-app notification soft deleted", "notification_id", id)
 	return err
 }
 
-func (r *PostgresInAppNotificationRepository) HardDelete(id string) error {
-	_, err := r.DB.Exec("DELETE FROM in_app_notifications WHERE id = $1", id)
+func (r *PostgresInAppNotificationRepository) HardDelete(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM in_app_notifications WHERE id = $1", id)
 	return err
 }
@@ -0,0 +1,120 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type NotificationPreferenceRepositoryInterface interface {
+	Get(ctx context.Context, userID string) (NotificationPreferences, error)
+	Save(ctx context.Context, preferences NotificationPreferences) error
+}
+
+type PostgresNotificationPreferenceRepository struct {
+	DB DBTX
+}
+
+func NewPostgresNotificationPreferenceRepository(db *sql.DB) *PostgresNotificationPreferenceRepository {
+	return &PostgresNotificationPreferenceRepository{DB: db}
+}
+
+// Get returns the preferences of a user; whatever the user has not chosen
+// has its default.
+func (r *PostgresNotificationPreferenceRepository) Get(ctx context.Context, userID string) (NotificationPreferences, error) {
+	preferences := DefaultNotificationPreferences(userID)
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT event_type, channel, enabled FROM notification_preferences WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return NotificationPreferences{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event, channel string
+		var enabled bool
+		if err := rows.Scan(&event, &channel, &enabled); err != nil {
+			return NotificationPreferences{}, err
+		}
+		// Choices for events or channels that no longer exist are ignored.
+		if channels, ok := preferences.Events[event]; ok {
+			if _, ok := channels[channel]; ok {
+				channels[channel] = enabled
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return NotificationPreferences{}, err
+	}
+
+	var start, end, timezone sql.NullString
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT delivery, digest_frequency, quiet_hours_start, quiet_hours_end, quiet_hours_timezone
+		FROM notification_settings WHERE user_id = $1
+	`, userID).Scan(&preferences.Delivery, &preferences.DigestFrequency, &start, &end, &timezone)
+	if err != nil && err != sql.ErrNoRows {
+		return NotificationPreferences{}, err
+	}
+	if start.Valid && end.Valid && timezone.Valid {
+		preferences.QuietHours = &QuietHours{Start: start.String, End: end.String, Timezone: timezone.String}
+	}
+
+	return preferences, nil
+}
+
+// Save replaces the preferences of a user.
+func (r *PostgresNotificationPreferenceRepository) Save(ctx context.Context, preferences NotificationPreferences) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, preferences.UserID); err != nil {
+		return err
+	}
+	for event, channels := range preferences.Events {
+		for channel, enabled := range channels {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO notification_preferences (user_id, event_type, channel, enabled, updated_at)
+				VALUES ($1, $2, $3, $4, $5)
+			`, preferences.UserID, event, channel, enabled, now)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var start, end, timezone *string
+	if preferences.QuietHours != nil {
+		start, end, timezone = &preferences.QuietHours.Start, &preferences.QuietHours.End, &preferences.QuietHours.Timezone
+	}
+	delivery := preferences.Delivery
+	if delivery == "" {
+		delivery = NotificationDeliveryImmediate
+	}
+	digestFrequency := preferences.DigestFrequency
+	if digestFrequency == "" {
+		digestFrequency = NotificationDigestDaily
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_settings (user_id, delivery, digest_frequency, quiet_hours_start, quiet_hours_end, quiet_hours_timezone, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE
+		SET delivery = EXCLUDED.delivery,
+			digest_frequency = EXCLUDED.digest_frequency,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			quiet_hours_timezone = EXCLUDED.quiet_hours_timezone,
+			updated_at = EXCLUDED.updated_at
+	`, preferences.UserID, delivery, digestFrequency, start, end, timezone, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,162 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxRepositoryInterface interface {
+	Add(ctx context.Context, events []OutboxEvent) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+	MarkFailed(ctx context.Context, id string, lastError string) error
+}
+
+type PostgresOutboxRepository struct {
+	DB DBTX
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{DB: db}
+}
+
+// Add stores events as pending. Call it in the unit of work that makes the
+// change the events describe, so both are committed together. Events without
+// a trace context get the one of ctx.
+func (r *PostgresOutboxRepository) Add(ctx context.Context, events []OutboxEvent) error {
+	now := time.Now()
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+		payload := string(event.Payload)
+		if payload == "" {
+			payload = "{}"
+		}
+		if event.TraceContext == nil {
+			event.TraceContext = InjectTraceContext(ctx)
+		}
+		traceContext, err := json.Marshal(event.TraceContext)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.DB.ExecContext(ctx, `
+			INSERT INTO outbox_events (id, aggregate_type, aggregate_id, correlation_id, event_type, payload, trace_context, status, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
+		`,
+			event.ID,
+			event.AggregateType,
+			event.AggregateID,
+			event.CorrelationID,
+			event.EventType,
+			payload,
+			string(traceContext),
+			OutboxStatusPending,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimDue returns up to limit pending events that are due, oldest first, and
+// pushes their next attempt lease into the future. Concurrent relays skip
+// each other's rows, and an event whose relay dies before marking it becomes
+// due again once the lease expires.
+func (r *PostgresOutboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	now := time.Now()
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE outbox_events
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY created_at ASC
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_type, aggregate_id, correlation_id, event_type, payload, trace_context, status, attempts,
+			last_error, next_attempt_at, published_at, created_at
+	`, now.Add(lease), OutboxStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var dbEvent DBOutboxEvent
+		err := rows.Scan(
+			&dbEvent.ID,
+			&dbEvent.AggregateType,
+			&dbEvent.AggregateID,
+			&dbEvent.CorrelationID,
+			&dbEvent.EventType,
+			&dbEvent.Payload,
+			&dbEvent.TraceContext,
+			&dbEvent.Status,
+			&dbEvent.Attempts,
+			&dbEvent.LastError,
+			&dbEvent.NextAttemptAt,
+			&dbEvent.PublishedAt,
+			&dbEvent.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, dbEvent.ToOutboxEvent())
+	}
+
+	return events, rows.Err()
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET status = $1, last_error = NULL, published_at = $2
+		WHERE id = $3
+	`, OutboxStatusPublished, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// MarkRetry records a failed publish attempt; the event stays pending until
+// nextAttemptAt.
+func (r *PostgresOutboxRepository) MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET last_error = $1, next_attempt_at = $2
+		WHERE id = $3
+	`, lastError, nextAttemptAt, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// MarkFailed gives up on an event after its last failed publish attempt.
+func (r *PostgresOutboxRepository) MarkFailed(ctx context.Context, id string, lastError string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE outbox_events
+		SET status = $1, last_error = $2
+		WHERE id = $3
+	`, OutboxStatusFailed, lastError, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
@@ -1,29 +1,29 @@
 package commons
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type PasswordResetTokenRepositoryInterface interface {
-	Create(token PasswordResetToken) (PasswordResetToken, error)
-	GetByToken(token string) (PasswordResetToken, error)
-	MarkAsUsed(token string) error
-	DeleteExpired() error
+	Create(ctx context.Context, token PasswordResetToken) (PasswordResetToken, error)
+	GetByToken(ctx context.Context, token string) (PasswordResetToken, error)
+	MarkAsUsed(ctx context.Context, token string) error
+	DeleteExpired(ctx context.Context) error
 }
 
 type PostgresPasswordResetTokenRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewPostgresPasswordResetTokenRepository(db *sql.DB) *PostgresPasswordResetTokenRepository {
 	return &PostgresPasswordResetTokenRepository{DB: db}
 }
 
-func (r *PostgresPasswordResetTokenRepository) Create(token PasswordResetToken) (PasswordResetToken, error) {
+func (r *PostgresPasswordResetTokenRepository) Create(ctx context.Context, token PasswordResetToken) (PasswordResetToken, error) {
 	dbToken := &DBPasswordResetToken{}
 	dbToken.FromPasswordResetToken(token)
 
@@ -35,7 +35,7 @@ func (r *PostgresPasswordResetTokenRepository) Create(token PasswordResetToken)
 		dbToken.CreatedAt = time.Now()
 	}
 
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		INSERT INTO password_reset_tokens (id, user_id, token, expires_at, used, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`,
@@ -54,10 +54,10 @@ func (r *PostgresPasswordResetTokenRepository) Create(token PasswordResetToken)
 	return dbToken.ToPasswordResetToken(), nil
 }
 
-func (r *PostgresPasswordResetTokenRepository) GetByToken(token string) (PasswordResetToken, error) {
+func (r *PostgresPasswordResetTokenRepository) GetByToken(ctx context.Context, token string) (PasswordResetToken, error) {
 	var dbToken DBPasswordResetToken
 
-	err := r.DB.QueryRow(`
+	err := r.DB.QueryRowContext(ctx, `
 		SELECT id, user_id, token, expires_at, used, created_at
 		FROM password_reset_tokens
 		WHERE token = $1 AND used = false AND expires_at > NOW()
@@ -80,8 +80,8 @@ func (r *PostgresPasswordResetTokenRepository) GetByToken(token string) (Passwor
 	return dbToken.ToPasswordResetToken(), nil
 }
 
-func (r *PostgresPasswordResetTokenRepository) MarkAsUsed(token string) error {
-	result, err := r.DB.Exec(`
+func (r *PostgresPasswordResetTokenRepository) MarkAsUsed(ctx context.Context, token string) error {
+	result, err := r.DB.ExecContext(ctx, `
 		UPDATE password_reset_tokens
 		SET used = true
 		WHERE token = $1 AND used = false AND expires_at > NOW()
@@ -100,12 +100,12 @@ func (r *PostgresPasswordResetTokenRepository) MarkAsUsed(token string) error {
 		return ErrNotFound
 	}
 
-	log.Println("Password reset token marked as used successfully")
+	logger.DebugContext(ctx, "Password reset token marked as used")
 	return nil
 }
 
-func (r *PostgresPasswordResetTokenRepository) DeleteExpired() error {
-	_, err := r.DB.Exec(`
+func (r *PostgresPasswordResetTokenRepository) DeleteExpired(ctx context.Context) error {
+	_, err := r.DB.ExecContext(ctx, `
 		DELETE FROM password_reset_tokens
 		WHERE expires_at <= NOW() OR used = true
 	`)
@@ -114,6 +114,6 @@ func (r *PostgresPasswordResetTokenRepository) DeleteExpired() error {
 		return err
 	}
 
-	log.Println("Expired password reset tokens deleted successfully")
+	logger.DebugContext(ctx, "Expired password reset tokens deleted")
 	return nil
 }
@@ -0,0 +1,105 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type PhoneVerificationRepositoryInterface interface {
+	Save(ctx context.Context, verification PhoneVerification) error
+	Get(ctx context.Context, userID string) (PhoneVerification, error)
+	ClaimAttempt(ctx context.Context, userID string, maxAttempts int) (PhoneVerification, error)
+	Delete(ctx context.Context, userID string) error
+}
+
+type PostgresPhoneVerificationRepository struct {
+	DB DBTX
+}
+
+func NewPostgresPhoneVerificationRepository(db *sql.DB) *PostgresPhoneVerificationRepository {
+	return &PostgresPhoneVerificationRepository{DB: db}
+}
+
+// Save replaces the pending verification of a user.
+func (r *PostgresPhoneVerificationRepository) Save(ctx context.Context, verification PhoneVerification) error {
+	if verification.CreatedAt.IsZero() {
+		verification.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO phone_verifications (user_id, phone_number, code_hash, attempts, expires_at, created_at)
+		VALUES ($1, $2, $3, 0, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET phone_number = EXCLUDED.phone_number,
+			code_hash = EXCLUDED.code_hash,
+			attempts = 0,
+			expires_at = EXCLUDED.expires_at,
+			created_at = EXCLUDED.created_at
+	`,
+		verification.UserID,
+		verification.PhoneNumber,
+		verification.CodeHash,
+		verification.ExpiresAt.UTC(),
+		verification.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// Get returns the pending verification of a user, or ErrNotFound.
+func (r *PostgresPhoneVerificationRepository) Get(ctx context.Context, userID string) (PhoneVerification, error) {
+	var verification PhoneVerification
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT user_id, phone_number, code_hash, attempts, expires_at, created_at
+		FROM phone_verifications WHERE user_id = $1
+	`, userID).Scan(
+		&verification.UserID,
+		&verification.PhoneNumber,
+		&verification.CodeHash,
+		&verification.Attempts,
+		&verification.ExpiresAt,
+		&verification.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return PhoneVerification{}, ErrNotFound
+	}
+	if err != nil {
+		return PhoneVerification{}, err
+	}
+
+	return verification, nil
+}
+
+// ClaimAttempt counts an attempt to enter the code of the pending
+// verification of a user and returns the verification. It returns
+// ErrNotFound if there is none, or it expired or has no attempts left. The
+// attempt is counted before the code is checked, so concurrent guesses
+// cannot exceed maxAttempts.
+func (r *PostgresPhoneVerificationRepository) ClaimAttempt(ctx context.Context, userID string, maxAttempts int) (PhoneVerification, error) {
+	var verification PhoneVerification
+	err := r.DB.QueryRowContext(ctx, `
+		UPDATE phone_verifications SET attempts = attempts + 1
+		WHERE user_id = $1 AND attempts < $2 AND expires_at > $3
+		RETURNING user_id, phone_number, code_hash, attempts, expires_at, created_at
+	`, userID, maxAttempts, time.Now().UTC()).Scan(
+		&verification.UserID,
+		&verification.PhoneNumber,
+		&verification.CodeHash,
+		&verification.Attempts,
+		&verification.ExpiresAt,
+		&verification.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return PhoneVerification{}, ErrNotFound
+	}
+	if err != nil {
+		return PhoneVerification{}, err
+	}
+
+	return verification, nil
+}
+
+func (r *PostgresPhoneVerificationRepository) Delete(ctx context.Context, userID string) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM phone_verifications WHERE user_id = $1`, userID)
+	return err
+}
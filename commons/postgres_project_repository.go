@@ -0,0 +1,328 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProjectRepositoryInterface interface {
+	Create(ctx context.Context, project Project, ownerID string) (Project, error)
+	GetByID(ctx context.Context, id string) (Project, error)
+	GetByUserID(ctx context.Context, userID string) ([]Project, error)
+	Update(ctx context.Context, project Project) error
+	Delete(ctx context.Context, id string) error
+	GetMember(ctx context.Context, projectID string, userID string) (ProjectMember, error)
+	GetMembers(ctx context.Context, projectID string) ([]ProjectMember, error)
+	AddMember(ctx context.Context, member ProjectMember) (ProjectMember, error)
+	UpdateMemberRole(ctx context.Context, projectID string, userID string, role string) error
+	RemoveMember(ctx context.Context, projectID string, userID string) error
+}
+
+type PostgresProjectRepository struct {
+	DB DBTX
+}
+
+func NewPostgresProjectRepository(db *sql.DB) *PostgresProjectRepository {
+	return &PostgresProjectRepository{DB: db}
+}
+
+// Create inserts the project and makes ownerID its first owner, atomically.
+func (r *PostgresProjectRepository) Create(ctx context.Context, project Project, ownerID string) (Project, error) {
+	dbProject := &DBProject{}
+	dbProject.FromProject(project)
+
+	if dbProject.ID == "" {
+		dbProject.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	dbProject.CreatedAt = now
+	dbProject.UpdatedAt = now
+
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return Project{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO projects (id, name, description, creator_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+		dbProject.ID,
+		dbProject.Name,
+		dbProject.Description,
+		dbProject.CreatorID,
+		dbProject.CreatedAt,
+		dbProject.UpdatedAt,
+	)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create project", "error", err)
+		return Project{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO project_members (project_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, dbProject.ID, ownerID, ProjectRoleOwner, now, now)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to add project owner", "project_id", project.ID, "error", err)
+		return Project{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Project{}, err
+	}
+
+	created := dbProject.ToProject()
+	created.Role = ProjectRoleOwner
+	return created, nil
+}
+
+func (r *PostgresProjectRepository) GetByID(ctx context.Context, id string) (Project, error) {
+	var dbProject DBProject
+	var description sql.NullString
+
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, name, description, creator_id, deleted, deleted_at, created_at, updated_at
+		FROM projects
+		WHERE id = $1 AND deleted = false
+	`, id).Scan(
+		&dbProject.ID,
+		&dbProject.Name,
+		&description,
+		&dbProject.CreatorID,
+		&dbProject.Deleted,
+		&dbProject.DeletedAt,
+		&dbProject.CreatedAt,
+		&dbProject.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Project{}, ErrNotFound
+		}
+		return Project{}, err
+	}
+	dbProject.Description = description.String
+
+	return dbProject.ToProject(), nil
+}
+
+// GetByUserID returns the projects userID is a member of, with Role set to
+// the user's role in each.
+func (r *PostgresProjectRepository) GetByUserID(ctx context.Context, userID string) ([]Project, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT p.id, p.name, p.description, p.creator_id, p.deleted, p.deleted_at, p.created_at, p.updated_at, m.role
+		FROM projects p
+		JOIN project_members m ON m.project_id = p.id
+		WHERE m.user_id = $1 AND p.deleted = false
+		ORDER BY p.name ASC, p.id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []Project{}
+	for rows.Next() {
+		var dbProject DBProject
+		var description sql.NullString
+		var role string
+
+		err := rows.Scan(
+			&dbProject.ID,
+			&dbProject.Name,
+			&description,
+			&dbProject.CreatorID,
+			&dbProject.Deleted,
+			&dbProject.DeletedAt,
+			&dbProject.CreatedAt,
+			&dbProject.UpdatedAt,
+			&role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		dbProject.Description = description.String
+
+		project := dbProject.ToProject()
+		project.Role = role
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (r *PostgresProjectRepository) Update(ctx context.Context, project Project) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE projects
+		SET name = $1, description = $2, updated_at = $3
+		WHERE id = $4 AND deleted = false
+	`, project.Name, project.Description, time.Now(), project.ID)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// Delete soft deletes the project together with its tasks.
+func (r *PostgresProjectRepository) Delete(ctx context.Context, id string) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `
+		UPDATE projects
+		SET deleted = true, deleted_at = $1, updated_at = $1
+		WHERE id = $2 AND deleted = false
+	`, now, id)
+	if err != nil {
+		return err
+	}
+	if err := requireRowsAffected(result); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tasks
+		SET deleted = true, deleted_at = $1, updated_at = $1
+		WHERE project_id = $2 AND deleted = false
+	`, now, id)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.DebugContext(ctx, "Project soft deleted", "project_id", id)
+	return nil
+}
+
+func (r *PostgresProjectRepository) GetMember(ctx context.Context, projectID string, userID string) (ProjectMember, error) {
+	var dbMember DBProjectMember
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT m.project_id, m.user_id, m.role, m.created_at, m.updated_at
+		FROM project_members m
+		JOIN projects p ON p.id = m.project_id
+		WHERE m.project_id = $1 AND m.user_id = $2 AND p.deleted = false
+	`, projectID, userID).Scan(
+		&dbMember.ProjectID,
+		&dbMember.UserID,
+		&dbMember.Role,
+		&dbMember.CreatedAt,
+		&dbMember.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ProjectMember{}, ErrNotFound
+		}
+		return ProjectMember{}, err
+	}
+
+	return dbMember.ToProjectMember(), nil
+}
+
+func (r *PostgresProjectRepository) GetMembers(ctx context.Context, projectID string) ([]ProjectMember, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT project_id, user_id, role, created_at, updated_at
+		FROM project_members
+		WHERE project_id = $1
+		ORDER BY created_at ASC
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []ProjectMember{}
+	for rows.Next() {
+		var dbMember DBProjectMember
+		err := rows.Scan(
+			&dbMember.ProjectID,
+			&dbMember.UserID,
+			&dbMember.Role,
+			&dbMember.CreatedAt,
+			&dbMember.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, dbMember.ToProjectMember())
+	}
+
+	return members, rows.Err()
+}
+
+// AddMember inserts a membership, or updates the role if the user is already
+// a member.
+func (r *PostgresProjectRepository) AddMember(ctx context.Context, member ProjectMember) (ProjectMember, error) {
+	now := time.Now()
+
+	var dbMember DBProjectMember
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO project_members (project_id, user_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role, updated_at = EXCLUDED.updated_at
+		RETURNING project_id, user_id, role, created_at, updated_at
+	`, member.ProjectID, member.UserID, member.Role, now).Scan(
+		&dbMember.ProjectID,
+		&dbMember.UserID,
+		&dbMember.Role,
+		&dbMember.CreatedAt,
+		&dbMember.UpdatedAt,
+	)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to add project member", "error", err)
+		return ProjectMember{}, err
+	}
+
+	return dbMember.ToProjectMember(), nil
+}
+
+func (r *PostgresProjectRepository) UpdateMemberRole(ctx context.Context, projectID string, userID string, role string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE project_members
+		SET role = $1, updated_at = $2
+		WHERE project_id = $3 AND user_id = $4
+	`, role, time.Now(), projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+func (r *PostgresProjectRepository) RemoveMember(ctx context.Context, projectID string, userID string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		DELETE FROM project_members
+		WHERE project_id = $1 AND user_id = $2
+	`, projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// requireRowsAffected returns ErrNotFound when a statement touched no rows.
+func requireRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RefreshTokenRepositoryInterface interface {
+	Create(ctx context.Context, token RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	Rotate(ctx context.Context, oldID string, next RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string, reason string) error
+	GetActiveSessions(ctx context.Context, userID string) ([]Session, error)
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeAccessToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type PostgresRefreshTokenRepository struct {
+	DB DBTX
+}
+
+func NewPostgresRefreshTokenRepository(db *sql.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{DB: db}
+}
+
+func insertRefreshToken(ctx context.Context, db DBTX, token RefreshToken) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, access_token_id, access_expires_at, user_agent, ip_address, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		token.ID,
+		token.UserID,
+		token.FamilyID,
+		token.TokenHash,
+		token.AccessTokenID,
+		token.AccessExpiresAt,
+		token.UserAgent,
+		token.IPAddress,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token RefreshToken) error {
+	if err := insertRefreshToken(ctx, r.DB, token); err != nil {
+		logger.ErrorContext(ctx, "Failed to create refresh token", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	var dbToken DBRefreshToken
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, user_id, family_id, token_hash, access_token_id, access_expires_at, user_agent, ip_address,
+			expires_at, revoked_at, revoked_reason, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`, tokenHash).Scan(
+		&dbToken.ID,
+		&dbToken.UserID,
+		&dbToken.FamilyID,
+		&dbToken.TokenHash,
+		&dbToken.AccessTokenID,
+		&dbToken.AccessExpiresAt,
+		&dbToken.UserAgent,
+		&dbToken.IPAddress,
+		&dbToken.ExpiresAt,
+		&dbToken.RevokedAt,
+		&dbToken.RevokedReason,
+		&dbToken.ReplacedBy,
+		&dbToken.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return RefreshToken{}, ErrNotFound
+		}
+		return RefreshToken{}, err
+	}
+
+	return dbToken.ToRefreshToken(), nil
+}
+
+// Rotate revokes oldID and stores next as its replacement, atomically. It
+// returns ErrTokenReused if oldID was already revoked, e.g. by a concurrent
+// refresh with the same token.
+func (r *PostgresRefreshTokenRepository) Rotate(ctx context.Context, oldID string, next RefreshToken) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = $1, revoked_reason = $2, replaced_by = $3
+		WHERE id = $4 AND revoked_at IS NULL
+	`, time.Now(), TokenRevokedRotated, next.ID, oldID)
+	if err != nil {
+		return err
+	}
+
+	if err := requireRowsAffected(result); err != nil {
+		if err == ErrNotFound {
+			return ErrTokenReused
+		}
+		return err
+	}
+
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeFamily revokes every active refresh token of the family and
+// deny-lists the access tokens issued with them that have not expired yet.
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, reason string) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+		SELECT access_token_id, user_id, access_expires_at, $1
+		FROM refresh_tokens
+		WHERE family_id = $2 AND access_token_id IS NOT NULL AND access_expires_at > $1
+		ON CONFLICT (jti) DO NOTHING
+	`, now, familyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = $1, revoked_reason = $2
+		WHERE family_id = $3 AND revoked_at IS NULL
+	`, now, reason, familyID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Refresh token family revoked", "family_id", familyID, "reason", reason)
+	return nil
+}
+
+// GetActiveSessions returns one entry per refresh token family of the user
+// that still has an unrevoked, unexpired token.
+func (r *PostgresRefreshTokenRepository) GetActiveSessions(ctx context.Context, userID string) ([]Session, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT t.family_id, t.user_agent, t.ip_address, f.started_at, t.created_at, t.expires_at
+		FROM refresh_tokens t
+		JOIN (
+			SELECT family_id, MIN(created_at) AS started_at
+			FROM refresh_tokens
+			WHERE user_id = $1
+			GROUP BY family_id
+		) f ON f.family_id = t.family_id
+		WHERE t.user_id = $1 AND t.revoked_at IS NULL AND t.expires_at > $2
+		ORDER BY t.created_at DESC
+	`, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *PostgresRefreshTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM revoked_access_tokens WHERE jti = $1)`, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeAccessToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO revoked_access_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, userID, expiresAt, time.Now())
+	return err
+}
+
+// DeleteExpired removes deny-list entries and refresh tokens that can no
+// longer be used.
+func (r *PostgresRefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	now := time.Now()
+
+	if _, err := r.DB.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < $1`, now); err != nil {
+		return err
+	}
+
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < $1`, now)
+	return err
+}
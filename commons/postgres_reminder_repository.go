@@ -0,0 +1,103 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ReminderRepositoryInterface interface {
+	GetLeadTimes(ctx context.Context, userID string) ([]int, error)
+	SetLeadTimes(ctx context.Context, userID string, leadTimes []int) error
+	Claim(ctx context.Context, reminder TaskReminder) (bool, error)
+}
+
+type PostgresReminderRepository struct {
+	DB DBTX
+}
+
+func NewPostgresReminderRepository(db *sql.DB) *PostgresReminderRepository {
+	return &PostgresReminderRepository{DB: db}
+}
+
+// GetLeadTimes returns the reminder lead times a user chose, in minutes and
+// largest first. It is empty if the user has not chosen any.
+func (r *PostgresReminderRepository) GetLeadTimes(ctx context.Context, userID string) ([]int, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT lead_time_minutes FROM reminder_lead_times
+		WHERE user_id = $1
+		ORDER BY lead_time_minutes DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leadTimes := []int{}
+	for rows.Next() {
+		var leadTime int
+		if err := rows.Scan(&leadTime); err != nil {
+			return nil, err
+		}
+		leadTimes = append(leadTimes, leadTime)
+	}
+
+	return leadTimes, rows.Err()
+}
+
+// SetLeadTimes replaces the reminder lead times of a user. An empty list
+// restores the defaults.
+func (r *PostgresReminderRepository) SetLeadTimes(ctx context.Context, userID string, leadTimes []int) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reminder_lead_times WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, leadTime := range leadTimes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO reminder_lead_times (user_id, lead_time_minutes, created_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, userID, leadTime, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Claim records a reminder as sent on its channel and reports whether this
+// call recorded it. A reminder that was already claimed on the channel, or
+// is being claimed in another transaction that commits, is not claimed
+// again, so it is sent once per channel.
+func (r *PostgresReminderRepository) Claim(ctx context.Context, reminder TaskReminder) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, `
+		INSERT INTO task_reminders (task_id, user_id, lead_time_minutes, due_date, channel, correlation_id, sent_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+	`,
+		reminder.TaskID,
+		reminder.UserID,
+		reminder.LeadTimeMinutes,
+		reminder.DueDate,
+		reminder.Channel,
+		reminder.CorrelationID,
+		reminder.SentAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
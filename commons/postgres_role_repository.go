@@ -0,0 +1,127 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type RoleRepositoryInterface interface {
+	GetRoles(ctx context.Context) ([]Role, error)
+	GetUserRoles(ctx context.Context, userID string) ([]string, error)
+	GetUserPermissions(ctx context.Context, userID string) ([]string, error)
+	AssignRole(ctx context.Context, userID string, roleID string) error
+	RemoveRole(ctx context.Context, userID string, roleID string) error
+}
+
+type PostgresRoleRepository struct {
+	DB DBTX
+}
+
+func NewPostgresRoleRepository(db *sql.DB) *PostgresRoleRepository {
+	return &PostgresRoleRepository{DB: db}
+}
+
+func (r *PostgresRoleRepository) GetRoles(ctx context.Context) ([]Role, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT r.id, r.description,
+			COALESCE(array_agg(rp.permission_id ORDER BY rp.permission_id) FILTER (WHERE rp.permission_id IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		GROUP BY r.id, r.description
+		ORDER BY r.id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.ID, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (r *PostgresRoleRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT role_id FROM user_roles WHERE user_id = $1 ORDER BY role_id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStrings(rows)
+}
+
+// GetUserPermissions returns the distinct permissions granted to the user by
+// all of their roles.
+func (r *PostgresRoleRepository) GetUserPermissions(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT DISTINCT rp.permission_id
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY rp.permission_id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStrings(rows)
+}
+
+func (r *PostgresRoleRepository) AssignRole(ctx context.Context, userID string, roleID string) error {
+	var exists bool
+	if err := r.DB.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM roles WHERE id = $1)`, roleID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO user_roles (user_id, role_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, role_id) DO NOTHING
+	`, userID, roleID, time.Now())
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to assign role", "role_id", roleID, "target_user_id", userID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresRoleRepository) RemoveRole(ctx context.Context, userID string, roleID string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2
+	`, userID, roleID)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
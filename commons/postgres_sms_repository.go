@@ -0,0 +1,120 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SmsRepositoryInterface interface {
+	LockRecipient(ctx context.Context, userID string) error
+	Queue(ctx context.Context, message SmsMessage) (SmsMessage, error)
+	CountSince(ctx context.Context, userID string, since time.Time) (int, error)
+	MarkSent(ctx context.Context, id string, providerMessageID string) error
+	MarkFailed(ctx context.Context, id string, status string, lastError string) error
+}
+
+type PostgresSmsRepository struct {
+	DB DBTX
+}
+
+func NewPostgresSmsRepository(db *sql.DB) *PostgresSmsRepository {
+	return &PostgresSmsRepository{DB: db}
+}
+
+// LockRecipient waits for and takes a lock on the SMS of a user, held until
+// the transaction ends, so that the rate limit of one user is checked by one
+// transaction at a time. Call it in a unit of work; outside of one the lock
+// is released right away.
+func (r *PostgresSmsRepository) LockRecipient(ctx context.Context, userID string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		SELECT pg_advisory_xact_lock(hashtext('sms_messages'), hashtext($1))
+	`, userID)
+	return err
+}
+
+// Queue records message as queued and returns it. If an SMS of the same
+// notification (same correlation ID) to the same user was recorded before,
+// the existing record is returned instead, so callers can skip SMS that were
+// already sent.
+func (r *PostgresSmsRepository) Queue(ctx context.Context, message SmsMessage) (SmsMessage, error) {
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+
+	var queued SmsMessage
+	var providerMessageID, lastError sql.NullString
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO sms_messages (id, user_id, task_id, correlation_id, phone_number, body, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (correlation_id, user_id) DO UPDATE SET status = sms_messages.status
+		RETURNING id, user_id, task_id, correlation_id, phone_number, body, status, provider_message_id, last_error, created_at, sent_at
+	`,
+		message.ID,
+		message.UserID,
+		message.TaskID,
+		message.CorrelationID,
+		message.PhoneNumber,
+		message.Body,
+		SmsStatusQueued,
+		time.Now().UTC(),
+	).Scan(
+		&queued.ID,
+		&queued.UserID,
+		&queued.TaskID,
+		&queued.CorrelationID,
+		&queued.PhoneNumber,
+		&queued.Body,
+		&queued.Status,
+		&providerMessageID,
+		&lastError,
+		&queued.CreatedAt,
+		&queued.SentAt,
+	)
+	if err != nil {
+		return SmsMessage{}, err
+	}
+	queued.ProviderMessageID = providerMessageID.String
+	queued.LastError = lastError.String
+
+	return queued, nil
+}
+
+// CountSince returns how many SMS were sent, or are being sent, to a user
+// since the given time. Rate limited ones are not counted.
+func (r *PostgresSmsRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	var count int
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sms_messages
+		WHERE user_id = $1 AND created_at > $2 AND status <> $3
+	`, userID, since.UTC(), SmsStatusRateLimited).Scan(&count)
+	return count, err
+}
+
+// MarkSent records a successful delivery.
+func (r *PostgresSmsRepository) MarkSent(ctx context.Context, id string, providerMessageID string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE sms_messages
+		SET status = $1, provider_message_id = $2, last_error = NULL, sent_at = $3
+		WHERE id = $4
+	`, SmsStatusSent, providerMessageID, time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// MarkFailed records an SMS that was not sent. status is SmsStatusFailed
+// for errors worth retrying and SmsStatusRateLimited when the recipient got
+// too many SMS.
+func (r *PostgresSmsRepository) MarkFailed(ctx context.Context, id string, status string, lastError string) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE sms_messages SET status = $1, last_error = $2 WHERE id = $3
+	`, status, lastError, id)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
@@ -0,0 +1,64 @@
+package commons
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval is how often an idle listener checks that its
+	// connection is still alive.
+	listenerPingInterval = 90 * time.Second
+)
+
+// PostgresStreamListener listens on StreamEventsChannel on a connection of
+// its own. Notices sent while the connection is down are lost.
+type PostgresStreamListener struct {
+	ConnStr string
+}
+
+func NewPostgresStreamListener(connStr string) *PostgresStreamListener {
+	return &PostgresStreamListener{ConnStr: connStr}
+}
+
+// Listen calls handle with every notice until ctx is canceled.
+func (l *PostgresStreamListener) Listen(ctx context.Context, handle func(notice StreamEventNotice)) error {
+	listener := pq.NewListener(l.ConnStr, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.WarnContext(ctx, "Stream listener connection event", "event", event, "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(StreamEventsChannel); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(listenerPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// nil is sent after a reconnect.
+			if notification == nil {
+				continue
+			}
+			var notice StreamEventNotice
+			if err := json.Unmarshal([]byte(notification.Extra), &notice); err != nil {
+				logger.WarnContext(ctx, "Invalid stream event notice", "notice", notification.Extra, "error", err)
+				continue
+			}
+			handle(notice)
+		case <-ticker.C:
+			go listener.Ping()
+		}
+	}
+}
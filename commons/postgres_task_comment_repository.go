@@ -0,0 +1,257 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TaskCommentRepositoryInterface interface {
+	Create(ctx context.Context, comment TaskComment) (TaskComment, error)
+	GetByID(ctx context.Context, id string) (TaskComment, error)
+	GetByTaskID(ctx context.Context, taskID string) ([]TaskComment, error)
+	UpdateBody(ctx context.Context, id string, body string, editedBy string) (TaskComment, error)
+	Delete(ctx context.Context, id string) error
+	GetEdits(ctx context.Context, commentID string) ([]TaskCommentEdit, error)
+	AddMentions(ctx context.Context, commentID string, userIDs []string) error
+}
+
+type PostgresTaskCommentRepository struct {
+	DB DBTX
+}
+
+func NewPostgresTaskCommentRepository(db *sql.DB) *PostgresTaskCommentRepository {
+	return &PostgresTaskCommentRepository{DB: db}
+}
+
+const taskCommentColumns = `
+	c.id, c.task_id, c.author_id, c.parent_id, c.body, c.edited, c.deleted, c.deleted_at, c.created_at, c.updated_at,
+	COALESCE((SELECT array_agg(m.user_id ORDER BY m.created_at) FROM task_comment_mentions m WHERE m.comment_id = c.id), '{}')
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTaskComment(row rowScanner) (TaskComment, error) {
+	var dbComment DBTaskComment
+	var parentID sql.NullString
+	var mentions []string
+
+	err := row.Scan(
+		&dbComment.ID,
+		&dbComment.TaskID,
+		&dbComment.AuthorID,
+		&parentID,
+		&dbComment.Body,
+		&dbComment.Edited,
+		&dbComment.Deleted,
+		&dbComment.DeletedAt,
+		&dbComment.CreatedAt,
+		&dbComment.UpdatedAt,
+		pq.Array(&mentions),
+	)
+	if err != nil {
+		return TaskComment{}, err
+	}
+
+	if parentID.Valid {
+		dbComment.ParentID = &parentID.String
+	}
+
+	comment := dbComment.ToTaskComment()
+	comment.MentionedUserIDs = mentions
+	return comment, nil
+}
+
+func (r *PostgresTaskCommentRepository) Create(ctx context.Context, comment TaskComment) (TaskComment, error) {
+	dbComment := &DBTaskComment{}
+	dbComment.FromTaskComment(comment)
+
+	if dbComment.ID == "" {
+		dbComment.ID = uuid.New().String()
+	}
+
+	now := time.Now()
+	dbComment.CreatedAt = now
+	dbComment.UpdatedAt = now
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO task_comments (id, task_id, author_id, parent_id, body, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`,
+		dbComment.ID,
+		dbComment.TaskID,
+		dbComment.AuthorID,
+		dbComment.ParentID,
+		dbComment.Body,
+		dbComment.CreatedAt,
+		dbComment.UpdatedAt,
+	)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to create task comment", "error", err)
+		return TaskComment{}, err
+	}
+
+	return dbComment.ToTaskComment(), nil
+}
+
+func (r *PostgresTaskCommentRepository) GetByID(ctx context.Context, id string) (TaskComment, error) {
+	row := r.DB.QueryRowContext(ctx, `SELECT `+taskCommentColumns+` FROM task_comments c WHERE c.id = $1`, id)
+
+	comment, err := scanTaskComment(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TaskComment{}, ErrNotFound
+		}
+		return TaskComment{}, err
+	}
+
+	return comment, nil
+}
+
+func (r *PostgresTaskCommentRepository) GetByTaskID(ctx context.Context, taskID string) ([]TaskComment, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+taskCommentColumns+`
+		FROM task_comments c
+		WHERE c.task_id = $1
+		ORDER BY c.created_at ASC
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []TaskComment{}
+	for rows.Next() {
+		comment, err := scanTaskComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// UpdateBody replaces the body of a comment and records the previous body in
+// task_comment_edits, atomically.
+func (r *PostgresTaskCommentRepository) UpdateBody(ctx context.Context, id string, body string, editedBy string) (TaskComment, error) {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return TaskComment{}, err
+	}
+	defer tx.Rollback()
+
+	var previousBody string
+	err = tx.QueryRowContext(ctx, `SELECT body FROM task_comments WHERE id = $1 AND deleted = false FOR UPDATE`, id).Scan(&previousBody)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return TaskComment{}, ErrNotFound
+		}
+		return TaskComment{}, err
+	}
+
+	now := time.Now()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_comment_edits (id, comment_id, previous_body, edited_by, edited_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, uuid.New().String(), id, previousBody, editedBy, now)
+	if err != nil {
+		return TaskComment{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE task_comments
+		SET body = $1, edited = true, updated_at = $2
+		WHERE id = $3
+	`, body, now, id)
+	if err != nil {
+		return TaskComment{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TaskComment{}, err
+	}
+
+	logger.DebugContext(ctx, "Task comment updated", "comment_id", id)
+	return r.GetByID(ctx, id)
+}
+
+func (r *PostgresTaskCommentRepository) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE task_comments
+		SET deleted = true, deleted_at = $1, updated_at = $2
+		WHERE id = $3 AND deleted = false
+	`,
+		now,
+		now,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	logger.DebugContext(ctx, "Task comment soft deleted", "comment_id", id)
+	return nil
+}
+
+func (r *PostgresTaskCommentRepository) GetEdits(ctx context.Context, commentID string) ([]TaskCommentEdit, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, comment_id, previous_body, edited_by, edited_at
+		FROM task_comment_edits
+		WHERE comment_id = $1
+		ORDER BY edited_at DESC
+	`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edits := []TaskCommentEdit{}
+	for rows.Next() {
+		var dbEdit DBTaskCommentEdit
+		err := rows.Scan(
+			&dbEdit.ID,
+			&dbEdit.CommentID,
+			&dbEdit.PreviousBody,
+			&dbEdit.EditedBy,
+			&dbEdit.EditedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, dbEdit.ToTaskCommentEdit())
+	}
+
+	return edits, rows.Err()
+}
+
+// AddMentions records the users mentioned in a comment. Already recorded
+// mentions are ignored.
+func (r *PostgresTaskCommentRepository) AddMentions(ctx context.Context, commentID string, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO task_comment_mentions (comment_id, user_id, created_at)
+		SELECT $1, user_id, $3 FROM unnest($2::text[]) AS user_id
+		ON CONFLICT (comment_id, user_id) DO NOTHING
+	`, commentID, pq.Array(userIDs), time.Now())
+	return err
+}
@@ -1,65 +1,191 @@
 package commons
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
-	"log"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type TaskRepositoryInterface interface {
-	GetAll() ([]Task, error)
-	GetByID(id string) (Task, error)
-	GetByUserID(userID string) ([]Task, error)
-	Create(task Task) (Task, error)
-	Update(task Task) error
-	Delete(id string) error
-	HardDelete(id string) error
+	List(ctx context.Context, query TaskQuery) (TaskPage, error)
+	GetByID(ctx context.Context, id string) (Task, error)
+	GetByUserID(ctx context.Context, userID string) ([]Task, error)
+	Create(ctx context.Context, task Task) (Task, error)
+	Update(ctx context.Context, task Task) error
+	MarkEmailSent(ctx context.Context, id string) error
+	MarkInAppSent(ctx context.Context, id string) error
+	ListOccurrences(ctx context.Context, seriesID string, from time.Time) ([]Task, error)
+	ListOpenDueBetween(ctx context.Context, from time.Time, to time.Time) ([]Task, error)
+	Delete(ctx context.Context, id string) error
+	HardDelete(ctx context.Context, id string) error
 }
 
 type PostgresTaskRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewPostgresTaskRepository(db *sql.DB) *PostgresTaskRepository {
 	return &PostgresTaskRepository{DB: db}
 }
 
-func (r *PostgresTaskRepository) GetAll() ([]Task, error) {
-	log.Println("Getting all tasks with related events")
+var taskSortColumns = map[string]struct {
+	expr     string
+	castType string
+}{
+	TaskSortCreatedAt: {"t.created_at", "timestamp"},
+	TaskSortUpdatedAt: {"t.updated_at", "timestamp"},
+	TaskSortDueDate:   {"COALESCE(t.due_date, 'infinity'::timestamp)", "timestamp"},
+	TaskSortPriority:  {"t.priority", "integer"},
+	TaskSortTitle:     {"t.title", "text"},
+}
 
-	rows, err := r.DB.Query(`
-		SELECT 
-			t.id, t.creator_id, t.assignee_id, t.title, t.description, t.status, t.priority,
+type taskCursor struct {
+	SortBy  string `json:"s"`
+	SortDir string `json:"d"`
+	Value   string `json:"v"`
+	ID      string `json:"id"`
+}
+
+func encodeTaskCursor(cursor taskCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTaskCursor decodes a cursor of a page sorted by sortBy in sortDir.
+// Cursors that do not decode, or belong to a differently sorted list, are
+// rejected with ErrInvalidInput.
+func decodeTaskCursor(encoded string, sortBy string, sortDir string) (taskCursor, error) {
+	var cursor taskCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return taskCursor{}, ErrInvalidInput
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return taskCursor{}, ErrInvalidInput
+	}
+	if cursor.SortBy != sortBy || cursor.SortDir != sortDir || cursor.ID == "" {
+		return taskCursor{}, ErrInvalidInput
+	}
+	return cursor, nil
+}
+
+// List returns one page of non-deleted tasks matching query, with their events.
+// Filtering, sorting and keyset pagination are all done in SQL.
+func (r *PostgresTaskRepository) List(ctx context.Context, query TaskQuery) (TaskPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = TaskSortCreatedAt
+	}
+	if query.SortDir == "" {
+		query.SortDir = SortDesc
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultTaskPageSize
+	}
+	if query.Limit > MaxTaskPageSize {
+		query.Limit = MaxTaskPageSize
+	}
+
+	sortColumn, ok := taskSortColumns[query.SortBy]
+	if !ok || (query.SortDir != SortAsc && query.SortDir != SortDesc) {
+		return TaskPage{}, ErrInvalidInput
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"t.deleted = false"}
+
+	if query.VisibleTo != "" {
+		// Project tasks are visible to project members, personal tasks to their creator and assignee.
+		p := arg(query.VisibleTo)
+		conditions = append(conditions, fmt.Sprintf(
+			"((t.project_id IS NULL AND (t.creator_id = %[1]s OR t.assignee_id = %[1]s)) OR t.project_id IN (SELECT pm.project_id FROM project_members pm WHERE pm.user_id = %[1]s))", p))
+	}
+	if query.ProjectID != "" {
+		conditions = append(conditions, "t.project_id = "+arg(query.ProjectID))
+	}
+	if len(query.Statuses) > 0 {
+		conditions = append(conditions, "t.status = ANY("+arg(pq.Array(query.Statuses))+")")
+	}
+	if query.MinPriority > 0 {
+		conditions = append(conditions, "t.priority >= "+arg(query.MinPriority))
+	}
+	if query.MaxPriority > 0 {
+		conditions = append(conditions, "t.priority <= "+arg(query.MaxPriority))
+	}
+	if query.DueAfter != nil {
+		conditions = append(conditions, "t.due_date >= "+arg(*query.DueAfter))
+	}
+	if query.DueBefore != nil {
+		conditions = append(conditions, "t.due_date <= "+arg(*query.DueBefore))
+	}
+	if query.AssigneeID != "" {
+		conditions = append(conditions, "t.assignee_id = "+arg(query.AssigneeID))
+	}
+	if query.CreatorID != "" {
+		conditions = append(conditions, "t.creator_id = "+arg(query.CreatorID))
+	}
+	if query.Search != "" {
+		p := arg("%" + likeEscaper.Replace(query.Search) + "%")
+		conditions = append(conditions, fmt.Sprintf("(t.title ILIKE %s OR t.description ILIKE %s)", p, p))
+	}
+
+	comparator := "<"
+	if query.SortDir == SortAsc {
+		comparator = ">"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTaskCursor(query.Cursor, query.SortBy, query.SortDir)
+		if err != nil {
+			return TaskPage{}, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, t.id) %s (%s::%s, %s)",
+			sortColumn.expr, comparator, arg(cursor.Value), sortColumn.castType, arg(cursor.ID)))
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			t.id, t.creator_id, t.assignee_id, t.project_id, t.series_id, t.occurrence_at, t.title, t.description, t.status, t.priority,
 			t.email_sent, t.in_app_sent, t.due_date, t.created_at, t.updated_at, t.deleted, t.deleted_at,
-			e.id, e.task_id, e.correlation_id, e.origin, e.action,
-			e.message, e.json_data, e.emit_at, e.created_at
+			(%[1]s)::text
 		FROM tasks t
-		LEFT JOIN task_system_events e ON t.id = e.task_id
-		WHERE t.deleted = false
-		ORDER BY t.created_at DESC, e.created_at DESC
-	`)
+		WHERE %[2]s
+		ORDER BY %[1]s %[3]s, t.id %[3]s
+		LIMIT %[4]s
+	`, sortColumn.expr, strings.Join(conditions, " AND "), strings.ToUpper(query.SortDir), arg(query.Limit+1))
+
+	rows, err := r.DB.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		log.Printf("Failed to get tasks with events: %v", err)
-		return nil, err
+		logger.ErrorContext(ctx, "Failed to list tasks", "error", err)
+		return TaskPage{}, err
 	}
 	defer rows.Close()
 
-	tasksMap := make(map[string]*DBTask)
-	var tasks []Task
-
+	var dbTasks []DBTask
+	var sortKeys []string
 	for rows.Next() {
 		var dbTask DBTask
 		var dueDate sql.NullTime
-		var assigneeID sql.NullString
-
-		var eventID, eventTaskId, eventCorrelationId, eventOrigin, eventAction, eventMessage, eventJsonData sql.NullString
-		var eventEmitAt, eventCreatedAt sql.NullTime
+		var assigneeID, projectID sql.NullString
+		var sortKey string
 
 		err := rows.Scan(
 			&dbTask.ID,
 			&dbTask.CreatorID,
 			&assigneeID,
+			&projectID,
+			&dbTask.SeriesID,
+			&dbTask.OccurrenceAt,
 			&dbTask.Title,
 			&dbTask.Description,
 			&dbTask.Status,
@@ -71,19 +197,11 @@ func (r *PostgresTaskRepository) GetAll() ([]Task, error) {
 			&dbTask.UpdatedAt,
 			&dbTask.Deleted,
 			&dbTask.DeletedAt,
-			&eventID,
-			&eventTaskId,
-			&eventCorrelationId,
-			&eventOrigin,
-			&eventAction,
-			&eventMessage,
-			&eventJsonData,
-			&eventEmitAt,
-			&eventCreatedAt,
+			&sortKey,
 		)
 		if err != nil {
-			log.Printf("Failed to scan task and event: %v", err)
-			return nil, err
+			logger.ErrorContext(ctx, "Failed to scan task", "error", err)
+			return TaskPage{}, err
 		}
 
 		if dueDate.Valid {
@@ -94,49 +212,102 @@ func (r *PostgresTaskRepository) GetAll() ([]Task, error) {
 			dbTask.AssigneeID = &assigneeID.String
 		}
 
-		existingTask, exists := tasksMap[dbTask.ID]
-		if !exists {
-			dbTask.Events = []TaskSystemEvent{}
-			tasksMap[dbTask.ID] = &dbTask
-			existingTask = &dbTask
+		if projectID.Valid {
+			dbTask.ProjectID = &projectID.String
 		}
 
-		if eventID.Valid {
-			dbEvent := DBTaskSystemEvent{
-				ID:            eventID.String,
-				TaskId:        eventTaskId.String,
-				CorrelationId: eventCorrelationId.String,
-				Origin:        eventOrigin.String,
-				Action:        eventAction.String,
-				Message:       eventMessage.String,
-				JsonData:      eventJsonData.String,
-				EmitAt:        eventEmitAt.Time,
-				CreatedAt:     eventCreatedAt.Time,
-			}
-			existingTask.Events = append(existingTask.Events, dbEvent.ToTaskSystemEvent())
-		}
+		dbTask.Events = []TaskSystemEvent{}
+		dbTasks = append(dbTasks, dbTask)
+		sortKeys = append(sortKeys, sortKey)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating over rows: %v", err)
-		return nil, err
+		logger.ErrorContext(ctx, "Failed to iterate over task rows", "error", err)
+		return TaskPage{}, err
 	}
 
-	// Convert DB tasks to domain tasks
-	for _, dbTask := range tasksMap {
-		tasks = append(tasks, dbTask.ToTask())
+	page := TaskPage{Tasks: []Task{}}
+	if len(dbTasks) > query.Limit {
+		dbTasks = dbTasks[:query.Limit]
+		last := dbTasks[len(dbTasks)-1]
+		page.NextCursor = encodeTaskCursor(taskCursor{
+			SortBy:  query.SortBy,
+			SortDir: query.SortDir,
+			Value:   sortKeys[len(dbTasks)-1],
+			ID:      last.ID,
+		})
 	}
 
-	log.Println("Tasks with events retrieved successfully")
-	return tasks, nil
+	if err := r.loadEvents(ctx, dbTasks); err != nil {
+		return TaskPage{}, err
+	}
+
+	for _, dbTask := range dbTasks {
+		page.Tasks = append(page.Tasks, dbTask.ToTask())
+	}
+
+	return page, nil
 }
 
-func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
-	log.Println("Getting task with related events by ID:", id)
+// loadEvents fetches the system events of all given tasks in a single query.
+func (r *PostgresTaskRepository) loadEvents(ctx context.Context, dbTasks []DBTask) error {
+	if len(dbTasks) == 0 {
+		return nil
+	}
+
+	taskIndex := make(map[string]int, len(dbTasks))
+	taskIDs := make([]string, len(dbTasks))
+	for i, dbTask := range dbTasks {
+		taskIndex[dbTask.ID] = i
+		taskIDs[i] = dbTask.ID
+	}
 
-	rows, err := r.DB.Query(`
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, task_id, correlation_id, origin, action, message, json_data, emit_at, created_at
+		FROM task_system_events
+		WHERE task_id = ANY($1)
+		ORDER BY created_at DESC
+	`, pq.Array(taskIDs))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to get task events", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbEvent DBTaskSystemEvent
+		var jsonData sql.NullString
+
+		err := rows.Scan(
+			&dbEvent.ID,
+			&dbEvent.TaskId,
+			&dbEvent.CorrelationId,
+			&dbEvent.Origin,
+			&dbEvent.Action,
+			&dbEvent.Message,
+			&jsonData,
+			&dbEvent.EmitAt,
+			&dbEvent.CreatedAt,
+		)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to scan task event", "error", err)
+			return err
+		}
+		dbEvent.JsonData = jsonData.String
+
+		i := taskIndex[dbEvent.TaskId]
+		dbTasks[i].Events = append(dbTasks[i].Events, dbEvent.ToTaskSystemEvent())
+	}
+
+	return rows.Err()
+}
+
+func (r *PostgresTaskRepository) GetByID(ctx context.Context, id string) (Task, error) {
+	logger.DebugContext(ctx, "Getting task with related events", "task_id", id)
+
+	rows, err := r.DB.QueryContext(ctx, `
 		SELECT 
-			t.id, t.creator_id, t.assignee_id, t.title, t.description, t.status, t.priority,
+			t.id, t.creator_id, t.assignee_id, t.project_id, t.series_id, t.occurrence_at, t.title, t.description, t.status, t.priority,
 			t.email_sent, t.in_app_sent, t.due_date, t.created_at, t.updated_at, t.deleted, t.deleted_at,
 			e.id, e.task_id, e.correlation_id, e.origin, e.action, e.message, e.json_data, e.emit_at, e.created_at
 		FROM tasks t
@@ -144,9 +315,9 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 		WHERE t.id = $1
 		ORDER BY e.created_at DESC
 	`, id)
-	
+
 	if err != nil {
-		log.Printf("Failed to get task with events: %v", err)
+		logger.ErrorContext(ctx, "Failed to get task with events", "task_id", id, "error", err)
 		return Task{}, err
 	}
 	defer rows.Close()
@@ -156,7 +327,7 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 
 	for rows.Next() {
 		var dueDate sql.NullTime
-		var assigneeID sql.NullString
+		var assigneeID, projectID sql.NullString
 
 		var eventID, eventTaskID, eventCorrelationID, eventOrigin, eventAction, eventMessage, eventJsonData sql.NullString
 		var eventEmitAt, eventCreatedAt sql.NullTime
@@ -165,6 +336,9 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 			&dbTask.ID,
 			&dbTask.CreatorID,
 			&assigneeID,
+			&projectID,
+			&dbTask.SeriesID,
+			&dbTask.OccurrenceAt,
 			&dbTask.Title,
 			&dbTask.Description,
 			&dbTask.Status,
@@ -187,7 +361,7 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 			&eventCreatedAt,
 		)
 		if err != nil {
-			log.Printf("Failed to scan task and event: %v", err)
+			logger.ErrorContext(ctx, "Failed to scan task and event", "error", err)
 			return Task{}, err
 		}
 
@@ -199,6 +373,10 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 			dbTask.AssigneeID = &assigneeID.String
 		}
 
+		if projectID.Valid {
+			dbTask.ProjectID = &projectID.String
+		}
+
 		if !found {
 			dbTask.Events = []TaskSystemEvent{}
 			found = true
@@ -228,7 +406,7 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating over rows: %v", err)
+		logger.ErrorContext(ctx, "Failed to iterate over task rows", "error", err)
 		return Task{}, err
 	}
 
@@ -236,13 +414,13 @@ func (r *PostgresTaskRepository) GetByID(id string) (Task, error) {
 		return Task{}, sql.ErrNoRows
 	}
 
-	log.Println("Task with events retrieved successfully")
+	logger.DebugContext(ctx, "Task with events retrieved", "task_id", id)
 	return dbTask.ToTask(), nil
 }
 
-func (r *PostgresTaskRepository) GetByUserID(userID string) ([]Task, error) {
-	rows, err := r.DB.Query(`
-		SELECT t.id, t.creator_id, t.assignee_id, t.title, t.description, t.status, t.priority, t.due_date, t.created_at, t.updated_at,
+func (r *PostgresTaskRepository) GetByUserID(ctx context.Context, userID string) ([]Task, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT t.id, t.creator_id, t.assignee_id, t.project_id, t.title, t.description, t.status, t.priority, t.due_date, t.created_at, t.updated_at,
 			json_agg(json_build_object(
 				'id', e.id,
 				'task_id', e.task_id,
@@ -257,7 +435,7 @@ func (r *PostgresTaskRepository) GetByUserID(userID string) ([]Task, error) {
 		FROM tasks t
 		LEFT JOIN task_system_events e ON t.id = e.task_id
 		WHERE (t.creator_id = $1 OR t.assignee_id = $1) AND t.deleted = false
-		GROUP BY t.id, t.creator_id, t.assignee_id, t.title, t.description, t.status, t.priority, t.due_date, t.created_at, t.updated_at
+		GROUP BY t.id, t.creator_id, t.assignee_id, t.project_id, t.title, t.description, t.status, t.priority, t.due_date, t.created_at, t.updated_at
 		ORDER BY t.created_at DESC
 	`, userID)
 	if err != nil {
@@ -269,13 +447,14 @@ func (r *PostgresTaskRepository) GetByUserID(userID string) ([]Task, error) {
 	for rows.Next() {
 		var dbTask DBTask
 		var eventsJSON []byte
-		var assigneeID sql.NullString
+		var assigneeID, projectID sql.NullString
 		var dueDate sql.NullTime
 
 		err := rows.Scan(
 			&dbTask.ID,
 			&dbTask.CreatorID,
 			&assigneeID,
+			&projectID,
 			&dbTask.Title,
 			&dbTask.Description,
 			&dbTask.Status,
@@ -293,6 +472,10 @@ func (r *PostgresTaskRepository) GetByUserID(userID string) ([]Task, error) {
 			dbTask.AssigneeID = &assigneeID.String
 		}
 
+		if projectID.Valid {
+			dbTask.ProjectID = &projectID.String
+		}
+
 		if dueDate.Valid {
 			dbTask.DueDate = dueDate.Time
 		}
@@ -313,19 +496,22 @@ func (r *PostgresTaskRepository) GetByUserID(userID string) ([]Task, error) {
 	return tasks, nil
 }
 
-func (r *PostgresTaskRepository) Create(task Task) (Task, error) {
+func (r *PostgresTaskRepository) Create(ctx context.Context, task Task) (Task, error) {
 	dbTask := &DBTask{}
 	dbTask.FromTask(task)
 	dbTask.CreatedAt = time.Now()
 	dbTask.UpdatedAt = time.Now()
 
-	_, err := r.DB.Exec(`
-		INSERT INTO tasks (id, creator_id, assignee_id, title, description, status, priority, email_sent, in_app_sent, due_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO tasks (id, creator_id, assignee_id, project_id, series_id, occurrence_at, title, description, status, priority, email_sent, in_app_sent, due_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`,
 		dbTask.ID,
 		dbTask.CreatorID,
 		dbTask.AssigneeID,
+		dbTask.ProjectID,
+		dbTask.SeriesID,
+		dbTask.OccurrenceAt,
 		dbTask.Title,
 		dbTask.Description,
 		dbTask.Status,
@@ -344,15 +530,16 @@ func (r *PostgresTaskRepository) Create(task Task) (Task, error) {
 	return dbTask.ToTask(), nil
 }
 
-func (r *PostgresTaskRepository) Update(task Task) error {
+func (r *PostgresTaskRepository) Update(ctx context.Context, task Task) error {
 	dbTask := &DBTask{}
 	dbTask.FromTask(task)
 	dbTask.UpdatedAt = time.Now()
-	
-	_, err := r.DB.Exec(`
+
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE tasks 
-		SET title = $1, description = $2, status = $3, priority = $4, email_sent = $5, in_app_sent = $6, due_date = $7, assignee_id = $8, updated_at = $9
-		WHERE id = $10
+		SET title = $1, description = $2, status = $3, priority = $4, email_sent = $5, in_app_sent = $6, due_date = $7, assignee_id = $8, project_id = $9,
+			series_id = $10, occurrence_at = $11, updated_at = $12
+		WHERE id = $13
 	`,
 		dbTask.Title,
 		dbTask.Description,
@@ -362,16 +549,110 @@ func (r *PostgresTaskRepository) Update(task Task) error {
 		dbTask.InAppSent,
 		dbTask.DueDate,
 		dbTask.AssigneeID,
+		dbTask.ProjectID,
+		dbTask.SeriesID,
+		dbTask.OccurrenceAt,
 		dbTask.UpdatedAt,
 		dbTask.ID,
 	)
-	log.Println("Task updated successfully")
+	logger.DebugContext(ctx, "Task updated", "task_id", task.ID)
+	return err
+}
+
+// MarkEmailSent flags that an email about the task was delivered. It leaves
+// updated_at alone, as the task itself did not change.
+func (r *PostgresTaskRepository) MarkEmailSent(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE tasks SET email_sent = true WHERE id = $1`, id)
 	return err
 }
 
-func (r *PostgresTaskRepository) Delete(id string) error {
+// MarkInAppSent flags that the in-app notifications about the task were
+// created. Like MarkEmailSent it leaves updated_at alone.
+func (r *PostgresTaskRepository) MarkInAppSent(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE tasks SET in_app_sent = true WHERE id = $1`, id)
+	return err
+}
+
+// ListOccurrences returns the occurrences of a recurring task series that
+// are scheduled at or after from and not deleted, earliest first. Events are
+// not loaded.
+func (r *PostgresTaskRepository) ListOccurrences(ctx context.Context, seriesID string, from time.Time) ([]Task, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, creator_id, assignee_id, project_id, series_id, occurrence_at, title, description, status, priority,
+			email_sent, in_app_sent, due_date, created_at, updated_at, deleted, deleted_at
+		FROM tasks
+		WHERE series_id = $1 AND occurrence_at >= $2 AND deleted = false
+		ORDER BY occurrence_at ASC
+	`, seriesID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTaskRows(rows)
+}
+
+// ListOpenDueBetween returns the tasks that are not done or deleted and due
+// between from and to, earliest first. Events are not loaded.
+func (r *PostgresTaskRepository) ListOpenDueBetween(ctx context.Context, from time.Time, to time.Time) ([]Task, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, creator_id, assignee_id, project_id, series_id, occurrence_at, title, description, status, priority,
+			email_sent, in_app_sent, due_date, created_at, updated_at, deleted, deleted_at
+		FROM tasks
+		WHERE due_date BETWEEN $1 AND $2 AND status <> 'DONE' AND deleted = false
+		ORDER BY due_date ASC
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTaskRows(rows)
+}
+
+// scanTaskRows reads tasks selected without their events.
+func scanTaskRows(rows *sql.Rows) ([]Task, error) {
+	var tasks []Task
+	for rows.Next() {
+		var dbTask DBTask
+		var dueDate sql.NullTime
+
+		err := rows.Scan(
+			&dbTask.ID,
+			&dbTask.CreatorID,
+			&dbTask.AssigneeID,
+			&dbTask.ProjectID,
+			&dbTask.SeriesID,
+			&dbTask.OccurrenceAt,
+			&dbTask.Title,
+			&dbTask.Description,
+			&dbTask.Status,
+			&dbTask.Priority,
+			&dbTask.EmailSent,
+			&dbTask.InAppSent,
+			&dueDate,
+			&dbTask.CreatedAt,
+			&dbTask.UpdatedAt,
+			&dbTask.Deleted,
+			&dbTask.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if dueDate.Valid {
+			dbTask.DueDate = dueDate.Time
+		}
+
+		tasks = append(tasks, dbTask.ToTask())
+	}
+
+	return tasks, rows.Err()
+}
+
+func (r *PostgresTaskRepository) Delete(ctx context.Context, id string) error {
 	now := time.Now()
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		UPDATE tasks 
 		SET deleted = $1, deleted_at = $2, updated_at = $3
 		WHERE id = $4
@@ -381,23 +662,23 @@ func (r *PostgresTaskRepository) Delete(id string) error {
 		now,
 		id,
 	)
-	log.Println("Task soft deleted successfully")
+	logger.DebugContext(ctx, "Task soft deleted", "task_id", id)
 	return err
 }
 
-func (r *PostgresTaskRepository) HardDelete(id string) error {
-	_, err := r.DB.Exec("DELETE FROM tasks WHERE id = $1", id)
+func (r *PostgresTaskRepository) HardDelete(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
 	if err != nil {
-		log.Printf("Error hard deleting task with ID %s: %v", id, err)
+		logger.ErrorContext(ctx, "Failed to hard delete task", "task_id", id, "error", err)
 		var exists bool
-		err = r.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", id).Scan(&exists)
+		err = r.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1)", id).Scan(&exists)
 		if err != nil {
-			log.Printf("Error checking if task exists: %v", err)
+			logger.ErrorContext(ctx, "Failed to check if task exists", "task_id", id, "error", err)
 		} else if !exists {
-			log.Printf("Task with ID %s does not exist", id)
+			logger.WarnContext(ctx, "Task does not exist", "task_id", id)
 		}
 	} else {
-		log.Printf("Task with ID %s hard deleted successfully", id)
+		logger.DebugContext(ctx, "Task hard deleted", "task_id", id)
 	}
 	return err
 }
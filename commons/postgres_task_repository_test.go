@@ -0,0 +1,55 @@
+package commons
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestTaskCursorRoundTrip(t *testing.T) {
+	cursor := taskCursor{
+		SortBy:  TaskSortDueDate,
+		SortDir: SortAsc,
+		Value:   "2025-01-31 09:00:00",
+		ID:      "6f1c2a5e-3f0e-4b8e-9d7a-0c4f2d9e8b1a",
+	}
+
+	got, err := decodeTaskCursor(encodeTaskCursor(cursor), TaskSortDueDate, SortAsc)
+	if err != nil {
+		t.Fatalf("decodeTaskCursor failed: %v", err)
+	}
+	if got != cursor {
+		t.Fatalf("decodeTaskCursor() = %+v, want %+v", got, cursor)
+	}
+}
+
+func TestDecodeTaskCursorRejectsInvalidCursors(t *testing.T) {
+	valid := encodeTaskCursor(taskCursor{SortBy: TaskSortPriority, SortDir: SortDesc, Value: "3", ID: "task"})
+	encode := func(data string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(data))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+		sortBy  string
+		sortDir string
+	}{
+		{"not base64", "not a cursor!", TaskSortPriority, SortDesc},
+		{"tampered base64", valid[:len(valid)-3] + "***", TaskSortPriority, SortDesc},
+		{"not json", encode("priority,desc,3,task"), TaskSortPriority, SortDesc},
+		{"json null", encode("null"), TaskSortPriority, SortDesc},
+		{"no id", encode(`{"s":"priority","d":"desc","v":"3"}`), TaskSortPriority, SortDesc},
+		{"other sort column", valid, TaskSortTitle, SortDesc},
+		{"other sort direction", valid, TaskSortPriority, SortAsc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeTaskCursor(tt.encoded, tt.sortBy, tt.sortDir)
+			if !errors.Is(err, ErrInvalidInput) {
+				t.Fatalf("decodeTaskCursor() error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TaskSeriesRepositoryInterface interface {
+	Create(ctx context.Context, series TaskSeries) (TaskSeries, error)
+	GetByID(ctx context.Context, id string) (TaskSeries, error)
+	GetForUpdate(ctx context.Context, id string) (TaskSeries, error)
+	Update(ctx context.Context, series TaskSeries) error
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]TaskSeries, error)
+}
+
+type PostgresTaskSeriesRepository struct {
+	DB DBTX
+}
+
+func NewPostgresTaskSeriesRepository(db *sql.DB) *PostgresTaskSeriesRepository {
+	return &PostgresTaskSeriesRepository{DB: db}
+}
+
+const taskSeriesColumns = `id, rrule, timezone, starts_at, lead_time_seconds, title, description, priority,
+	creator_id, assignee_id, project_id, occurrence_count, next_occurrence_at, created_at, updated_at`
+
+func scanTaskSeries(row rowScanner) (TaskSeries, error) {
+	var dbSeries DBTaskSeries
+	err := row.Scan(
+		&dbSeries.ID,
+		&dbSeries.Rule,
+		&dbSeries.Timezone,
+		&dbSeries.StartsAt,
+		&dbSeries.LeadTimeSeconds,
+		&dbSeries.Title,
+		&dbSeries.Description,
+		&dbSeries.Priority,
+		&dbSeries.CreatorID,
+		&dbSeries.AssigneeID,
+		&dbSeries.ProjectID,
+		&dbSeries.OccurrenceCount,
+		&dbSeries.NextOccurrenceAt,
+		&dbSeries.CreatedAt,
+		&dbSeries.UpdatedAt,
+	)
+	if err != nil {
+		return TaskSeries{}, err
+	}
+	return dbSeries.ToTaskSeries(), nil
+}
+
+func (r *PostgresTaskSeriesRepository) Create(ctx context.Context, series TaskSeries) (TaskSeries, error) {
+	if series.ID == "" {
+		series.ID = uuid.New().String()
+	}
+	now := time.Now()
+	series.CreatedAt = now
+	series.UpdatedAt = now
+
+	_, err := r.DB.ExecContext(ctx, `
+		INSERT INTO task_series (id, rrule, timezone, starts_at, lead_time_seconds, title, description, priority,
+			creator_id, assignee_id, project_id, occurrence_count, next_occurrence_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`,
+		series.ID,
+		series.Rule,
+		series.Timezone,
+		series.StartsAt.UTC(),
+		int(series.LeadTime.Seconds()),
+		series.Title,
+		series.Description,
+		series.Priority,
+		series.CreatorID,
+		series.AssigneeID,
+		series.ProjectID,
+		series.OccurrenceCount,
+		utcOrNil(series.NextOccurrenceAt),
+		series.CreatedAt,
+		series.UpdatedAt,
+	)
+	if err != nil {
+		return TaskSeries{}, err
+	}
+
+	return series, nil
+}
+
+func (r *PostgresTaskSeriesRepository) GetByID(ctx context.Context, id string) (TaskSeries, error) {
+	series, err := scanTaskSeries(r.DB.QueryRowContext(ctx, `SELECT `+taskSeriesColumns+` FROM task_series WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return TaskSeries{}, ErrNotFound
+	}
+	return series, err
+}
+
+// GetForUpdate reads a series and locks it until the end of the unit of
+// work, so it is not advanced twice concurrently.
+func (r *PostgresTaskSeriesRepository) GetForUpdate(ctx context.Context, id string) (TaskSeries, error) {
+	series, err := scanTaskSeries(r.DB.QueryRowContext(ctx, `SELECT `+taskSeriesColumns+` FROM task_series WHERE id = $1 FOR UPDATE`, id))
+	if err == sql.ErrNoRows {
+		return TaskSeries{}, ErrNotFound
+	}
+	return series, err
+}
+
+func (r *PostgresTaskSeriesRepository) Update(ctx context.Context, series TaskSeries) error {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE task_series
+		SET rrule = $1, timezone = $2, starts_at = $3, lead_time_seconds = $4, title = $5, description = $6, priority = $7,
+			assignee_id = $8, project_id = $9, occurrence_count = $10, next_occurrence_at = $11, updated_at = $12
+		WHERE id = $13
+	`,
+		series.Rule,
+		series.Timezone,
+		series.StartsAt.UTC(),
+		int(series.LeadTime.Seconds()),
+		series.Title,
+		series.Description,
+		series.Priority,
+		series.AssigneeID,
+		series.ProjectID,
+		series.OccurrenceCount,
+		utcOrNil(series.NextOccurrenceAt),
+		time.Now(),
+		series.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// ClaimDue returns up to limit series whose next occurrence's window has
+// opened by now and locks them until the end of the unit of work. Series
+// locked by another scheduler are skipped.
+func (r *PostgresTaskSeriesRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]TaskSeries, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+taskSeriesColumns+`
+		FROM task_series
+		WHERE next_occurrence_at IS NOT NULL
+			AND next_occurrence_at - make_interval(secs => lead_time_seconds) <= $1
+		ORDER BY next_occurrence_at ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []TaskSeries
+	for rows.Next() {
+		s, err := scanTaskSeries(rows)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, s)
+	}
+
+	return series, rows.Err()
+}
+
+// utcOrNil stores t as UTC, since timestamps are saved without a time zone.
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
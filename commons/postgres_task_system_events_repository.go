@@ -1,39 +1,124 @@
 package commons
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type TaskSystemEventRepositoryInterface interface {
-	GetAll() ([]TaskSystemEvent, error)
-	Create(systemEvent TaskSystemEvent, delay int) (TaskSystemEvent, error)
+	List(ctx context.Context, query TaskSystemEventQuery) (TaskSystemEventPage, error)
+	GetByID(ctx context.Context, id string) (TaskSystemEvent, error)
+	Create(ctx context.Context, systemEvent TaskSystemEvent, delay int) (TaskSystemEvent, error)
 }
 
 type PostgresTaskSystemEventRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewPostgresTaskSystemEventRepository(db *sql.DB) *PostgresTaskSystemEventRepository {
 	return &PostgresTaskSystemEventRepository{DB: db}
 }
 
-func (r *PostgresTaskSystemEventRepository) GetAll() ([]TaskSystemEvent, error) {
-	rows, err := r.DB.Query(`
-		SELECT id, task_id, correlation_id, origin, action, message, json_data, emit_at, created_at
-		FROM task_system_events
-	`)
+// List returns one page of the events matching query whose emit_at has
+// passed. Events of deleted tasks are included.
+func (r *PostgresTaskSystemEventRepository) List(ctx context.Context, query TaskSystemEventQuery) (TaskSystemEventPage, error) {
+	if query.SortDir == "" {
+		query.SortDir = SortDesc
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultTaskSystemEventPageSize
+	}
+	if query.Limit > MaxTaskSystemEventPageSize {
+		query.Limit = MaxTaskSystemEventPageSize
+	}
+	if query.SortDir != SortAsc && query.SortDir != SortDesc {
+		return TaskSystemEventPage{}, ErrInvalidInput
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// emit_at is a timestamp without time zone written from local time, so
+	// times are compared in local time too.
+	conditions := []string{"e.emit_at <= " + arg(time.Now())}
+
+	if query.VisibleTo != "" {
+		// Same rule as for tasks: project tasks are visible to project members, personal tasks to their creator and assignee.
+		p := arg(query.VisibleTo)
+		conditions = append(conditions, fmt.Sprintf(
+			"((t.project_id IS NULL AND (t.creator_id = %[1]s OR t.assignee_id = %[1]s)) OR t.project_id IN (SELECT pm.project_id FROM project_members pm WHERE pm.user_id = %[1]s))", p))
+	}
+	if query.TaskID != "" {
+		conditions = append(conditions, "e.task_id = "+arg(query.TaskID))
+	}
+	if query.CorrelationID != "" {
+		conditions = append(conditions, "e.correlation_id = "+arg(query.CorrelationID))
+	}
+	if query.Origin != "" {
+		conditions = append(conditions, "e.origin = "+arg(query.Origin))
+	}
+	if query.ActionPrefix != "" {
+		conditions = append(conditions, "e.action LIKE "+arg(likeEscaper.Replace(query.ActionPrefix)+"%"))
+	}
+	if query.EmittedAfter != nil {
+		conditions = append(conditions, "e.emit_at >= "+arg(query.EmittedAfter.Local()))
+	}
+	if query.EmittedBefore != nil {
+		conditions = append(conditions, "e.emit_at <= "+arg(query.EmittedBefore.Local()))
+	}
+
+	comparator := "<"
+	if query.SortDir == SortAsc {
+		comparator = ">"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTaskCursor(query.Cursor, "emit_at", query.SortDir)
+		if err != nil {
+			return TaskSystemEventPage{}, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(e.emit_at, e.id) %s (%s::timestamp, %s)",
+			comparator, arg(cursor.Value), arg(cursor.ID)))
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT e.id, e.task_id, e.correlation_id, e.origin, e.action, e.message, e.json_data, e.emit_at, e.created_at, e.emit_at::text
+		FROM task_system_events e
+		JOIN tasks t ON t.id = e.task_id
+		WHERE %[1]s
+		ORDER BY e.emit_at %[2]s, e.id %[2]s
+		LIMIT %[3]s
+	`, strings.Join(conditions, " AND "), strings.ToUpper(query.SortDir), arg(query.Limit+1))
+
+	rows, err := r.DB.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
-		return nil, err
+		return TaskSystemEventPage{}, err
 	}
 	defer rows.Close()
 
-	var taskSystemEvents []TaskSystemEvent
+	page := TaskSystemEventPage{Events: []TaskSystemEvent{}}
+	var sortKey string
 	for rows.Next() {
+		if len(page.Events) == query.Limit {
+			last := page.Events[len(page.Events)-1]
+			page.NextCursor = encodeTaskCursor(taskCursor{
+				SortBy:  "emit_at",
+				SortDir: query.SortDir,
+				Value:   sortKey,
+				ID:      last.ID,
+			})
+			break
+		}
+
 		var dbEvent DBTaskSystemEvent
-		
 		err := rows.Scan(
 			&dbEvent.ID,
 			&dbEvent.TaskId,
@@ -44,18 +129,49 @@ func (r *PostgresTaskSystemEventRepository) GetAll() ([]TaskSystemEvent, error)
 			&dbEvent.JsonData,
 			&dbEvent.EmitAt,
 			&dbEvent.CreatedAt,
+			&sortKey,
 		)
 		if err != nil {
-			return nil, err
+			return TaskSystemEventPage{}, err
 		}
-		
-		taskSystemEvents = append(taskSystemEvents, dbEvent.ToTaskSystemEvent())
+
+		page.Events = append(page.Events, dbEvent.ToTaskSystemEvent())
 	}
 
-	return taskSystemEvents, nil
+	if err := rows.Err(); err != nil {
+		return TaskSystemEventPage{}, err
+	}
+
+	return page, nil
+}
+
+func (r *PostgresTaskSystemEventRepository) GetByID(ctx context.Context, id string) (TaskSystemEvent, error) {
+	var dbEvent DBTaskSystemEvent
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, task_id, correlation_id, origin, action, message, json_data, emit_at, created_at
+		FROM task_system_events WHERE id = $1
+	`, id).Scan(
+		&dbEvent.ID,
+		&dbEvent.TaskId,
+		&dbEvent.CorrelationId,
+		&dbEvent.Origin,
+		&dbEvent.Action,
+		&dbEvent.Message,
+		&dbEvent.JsonData,
+		&dbEvent.EmitAt,
+		&dbEvent.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return TaskSystemEvent{}, ErrNotFound
+	}
+	if err != nil {
+		return TaskSystemEvent{}, err
+	}
+
+	return dbEvent.ToTaskSystemEvent(), nil
 }
 
-func (r *PostgresTaskSystemEventRepository) Create(taskSystemEvent TaskSystemEvent, delay int) (TaskSystemEvent, error) {
+func (r *PostgresTaskSystemEventRepository) Create(ctx context.Context, taskSystemEvent TaskSystemEvent, delay int) (TaskSystemEvent, error) {
 	dbEvent := &DBTaskSystemEvent{}
 	dbEvent.FromTaskSystemEvent(taskSystemEvent)
 
@@ -66,7 +182,7 @@ func (r *PostgresTaskSystemEventRepository) Create(taskSystemEvent TaskSystemEve
 	dbEvent.EmitAt = time.Now().Add(time.Duration(delay) * time.Second)
 	dbEvent.CreatedAt = time.Now()
 
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		INSERT INTO task_system_events (id, task_id, correlation_id, origin, action, message, json_data, emit_at, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`,
@@ -0,0 +1,254 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type TraceRepositoryInterface interface {
+	GetEvents(ctx context.Context, correlationID string, visibleTo string) ([]TaskSystemEvent, error)
+	List(ctx context.Context, query TraceQuery) (TracePage, error)
+}
+
+// PostgresTraceRepository reads traces, the task system events sharing a
+// correlation ID, from task_system_events. Like the event listing it leaves
+// out events whose emit_at has not come yet.
+type PostgresTraceRepository struct {
+	DB DBTX
+}
+
+func NewPostgresTraceRepository(db *sql.DB) *PostgresTraceRepository {
+	return &PostgresTraceRepository{DB: db}
+}
+
+// traceVisibility is the condition on the task t of an event under which it
+// is visible to the user in the given placeholder, the same rule as for
+// tasks.
+const traceVisibility = "((t.project_id IS NULL AND (t.creator_id = %[1]s OR t.assignee_id = %[1]s)) OR t.project_id IN (SELECT pm.project_id FROM project_members pm WHERE pm.user_id = %[1]s))"
+
+// GetEvents returns the events of a trace in the order they happened, or
+// ErrNotFound if none of them is visible to visibleTo. An empty visibleTo
+// sees every event.
+func (r *PostgresTraceRepository) GetEvents(ctx context.Context, correlationID string, visibleTo string) ([]TaskSystemEvent, error) {
+	args := []interface{}{correlationID, time.Now()}
+	conditions := []string{"e.correlation_id = $1", "e.emit_at <= $2"}
+	if visibleTo != "" {
+		args = append(args, visibleTo)
+		conditions = append(conditions, fmt.Sprintf(traceVisibility, "$3"))
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT e.id, e.task_id, e.correlation_id, e.origin, e.action, e.message, e.json_data, e.emit_at, e.created_at
+		FROM task_system_events e
+		JOIN tasks t ON t.id = e.task_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY e.created_at, e.id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []TaskSystemEvent
+	for rows.Next() {
+		var dbEvent DBTaskSystemEvent
+		err := rows.Scan(
+			&dbEvent.ID,
+			&dbEvent.TaskId,
+			&dbEvent.CorrelationId,
+			&dbEvent.Origin,
+			&dbEvent.Action,
+			&dbEvent.Message,
+			&dbEvent.JsonData,
+			&dbEvent.EmitAt,
+			&dbEvent.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, dbEvent.ToTaskSystemEvent())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, ErrNotFound
+	}
+	return events, nil
+}
+
+// List returns one page of trace summaries matching query, the most recently
+// started first. Statuses are worked out in SQL from TraceExpectations and
+// TraceFailureSuffixes, the same way TraceStatus does for a single trace.
+func (r *PostgresTraceRepository) List(ctx context.Context, query TraceQuery) (TracePage, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultTracePageSize
+	}
+	if query.Limit > MaxTracePageSize {
+		query.Limit = MaxTracePageSize
+	}
+	if query.SlowThreshold <= 0 {
+		query.SlowThreshold = DefaultTraceSlowThreshold
+	}
+
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	now := time.Now()
+	conditions := []string{"e.emit_at <= " + arg(now)}
+	var having []string
+
+	if query.VisibleTo != "" {
+		conditions = append(conditions, fmt.Sprintf(traceVisibility, arg(query.VisibleTo)))
+	}
+	if query.TaskID != "" {
+		conditions = append(conditions, "e.task_id = "+arg(query.TaskID))
+	}
+
+	// Traces are picked by their first event, but once picked all of their
+	// events count, also those from before the time range.
+	var window []string
+	if query.StartedAfter != nil {
+		p := arg(query.StartedAfter.Local())
+		window = append(window, "created_at >= "+p)
+		having = append(having, "MIN(e.created_at) >= "+p)
+	}
+	if query.StartedBefore != nil {
+		p := arg(query.StartedBefore.Local())
+		window = append(window, "created_at <= "+p)
+		having = append(having, "MIN(e.created_at) <= "+p)
+	}
+	if len(window) > 0 {
+		conditions = append(conditions, "e.correlation_id IN (SELECT correlation_id FROM task_system_events WHERE "+strings.Join(window, " AND ")+")")
+	}
+
+	if len(query.Statuses) > 0 {
+		patterns := make([]string, len(TraceFailureSuffixes))
+		for i, suffix := range TraceFailureSuffixes {
+			patterns[i] = "%" + likeEscaper.Replace(suffix)
+		}
+		failed := "BOOL_OR(e.action LIKE ANY(" + arg(pq.Array(patterns)) + "))"
+
+		missing := []string{"false"}
+		for _, expectation := range TraceExpectations {
+			missing = append(missing, fmt.Sprintf("(BOOL_OR(e.action = %s) AND NOT BOOL_OR(e.action = ANY(%s)))",
+				arg(expectation.Trigger), arg(pq.Array(expectation.Outcomes))))
+		}
+		anyMissing := "(" + strings.Join(missing, " OR ") + ")"
+		settled := "MAX(e.created_at) < " + arg(now.Add(-TraceSettleTime))
+
+		var statuses []string
+		for _, status := range query.Statuses {
+			switch status {
+			case TraceStatusFailed:
+				statuses = append(statuses, failed)
+			case TraceStatusCompleted:
+				statuses = append(statuses, fmt.Sprintf("(NOT %s AND NOT %s)", failed, anyMissing))
+			case TraceStatusInProgress:
+				statuses = append(statuses, fmt.Sprintf("(NOT %s AND %s AND NOT %s)", failed, anyMissing, settled))
+			case TraceStatusIncomplete:
+				statuses = append(statuses, fmt.Sprintf("(NOT %s AND %s AND %s)", failed, anyMissing, settled))
+			case TraceFilterSlow:
+				statuses = append(statuses, "EXTRACT(EPOCH FROM MAX(e.created_at) - MIN(e.created_at)) > "+arg(query.SlowThreshold.Seconds()))
+			default:
+				return TracePage{}, ErrInvalidInput
+			}
+		}
+		having = append(having, "("+strings.Join(statuses, " OR ")+")")
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTaskCursor(query.Cursor, "started_at", "")
+		if err != nil {
+			return TracePage{}, err
+		}
+		having = append(having, fmt.Sprintf("(MIN(e.created_at), e.correlation_id) < (%s::timestamp, %s)", arg(cursor.Value), arg(cursor.ID)))
+	}
+
+	havingClause := ""
+	if len(having) > 0 {
+		havingClause = "HAVING " + strings.Join(having, " AND ")
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT
+			e.correlation_id, MIN(e.task_id), MIN(e.created_at), MAX(e.created_at), COUNT(*),
+			ARRAY_AGG(e.origin ORDER BY e.created_at, e.id), ARRAY_AGG(e.action ORDER BY e.created_at, e.id),
+			MIN(e.created_at)::text
+		FROM task_system_events e
+		JOIN tasks t ON t.id = e.task_id
+		WHERE %s
+		GROUP BY e.correlation_id
+		%s
+		ORDER BY MIN(e.created_at) DESC, e.correlation_id DESC
+		LIMIT %s
+	`, strings.Join(conditions, " AND "), havingClause, arg(query.Limit+1))
+
+	rows, err := r.DB.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return TracePage{}, err
+	}
+	defer rows.Close()
+
+	page := TracePage{Traces: []TraceSummary{}}
+	var sortKey string
+	for rows.Next() {
+		if len(page.Traces) == query.Limit {
+			page.NextCursor = encodeTaskCursor(taskCursor{
+				SortBy: "started_at",
+				Value:  sortKey,
+				ID:     page.Traces[len(page.Traces)-1].CorrelationID,
+			})
+			break
+		}
+
+		var summary TraceSummary
+		var origins, actions []string
+		err := rows.Scan(
+			&summary.CorrelationID,
+			&summary.TaskID,
+			&summary.StartedAt,
+			&summary.EndedAt,
+			&summary.EventCount,
+			pq.Array(&origins),
+			pq.Array(&actions),
+			&sortKey,
+		)
+		if err != nil {
+			return TracePage{}, err
+		}
+
+		summary.DurationMs = summary.EndedAt.Sub(summary.StartedAt).Milliseconds()
+		summary.Slow = summary.EndedAt.Sub(summary.StartedAt) > query.SlowThreshold
+		summary.Origins = []string{}
+		for _, origin := range origins {
+			if !slices.Contains(summary.Origins, origin) {
+				summary.Origins = append(summary.Origins, origin)
+			}
+		}
+		for _, action := range actions {
+			if IsFailureAction(action) {
+				summary.Failures++
+			}
+		}
+		summary.Missing = MissingTraceOutcomes(actions)
+		summary.Status = TraceStatus(summary.Failures > 0, summary.Missing, summary.EndedAt)
+
+		page.Traces = append(page.Traces, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return TracePage{}, err
+	}
+
+	return page, nil
+}
@@ -1,36 +1,38 @@
 package commons
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"time"
 )
 
 type UserRepositoryInterface interface {
-	Create(user User) (User, error)
-	GetByID(id string) (User, error)
-	GetByEmail(email string) (User, error)
-	GetByHandle(handle string) (User, error)
-	UpdatePassword(id string, hashedPassword string, salt string) (User, error)
+	Create(ctx context.Context, user User) (User, error)
+	GetByID(ctx context.Context, id string) (User, error)
+	GetByEmail(ctx context.Context, email string) (User, error)
+	GetByHandle(ctx context.Context, handle string) (User, error)
+	UpdatePassword(ctx context.Context, id string, hashedPassword string, salt string) (User, error)
+	SetPhoneNumber(ctx context.Context, id string, phoneNumber string) error
+	MarkPhoneVerified(ctx context.Context, id string, phoneNumber string) (bool, error)
 }
 
 type PostgresUserRepository struct {
-	DB *sql.DB
+	DB DBTX
 }
 
 func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
 	return &PostgresUserRepository{DB: db}
 }
 
-func (r *PostgresUserRepository) Create(user User) (User, error) {
-	log.Printf("Creating user with handle: %s", user.Handle)
+func (r *PostgresUserRepository) Create(ctx context.Context, user User) (User, error) {
+	logger.DebugContext(ctx, "Creating user", "handle", user.Handle)
 
 	dbUser := &DBUser{}
 	dbUser.FromUser(user)
 	dbUser.CreatedAt = time.Now()
 	dbUser.UpdatedAt = time.Now()
 
-	_, err := r.DB.Exec(`
+	_, err := r.DB.ExecContext(ctx, `
 		INSERT INTO users (id, handle, email, password_hash, salt, status, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`,
@@ -44,20 +46,20 @@ func (r *PostgresUserRepository) Create(user User) (User, error) {
 		dbUser.UpdatedAt,
 	)
 	if err != nil {
-		log.Printf("Error creating user: %v", err)
+		logger.ErrorContext(ctx, "Failed to create user", "error", err)
 		return User{}, err
 	}
 
-	log.Printf("User created successfully with ID: %s", dbUser.ID)
+	logger.DebugContext(ctx, "User created", "created_user_id", dbUser.ID)
 	return dbUser.ToUser(), nil
 }
 
-func (r *PostgresUserRepository) GetByID(id string) (User, error) {
-	log.Printf("Getting user by ID: %s", id)
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (User, error) {
+	logger.DebugContext(ctx, "Getting user by ID", "lookup_user_id", id)
 
 	var dbUser DBUser
-	err := r.DB.QueryRow(`
-		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at, phone_number, phone_verified_at
 		FROM users
 		WHERE id = $1
 	`, id).Scan(
@@ -69,26 +71,28 @@ func (r *PostgresUserRepository) GetByID(id string) (User, error) {
 		&dbUser.Status,
 		&dbUser.CreatedAt,
 		&dbUser.UpdatedAt,
+		&dbUser.PhoneNumber,
+		&dbUser.PhoneVerifiedAt,
 	)
 	if err == sql.ErrNoRows {
-		log.Printf("User not found with ID: %s", id)
+		logger.DebugContext(ctx, "User not found", "lookup_user_id", id)
 		return User{}, nil
 	}
 	if err != nil {
-		log.Printf("Error getting user: %v", err)
+		logger.ErrorContext(ctx, "Failed to get user", "error", err)
 		return User{}, err
 	}
 
-	log.Printf("User retrieved successfully with ID: %s", id)
+	logger.DebugContext(ctx, "User retrieved", "lookup_user_id", id)
 	return dbUser.ToUser(), nil
 }
 
-func (r *PostgresUserRepository) GetByEmail(email string) (User, error) {
-	log.Printf("Getting user by email: %s", email)
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	logger.DebugContext(ctx, "Getting user by email")
 
 	var dbUser DBUser
-	err := r.DB.QueryRow(`
-		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at, phone_number, phone_verified_at
 		FROM users
 		WHERE email = $1
 	`, email).Scan(
@@ -100,26 +104,28 @@ func (r *PostgresUserRepository) GetByEmail(email string) (User, error) {
 		&dbUser.Status,
 		&dbUser.CreatedAt,
 		&dbUser.UpdatedAt,
+		&dbUser.PhoneNumber,
+		&dbUser.PhoneVerifiedAt,
 	)
 	if err == sql.ErrNoRows {
-		log.Printf("User not found with email: %s", email)
+		logger.DebugContext(ctx, "User not found by email")
 		return User{}, nil
 	}
 	if err != nil {
-		log.Printf("Error getting user: %v", err)
+		logger.ErrorContext(ctx, "Failed to get user", "error", err)
 		return User{}, err
 	}
 
-	log.Printf("User retrieved successfully with email: %s", email)
+	logger.DebugContext(ctx, "User retrieved by email")
 	return dbUser.ToUser(), nil
 }
 
-func (r *PostgresUserRepository) GetByHandle(handle string) (User, error) {
-	log.Printf("Getting user by handle: %s", handle)
+func (r *PostgresUserRepository) GetByHandle(ctx context.Context, handle string) (User, error) {
+	logger.DebugContext(ctx, "Getting user by handle", "handle", handle)
 
 	var dbUser DBUser
-	err := r.DB.QueryRow(`
-		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT id, handle, email, password_hash, salt, status, created_at, updated_at, phone_number, phone_verified_at
 		FROM users
 		WHERE handle = $1
 	`, handle).Scan(
@@ -131,29 +137,31 @@ func (r *PostgresUserRepository) GetByHandle(handle string) (User, error) {
 		&dbUser.Status,
 		&dbUser.CreatedAt,
 		&dbUser.UpdatedAt,
+		&dbUser.PhoneNumber,
+		&dbUser.PhoneVerifiedAt,
 	)
 	if err == sql.ErrNoRows {
-		log.Printf("User not found with handle: %s", handle)
+		logger.DebugContext(ctx, "User not found", "handle", handle)
 		return User{}, nil
 	}
 	if err != nil {
-		log.Printf("Error getting user: %v", err)
+		logger.ErrorContext(ctx, "Failed to get user", "error", err)
 		return User{}, err
 	}
 
-	log.Printf("User retrieved successfully with handle: %s", handle)
+	logger.DebugContext(ctx, "User retrieved", "handle", handle)
 	return dbUser.ToUser(), nil
 }
 
-func (r *PostgresUserRepository) UpdatePassword(id string, hashedPassword string, salt string) (User, error) {
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id string, hashedPassword string, salt string) (User, error) {
 	var dbUser DBUser
 	query := `
 		UPDATE users 
 		SET password_hash = $1, salt = $2, updated_at = NOW()
 		WHERE id = $3
-		RETURNING id, handle, email, password_hash, salt, status, created_at, updated_at
+		RETURNING id, handle, email, password_hash, salt, status, created_at, updated_at, phone_number, phone_verified_at
 	`
-	err := r.DB.QueryRow(query, hashedPassword, salt, id).Scan(
+	err := r.DB.QueryRowContext(ctx, query, hashedPassword, salt, id).Scan(
 		&dbUser.ID,
 		&dbUser.Handle,
 		&dbUser.Email,
@@ -162,10 +170,50 @@ func (r *PostgresUserRepository) UpdatePassword(id string, hashedPassword string
 		&dbUser.Status,
 		&dbUser.CreatedAt,
 		&dbUser.UpdatedAt,
+		&dbUser.PhoneNumber,
+		&dbUser.PhoneVerifiedAt,
 	)
 	if err != nil {
-		log.Printf("Error updating user password: %v", err)
+		logger.ErrorContext(ctx, "Failed to update user password", "error", err)
 		return User{}, err
 	}
 	return dbUser.ToUser(), nil
 }
+
+// SetPhoneNumber replaces the phone number of a user, which then needs to be
+// verified again. An empty number removes it.
+func (r *PostgresUserRepository) SetPhoneNumber(ctx context.Context, id string, phoneNumber string) error {
+	var number *string
+	if phoneNumber != "" {
+		number = &phoneNumber
+	}
+
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE users
+		SET phone_number = $1, phone_verified_at = NULL, updated_at = NOW()
+		WHERE id = $2
+	`, number, id)
+	if err != nil {
+		return err
+	}
+	return requireRowsAffected(result)
+}
+
+// MarkPhoneVerified marks the phone number of a user as verified and reports
+// whether it did, which it does not if the number has changed meanwhile.
+func (r *PostgresUserRepository) MarkPhoneVerified(ctx context.Context, id string, phoneNumber string) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, `
+		UPDATE users
+		SET phone_verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND phone_number = $2
+	`, id, phoneNumber)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
@@ -0,0 +1,395 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type WebhookRepositoryInterface interface {
+	Create(ctx context.Context, webhook Webhook) (Webhook, error)
+	GetByID(ctx context.Context, id string) (Webhook, error)
+	ListByUser(ctx context.Context, userID string) ([]Webhook, error)
+	Update(ctx context.Context, webhook Webhook) (Webhook, error)
+	Delete(ctx context.Context, id string) error
+	Enqueue(ctx context.Context, event WebhookEvent) (int, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, attempt WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id string) (WebhookDelivery, error)
+	Redeliver(ctx context.Context, id string) (WebhookDelivery, error)
+}
+
+type PostgresWebhookRepository struct {
+	DB DBTX
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{DB: db}
+}
+
+const webhookColumns = `id, user_id, project_id, url, secret, events, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, response_status,
+	last_error, next_attempt_at, delivered_at, created_at`
+
+func (r *PostgresWebhookRepository) Create(ctx context.Context, webhook Webhook) (Webhook, error) {
+	if webhook.ID == "" {
+		webhook.ID = uuid.New().String()
+	}
+	now := time.Now()
+
+	return scanWebhook(r.DB.QueryRowContext(ctx, `
+		INSERT INTO webhooks (id, user_id, project_id, url, secret, events, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING `+webhookColumns,
+		webhook.ID,
+		webhook.UserID,
+		webhook.ProjectID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		now,
+	))
+}
+
+func (r *PostgresWebhookRepository) GetByID(ctx context.Context, id string) (Webhook, error) {
+	webhook, err := scanWebhook(r.DB.QueryRowContext(ctx, `
+		SELECT `+webhookColumns+` FROM webhooks WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return Webhook{}, ErrNotFound
+	}
+	return webhook, err
+}
+
+// ListByUser returns the webhooks a user registered, newest first.
+func (r *PostgresWebhookRepository) ListByUser(ctx context.Context, userID string) ([]Webhook, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Update changes the URL, events and active flag of a webhook.
+func (r *PostgresWebhookRepository) Update(ctx context.Context, webhook Webhook) (Webhook, error) {
+	updated, err := scanWebhook(r.DB.QueryRowContext(ctx, `
+		UPDATE webhooks
+		SET url = $1, events = $2, active = $3, updated_at = $4
+		WHERE id = $5
+		RETURNING `+webhookColumns,
+		webhook.URL,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		time.Now(),
+		webhook.ID,
+	))
+	if err == sql.ErrNoRows {
+		return Webhook{}, ErrNotFound
+	}
+	return updated, err
+}
+
+// Delete removes a webhook with its deliveries.
+func (r *PostgresWebhookRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowsAffected(result)
+}
+
+// Enqueue stores a pending delivery of event for every active webhook
+// subscribed to it and returns how many there are. Project webhooks only get
+// events while their owner is still a member of the project. An event that
+// was enqueued before is not enqueued again, so callers may retry.
+func (r *PostgresWebhookRepository) Enqueue(ctx context.Context, event WebhookEvent) (int, error) {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT w.id FROM webhooks w
+		WHERE w.active AND $1 = ANY(w.events) AND (
+			(w.project_id IS NULL AND w.user_id = ANY($2))
+			OR (w.project_id = $3 AND EXISTS (
+				SELECT 1 FROM project_members m WHERE m.project_id = w.project_id AND m.user_id = w.user_id
+			))
+		)
+	`, event.Type, pq.Array(event.UserIDs), event.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	var webhookIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		webhookIDs = append(webhookIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(webhookIDs) == 0 {
+		return 0, nil
+	}
+
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: createdAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, webhookID := range webhookIDs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+			ON CONFLICT (webhook_id, event_id) DO NOTHING
+		`, uuid.New().String(), webhookID, event.ID, event.Type, string(payload), WebhookDeliveryStatusPending, now)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(webhookIDs), tx.Commit()
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries of active
+// webhooks that are due, oldest first, counts the attempt and pushes their
+// next attempt lease into the future, like the outbox does with its events.
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error) {
+	now := time.Now()
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, next_attempt_at = $1
+		WHERE id IN (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = $2 AND d.next_attempt_at <= $3 AND w.active
+			ORDER BY d.created_at ASC
+			LIMIT $4
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns,
+		now.Add(lease), WebhookDeliveryStatusPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt logs an attempt to post a delivery and moves the delivery to
+// status; pending deliveries are tried again at nextAttemptAt.
+func (r *PostgresWebhookRepository) RecordAttempt(ctx context.Context, attempt WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	tx, err := beginTx(ctx, r.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if attempt.ID == "" {
+		attempt.ID = uuid.New().String()
+	}
+	now := time.Now()
+	var lastError *string
+	if attempt.Error != "" {
+		lastError = &attempt.Error
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (id, delivery_id, attempt, response_status, response_length, error, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, attempt.ID, attempt.DeliveryID, attempt.Attempt, attempt.ResponseStatus, attempt.ResponseLength, lastError, attempt.DurationMs, now)
+	if err != nil {
+		return err
+	}
+
+	var deliveredAt *time.Time
+	if status == WebhookDeliveryStatusDelivered {
+		deliveredAt = &now
+	}
+	result, err := tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, response_status = $2, last_error = $3, next_attempt_at = $4, delivered_at = $5
+		WHERE id = $6
+	`, status, attempt.ResponseStatus, lastError, nextAttemptAt, deliveredAt, attempt.DeliveryID)
+	if err != nil {
+		return err
+	}
+	if err := requireRowsAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first,
+// without their attempts.
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetDelivery returns a delivery with all its attempts, oldest first.
+func (r *PostgresWebhookRepository) GetDelivery(ctx context.Context, id string) (WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.DB.QueryRowContext(ctx, `
+		SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return WebhookDelivery{}, ErrNotFound
+	}
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, delivery_id, attempt, response_status, response_length, error, duration_ms, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY created_at ASC
+	`, id)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt WebhookDeliveryAttempt
+		var attemptError sql.NullString
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.DeliveryID,
+			&attempt.Attempt,
+			&attempt.ResponseStatus,
+			&attempt.ResponseLength,
+			&attemptError,
+			&attempt.DurationMs,
+			&attempt.CreatedAt,
+		)
+		if err != nil {
+			return WebhookDelivery{}, err
+		}
+		attempt.Error = attemptError.String
+		delivery.AttemptLog = append(delivery.AttemptLog, attempt)
+	}
+
+	return delivery, rows.Err()
+}
+
+// Redeliver makes a delivery pending again and due right away, with a fresh
+// count of attempts. Its earlier attempts stay in its log.
+func (r *PostgresWebhookRepository) Redeliver(ctx context.Context, id string) (WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(r.DB.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = 0, next_attempt_at = $2
+		WHERE id = $3
+		RETURNING `+webhookDeliveryColumns,
+		WebhookDeliveryStatusPending, time.Now(), id))
+	if err == sql.ErrNoRows {
+		return WebhookDelivery{}, ErrNotFound
+	}
+	return delivery, err
+}
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var webhook Webhook
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.ProjectID,
+		&webhook.URL,
+		&webhook.Secret,
+		pq.Array(&webhook.Events),
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	return webhook, err
+}
+
+func scanWebhookDelivery(row rowScanner) (WebhookDelivery, error) {
+	var dbDelivery DBWebhookDelivery
+	err := row.Scan(
+		&dbDelivery.ID,
+		&dbDelivery.WebhookID,
+		&dbDelivery.EventID,
+		&dbDelivery.EventType,
+		&dbDelivery.Payload,
+		&dbDelivery.Status,
+		&dbDelivery.Attempts,
+		&dbDelivery.ResponseStatus,
+		&dbDelivery.LastError,
+		&dbDelivery.NextAttemptAt,
+		&dbDelivery.DeliveredAt,
+		&dbDelivery.CreatedAt,
+	)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	return dbDelivery.ToWebhookDelivery(), nil
+}
@@ -0,0 +1,399 @@
+package commons
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RecurrenceDaily   = "DAILY"
+	RecurrenceWeekly  = "WEEKLY"
+	RecurrenceMonthly = "MONTHLY"
+)
+
+// maxRecurrencePeriods bounds the search for the next occurrence, so a rule
+// that matches rarely or never (e.g. every 5th Friday) cannot loop forever.
+const maxRecurrencePeriods = 1000
+
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceDay is a BYDAY entry: a weekday and, in monthly rules, which one
+// of the month it is (1 for the first, -1 for the last, 0 for every one).
+type RecurrenceDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE that recurring tasks
+// support: FREQ (DAILY, WEEKLY or MONTHLY), INTERVAL, BYDAY, BYMONTHDAY,
+// COUNT and UNTIL.
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// ParseRecurrenceRule parses an RRULE value such as
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10". An "RRULE:" prefix is
+// accepted. Errors wrap ErrInvalidRecurrence and name the offending part.
+func ParseRecurrenceRule(value string) (RecurrenceRule, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 6 && strings.EqualFold(value[:6], "RRULE:") {
+		value = value[6:]
+	}
+
+	rule := RecurrenceRule{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" {
+			return RecurrenceRule{}, recurrenceError("invalid rule part %q", part)
+		}
+		if seen[key] {
+			return RecurrenceRule{}, recurrenceError("%s is given more than once", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			if val != RecurrenceDaily && val != RecurrenceWeekly && val != RecurrenceMonthly {
+				return RecurrenceRule{}, recurrenceError("FREQ must be one of DAILY, WEEKLY, MONTHLY")
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 || interval > 999 {
+				return RecurrenceRule{}, recurrenceError("INTERVAL must be between 1 and 999")
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return RecurrenceRule{}, recurrenceError("COUNT must be a positive number")
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRecurrenceUntil(val)
+			if err != nil {
+				return RecurrenceRule{}, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				byDay, err := parseRecurrenceDay(day)
+				if err != nil {
+					return RecurrenceRule{}, err
+				}
+				rule.ByDay = append(rule.ByDay, byDay)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return RecurrenceRule{}, recurrenceError("BYMONTHDAY values must be between 1 and 31 or -31 and -1")
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		default:
+			return RecurrenceRule{}, recurrenceError("%s is not supported", key)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return RecurrenceRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (r RecurrenceRule) validate() error {
+	if r.Freq == "" {
+		return recurrenceError("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return recurrenceError("COUNT and UNTIL cannot be combined")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != RecurrenceMonthly {
+		return recurrenceError("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+	if len(r.ByMonthDay) > 0 && len(r.ByDay) > 0 {
+		return recurrenceError("BYDAY and BYMONTHDAY cannot be combined")
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != RecurrenceMonthly {
+			return recurrenceError("numbered BYDAY values are only supported with FREQ=MONTHLY")
+		}
+	}
+	return nil
+}
+
+// String formats the rule as an RRULE value; ParseRecurrenceRule reads it
+// back unchanged.
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = formatRecurrenceDay(day)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// After returns the first occurrence later than t of the series that starts
+// at start. Occurrences keep the time of day of start in its location, also
+// across daylight saving changes. ok is false when the rule has no further
+// occurrence before UNTIL; COUNT is left to the caller, which knows how many
+// occurrences it has used up.
+func (r RecurrenceRule) After(start time.Time, t time.Time) (time.Time, bool) {
+	interval := max(r.Interval, 1)
+
+	first := r.firstPeriod(start, t, interval)
+	for period := first; period < first+maxRecurrencePeriods; period++ {
+		for _, candidate := range r.candidates(start, period*interval) {
+			if candidate.Before(start) || !candidate.After(t) {
+				continue
+			}
+			if r.Until != nil && candidate.After(*r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// firstPeriod skips the periods that end before t, leaving one period of
+// slack for occurrences earlier in the day than start.
+func (r RecurrenceRule) firstPeriod(start time.Time, t time.Time, interval int) int {
+	t = t.In(start.Location())
+	if !t.After(start) {
+		return 0
+	}
+
+	var elapsed int
+	switch r.Freq {
+	case RecurrenceDaily:
+		elapsed = daysBetween(start, t)
+	case RecurrenceWeekly:
+		elapsed = daysBetween(weekStart(start), t) / 7
+	case RecurrenceMonthly:
+		elapsed = (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	}
+
+	return max(elapsed/interval-1, 0)
+}
+
+// candidates returns the occurrences of the period offset periods after the
+// one containing start, in order.
+func (r RecurrenceRule) candidates(start time.Time, offset int) []time.Time {
+	loc := start.Location()
+	year, month, day := start.Date()
+	hour, minute, second := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, loc)
+	}
+
+	switch r.Freq {
+	case RecurrenceDaily:
+		candidate := at(year, month, day+offset)
+		if len(r.ByDay) > 0 && !r.matchesWeekday(candidate.Weekday()) {
+			return nil
+		}
+		return []time.Time{candidate}
+
+	case RecurrenceWeekly:
+		monday := weekStart(start).AddDate(0, 0, 7*offset)
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(monday.Year(), monday.Month(), monday.Day()+weekdayOffset(start.Weekday()))}
+		}
+		var candidates []time.Time
+		for _, byDay := range r.ByDay {
+			candidates = append(candidates, at(monday.Year(), monday.Month(), monday.Day()+weekdayOffset(byDay.Weekday)))
+		}
+		return sortedUniqueTimes(candidates)
+
+	case RecurrenceMonthly:
+		first := time.Date(year, month+time.Month(offset), 1, 0, 0, 0, 0, loc)
+		daysInMonth := first.AddDate(0, 1, -1).Day()
+
+		var days []int
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, monthDay := range r.ByMonthDay {
+				if monthDay < 0 {
+					monthDay = daysInMonth + monthDay + 1
+				}
+				days = append(days, monthDay)
+			}
+		case len(r.ByDay) > 0:
+			for _, byDay := range r.ByDay {
+				days = append(days, monthDaysOf(first, daysInMonth, byDay)...)
+			}
+		default:
+			days = []int{day}
+		}
+
+		var candidates []time.Time
+		for _, monthDay := range days {
+			if monthDay >= 1 && monthDay <= daysInMonth {
+				candidates = append(candidates, at(first.Year(), first.Month(), monthDay))
+			}
+		}
+		return sortedUniqueTimes(candidates)
+	}
+
+	return nil
+}
+
+func (r RecurrenceRule) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDaysOf returns the days of the month starting at first that byDay
+// selects.
+func monthDaysOf(first time.Time, daysInMonth int, byDay RecurrenceDay) []int {
+	firstMatch := 1 + (int(byDay.Weekday)-int(first.Weekday())+7)%7
+
+	switch {
+	case byDay.N > 0:
+		return []int{firstMatch + 7*(byDay.N-1)}
+	case byDay.N < 0:
+		lastWeekday := first.AddDate(0, 0, daysInMonth-1).Weekday()
+		lastMatch := daysInMonth - (int(lastWeekday)-int(byDay.Weekday)+7)%7
+		return []int{lastMatch + 7*(byDay.N+1)}
+	}
+
+	var days []int
+	for day := firstMatch; day <= daysInMonth; day += 7 {
+		days = append(days, day)
+	}
+	return days
+}
+
+// weekStart returns midnight of the Monday of t's week; RRULE weeks start on
+// Monday.
+func weekStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day-weekdayOffset(t.Weekday()), 0, 0, 0, 0, t.Location())
+}
+
+// weekdayOffset is the number of days from Monday to weekday.
+func weekdayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// daysBetween counts calendar days, so daylight saving changes do not matter.
+func daysBetween(from time.Time, to time.Time) int {
+	fromYear, fromMonth, fromDay := from.Date()
+	toYear, toMonth, toDay := to.Date()
+	fromDate := time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+func sortedUniqueTimes(times []time.Time) []time.Time {
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	unique := times[:0]
+	for i, t := range times {
+		if i == 0 || !t.Equal(times[i-1]) {
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+func parseRecurrenceDay(value string) (RecurrenceDay, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return RecurrenceDay{}, recurrenceError("invalid BYDAY value %q", value)
+	}
+
+	weekday, ok := recurrenceWeekdays[value[len(value)-2:]]
+	if !ok {
+		return RecurrenceDay{}, recurrenceError("invalid BYDAY value %q", value)
+	}
+
+	day := RecurrenceDay{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return RecurrenceDay{}, recurrenceError("invalid BYDAY value %q", value)
+		}
+		day.N = n
+	}
+
+	return day, nil
+}
+
+func formatRecurrenceDay(day RecurrenceDay) string {
+	for code, weekday := range recurrenceWeekdays {
+		if weekday == day.Weekday {
+			if day.N != 0 {
+				return strconv.Itoa(day.N) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+// parseRecurrenceUntil accepts UTC date-times and dates. A date includes the
+// whole day.
+func parseRecurrenceUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102", value); err == nil {
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, recurrenceError("UNTIL must be a UTC date-time (20060102T150405Z) or a date (20060102)")
+}
+
+func recurrenceError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRecurrence, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,172 @@
+package commons
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRuleRoundTrip(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31,-1;UNTIL=20251231T235959Z", "FREQ=MONTHLY;BYMONTHDAY=31,-1;UNTIL=20251231T235959Z"},
+		{"RRULE:freq=weekly;byday=mo", "FREQ=WEEKLY;BYDAY=MO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.input)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) failed: %v", tt.input, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Fatalf("String() = %q, want %q", got, tt.want)
+			}
+
+			reparsed, err := ParseRecurrenceRule(rule.String())
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) failed: %v", rule.String(), err)
+			}
+			if !reflect.DeepEqual(reparsed, rule) {
+				t.Fatalf("round trip = %+v, want %+v", reparsed, rule)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6FR",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=MONTHLY;BYSETPOS=-1",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseRecurrenceRule(input)
+			if !errors.Is(err, ErrInvalidRecurrence) {
+				t.Fatalf("ParseRecurrenceRule(%q) error = %v, want ErrInvalidRecurrence", input, err)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleAfter(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	local := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name   string
+		rule   string
+		start  time.Time
+		t      time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{"last friday of a short month", "FREQ=MONTHLY;BYDAY=-1FR", utc(2025, 1, 31, 9), utc(2025, 1, 31, 9), utc(2025, 2, 28, 9), true},
+		{"last friday of the next month", "FREQ=MONTHLY;BYDAY=-1FR", utc(2025, 1, 31, 9), utc(2025, 2, 28, 9), utc(2025, 3, 28, 9), true},
+		{"fifth friday skips months without one", "FREQ=MONTHLY;BYDAY=5FR", utc(2025, 1, 31, 9), utc(2025, 1, 31, 9), utc(2025, 5, 30, 9), true},
+		{"31st skips 30-day months", "FREQ=MONTHLY;BYMONTHDAY=31", utc(2025, 1, 31, 9), utc(2025, 1, 31, 9), utc(2025, 3, 31, 9), true},
+		{"31st after march", "FREQ=MONTHLY;BYMONTHDAY=31", utc(2025, 1, 31, 9), utc(2025, 3, 31, 9), utc(2025, 5, 31, 9), true},
+		{"every other week within the week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", utc(2025, 1, 6, 10), utc(2025, 1, 6, 10), utc(2025, 1, 9, 10), true},
+		{"every other week skips a week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", utc(2025, 1, 6, 10), utc(2025, 1, 9, 10), utc(2025, 1, 20, 10), true},
+		{"every other week long after the start", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", utc(2025, 1, 6, 10), utc(2025, 3, 5, 0), utc(2025, 3, 6, 10), true},
+		{"daily across the start of summer time", "FREQ=DAILY", local(2025, 3, 29, 9), local(2025, 3, 29, 9), local(2025, 3, 30, 9), true},
+		{"weekly across the end of summer time", "FREQ=WEEKLY", local(2025, 10, 20, 9), local(2025, 10, 20, 9), local(2025, 10, 27, 9), true},
+		{"until includes its own instant", "FREQ=DAILY;UNTIL=20250105T090000Z", utc(2025, 1, 1, 9), utc(2025, 1, 4, 9), utc(2025, 1, 5, 9), true},
+		{"until ends the rule", "FREQ=DAILY;UNTIL=20250105T090000Z", utc(2025, 1, 1, 9), utc(2025, 1, 5, 9), time.Time{}, false},
+		{"until date covers the whole day", "FREQ=DAILY;UNTIL=20250105", utc(2025, 1, 1, 23), utc(2025, 1, 4, 23), utc(2025, 1, 5, 23), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) failed: %v", tt.rule, err)
+			}
+
+			got, ok := rule.After(tt.start, tt.t)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Fatalf("After(%v, %v) = %v, %v, want %v, %v", tt.start, tt.t, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleFirstPeriod(t *testing.T) {
+	utc := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		freq     string
+		interval int
+		start    time.Time
+		t        time.Time
+		want     int
+	}{
+		{"before the start", RecurrenceDaily, 1, utc(2025, 1, 10), utc(2025, 1, 1), 0},
+		{"at the start", RecurrenceDaily, 1, utc(2025, 1, 1), utc(2025, 1, 1), 0},
+		{"daily", RecurrenceDaily, 1, utc(2025, 1, 1), utc(2025, 1, 11), 9},
+		{"every other week", RecurrenceWeekly, 2, utc(2025, 1, 6), utc(2025, 3, 5), 3},
+		{"monthly", RecurrenceMonthly, 1, utc(2025, 1, 31), utc(2025, 5, 15), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := RecurrenceRule{Freq: tt.freq, Interval: tt.interval}
+			if got := rule.firstPeriod(tt.start, tt.t, tt.interval); got != tt.want {
+				t.Fatalf("firstPeriod() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthDaysOf(t *testing.T) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		first       time.Time
+		daysInMonth int
+		byDay       RecurrenceDay
+		want        []int
+	}{
+		{"every friday", january, 31, RecurrenceDay{Weekday: time.Friday}, []int{3, 10, 17, 24, 31}},
+		{"first friday", january, 31, RecurrenceDay{Weekday: time.Friday, N: 1}, []int{3}},
+		{"last friday", january, 31, RecurrenceDay{Weekday: time.Friday, N: -1}, []int{31}},
+		{"fifth friday", january, 31, RecurrenceDay{Weekday: time.Friday, N: 5}, []int{31}},
+		{"last friday of february", february, 28, RecurrenceDay{Weekday: time.Friday, N: -1}, []int{28}},
+		{"fifth friday of february is past its end", february, 28, RecurrenceDay{Weekday: time.Friday, N: 5}, []int{35}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthDaysOf(tt.first, tt.daysInMonth, tt.byDay); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("monthDaysOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package commons
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Values of OTEL_TRACES_EXPORTER.
+const (
+	TracesExporterOTLP   = "otlp"
+	TracesExporterStdout = "stdout"
+	TracesExporterNone   = "none"
+)
+
+// InitTracing installs the global tracer provider of a service and the W3C
+// trace context propagator used between services. Spans are exported as
+// chosen by OTEL_TRACES_EXPORTER: "otlp" sends them over gRPC to
+// OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT),
+// "stdout" prints them, and "none" drops them. Without it spans are sent over
+// OTLP if an endpoint is set and dropped otherwise. Trace context is
+// propagated either way, so a service without an exporter does not break the
+// traces of the others.
+//
+// The returned function flushes the remaining spans and must be called
+// before the service exits.
+func InitTracing(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporterName := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER"))
+	if exporterName == "" {
+		exporterName = TracesExporterNone
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+			exporterName = TracesExporterOTLP
+		}
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch exporterName {
+	case TracesExporterOTLP:
+		// Endpoint, headers, TLS and timeouts come from the standard
+		// OTEL_EXPORTER_OTLP_* variables.
+		exporter, err = otlptracegrpc.New(ctx)
+	case TracesExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case TracesExporterNone:
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q", exporterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporterName, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(serviceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// FlushTraces exports the spans ended so far, for processes such as Lambda
+// functions that may be frozen between invocations.
+func FlushTraces(ctx context.Context) error {
+	if provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider); ok {
+		return provider.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// InjectTraceContext returns the W3C trace context (traceparent, tracestate
+// and baggage) of ctx, to be carried with a message to another service. It
+// is empty when ctx has no span.
+func InjectTraceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// ExtractTraceContext returns ctx with the remote span of a trace context
+// injected by InjectTraceContext, so that spans started from it continue
+// that trace.
+func ExtractTraceContext(ctx context.Context, traceContext map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(traceContext))
+}
@@ -0,0 +1,140 @@
+package commons
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Statuses of a trace, the task system events sharing a correlation ID.
+const (
+	TraceStatusCompleted = "completed"
+	// TraceStatusInProgress is a trace still waiting for an expected event
+	// within TraceSettleTime of its last event.
+	TraceStatusInProgress = "in_progress"
+	TraceStatusIncomplete = "incomplete"
+	TraceStatusFailed     = "failed"
+)
+
+const (
+	// TraceSettleTime is how long after its last event a trace still waiting
+	// for an expected event counts as in progress rather than incomplete.
+	TraceSettleTime = 5 * time.Minute
+	// DefaultTraceSlowThreshold is the duration above which a trace is slow.
+	DefaultTraceSlowThreshold = 30 * time.Second
+
+	DefaultTracePageSize = 50
+	MaxTracePageSize     = 200
+)
+
+// TraceExpectation is an event that, once it happened, must be followed by
+// one of its outcomes for a trace to be complete.
+type TraceExpectation struct {
+	Trigger  string
+	Outcomes []string
+	// Missing describes the trace when no outcome happened.
+	Missing string
+}
+
+// TraceExpectations are the flows across services a trace is checked for.
+var TraceExpectations = []TraceExpectation{
+	{
+		Trigger:  "api:db:task-created",
+		Outcomes: []string{"api:event:task-created"},
+		Missing:  "Task created but its event was never published",
+	},
+	{
+		Trigger: "notification:event:email-task-created",
+		Outcomes: []string{
+			"email:third-party:email-delivery-sent",
+			"email:third-party:email-delivery-failed",
+			"email:db:email-skipped",
+		},
+		Missing: "Email requested but no delivery event",
+	},
+}
+
+// TraceFailureSuffixes end the actions of events recording a failure.
+var TraceFailureSuffixes = []string{"-failed", "-rate-limited"}
+
+// IsFailureAction reports whether an event with action records a failure.
+func IsFailureAction(action string) bool {
+	for _, suffix := range TraceFailureSuffixes {
+		if strings.HasSuffix(action, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingTraceOutcomes returns the Missing descriptions of the expectations
+// triggered but not met by a trace with the given actions.
+func MissingTraceOutcomes(actions []string) []string {
+	missing := []string{}
+	for _, expectation := range TraceExpectations {
+		if !slices.Contains(actions, expectation.Trigger) {
+			continue
+		}
+		met := slices.ContainsFunc(expectation.Outcomes, func(outcome string) bool {
+			return slices.Contains(actions, outcome)
+		})
+		if !met {
+			missing = append(missing, expectation.Missing)
+		}
+	}
+	return missing
+}
+
+// TraceStatus returns the status of a trace whose last event happened at
+// endedAt.
+func TraceStatus(failed bool, missing []string, endedAt time.Time) string {
+	switch {
+	case failed:
+		return TraceStatusFailed
+	case len(missing) == 0:
+		return TraceStatusCompleted
+	case time.Since(endedAt) < TraceSettleTime:
+		return TraceStatusInProgress
+	default:
+		return TraceStatusIncomplete
+	}
+}
+
+// TraceQuery describes a filtered and paginated listing of traces, newest
+// first. Zero values mean "no filter"; an empty VisibleTo lists the traces
+// of all tasks.
+type TraceQuery struct {
+	VisibleTo     string
+	TaskID        string
+	StartedAfter  *time.Time
+	StartedBefore *time.Time
+	// Statuses keeps the traces with any of these statuses, where "slow" is
+	// the traces that took longer than SlowThreshold.
+	Statuses      []string
+	SlowThreshold time.Duration
+	Cursor        string
+	Limit         int
+}
+
+// TraceFilterSlow is the Statuses value selecting slow traces.
+const TraceFilterSlow = "slow"
+
+// TraceSummary sums up a trace without its events.
+type TraceSummary struct {
+	CorrelationID string    `json:"correlation_id"`
+	TaskID        string    `json:"task_id"`
+	StartedAt     time.Time `json:"started_at"`
+	EndedAt       time.Time `json:"ended_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	EventCount    int       `json:"event_count"`
+	Origins       []string  `json:"origins"`
+	Status        string    `json:"status"`
+	Slow          bool      `json:"slow"`
+	Failures      int       `json:"failures"`
+	Missing       []string  `json:"missing"`
+}
+
+type TracePage struct {
+	Traces     []TraceSummary `json:"traces"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
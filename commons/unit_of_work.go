@@ -0,0 +1,119 @@
+package commons
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is what the Postgres repositories run their queries on: the database
+// itself, or a transaction when they are used inside a unit of work.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx is a transaction started by a repository method.
+type Tx interface {
+	DBTX
+	Commit() error
+	Rollback() error
+}
+
+// joinedTx lets a repository method that needs a transaction run inside the
+// transaction of a unit of work. Committing and rolling back is left to the
+// unit of work.
+type joinedTx struct {
+	*sql.Tx
+}
+
+func (joinedTx) Commit() error   { return nil }
+func (joinedTx) Rollback() error { return nil }
+
+// beginTx starts a transaction on db, or joins it if db already is one.
+func beginTx(ctx context.Context, db DBTX) (Tx, error) {
+	switch db := db.(type) {
+	case *sql.DB:
+		return db.BeginTx(ctx, nil)
+	case *sql.Tx:
+		return joinedTx{db}, nil
+	default:
+		return nil, fmt.Errorf("cannot begin a transaction on %T", db)
+	}
+}
+
+// Repositories are the repositories of one unit of work, all bound to its
+// transaction.
+type Repositories struct {
+	Tasks               TaskRepositoryInterface
+	TaskSystemEvents    TaskSystemEventRepositoryInterface
+	TaskComments        TaskCommentRepositoryInterface
+	InAppNotifications  InAppNotificationRepositoryInterface
+	Emails              EmailRepositoryInterface
+	Outbox              OutboxRepositoryInterface
+	TaskSeries          TaskSeriesRepositoryInterface
+	Reminders           ReminderRepositoryInterface
+	Digests             DigestRepositoryInterface
+	Deferred            DeferredNotificationRepositoryInterface
+	Projects            ProjectRepositoryInterface
+	Users               UserRepositoryInterface
+	Roles               RoleRepositoryInterface
+	RefreshTokens       RefreshTokenRepositoryInterface
+	PasswordResetTokens PasswordResetTokenRepositoryInterface
+	PhoneVerifications  PhoneVerificationRepositoryInterface
+	Sms                 SmsRepositoryInterface
+	Webhooks            WebhookRepositoryInterface
+}
+
+func newRepositories(db DBTX) Repositories {
+	return Repositories{
+		Tasks:               &PostgresTaskRepository{DB: db},
+		TaskSystemEvents:    &PostgresTaskSystemEventRepository{DB: db},
+		TaskComments:        &PostgresTaskCommentRepository{DB: db},
+		InAppNotifications:  &PostgresInAppNotificationRepository{DB: db},
+		Emails:              &PostgresEmailRepository{DB: db},
+		Outbox:              &PostgresOutboxRepository{DB: db},
+		TaskSeries:          &PostgresTaskSeriesRepository{DB: db},
+		Reminders:           &PostgresReminderRepository{DB: db},
+		Digests:             &PostgresDigestRepository{DB: db},
+		Deferred:            &PostgresDeferredNotificationRepository{DB: db},
+		Projects:            &PostgresProjectRepository{DB: db},
+		Users:               &PostgresUserRepository{DB: db},
+		Roles:               &PostgresRoleRepository{DB: db},
+		RefreshTokens:       &PostgresRefreshTokenRepository{DB: db},
+		PasswordResetTokens: &PostgresPasswordResetTokenRepository{DB: db},
+		PhoneVerifications:  &PostgresPhoneVerificationRepository{DB: db},
+		Sms:                 &PostgresSmsRepository{DB: db},
+		Webhooks:            &PostgresWebhookRepository{DB: db},
+	}
+}
+
+// UnitOfWork runs several repository calls in one transaction.
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a new transaction. The
+	// transaction is committed if fn returns nil and rolled back otherwise.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type PostgresUnitOfWork struct {
+	DB *sql.DB
+}
+
+func NewPostgresUnitOfWork(db *sql.DB) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{DB: db}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	tx, err := u.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(newRepositories(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
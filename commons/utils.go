@@ -1,6 +1,8 @@
 package commons
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 )
 
@@ -8,3 +10,6 @@ func IsValidUUID(uuidStr string) bool {
 	_, err := uuid.Parse(uuidStr)
 	return err == nil
 }
+
+// likeEscaper escapes the LIKE/ILIKE wildcards of user supplied search terms.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -39,10 +39,11 @@ DLQ_QUEUE_ARN=$(aws --endpoint-url="${ENDPOINT_URL}" sqs get-queue-attributes \
 
 echo "DLQ Queue ARN: ${DLQ_QUEUE_ARN}"
 
-# Create the main queue with redrive policy
+# Create the main queue with redrive policy. The email service moves failed
+# messages to the DLQ itself after EMAIL_MAX_ATTEMPTS; this is a safety net.
 aws --endpoint-url="${ENDPOINT_URL}" sqs create-queue \
     --queue-name "${QUEUE_NAME}" \
-    --attributes "{\"VisibilityTimeout\":\"300\", \"RedrivePolicy\":\"{\\\"deadLetterTargetArn\\\":\\\"${DLQ_QUEUE_ARN}\\\",\\\"maxReceiveCount\\\":\\\"3\\\"}\"}" \
+    --attributes "{\"VisibilityTimeout\":\"300\", \"RedrivePolicy\":\"{\\\"deadLetterTargetArn\\\":\\\"${DLQ_QUEUE_ARN}\\\",\\\"maxReceiveCount\\\":\\\"10\\\"}\"}" \
     --region ${REGION}
 
 echo "SQS queues created successfully!"
@@ -22,10 +22,22 @@ export class EmailServiceStack extends Stack {
       db: "email_service",
     }
 
+    const emailServiceDeadLetterSqs = new GoEmailServiceSqs(this, `${props.stackName}${GoEmailServiceSqs.name}DeadLetters`, {
+      queueName: "go-email-service-queue-dead-letters",
+      visibilityTimeout: 300,
+      retentionPeriod: 14,
+    })
+
+    // The lambda moves failed messages to the dead-letter queue itself after
+    // EMAIL_MAX_ATTEMPTS; the redrive policy is only a safety net.
     const emailServiceSqs = new GoEmailServiceSqs(this, `${props.stackName}${GoEmailServiceSqs.name}`, {
       queueName: "go-email-service-queue",
       visibilityTimeout: 300,
       retentionPeriod: 4,
+      deadLetterQueue: {
+        maxReceiveCount: 10,
+        queue: emailServiceDeadLetterSqs.queue,
+      },
     })
 
     new EmailService(this, `${props.stackName}${EmailService.name}Lambda`, {
@@ -34,6 +46,7 @@ export class EmailServiceStack extends Stack {
       emailServiceLambda: props.emailServiceLambda,
       postgresConfig: postgresConfig,
       sqs: emailServiceSqs.queue,
+      deadLetterSqs: emailServiceDeadLetterSqs.queue,
     })
   }
 }
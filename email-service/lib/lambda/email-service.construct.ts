@@ -19,6 +19,7 @@ export interface EmailServiceProps extends EmailServiceStackProps {
   readonly postgresConfig: PostgresConfigInterface
   readonly emailServiceLambda: LambdaProps
   readonly sqs: Queue
+  readonly deadLetterSqs: Queue
 }
 
 export class EmailService extends Construct {
@@ -51,16 +52,22 @@ export class EmailService extends Construct {
         POSTGRES_USER: props.postgresConfig.user,
         POSTGRES_PASSWORD: props.postgresConfig.password,
         POSTGRES_DB: props.postgresConfig.db,
+        SQS_QUEUE_NAME: props.sqs.queueName,
+        SQS_DLQ_NAME: props.deadLetterSqs.queueName,
+        EMAIL_MAX_ATTEMPTS: "5",
+        DB_QUERY_TIMEOUT_SECONDS: "10",
       },
     })
 
     const sqsEventSource = new SqsEventSource(props.sqs, {
       batchSize: 1,
       maxBatchingWindow: Duration.seconds(10),
+      reportBatchItemFailures: true,
       enabled: true,
     })
 
     this.function.addEventSource(sqsEventSource)
+    props.deadLetterSqs.grantSendMessages(this.function)
 
     new CfnOutput(this, "EmailServiceLambdaArn", {
       value: this.function.functionArn,
@@ -0,0 +1,141 @@
+// Command smtp-capture is a minimal SMTP server for local development. It
+// accepts every message and writes it to a mailbox directory as an .eml file
+// instead of delivering it, so emails can be checked without network access
+// or a mail provider.
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var messageCount atomic.Int64
+
+func main() {
+	addr := getEnvOrDefault("SMTP_CAPTURE_ADDR", ":1025")
+	dir := getEnvOrDefault("SMTP_CAPTURE_DIR", "/tmp/mailbox")
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Failed to create mailbox directory: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	log.Printf("SMTP capture listening on %s, writing messages to %s", addr, dir)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("Error accepting connection: %v", err)
+			continue
+		}
+		go handleConnection(conn, dir)
+	}
+}
+
+func handleConnection(conn net.Conn, dir string) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+
+	tp := textproto.NewConn(conn)
+	reply := func(code int, message string) {
+		tp.PrintfLine("%d %s", code, message)
+	}
+
+	reply(220, "smtp-capture ready")
+
+	var from string
+	var to []string
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reply(250, "smtp-capture")
+		case "EHLO":
+			tp.PrintfLine("250-smtp-capture")
+			reply(250, "8BITMIME")
+		case "MAIL":
+			from, to = envelopeAddress(arg), nil
+			reply(250, "OK")
+		case "RCPT":
+			to = append(to, envelopeAddress(arg))
+			reply(250, "OK")
+		case "DATA":
+			if len(to) == 0 {
+				reply(503, "RCPT required before DATA")
+				continue
+			}
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			path, err := saveMessage(dir, from, to, data)
+			if err != nil {
+				log.Printf("Error saving message: %v", err)
+				reply(451, "Failed to store message")
+				continue
+			}
+
+			log.Printf("Captured message from %s to %s: %s", from, strings.Join(to, ", "), path)
+			from, to = "", nil
+			reply(250, "OK")
+		case "RSET":
+			from, to = "", nil
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// envelopeAddress extracts the address from a "FROM:<addr> ..." or
+// "TO:<addr>" argument.
+func envelopeAddress(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+// saveMessage writes the message with its envelope prepended as headers.
+func saveMessage(dir, from string, to []string, data []byte) (string, error) {
+	name := fmt.Sprintf("%s-%d.eml", time.Now().UTC().Format("20060102T150405.000"), messageCount.Add(1))
+	path := filepath.Join(dir, name)
+
+	envelope := fmt.Sprintf("X-Envelope-From: %s\nX-Envelope-To: %s\n", from, strings.Join(to, ", "))
+
+	return path, os.WriteFile(path, append([]byte(envelope), data...), 0644)
+}
+
+func getEnvOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
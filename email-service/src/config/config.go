@@ -2,17 +2,42 @@ package config
 
 import (
 	"io"
-	"log"
 	"os"
+	"strconv"
+	"time"
+
+	"sama/go-task-management/commons"
 )
 
 type Config struct {
-	Port           string
-	QueueName      string
-	AWSEndpoint    string
-	AWSRegion      string
-	LogFilePath    string
-	PostgresConfig PostgresConfig
+	Port      string
+	QueueName string
+	// DeadLetterQueueName receives messages that failed MaxAttempts times or
+	// can never succeed, together with the failure reason.
+	DeadLetterQueueName string
+	// MaxAttempts is how often a message is received before it is moved to
+	// the dead-letter queue.
+	MaxAttempts int
+	// RetryBaseDelay is the backoff after the first failed attempt; it
+	// doubles with every further attempt up to RetryMaxDelay.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// VisibilityTimeout is kept extended while a message is being processed.
+	VisibilityTimeout time.Duration
+	// ProcessingTimeout is the deadline for handling one message, database
+	// queries and email delivery included.
+	ProcessingTimeout time.Duration
+	AWSEndpoint       string
+	AWSRegion         string
+	LogFilePath       string
+	PostgresConfig    PostgresConfig
+	SMTPConfig        SMTPConfig
+	// EmailSender selects how emails are sent: "smtp" or "file", which
+	// writes them to MailboxDir instead.
+	EmailSender string
+	MailboxDir  string
+	// AppURL is the frontend base URL used for links in emails.
+	AppURL string
 }
 
 type PostgresConfig struct {
@@ -23,6 +48,14 @@ type PostgresConfig struct {
 	DBName   string
 }
 
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
 func InitLogging(config *Config) error {
 	logFile, err := os.OpenFile(config.LogFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -30,17 +63,23 @@ func InitLogging(config *Config) error {
 	}
 
 	mw := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(mw)
+	commons.SetDefaultLogger(commons.NewLoggerWithOutput(mw, "email-service"))
 	return nil
 }
 
 func LoadConfig() *Config {
 	config := &Config{
-		Port:        getEnvOrDefault("PORT", "8080"),
-		QueueName:   getEnvOrDefault("SQS_QUEUE_NAME", "go-email-service-queue"),
-		AWSEndpoint: os.Getenv("AWS_ENDPOINT_URL"),
-		AWSRegion:   getEnvOrDefault("AWS_REGION", "us-east-1"),
-		LogFilePath: "/tmp/email-service.log",
+		Port:                getEnvOrDefault("PORT", "8080"),
+		QueueName:           getEnvOrDefault("SQS_QUEUE_NAME", "go-email-service-queue"),
+		DeadLetterQueueName: getEnvOrDefault("SQS_DLQ_NAME", "go-email-service-queue-dead-letters"),
+		MaxAttempts:         getEnvIntOrDefault("EMAIL_MAX_ATTEMPTS", 5),
+		RetryBaseDelay:      time.Duration(getEnvIntOrDefault("EMAIL_RETRY_BASE_DELAY_SECONDS", 30)) * time.Second,
+		RetryMaxDelay:       time.Duration(getEnvIntOrDefault("EMAIL_RETRY_MAX_DELAY_SECONDS", 900)) * time.Second,
+		VisibilityTimeout:   time.Duration(getEnvIntOrDefault("SQS_VISIBILITY_TIMEOUT_SECONDS", 300)) * time.Second,
+		ProcessingTimeout:   time.Duration(getEnvIntOrDefault("EMAIL_PROCESSING_TIMEOUT_SECONDS", 120)) * time.Second,
+		AWSEndpoint:         os.Getenv("AWS_ENDPOINT_URL"),
+		AWSRegion:           getEnvOrDefault("AWS_REGION", "us-east-1"),
+		LogFilePath:         "/tmp/email-service.log",
 		PostgresConfig: PostgresConfig{
 			Host:     os.Getenv("POSTGRES_HOST"),
 			Port:     os.Getenv("POSTGRES_PORT"),
@@ -48,6 +87,16 @@ func LoadConfig() *Config {
 			Password: os.Getenv("POSTGRES_PASSWORD"),
 			DBName:   os.Getenv("POSTGRES_DB"),
 		},
+		SMTPConfig: SMTPConfig{
+			Host:     getEnvOrDefault("SMTP_HOST", "localhost"),
+			Port:     getEnvOrDefault("SMTP_PORT", "1025"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     getEnvOrDefault("SMTP_FROM", "no-reply@task-management.local"),
+		},
+		EmailSender: getEnvOrDefault("EMAIL_SENDER", "smtp"),
+		MailboxDir:  getEnvOrDefault("MAILBOX_DIR", "/tmp/mailbox"),
+		AppURL:      getEnvOrDefault("APP_URL", "http://localhost:3010"),
 	}
 
 	return config
@@ -59,3 +108,11 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -2,86 +2,443 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 
 	commons "sama/go-task-management/commons"
+	"sama/go-task-management/email-service/src/mailer"
+	"sama/go-task-management/email-service/src/renderer"
 )
 
+// EmailNotificationEvent is a message from the email queue. Messages without
+// a type are task notifications.
 type EmailNotificationEvent struct {
-	TaskId        string `json:"taskId"`
-	CorrelationId string `json:"correlationId"`
+	Type          string   `json:"type"`
+	Event         string   `json:"event"`
+	TaskId        string   `json:"taskId"`
+	CorrelationId string   `json:"correlationId"`
+	RecipientIDs  []string `json:"recipientIds"`
+	To            string   `json:"to"`
+	Handle        string   `json:"handle"`
+	Message       string   `json:"message"`
+	Link          string   `json:"link"`
+	// Digest is the content of a digest email.
+	Digest *commons.DigestEmail `json:"digest"`
 }
 
+// ErrInvalidMessage marks messages that will never succeed, however often
+// they are retried.
+var ErrInvalidMessage = errors.New("invalid message")
+
+var logger = commons.DefaultLogger()
+
 type MessageHandler struct {
 	taskSystemEventRepository commons.TaskSystemEventRepositoryInterface
+	emailRepository           commons.EmailRepositoryInterface
+	taskRepository            commons.TaskRepositoryInterface
+	userRepository            commons.UserRepositoryInterface
+	renderer                  *renderer.Renderer
+	sender                    mailer.Sender
+	appURL                    string
 }
 
-func NewMessageHandler(eventRepo commons.TaskSystemEventRepositoryInterface) *MessageHandler {
+func NewMessageHandler(
+	eventRepo commons.TaskSystemEventRepositoryInterface,
+	emailRepo commons.EmailRepositoryInterface,
+	taskRepo commons.TaskRepositoryInterface,
+	userRepo commons.UserRepositoryInterface,
+	renderer *renderer.Renderer,
+	sender mailer.Sender,
+	appURL string,
+) *MessageHandler {
 	return &MessageHandler{
 		taskSystemEventRepository: eventRepo,
+		emailRepository:           emailRepo,
+		taskRepository:            taskRepo,
+		userRepository:            userRepo,
+		renderer:                  renderer,
+		sender:                    sender,
+		appURL:                    appURL,
 	}
 }
 
 func (h *MessageHandler) HandleMessage(ctx context.Context, message []byte) error {
 	var event EmailNotificationEvent
 	if err := json.Unmarshal(message, &event); err != nil {
-		return fmt.Errorf("failed to unmarshal event: %w", err)
+		return fmt.Errorf("%w: failed to unmarshal event: %v", ErrInvalidMessage, err)
+	}
+	ctx = commons.WithCorrelationID(ctx, event.CorrelationId)
+
+	switch event.Type {
+	case "", commons.EmailMessageTypeTaskNotification:
+		return h.handleTaskNotification(ctx, event)
+	case commons.EmailMessageTypePasswordReset:
+		return h.handlePasswordReset(ctx, event)
+	case commons.EmailMessageTypeDigest:
+		return h.handleDigest(ctx, event)
+	default:
+		return fmt.Errorf("%w: unknown email message type: %s", ErrInvalidMessage, event.Type)
+	}
+}
+
+type deliveryFailure struct {
+	UserID string `json:"userId"`
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// taskEmailPayload is what gets stored with each task email.
+type taskEmailPayload struct {
+	Event           string `json:"event"`
+	RecipientHandle string `json:"recipientHandle"`
+	TaskTitle       string `json:"taskTitle"`
+	TaskURL         string `json:"taskUrl"`
+}
+
+// handleTaskNotification emails every recipient of the task event. Each
+// email is recorded in the emails table first; emails already sent for this
+// correlation ID are skipped, so a redelivered message only retries the
+// emails that failed. The message is reported as failed while any email
+// failed with a retryable error.
+func (h *MessageHandler) handleTaskNotification(ctx context.Context, event EmailNotificationEvent) error {
+	logger.InfoContext(ctx, "Processing task notification email", "task_id", event.TaskId)
+
+	templateName, ok := renderer.TemplateForEvent(event.Event)
+	if !ok {
+		return fmt.Errorf("%w: no email template for event: %s", ErrInvalidMessage, event.Event)
 	}
 
-	log.Printf(
-		"Processing message for task: %s, correlation: %s",
-		event.TaskId,
-		event.CorrelationId,
-	)
+	task, err := h.taskRepository.GetByID(ctx, event.TaskId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: task %s not found", ErrInvalidMessage, event.TaskId)
+		}
+		return fmt.Errorf("failed to get task: %w", err)
+	}
 
-	if err := h.createEmailCreatedEvent(ctx, event); err != nil {
+	recipients, err := h.resolveRecipients(ctx, task, event.RecipientIDs)
+	if err != nil {
 		return err
 	}
 
-	if err := h.createEmailDeliveryEvent(ctx, event); err != nil {
+	if len(recipients) == 0 {
+		return h.createSystemEvent(ctx, event, "email:db:email-skipped",
+			"No recipient with an email address", 11, map[string]any{"template": templateName})
+	}
+
+	taskURL := fmt.Sprintf("%s/tasks/%s", h.appURL, task.ID)
+
+	records := make([]commons.Email, len(recipients))
+	for i, recipient := range recipients {
+		payload, err := json.Marshal(taskEmailPayload{
+			Event:           event.Event,
+			RecipientHandle: recipient.Handle,
+			TaskTitle:       task.Title,
+			TaskURL:         taskURL,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal email payload: %w", err)
+		}
+
+		records[i], err = h.emailRepository.Queue(ctx, commons.Email{
+			TaskID:        &task.ID,
+			CorrelationID: event.CorrelationId,
+			RecipientID:   &recipients[i].ID,
+			Recipient:     recipient.Email,
+			Template:      templateName,
+			Payload:       payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store email: %w", err)
+		}
+	}
+
+	if err := h.createSystemEvent(ctx, event, "email:db:email-created",
+		fmt.Sprintf("Email stored in database for %d recipient(s)", len(records)), 11,
+		map[string]any{"template": templateName}); err != nil {
 		return err
 	}
 
+	var messageIDs []string
+	var failures []deliveryFailure
+	rendered := 0
+	for i, record := range records {
+		if record.Status == commons.EmailStatusSent || record.Status == commons.EmailStatusBounced {
+			continue
+		}
+
+		email, err := h.renderer.Render(templateName, renderer.TaskEmailData{
+			RecipientHandle: recipients[i].Handle,
+			Task:            task,
+			TaskURL:         taskURL,
+		})
+		if err != nil {
+			if markErr := h.emailRepository.MarkFailed(ctx, record.ID, commons.EmailStatusFailed, err.Error()); markErr != nil {
+				logger.ErrorContext(ctx, "Failed to update email", "email_id", record.ID, "error", markErr)
+			}
+			failures = append(failures, deliveryFailure{UserID: recipients[i].ID, Status: commons.EmailStatusFailed, Error: err.Error()})
+			continue
+		}
+		rendered++
+
+		messageID, status, err := h.deliver(ctx, record, email)
+		if err != nil {
+			failures = append(failures, deliveryFailure{UserID: recipients[i].ID, Status: status, Error: err.Error()})
+			continue
+		}
+		messageIDs = append(messageIDs, messageID)
+	}
+
+	if rendered > 0 {
+		if err := h.createSystemEvent(ctx, event, "email:render:email-rendered",
+			fmt.Sprintf("Email rendered for %d recipient(s)", rendered), 12,
+			map[string]any{"template": templateName}); err != nil {
+			return err
+		}
+	}
+
+	if len(messageIDs) > 0 {
+		if err := h.taskRepository.MarkEmailSent(ctx, task.ID); err != nil {
+			logger.ErrorContext(ctx, "Failed to mark email sent for task", "task_id", task.ID, "error", err)
+		}
+
+		if err := h.createSystemEvent(ctx, event, "email:third-party:email-delivery-sent",
+			fmt.Sprintf("Email sent to %d recipient(s)", len(messageIDs)), 14,
+			map[string]any{"messageIds": messageIDs}); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		if err := h.createSystemEvent(ctx, event, "email:third-party:email-delivery-failed",
+			fmt.Sprintf("Email could not be sent to %d recipient(s)", len(failures)), 14,
+			map[string]any{"failures": failures}); err != nil {
+			return err
+		}
+	}
+
+	for _, failure := range failures {
+		if failure.Status == commons.EmailStatusFailed {
+			return fmt.Errorf("failed to send email for task %s: %s", event.TaskId, failure.Error)
+		}
+	}
+
 	return nil
 }
 
-func (h *MessageHandler) createEmailCreatedEvent(ctx context.Context, event EmailNotificationEvent) error {
-	return h.createSystemEvent(
-		ctx,
-		event.TaskId,
-		event.CorrelationId,
-		"Email Service",
-		"email:db:email-created",
-		"Email successfully created in database",
-		11,
-	)
+// deliver sends a rendered email and records the outcome on its record. On
+// failure it returns the status the record was given.
+func (h *MessageHandler) deliver(ctx context.Context, record commons.Email, email renderer.Email) (string, string, error) {
+	messageID, err := h.sender.Send(ctx, mailer.Message{
+		To:      record.Recipient,
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+	if err != nil {
+		status := commons.EmailStatusFailed
+		if errors.Is(err, mailer.ErrRecipientRejected) {
+			status = commons.EmailStatusBounced
+		}
+
+		logger.ErrorContext(ctx, "Failed to send email", "email_id", record.ID, "error", err)
+		if markErr := h.emailRepository.MarkFailed(ctx, record.ID, status, err.Error()); markErr != nil {
+			logger.ErrorContext(ctx, "Failed to update email", "email_id", record.ID, "error", markErr)
+		}
+		return "", status, err
+	}
+
+	// The email is out; failing the message now would only send it twice.
+	// Record that even if the message deadline has just passed.
+	if err := h.emailRepository.MarkSent(context.WithoutCancel(ctx), record.ID, messageID); err != nil {
+		logger.ErrorContext(ctx, "Failed to update email", "email_id", record.ID, "error", err)
+	}
+
+	return messageID, commons.EmailStatusSent, nil
+}
+
+// resolveRecipients returns the users to email: the explicit recipients if
+// given, otherwise the task creator and assignee. Users without an email
+// address are skipped.
+func (h *MessageHandler) resolveRecipients(ctx context.Context, task commons.Task, recipientIDs []string) ([]commons.User, error) {
+	if len(recipientIDs) == 0 {
+		recipientIDs = []string{task.CreatorID}
+		if task.AssigneeID != nil {
+			recipientIDs = append(recipientIDs, *task.AssigneeID)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var recipients []commons.User
+	for _, userID := range recipientIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		user, err := h.userRepository.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.ID == "" || user.Email == "" {
+			continue
+		}
+		recipients = append(recipients, user)
+	}
+
+	return recipients, nil
+}
+
+// handlePasswordReset sends the reset link. It is not tied to a task, so no
+// system events are recorded. The link itself is not stored.
+func (h *MessageHandler) handlePasswordReset(ctx context.Context, event EmailNotificationEvent) error {
+	if event.To == "" || event.Link == "" {
+		return fmt.Errorf("%w: password reset message requires a recipient and a link", ErrInvalidMessage)
+	}
+
+	logger.InfoContext(ctx, "Processing password reset email")
+
+	payload, err := json.Marshal(map[string]string{"handle": event.Handle})
+	if err != nil {
+		return fmt.Errorf("failed to marshal email payload: %w", err)
+	}
+
+	var recipientID *string
+	if len(event.RecipientIDs) == 1 {
+		recipientID = &event.RecipientIDs[0]
+	}
+
+	record, err := h.emailRepository.Queue(ctx, commons.Email{
+		CorrelationID: event.CorrelationId,
+		RecipientID:   recipientID,
+		Recipient:     event.To,
+		Template:      renderer.TemplatePasswordReset,
+		Payload:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store email: %w", err)
+	}
+
+	if record.Status == commons.EmailStatusSent || record.Status == commons.EmailStatusBounced {
+		logger.InfoContext(ctx, "Password reset email already processed, skipping", "email_id", record.ID)
+		return nil
+	}
+
+	email, err := h.renderer.Render(renderer.TemplatePasswordReset, renderer.PasswordResetData{
+		Handle:  event.Handle,
+		Link:    event.Link,
+		Message: event.Message,
+	})
+	if err != nil {
+		if markErr := h.emailRepository.MarkFailed(ctx, record.ID, commons.EmailStatusFailed, err.Error()); markErr != nil {
+			logger.ErrorContext(ctx, "Failed to update email", "email_id", record.ID, "error", markErr)
+		}
+		return err
+	}
+
+	if _, status, err := h.deliver(ctx, record, email); err != nil {
+		if status == commons.EmailStatusBounced {
+			return nil
+		}
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	logger.InfoContext(ctx, "Password reset email sent")
+	return nil
 }
 
-func (h *MessageHandler) createEmailDeliveryEvent(ctx context.Context, event EmailNotificationEvent) error {
-	return h.createSystemEvent(
-		ctx,
-		event.TaskId,
-		event.CorrelationId,
-		"Email Service",
-		"email:third-party:email-delivery-sent",
-		"Email sent for delivery",
-		14,
-	)
+// handleDigest sends a digest email. Like password reset emails, digests
+// are not tied to a task, so no system events are recorded.
+func (h *MessageHandler) handleDigest(ctx context.Context, event EmailNotificationEvent) error {
+	if event.To == "" || event.Digest == nil {
+		return fmt.Errorf("%w: digest message requires a recipient and a digest", ErrInvalidMessage)
+	}
+
+	logger.InfoContext(ctx, "Processing digest email")
+
+	payload, err := json.Marshal(map[string]any{
+		"handle":    event.Handle,
+		"frequency": event.Digest.Frequency,
+		"entries":   len(event.Digest.Entries) + event.Digest.More,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal email payload: %w", err)
+	}
+
+	var recipientID *string
+	if len(event.RecipientIDs) == 1 {
+		recipientID = &event.RecipientIDs[0]
+	}
+
+	record, err := h.emailRepository.Queue(ctx, commons.Email{
+		CorrelationID: event.CorrelationId,
+		RecipientID:   recipientID,
+		Recipient:     event.To,
+		Template:      renderer.TemplateDigest,
+		Payload:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store email: %w", err)
+	}
+
+	if record.Status == commons.EmailStatusSent || record.Status == commons.EmailStatusBounced {
+		logger.InfoContext(ctx, "Digest email already processed, skipping", "email_id", record.ID)
+		return nil
+	}
+
+	entries := make([]renderer.DigestEntryData, len(event.Digest.Entries))
+	for i, entry := range event.Digest.Entries {
+		entries[i] = renderer.DigestEntryData{Title: entry.Title, Message: entry.Message}
+		if entry.TaskID != "" {
+			entries[i].URL = fmt.Sprintf("%s/tasks/%s", h.appURL, entry.TaskID)
+		}
+	}
+
+	email, err := h.renderer.Render(renderer.TemplateDigest, renderer.DigestData{
+		Handle:      event.Handle,
+		Frequency:   event.Digest.Frequency,
+		PeriodStart: event.Digest.PeriodStart,
+		PeriodEnd:   event.Digest.PeriodEnd,
+		Entries:     entries,
+		More:        event.Digest.More,
+		AppURL:      h.appURL,
+	})
+	if err != nil {
+		if markErr := h.emailRepository.MarkFailed(ctx, record.ID, commons.EmailStatusFailed, err.Error()); markErr != nil {
+			logger.ErrorContext(ctx, "Failed to update email", "email_id", record.ID, "error", markErr)
+		}
+		return err
+	}
+
+	if _, status, err := h.deliver(ctx, record, email); err != nil {
+		if status == commons.EmailStatusBounced {
+			return nil
+		}
+		return fmt.Errorf("failed to send digest email: %w", err)
+	}
+
+	logger.InfoContext(ctx, "Digest email sent")
+	return nil
 }
 
-func (h *MessageHandler) createSystemEvent(_ context.Context, taskID, correlationID, origin, action, message string, priority int) error {
+func (h *MessageHandler) createSystemEvent(ctx context.Context, source EmailNotificationEvent, action, message string, priority int, data map[string]any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal system event data: %w", err)
+	}
+
 	event := commons.TaskSystemEvent{
-		TaskId:        taskID,
-		CorrelationId: correlationID,
-		Origin:        origin,
+		TaskId:        source.TaskId,
+		CorrelationId: source.CorrelationId,
+		Origin:        "Email Service",
 		Action:        action,
 		Message:       message,
-		JsonData:      "{}",
+		JsonData:      string(jsonData),
 	}
 
-	_, err := h.taskSystemEventRepository.Create(event, priority)
+	_, err = h.taskSystemEventRepository.Create(ctx, event, priority)
 	if err != nil {
 		return fmt.Errorf("failed to create system event: %w", err)
 	}
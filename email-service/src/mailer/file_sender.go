@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender writes every email to a mailbox directory as an .eml file
+// instead of delivering it.
+type FileSender struct {
+	dir   string
+	from  string
+	count atomic.Int64
+}
+
+func NewFileSender(dir string, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mailbox directory: %w", err)
+	}
+
+	return &FileSender{dir: dir, from: from}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, message Message) (string, error) {
+	data, messageID, err := buildMessage(s.from, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%d-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000"),
+		s.count.Add(1),
+		strings.NewReplacer("@", "_at_", "/", "_").Replace(message.To),
+	)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+
+	return messageID, nil
+}
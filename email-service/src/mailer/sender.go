@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"sama/go-task-management/email-service/src/config"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SenderSMTP = "smtp"
+	SenderFile = "file"
+)
+
+// ErrRecipientRejected is returned when the recipient was permanently
+// rejected, so retrying the email is pointless.
+var ErrRecipientRejected = errors.New("recipient rejected")
+
+// Message is a rendered email. HTML is optional; when set the email is sent
+// as multipart/alternative with Text as the fallback.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers emails. Send returns the Message-ID the email was sent
+// with.
+type Sender interface {
+	Send(ctx context.Context, message Message) (string, error)
+}
+
+func NewSender(cfg *config.Config) (Sender, error) {
+	switch cfg.EmailSender {
+	case SenderSMTP:
+		return NewSMTPSender(cfg.SMTPConfig), nil
+	case SenderFile:
+		return NewFileSender(cfg.MailboxDir, cfg.SMTPConfig.From)
+	default:
+		return nil, fmt.Errorf("unknown email sender: %s", cfg.EmailSender)
+	}
+}
+
+// buildMessage encodes message as an RFC 5322 email with CRLF line endings
+// and returns it together with its generated Message-ID.
+func buildMessage(from string, message Message) ([]byte, string, error) {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at != -1 {
+		domain = from[at+1:]
+	}
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New().String(), domain)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		buf.WriteString(crlf(message.Text))
+		return buf.Bytes(), messageID, nil
+	}
+
+	writer := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", message.Text},
+		{"text/html; charset=UTF-8", message.HTML},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := w.Write([]byte(crlf(part.body))); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), messageID, nil
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,102 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"sama/go-task-management/email-service/src/config"
+)
+
+// SMTPSender delivers emails through an SMTP server. Locally that is the
+// smtp-capture command, which stores emails instead of delivering them.
+type SMTPSender struct {
+	addr string
+	host string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return &SMTPSender{
+		addr: net.JoinHostPort(cfg.Host, cfg.Port),
+		host: cfg.Host,
+		from: cfg.From,
+		auth: auth,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) (string, error) {
+	data, messageID, err := buildMessage(s.from, message)
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return "", fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(nil); err != nil {
+			return "", fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return "", fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return "", fmt.Errorf("failed to set sender: %w", err)
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		var smtpErr *textproto.Error
+		if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+			return "", fmt.Errorf("%w: %v", ErrRecipientRejected, err)
+		}
+		return "", fmt.Errorf("failed to set recipient: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("failed to start message data: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		return "", fmt.Errorf("failed to close SMTP session: %w", err)
+	}
+
+	return messageID, nil
+}
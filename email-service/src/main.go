@@ -2,61 +2,131 @@ package main
 
 import (
 	"context"
-	"log"
 	"os"
+	"time"
 
 	"sama/go-task-management/commons"
 	"sama/go-task-management/email-service/src/config"
 	"sama/go-task-management/email-service/src/handlers"
+	"sama/go-task-management/email-service/src/mailer"
+	"sama/go-task-management/email-service/src/renderer"
 	"sama/go-task-management/email-service/src/server"
 	"sama/go-task-management/email-service/src/sqs"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"go.opentelemetry.io/otel/codes"
 )
 
+var logger = commons.DefaultLogger()
+
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("PANIC in main: %v", r)
+			logger.Error("Panic in main", "panic", r)
 		}
 	}()
 
 	cfg := config.LoadConfig()
 	if err := config.InitLogging(cfg); err != nil {
-		log.Fatalf("Failed to initialize logging: %v", err)
+		logger.Error("Failed to initialize logging", "error", err)
+		os.Exit(1)
+	}
+
+	if commons.IsMigrateCommand(os.Args) {
+		if err := commons.RunMigrateCLI(os.Args[2:]); err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	log.Println("======= Email Service Starting Up =======")
+	logger.Info("Email service starting up")
+
+	shutdownTracing, err := commons.InitTracing(context.Background(), "email-service")
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
 
 	database, err := commons.InitDB()
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		logger.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
 	}
 
 	eventRepo := commons.NewPostgresTaskSystemEventRepository(database)
-	messageHandler := handlers.NewMessageHandler(eventRepo)
+	emailRepo := commons.NewPostgresEmailRepository(database)
+	taskRepo := commons.NewPostgresTaskRepository(database)
+	userRepo := commons.NewPostgresUserRepository(database)
+
+	emailRenderer, err := renderer.New()
+	if err != nil {
+		logger.Error("Failed to load email templates", "error", err)
+		os.Exit(1)
+	}
+
+	sender, err := mailer.NewSender(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize email sender", "error", err)
+		os.Exit(1)
+	}
+
+	messageHandler := handlers.NewMessageHandler(eventRepo, emailRepo, taskRepo, userRepo, emailRenderer, sender, cfg.AppURL)
 
 	if _, ok := os.LookupEnv("AWS_LAMBDA_RUNTIME_API"); ok {
-		log.Println("Running in AWS Lambda environment")
-		startLambda(messageHandler)
+		logger.Info("Running in AWS Lambda environment")
+		startLambda(cfg, messageHandler)
 	} else {
-		log.Println("Starting local development environment")
+		logger.Info("Starting local development environment")
 		startLocalServer(cfg, messageHandler)
 	}
 }
 
-func startLambda(handler *handlers.MessageHandler) {
-	lambda.Start(func(ctx context.Context, event events.SQSEvent) error {
-		log.Printf("Processing Lambda event with %d records", len(event.Records))
+// startLambda reports failed records individually, which requires
+// ReportBatchItemFailures on the event source mapping.
+func startLambda(cfg *config.Config, handler *handlers.MessageHandler) {
+	sqsManager, err := sqs.NewSQSManager(cfg, handler)
+	if err != nil {
+		logger.Warn("Failed to initialize SQS manager, retries fall back to the queue redrive policy", "error", err)
+	}
+
+	lambda.Start(func(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+		logger.InfoContext(ctx, "Processing Lambda event", "records", len(event.Records))
+
+		// The execution environment may be frozen until the next event, so
+		// spans are exported before returning.
+		defer func() {
+			if err := commons.FlushTraces(ctx); err != nil {
+				logger.ErrorContext(ctx, "Failed to flush traces", "error", err)
+			}
+		}()
+
+		if sqsManager != nil {
+			return sqsManager.HandleLambdaEvent(ctx, event), nil
+		}
 
+		var response events.SQSEventResponse
 		for _, record := range event.Records {
-			if err := handler.HandleMessage(ctx, []byte(record.Body)); err != nil {
-				log.Printf("Error processing Lambda event: %v", err)
-				return err
+			recordCtx, span := sqs.StartProcessSpan(ctx, cfg.QueueName, sqs.MessageFromLambdaRecord(record))
+			if err := handler.HandleMessage(recordCtx, []byte(record.Body)); err != nil {
+				logger.ErrorContext(recordCtx, "Failed to process Lambda record", "message_id", record.MessageId, "error", err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: record.MessageId,
+				})
 			}
+			span.End()
 		}
-		return nil
+		return response, nil
 	})
 }
 
@@ -65,12 +135,13 @@ func startLocalServer(cfg *config.Config, handler *handlers.MessageHandler) {
 
 	sqsManager, err := sqs.NewSQSManager(cfg, handler)
 	if err != nil {
-		log.Printf("Warning: Failed to initialize SQS manager: %v", err)
+		logger.Warn("Failed to initialize SQS manager", "error", err)
 		// Continue without SQS in local environment
 	}
 
 	srv := server.NewServer(cfg, handler, sqsManager)
 	if err := srv.Start(ctx); err != nil {
-		log.Fatalf("Server error: %v", err)
+		logger.Error("Server error", "error", err)
+		os.Exit(1)
 	}
 }
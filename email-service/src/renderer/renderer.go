@@ -0,0 +1,170 @@
+package renderer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	commons "sama/go-task-management/commons"
+)
+
+const (
+	TemplateTaskCreated       = "task_created"
+	TemplateTaskAssigned      = "task_assigned"
+	TemplateTaskDueSoon       = "task_due_soon"
+	TemplateTaskStatusChanged = "task_status_changed"
+	TemplatePasswordReset     = "password_reset"
+	TemplateDigest            = "digest"
+)
+
+var templateNames = []string{
+	TemplateTaskCreated,
+	TemplateTaskAssigned,
+	TemplateTaskDueSoon,
+	TemplateTaskStatusChanged,
+	TemplatePasswordReset,
+	TemplateDigest,
+}
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Every template consists of <name>.subject.tmpl, <name>.txt.tmpl and
+// <name>.html.tmpl. The HTML part defines "content", which layout.html.tmpl
+// wraps.
+const layoutFile = "templates/layout.html.tmpl"
+
+var funcs = map[string]any{
+	"date": func(t time.Time) string {
+		return t.UTC().Format("Mon, 02 Jan 2006 15:04 MST")
+	},
+	"pastDue": func(t time.Time) bool {
+		return !t.IsZero() && time.Now().After(t)
+	},
+	"total": func(data DigestData) int {
+		return len(data.Entries) + data.More
+	},
+}
+
+type TaskEmailData struct {
+	RecipientHandle string
+	Task            commons.Task
+	TaskURL         string
+}
+
+type PasswordResetData struct {
+	Handle  string
+	Link    string
+	Message string
+}
+
+type DigestData struct {
+	Handle      string
+	Frequency   string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Entries     []DigestEntryData
+	// More is how many notifications are not listed.
+	More   int
+	AppURL string
+}
+
+type DigestEntryData struct {
+	Title   string
+	Message string
+	// URL links the task of the notification, if it has one.
+	URL string
+}
+
+type Email struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+type Renderer struct {
+	templates map[string]emailTemplate
+}
+
+// New parses all templates, so a broken template fails at startup rather
+// than when the first email of its kind is sent.
+func New() (*Renderer, error) {
+	layout, err := htmltemplate.New("layout.html.tmpl").Funcs(funcs).ParseFS(templateFiles, layoutFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layout: %w", err)
+	}
+
+	templates := make(map[string]emailTemplate, len(templateNames))
+	for _, name := range templateNames {
+		subject, err := texttemplate.New(name+".subject.tmpl").Funcs(funcs).ParseFS(templateFiles, "templates/"+name+".subject.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s subject: %w", name, err)
+		}
+
+		text, err := texttemplate.New(name+".txt.tmpl").Funcs(funcs).ParseFS(templateFiles, "templates/"+name+".txt.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text: %w", name, err)
+		}
+
+		html, err := htmltemplate.Must(layout.Clone()).ParseFS(templateFiles, "templates/"+name+".html.tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s html: %w", name, err)
+		}
+
+		templates[name] = emailTemplate{subject: subject, text: text, html: html}
+	}
+
+	return &Renderer{templates: templates}, nil
+}
+
+func (r *Renderer) Render(name string, data any) (Email, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return Email{}, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return Email{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// TemplateForEvent returns the template used for a notification event.
+func TemplateForEvent(event string) (string, bool) {
+	switch event {
+	case "", commons.NotificationEventTaskCreated:
+		return TemplateTaskCreated, true
+	case commons.NotificationEventTaskAssigned:
+		return TemplateTaskAssigned, true
+	case commons.NotificationEventTaskDueSoon:
+		return TemplateTaskDueSoon, true
+	case commons.NotificationEventTaskStatusChanged:
+		return TemplateTaskStatusChanged, true
+	case commons.NotificationEventPasswordReset:
+		return TemplatePasswordReset, true
+	default:
+		return "", false
+	}
+}
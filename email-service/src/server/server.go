@@ -4,18 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"sama/go-task-management/commons"
 	"sama/go-task-management/email-service/src/config"
 	"sama/go-task-management/email-service/src/handlers"
 	"sama/go-task-management/email-service/src/sqs"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+var logger = commons.DefaultLogger()
+
 type Server struct {
 	config        *config.Config
 	handler       *handlers.MessageHandler
@@ -32,18 +36,21 @@ func NewServer(cfg *config.Config, handler *handlers.MessageHandler, sqsManager
 }
 
 func (s *Server) Start(ctx context.Context) error {
+	// The handler continues the trace of callers that send a traceparent
+	// header.
 	s.httpServer = &http.Server{
 		Addr:    fmt.Sprintf(":%s", s.config.Port),
-		Handler: s.createHandler(),
+		Handler: otelhttp.NewHandler(s.createHandler(), "email-service"),
 	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		log.Printf("HTTP server listening on port %s", s.config.Port)
+		logger.Info("HTTP server listening", "port", s.config.Port)
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+			logger.Error("Server error", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -57,7 +64,7 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) Shutdown() error {
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -65,13 +72,18 @@ func (s *Server) Shutdown() error {
 		return fmt.Errorf("server forced to shutdown: %w", err)
 	}
 
-	log.Println("Server stopped gracefully")
+	logger.Info("Server stopped")
 	return nil
 }
 
 func (s *Server) createHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("Received %s request from %s", r.Method, r.RemoteAddr)
+		if r.URL.Path == "/metrics" {
+			commons.MetricsHandler().ServeHTTP(w, r)
+			return
+		}
+
+		logger.InfoContext(r.Context(), "Received request", "method", r.Method, "ip", r.RemoteAddr)
 
 		switch r.Method {
 		case http.MethodGet:
@@ -0,0 +1,36 @@
+package sqs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "email_service_sqs_messages_received_total",
+		Help: "Queue messages received, polled or delivered by Lambda.",
+	})
+
+	messagesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "email_service_sqs_messages_processed_total",
+		Help: "Queue messages handled successfully.",
+	})
+
+	messagesFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "email_service_sqs_messages_failed_total",
+		Help: "Failed attempts at handling a queue message, by what happened to the message (retry or dead_letter).",
+	}, []string{"action"})
+
+	pollDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "email_service_sqs_poll_duration_seconds",
+		Help: "Time taken by ReceiveMessage calls, long polling included, by outcome (success or error).",
+		// Long polls wait up to 20 seconds for messages.
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 15, 20, 25},
+	}, []string{"outcome"})
+
+	messageLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "email_service_sqs_message_lag_seconds",
+		Help:    "Time messages spent on the queue, from being sent to being received for processing.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	})
+)
@@ -0,0 +1,203 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/email-service/src/handlers"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// maxVisibilityTimeout is the longest visibility timeout SQS accepts.
+const maxVisibilityTimeout = 12 * time.Hour
+
+// maxFailureReasonLength keeps the failure reason attribute well below the
+// SQS message size limit.
+const maxFailureReasonLength = 1024
+
+// Message is a received queue message, whether polled or delivered by Lambda.
+type Message struct {
+	ID            string
+	Body          string
+	ReceiptHandle string
+	// ReceiveCount is the ApproximateReceiveCount, 1 on the first attempt.
+	ReceiveCount int
+	// TraceContext is the W3C trace context the producer sent along.
+	TraceContext map[string]string
+	// SentAt is when the message was sent to the queue, zero if unknown.
+	SentAt time.Time
+}
+
+// MessageFromLambdaRecord converts a record of a Lambda SQS event.
+func MessageFromLambdaRecord(record events.SQSMessage) Message {
+	return Message{
+		ID:            record.MessageId,
+		Body:          record.Body,
+		ReceiptHandle: record.ReceiptHandle,
+		ReceiveCount:  parseReceiveCount(record.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]),
+		TraceContext: traceContext(func(name string) *string {
+			return record.MessageAttributes[name].StringValue
+		}),
+		SentAt: parseSentTimestamp(record.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]),
+	}
+}
+
+// Process handles a message and reports whether it is done with, either
+// because it succeeded or because it was moved to the dead-letter queue. A
+// message that is not done stays on the queue and becomes visible again
+// after an exponential backoff.
+func (m *SQSManager) Process(ctx context.Context, message Message) bool {
+	ctx, span := StartProcessSpan(ctx, m.config.QueueName, message)
+	defer span.End()
+
+	messagesReceived.Inc()
+	if !message.SentAt.IsZero() {
+		messageLag.Observe(time.Since(message.SentAt).Seconds())
+	}
+
+	err := m.handleWithHeartbeat(ctx, message)
+	if err == nil {
+		messagesProcessed.Inc()
+		return true
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	logger.ErrorContext(ctx, "Failed to process message",
+		"message_id", message.ID, "attempt", message.ReceiveCount, "max_attempts", m.config.MaxAttempts, "error", err)
+
+	if errors.Is(err, handlers.ErrInvalidMessage) || message.ReceiveCount >= m.config.MaxAttempts {
+		if dlqErr := m.sendToDeadLetterQueue(ctx, message, err); dlqErr != nil {
+			logger.ErrorContext(ctx, "Failed to move message to the dead-letter queue", "message_id", message.ID, "error", dlqErr)
+			messagesFailed.WithLabelValues("retry").Inc()
+			return false
+		}
+		logger.WarnContext(ctx, "Moved message to the dead-letter queue", "message_id", message.ID)
+		messagesFailed.WithLabelValues("dead_letter").Inc()
+		return true
+	}
+
+	messagesFailed.WithLabelValues("retry").Inc()
+
+	delay := m.backoff(message.ReceiveCount)
+	if err := m.changeVisibility(ctx, message.ReceiptHandle, delay); err != nil {
+		logger.ErrorContext(ctx, "Failed to delay retry of message", "message_id", message.ID, "error", err)
+	} else {
+		logger.InfoContext(ctx, "Retrying message", "message_id", message.ID, "retry_in", delay)
+	}
+	return false
+}
+
+// HandleLambdaEvent processes an SQS batch and reports only the records that
+// failed, so Lambda retries those and deletes the rest.
+func (m *SQSManager) HandleLambdaEvent(ctx context.Context, event events.SQSEvent) events.SQSEventResponse {
+	var response events.SQSEventResponse
+	for _, record := range event.Records {
+		if !m.Process(ctx, MessageFromLambdaRecord(record)) {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: record.MessageId,
+			})
+		}
+	}
+	return response
+}
+
+// handleWithHeartbeat runs the handler while keeping the message invisible,
+// so a slow SMTP server does not cause the message to be processed twice.
+func (m *SQSManager) handleWithHeartbeat(ctx context.Context, message Message) error {
+	heartbeatCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	go func() {
+		ticker := time.NewTicker(m.config.VisibilityTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-heartbeatCtx.Done():
+				return
+			case <-ticker.C:
+				if err := m.changeVisibility(heartbeatCtx, message.ReceiptHandle, m.config.VisibilityTimeout); err != nil {
+					logger.ErrorContext(heartbeatCtx, "Failed to extend visibility of message", "message_id", message.ID, "error", err)
+				}
+			}
+		}
+	}()
+
+	handleCtx, cancel := context.WithTimeout(ctx, m.config.ProcessingTimeout)
+	defer cancel()
+
+	return m.handler.HandleMessage(handleCtx, []byte(message.Body))
+}
+
+// backoff returns how long a message stays invisible after its
+// receiveCount-th failed attempt.
+func (m *SQSManager) backoff(receiveCount int) time.Duration {
+	return min(commons.Backoff(receiveCount, m.config.RetryBaseDelay, m.config.RetryMaxDelay), maxVisibilityTimeout)
+}
+
+func (m *SQSManager) changeVisibility(ctx context.Context, receiptHandle string, timeout time.Duration) error {
+	_, err := m.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          m.queueURL,
+		ReceiptHandle:     aws.String(receiptHandle),
+		VisibilityTimeout: int32(timeout.Seconds()),
+	})
+	return err
+}
+
+// sendToDeadLetterQueue copies the message to the dead-letter queue with the
+// reason it failed and the trace context of ctx, so that a redriven message
+// stays in the same trace. The caller removes it from the source queue.
+func (m *SQSManager) sendToDeadLetterQueue(ctx context.Context, message Message, cause error) error {
+	reason := cause.Error()
+	if len(reason) > maxFailureReasonLength {
+		reason = reason[:maxFailureReasonLength]
+	}
+
+	attributes := traceAttributes(ctx)
+	attributes["FailureReason"] = stringAttribute(reason)
+	attributes["ReceiveCount"] = types.MessageAttributeValue{DataType: aws.String("Number"), StringValue: aws.String(strconv.Itoa(message.ReceiveCount))}
+	attributes["OriginalMessageId"] = stringAttribute(message.ID)
+	attributes["SourceQueue"] = stringAttribute(m.config.QueueName)
+
+	_, err := m.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          m.deadLetterQueueURL,
+		MessageBody:       aws.String(message.Body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to dead-letter queue: %w", err)
+	}
+	return nil
+}
+
+func stringAttribute(value string) types.MessageAttributeValue {
+	return types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+}
+
+func parseReceiveCount(value string) int {
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// parseSentTimestamp parses the SentTimestamp attribute, milliseconds since
+// the epoch.
+func parseSentTimestamp(value string) time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
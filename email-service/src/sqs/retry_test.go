@@ -0,0 +1,190 @@
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"sama/go-task-management/email-service/src/config"
+	"sama/go-task-management/email-service/src/handlers"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+type fakeSQSClient struct {
+	sqsClient
+	sent       []*sqs.SendMessageInput
+	sendErr    error
+	visibility []*sqs.ChangeMessageVisibilityInput
+}
+
+func (c *fakeSQSClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	if c.sendErr != nil {
+		return nil, c.sendErr
+	}
+	c.sent = append(c.sent, params)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (c *fakeSQSClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	c.visibility = append(c.visibility, params)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// fakeMessageHandler fails every message whose body has an error set.
+type fakeMessageHandler struct {
+	errs map[string]error
+}
+
+func (h *fakeMessageHandler) HandleMessage(ctx context.Context, message []byte) error {
+	return h.errs[string(message)]
+}
+
+func newTestManager(client *fakeSQSClient, handler *fakeMessageHandler) *SQSManager {
+	return &SQSManager{
+		client:             client,
+		queueURL:           aws.String("https://sqs.local/emails"),
+		deadLetterQueueURL: aws.String("https://sqs.local/emails-dlq"),
+		config: &config.Config{
+			QueueName:         "emails",
+			MaxAttempts:       3,
+			RetryBaseDelay:    30 * time.Second,
+			RetryMaxDelay:     5 * time.Minute,
+			VisibilityTimeout: time.Minute,
+			ProcessingTimeout: time.Second,
+		},
+		handler: handler,
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		receiveCount int
+		baseDelay    time.Duration
+		maxDelay     time.Duration
+		want         time.Duration
+	}{
+		{1, 30 * time.Second, 5 * time.Minute, 30 * time.Second},
+		{2, 30 * time.Second, 5 * time.Minute, time.Minute},
+		{3, 30 * time.Second, 5 * time.Minute, 2 * time.Minute},
+		{4, 30 * time.Second, 5 * time.Minute, 4 * time.Minute},
+		{5, 30 * time.Second, 5 * time.Minute, 5 * time.Minute},
+		{1000, 30 * time.Second, 5 * time.Minute, 5 * time.Minute},
+		{10, time.Hour, 48 * time.Hour, maxVisibilityTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d receives up to %v", tt.receiveCount, tt.maxDelay), func(t *testing.T) {
+			manager := &SQSManager{config: &config.Config{RetryBaseDelay: tt.baseDelay, RetryMaxDelay: tt.maxDelay}}
+			if got := manager.backoff(tt.receiveCount); got != tt.want {
+				t.Fatalf("backoff(%d) = %v, want %v", tt.receiveCount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReceiveCount(t *testing.T) {
+	tests := map[string]int{"": 1, "0": 1, "abc": 1, "1": 1, "4": 4}
+
+	for value, want := range tests {
+		if got := parseReceiveCount(value); got != want {
+			t.Errorf("parseReceiveCount(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	failed := errors.New("smtp unavailable")
+
+	tests := []struct {
+		name           string
+		handlerErr     error
+		receiveCount   int
+		sendErr        error
+		wantDone       bool
+		wantDeadLetter bool
+		wantRetryIn    time.Duration
+	}{
+		{"success", nil, 1, nil, true, false, 0},
+		{"first failure is retried", failed, 1, nil, false, false, 30 * time.Second},
+		{"later failure backs off", failed, 2, nil, false, false, time.Minute},
+		{"last attempt goes to the dead-letter queue", failed, 3, nil, true, true, 0},
+		{"invalid message goes to the dead-letter queue at once", fmt.Errorf("%w: no recipient", handlers.ErrInvalidMessage), 1, nil, true, true, 0},
+		{"failed dead-lettering is retried", failed, 3, errors.New("queue unavailable"), false, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSQSClient{sendErr: tt.sendErr}
+			manager := newTestManager(client, &fakeMessageHandler{errs: map[string]error{"body": tt.handlerErr}})
+
+			done := manager.Process(context.Background(), Message{ID: "message", Body: "body", ReceiptHandle: "receipt", ReceiveCount: tt.receiveCount})
+			if done != tt.wantDone {
+				t.Fatalf("Process() = %v, want %v", done, tt.wantDone)
+			}
+
+			if deadLettered := len(client.sent) == 1; deadLettered != tt.wantDeadLetter {
+				t.Fatalf("sent %d messages to the dead-letter queue, want dead-lettered = %v", len(client.sent), tt.wantDeadLetter)
+			}
+			if tt.wantDeadLetter {
+				sent := client.sent[0]
+				if aws.ToString(sent.QueueUrl) != "https://sqs.local/emails-dlq" || aws.ToString(sent.MessageBody) != "body" {
+					t.Fatalf("sent %q to %q, want the message body to the dead-letter queue", aws.ToString(sent.MessageBody), aws.ToString(sent.QueueUrl))
+				}
+				if reason := aws.ToString(sent.MessageAttributes["FailureReason"].StringValue); reason != tt.handlerErr.Error() {
+					t.Fatalf("FailureReason = %q, want %q", reason, tt.handlerErr.Error())
+				}
+			}
+
+			if tt.wantRetryIn == 0 {
+				if len(client.visibility) != 0 {
+					t.Fatalf("changed visibility %d times, want none", len(client.visibility))
+				}
+				return
+			}
+			if len(client.visibility) != 1 || client.visibility[0].VisibilityTimeout != int32(tt.wantRetryIn.Seconds()) {
+				t.Fatalf("visibility changes = %+v, want one to %v", client.visibility, tt.wantRetryIn)
+			}
+		})
+	}
+}
+
+func TestHandleLambdaEventReportsOnlyFailedRecords(t *testing.T) {
+	failed := errors.New("smtp unavailable")
+	client := &fakeSQSClient{}
+	manager := newTestManager(client, &fakeMessageHandler{errs: map[string]error{
+		"retry":   failed,
+		"invalid": handlers.ErrInvalidMessage,
+	}})
+
+	record := func(id string, body string, receiveCount string) events.SQSMessage {
+		return events.SQSMessage{
+			MessageId:     id,
+			Body:          body,
+			ReceiptHandle: "receipt-" + id,
+			Attributes:    map[string]string{"ApproximateReceiveCount": receiveCount},
+		}
+	}
+	response := manager.HandleLambdaEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		record("1", "ok", "1"),
+		record("2", "retry", "1"),
+		record("3", "invalid", "1"),
+		record("4", "retry", "3"),
+		record("5", "retry", "2"),
+	}})
+
+	var failures []string
+	for _, failure := range response.BatchItemFailures {
+		failures = append(failures, failure.ItemIdentifier)
+	}
+	if fmt.Sprint(failures) != "[2 5]" {
+		t.Fatalf("BatchItemFailures = %v, want [2 5]", failures)
+	}
+	if len(client.sent) != 2 {
+		t.Fatalf("sent %d messages to the dead-letter queue, want 2", len(client.sent))
+	}
+}
@@ -3,22 +3,38 @@ package sqs
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"sama/go-task-management/commons"
 	"sama/go-task-management/email-service/src/config"
 	"sama/go-task-management/email-service/src/handlers"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
+var logger = commons.DefaultLogger()
+
+// sqsClient is the part of the SQS API the manager uses.
+type sqsClient interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+type messageHandler interface {
+	HandleMessage(ctx context.Context, message []byte) error
+}
+
 type SQSManager struct {
-	client    *sqs.Client
-	queueURL  *string
-	config    *config.Config
-	handler   *handlers.MessageHandler
+	client             sqsClient
+	queueURL           *string
+	deadLetterQueueURL *string
+	config             *config.Config
+	handler            messageHandler
 }
 
 func NewSQSManager(cfg *config.Config, handler *handlers.MessageHandler) (*SQSManager, error) {
@@ -33,16 +49,22 @@ func NewSQSManager(cfg *config.Config, handler *handlers.MessageHandler) (*SQSMa
 		return nil, err
 	}
 
+	deadLetterQueueURL, err := getQueueURL(ctx, client, cfg.DeadLetterQueueName)
+	if err != nil {
+		return nil, err
+	}
+
 	return &SQSManager{
-		client:   client,
-		queueURL: queueURL,
-		config:   cfg,
-		handler:  handler,
+		client:             client,
+		queueURL:           queueURL,
+		deadLetterQueueURL: deadLetterQueueURL,
+		config:             cfg,
+		handler:            handler,
 	}, nil
 }
 
 func getSQSClient(ctx context.Context, cfg *config.Config) (*sqs.Client, error) {
-	log.Println("Configuring SQS client...")
+	logger.InfoContext(ctx, "Configuring SQS client")
 
 	customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 		if cfg.AWSEndpoint != "" {
@@ -77,8 +99,9 @@ func getQueueURL(ctx context.Context, client *sqs.Client, queueName string) (*st
 
 func (m *SQSManager) SendMessage(ctx context.Context, message string) error {
 	_, err := m.client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    m.queueURL,
-		MessageBody: aws.String(message),
+		QueueUrl:          m.queueURL,
+		MessageBody:       aws.String(message),
+		MessageAttributes: traceAttributes(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to send message to SQS: %w", err)
@@ -87,13 +110,13 @@ func (m *SQSManager) SendMessage(ctx context.Context, message string) error {
 }
 
 func (m *SQSManager) StartPolling(ctx context.Context) {
-	log.Printf("Starting to poll SQS queue: %s", *m.queueURL)
+	logger.InfoContext(ctx, "Starting to poll SQS queue", "queue_url", *m.queueURL)
 
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
-				log.Println("Context canceled, stopping SQS polling")
+				logger.InfoContext(ctx, "Context canceled, stopping SQS polling")
 				return
 			default:
 				m.pollMessages(ctx)
@@ -107,25 +130,46 @@ func (m *SQSManager) pollMessages(ctx context.Context) {
 	receiveCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
+	start := time.Now()
 	resp, err := m.client.ReceiveMessage(receiveCtx, &sqs.ReceiveMessageInput{
 		QueueUrl:            m.queueURL,
 		MaxNumberOfMessages: 10,
 		WaitTimeSeconds:     20,
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{
+			types.MessageSystemAttributeNameApproximateReceiveCount,
+			types.MessageSystemAttributeNameSentTimestamp,
+		},
+		MessageAttributeNames: traceAttributeNames,
 	})
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	pollDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
 
 	if err != nil {
-		log.Printf("Error receiving messages: %v", err)
+		logger.ErrorContext(ctx, "Failed to receive messages", "error", err)
 		return
 	}
 
 	for _, msg := range resp.Messages {
-		if err := m.handler.HandleMessage(ctx, []byte(*msg.Body)); err != nil {
-			log.Printf("Error processing message: %v", err)
+		message := Message{
+			ID:            aws.ToString(msg.MessageId),
+			Body:          aws.ToString(msg.Body),
+			ReceiptHandle: aws.ToString(msg.ReceiptHandle),
+			ReceiveCount:  parseReceiveCount(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]),
+			TraceContext: traceContext(func(name string) *string {
+				return msg.MessageAttributes[name].StringValue
+			}),
+			SentAt: parseSentTimestamp(msg.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]),
+		}
+
+		if !m.Process(ctx, message) {
 			continue
 		}
 
 		if err := m.deleteMessage(ctx, msg.ReceiptHandle); err != nil {
-			log.Printf("Error deleting message: %v", err)
+			logger.ErrorContext(ctx, "Failed to delete message", "message_id", message.ID, "error", err)
 		}
 	}
 }
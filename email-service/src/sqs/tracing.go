@@ -0,0 +1,54 @@
+package sqs
+
+import (
+	"context"
+
+	"sama/go-task-management/commons"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("sama/go-task-management/email-service/src/sqs")
+
+// traceAttributeNames are the message attributes the producer stores the
+// W3C trace context in.
+var traceAttributeNames = []string{"traceparent", "tracestate", "baggage"}
+
+// StartProcessSpan starts the consumer span of a message from queueName,
+// continuing the trace the producer sent along with it. The caller ends the
+// span once the message is handled.
+func StartProcessSpan(ctx context.Context, queueName string, message Message) (context.Context, trace.Span) {
+	return tracer.Start(commons.ExtractTraceContext(ctx, message.TraceContext), "process "+queueName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.String("messaging.operation.type", "process"),
+			attribute.String("messaging.destination.name", queueName),
+			attribute.String("messaging.message.id", message.ID),
+			attribute.Int("messaging.aws_sqs.receive_count", message.ReceiveCount),
+		),
+	)
+}
+
+// traceAttributes returns the trace context of ctx as message attributes.
+func traceAttributes(ctx context.Context) map[string]types.MessageAttributeValue {
+	attributes := map[string]types.MessageAttributeValue{}
+	for key, value := range commons.InjectTraceContext(ctx) {
+		attributes[key] = stringAttribute(value)
+	}
+	return attributes
+}
+
+// traceContext returns the trace context among the attributes of a message.
+func traceContext(stringValues func(name string) *string) map[string]string {
+	traceContext := map[string]string{}
+	for _, name := range traceAttributeNames {
+		if value := stringValues(name); value != nil {
+			traceContext[name] = *value
+		}
+	}
+	return traceContext
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"sama/go-task-management/commons"
+)
+
+const grantAdminUsage = `usage: gateway grant-admin <email>
+
+grants the admin role to the user with the given email address, e.g. to
+bootstrap the first administrator; the role is in their access tokens from
+their next sign-in or refresh`
+
+// isGrantAdminCommand reports whether the gateway was started as
+// "gateway grant-admin ...".
+func isGrantAdminCommand(args []string) bool {
+	return len(args) > 1 && args[1] == "grant-admin"
+}
+
+// runGrantAdmin runs the grant-admin sub-command. args are the arguments
+// following "grant-admin".
+func runGrantAdmin(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%s", grantAdminUsage)
+	}
+
+	db, err := commons.GetConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user, err := commons.NewPostgresUserRepository(db).GetByEmail(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if user.ID == "" {
+		return fmt.Errorf("no user with email %s", args[0])
+	}
+
+	return commons.NewPostgresRoleRepository(db).AssignRole(ctx, user.ID, commons.RoleAdmin)
+}
@@ -2,14 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 
 	"sama/go-task-management/commons"
 	"sama/go-task-management/gateway/handlers/constants"
 	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
 	"sama/go-task-management/gateway/services/auth"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type SignUpRequest struct {
@@ -146,6 +146,7 @@ func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		Handle:   input.Handle,
 		Email:    input.Email,
 		Password: input.Password,
+		Client:   clientInfo(r),
 	})
 
 	if err != nil {
@@ -181,11 +182,13 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	response, err := h.authService.SignIn(r.Context(), auth.SignInInput{
 		Email:    input.Email,
 		Password: input.Password,
+		Client:   clientInfo(r),
 	})
 
 	if err != nil {
 		switch err {
 		case commons.ErrInvalidCredentials:
+			middleware.RecordAuthFailure(middleware.AuthFailureInvalidCredentials)
 			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Invalid credentials", "")
 		default:
 			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to sign in", err.Error())
@@ -199,7 +202,20 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SignOut ends the current session: its refresh tokens are revoked and the
+// access token used for this request stops being accepted.
 func (h *AuthHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.SignOut(r.Context(), auth.AccessTokenInfo{
+		UserID:    middleware.GetUserIDFromContext(r),
+		SessionID: middleware.GetSessionIDFromContext(r),
+		TokenID:   middleware.GetTokenIDFromContext(r),
+		ExpiresAt: middleware.GetTokenExpiryFromContext(r),
+	})
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to sign out", err.Error())
+		return
+	}
+
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
 		Data: map[string]string{
@@ -220,36 +236,57 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := jwt.Parse(input.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		return []byte(h.config.JWTSecret), nil
-	})
-
-	if err != nil || !token.Valid {
-		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Invalid refresh token", "")
+	response, err := h.authService.RefreshToken(r.Context(), input.RefreshToken, clientInfo(r))
+	if err != nil {
+		switch err {
+		case commons.ErrUnauthorized:
+			middleware.RecordAuthFailure(middleware.AuthFailureInvalidRefresh)
+			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Invalid refresh token", "")
+		case commons.ErrTokenReused:
+			middleware.RecordAuthFailure(middleware.AuthFailureRefreshReused)
+			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Refresh token reuse detected, session revoked", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to refresh token", err.Error())
+		}
 		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Invalid refresh token", "")
-		return
-	}
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
 
-	userID, ok := claims["sub"].(string)
-	if !ok {
-		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Invalid refresh token", "")
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.authService.ListSessions(r.Context(), middleware.GetUserIDFromContext(r), middleware.GetSessionIDFromContext(r))
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get sessions", err.Error())
 		return
 	}
 
-	response, err := h.authService.RefreshToken(r.Context(), userID)
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    sessions,
+	})
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	err := h.authService.RevokeSession(r.Context(), middleware.GetUserIDFromContext(r), r.PathValue("id"))
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to refresh token", err.Error())
+		switch err {
+		case commons.ErrNotFound:
+			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Session not found", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to revoke session", err.Error())
+		}
 		return
 	}
 
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
-		Data:    response,
+		Data: map[string]string{
+			"message": "Session revoked successfully",
+		},
 	})
 }
 
@@ -319,3 +356,15 @@ func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+func clientInfo(r *http.Request) auth.ClientInfo {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	return auth.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
+}
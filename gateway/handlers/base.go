@@ -63,7 +63,7 @@ func (h *BaseHandler) respondWithJSON(w http.ResponseWriter, status int, data in
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.logger.Printf("Error encoding response: %v", err)
+		h.logger.Error("Failed to encode response", "error", err)
 	}
 }
 
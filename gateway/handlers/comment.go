@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/comment"
+)
+
+type CommentHandler struct {
+	*BaseHandler
+	commentService *comment.Service
+}
+
+func NewCommentHandler(base *BaseHandler, commentService *comment.Service) *CommentHandler {
+	return &CommentHandler{
+		BaseHandler:    base,
+		commentService: commentService,
+	}
+}
+
+// @Summary Get task comments
+// @Description Retrieves the comment threads of a task, replies nested under their parent
+// @Tags comments
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} comment.CommentResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Task not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/comments [get]
+func (h *CommentHandler) GetTaskComments(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	comments, err := h.commentService.ListComments(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		h.respondWithCommentError(w, err, "Failed to get comments")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    comments,
+	})
+}
+
+// @Summary Comment on a task
+// @Description Adds a comment or a reply to a task. @handle mentions notify the mentioned users in-app
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param input body comment.CreateCommentInput true "Comment details"
+// @Success 201 {object} comment.CommentResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Task not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/comments [post]
+func (h *CommentHandler) CreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	var input comment.CreateCommentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if bodyErr := validation.ValidateCommentBody(input.Body); bodyErr != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*bodyErr})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	created, err := h.commentService.CreateComment(r.Context(), r.PathValue("id"), userID, input)
+	if err != nil {
+		h.respondWithCommentError(w, err, "Failed to create comment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, StandardResponse{
+		Success: true,
+		Data:    created,
+	})
+}
+
+// @Summary Edit a comment
+// @Description Replaces the body of a comment. Only the author may edit; the previous body is kept in the edit history
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param commentId path string true "Comment ID"
+// @Param input body comment.UpdateCommentInput true "New comment body"
+// @Success 200 {object} comment.CommentResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Comment not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/comments/{commentId} [put]
+func (h *CommentHandler) UpdateTaskComment(w http.ResponseWriter, r *http.Request) {
+	var input comment.UpdateCommentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if bodyErr := validation.ValidateCommentBody(input.Body); bodyErr != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*bodyErr})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	updated, err := h.commentService.UpdateComment(r.Context(), r.PathValue("id"), r.PathValue("commentId"), userID, input)
+	if err != nil {
+		h.respondWithCommentError(w, err, "Failed to update comment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    updated,
+	})
+}
+
+// @Summary Delete a comment
+// @Description Soft deletes a comment. Allowed for the author and the task creator; replies are kept
+// @Tags comments
+// @Param id path string true "Task ID"
+// @Param commentId path string true "Comment ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Comment not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/comments/{commentId} [delete]
+func (h *CommentHandler) DeleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	err := h.commentService.DeleteComment(r.Context(), r.PathValue("id"), r.PathValue("commentId"), userID)
+	if err != nil {
+		h.respondWithCommentError(w, err, "Failed to delete comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get comment edit history
+// @Description Retrieves the previous bodies of a comment, most recent first
+// @Tags comments
+// @Produce json
+// @Param id path string true "Task ID"
+// @Param commentId path string true "Comment ID"
+// @Success 200 {array} comment.CommentEditResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Comment not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/comments/{commentId}/edits [get]
+func (h *CommentHandler) GetTaskCommentEdits(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	edits, err := h.commentService.GetCommentEdits(r.Context(), r.PathValue("id"), r.PathValue("commentId"), userID)
+	if err != nil {
+		h.respondWithCommentError(w, err, "Failed to get comment edits")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    edits,
+	})
+}
+
+func (h *CommentHandler) respondWithCommentError(w http.ResponseWriter, err error, message string) {
+	switch err {
+	case commons.ErrNotFound:
+		h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Not found", "")
+	case commons.ErrForbidden:
+		h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+	case commons.ErrInvalidInput:
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid input", "parent_id must reference a comment of the same task")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, message, err.Error())
+	}
+}
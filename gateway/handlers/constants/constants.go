@@ -21,6 +21,12 @@ const (
 	ErrCodeForbidden    = "FORBIDDEN"
 	ErrCodeInternal     = "INTERNAL_ERROR"
 	ErrCodeBadRequest   = "BAD_REQUEST"
+	// ErrCodeInvalidRecurrence is returned for an invalid schedule of a
+	// recurring task.
+	ErrCodeInvalidRecurrence = "INVALID_RECURRENCE"
+	// ErrCodeInvalidVerificationCode is returned for a wrong or expired
+	// phone verification code.
+	ErrCodeInvalidVerificationCode = "INVALID_VERIFICATION_CODE"
 )
 
 const (
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/email"
+)
+
+type EmailHandler struct {
+	*BaseHandler
+	emailService *email.Service
+}
+
+func NewEmailHandler(base *BaseHandler, emailService *email.Service) *EmailHandler {
+	return &EmailHandler{
+		BaseHandler:  base,
+		emailService: emailService,
+	}
+}
+
+// @Summary Get task emails
+// @Description Retrieves the emails sent for a task with their delivery status
+// @Tags emails
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {array} email.EmailResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Task not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/emails [get]
+func (h *EmailHandler) GetTaskEmails(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	emails, err := h.emailService.ListTaskEmails(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		switch err {
+		case commons.ErrNotFound:
+			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Task not found", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get emails", err.Error())
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    emails,
+	})
+}
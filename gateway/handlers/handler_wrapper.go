@@ -3,11 +3,21 @@ package handlers
 import "net/http"
 
 type HandlerWrapper struct {
-	Base              *BaseHandler
-	Auth              *AuthHandler
-	Task              *TaskHandler
-	InAppNotification *InAppNotificationHandler
-	TaskSystemEvent   *TaskSystemEventHandler
+	Base                   *BaseHandler
+	Auth                   *AuthHandler
+	Task                   *TaskHandler
+	InAppNotification      *InAppNotificationHandler
+	TaskSystemEvent        *TaskSystemEventHandler
+	Comment                *CommentHandler
+	Email                  *EmailHandler
+	Project                *ProjectHandler
+	Role                   *RoleHandler
+	Reminder               *ReminderHandler
+	NotificationPreference *NotificationPreferenceHandler
+	Phone                  *PhoneHandler
+	Webhook                *WebhookHandler
+	Stream                 *StreamHandler
+	Trace                  *TraceHandler
 }
 
 func (h *HandlerWrapper) Health(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +40,14 @@ func (h *HandlerWrapper) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	h.Auth.RefreshToken(w, r)
 }
 
+func (h *HandlerWrapper) ListSessions(w http.ResponseWriter, r *http.Request) {
+	h.Auth.ListSessions(w, r)
+}
+
+func (h *HandlerWrapper) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	h.Auth.RevokeSession(w, r)
+}
+
 func (h *HandlerWrapper) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 	h.Auth.ForgotPassword(w, r)
 }
@@ -58,6 +76,10 @@ func (h *HandlerWrapper) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	h.Task.DeleteTask(w, r)
 }
 
+func (h *HandlerWrapper) GetTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	h.Task.GetTaskRecurrence(w, r)
+}
+
 func (h *HandlerWrapper) GetAllInAppNotifications(w http.ResponseWriter, r *http.Request) {
 	h.InAppNotification.GetUserNotifications(w, r)
 }
@@ -73,3 +95,159 @@ func (h *HandlerWrapper) DeleteInAppNotification(w http.ResponseWriter, r *http.
 func (h *HandlerWrapper) GetAllTaskSystemEvents(w http.ResponseWriter, r *http.Request) {
 	h.TaskSystemEvent.GetAllTaskSystemEvents(w, r)
 }
+
+func (h *HandlerWrapper) GetTaskComments(w http.ResponseWriter, r *http.Request) {
+	h.Comment.GetTaskComments(w, r)
+}
+
+func (h *HandlerWrapper) CreateTaskComment(w http.ResponseWriter, r *http.Request) {
+	h.Comment.CreateTaskComment(w, r)
+}
+
+func (h *HandlerWrapper) UpdateTaskComment(w http.ResponseWriter, r *http.Request) {
+	h.Comment.UpdateTaskComment(w, r)
+}
+
+func (h *HandlerWrapper) DeleteTaskComment(w http.ResponseWriter, r *http.Request) {
+	h.Comment.DeleteTaskComment(w, r)
+}
+
+func (h *HandlerWrapper) GetTaskCommentEdits(w http.ResponseWriter, r *http.Request) {
+	h.Comment.GetTaskCommentEdits(w, r)
+}
+
+func (h *HandlerWrapper) GetTaskEmails(w http.ResponseWriter, r *http.Request) {
+	h.Email.GetTaskEmails(w, r)
+}
+
+func (h *HandlerWrapper) GetAllProjects(w http.ResponseWriter, r *http.Request) {
+	h.Project.GetAllProjects(w, r)
+}
+
+func (h *HandlerWrapper) GetProject(w http.ResponseWriter, r *http.Request) {
+	h.Project.GetProject(w, r)
+}
+
+func (h *HandlerWrapper) CreateProject(w http.ResponseWriter, r *http.Request) {
+	h.Project.CreateProject(w, r)
+}
+
+func (h *HandlerWrapper) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	h.Project.UpdateProject(w, r)
+}
+
+func (h *HandlerWrapper) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	h.Project.DeleteProject(w, r)
+}
+
+func (h *HandlerWrapper) GetProjectMembers(w http.ResponseWriter, r *http.Request) {
+	h.Project.GetProjectMembers(w, r)
+}
+
+func (h *HandlerWrapper) AddProjectMember(w http.ResponseWriter, r *http.Request) {
+	h.Project.AddProjectMember(w, r)
+}
+
+func (h *HandlerWrapper) UpdateProjectMember(w http.ResponseWriter, r *http.Request) {
+	h.Project.UpdateProjectMember(w, r)
+}
+
+func (h *HandlerWrapper) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
+	h.Project.RemoveProjectMember(w, r)
+}
+
+func (h *HandlerWrapper) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	h.Role.GetAllRoles(w, r)
+}
+
+func (h *HandlerWrapper) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	h.Role.GetUserRoles(w, r)
+}
+
+func (h *HandlerWrapper) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	h.Role.AssignUserRole(w, r)
+}
+
+func (h *HandlerWrapper) RemoveUserRole(w http.ResponseWriter, r *http.Request) {
+	h.Role.RemoveUserRole(w, r)
+}
+
+func (h *HandlerWrapper) GetReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	h.Reminder.GetReminderPreferences(w, r)
+}
+
+func (h *HandlerWrapper) UpdateReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	h.Reminder.UpdateReminderPreferences(w, r)
+}
+
+func (h *HandlerWrapper) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	h.NotificationPreference.GetNotificationPreferences(w, r)
+}
+
+func (h *HandlerWrapper) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	h.NotificationPreference.UpdateNotificationPreferences(w, r)
+}
+
+func (h *HandlerWrapper) GetPhone(w http.ResponseWriter, r *http.Request) {
+	h.Phone.GetPhone(w, r)
+}
+
+func (h *HandlerWrapper) UpdatePhone(w http.ResponseWriter, r *http.Request) {
+	h.Phone.UpdatePhone(w, r)
+}
+
+func (h *HandlerWrapper) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	h.Phone.VerifyPhone(w, r)
+}
+
+func (h *HandlerWrapper) DeletePhone(w http.ResponseWriter, r *http.Request) {
+	h.Phone.DeletePhone(w, r)
+}
+
+func (h *HandlerWrapper) GetAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.GetAllWebhooks(w, r)
+}
+
+func (h *HandlerWrapper) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.CreateWebhook(w, r)
+}
+
+func (h *HandlerWrapper) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.GetWebhook(w, r)
+}
+
+func (h *HandlerWrapper) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.UpdateWebhook(w, r)
+}
+
+func (h *HandlerWrapper) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.DeleteWebhook(w, r)
+}
+
+func (h *HandlerWrapper) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.GetWebhookDeliveries(w, r)
+}
+
+func (h *HandlerWrapper) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.GetWebhookDelivery(w, r)
+}
+
+func (h *HandlerWrapper) RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	h.Webhook.RedeliverWebhookDelivery(w, r)
+}
+
+func (h *HandlerWrapper) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	h.Stream.StreamEvents(w, r)
+}
+
+func (h *HandlerWrapper) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	h.Stream.StreamEventsWebSocket(w, r)
+}
+
+func (h *HandlerWrapper) GetAllTraces(w http.ResponseWriter, r *http.Request) {
+	h.Trace.GetAllTraces(w, r)
+}
+
+func (h *HandlerWrapper) GetTrace(w http.ResponseWriter, r *http.Request) {
+	h.Trace.GetTrace(w, r)
+}
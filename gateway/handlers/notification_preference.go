@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/notification_preference"
+)
+
+type NotificationPreferenceHandler struct {
+	*BaseHandler
+	notificationPreferenceService *notification_preference.Service
+}
+
+func NewNotificationPreferenceHandler(base *BaseHandler, notificationPreferenceService *notification_preference.Service) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{
+		BaseHandler:                   base,
+		notificationPreferenceService: notificationPreferenceService,
+	}
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	// Events holds, per event and channel, whether to get it. Events and
+	// channels left out are enabled.
+	Events map[string]map[string]bool `json:"events"`
+	// QuietHours hold back everything but in-app notifications; leave them
+	// out to turn them off.
+	QuietHours *commons.QuietHours `json:"quiet_hours,omitempty"`
+	// Delivery is "immediate" or "digest"; digest sums up everything but
+	// in-app notifications in digest emails.
+	Delivery string `json:"delivery" example:"immediate"`
+	// DigestFrequency is "daily" or "weekly".
+	DigestFrequency string `json:"digest_frequency" example:"daily"`
+}
+
+func (r *UpdateNotificationPreferencesRequest) Validate() []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	for event, channels := range r.Events {
+		allowed, ok := commons.NotificationEventChannels[event]
+		if !ok {
+			errors = append(errors, validation.ValidationError{
+				Field:   "events",
+				Message: fmt.Sprintf("Unknown event %q", event),
+			})
+			continue
+		}
+		for channel := range channels {
+			if !slices.Contains(allowed, channel) {
+				errors = append(errors, validation.ValidationError{
+					Field:   "events." + event,
+					Message: fmt.Sprintf("Event %q is not sent on channel %q", event, channel),
+				})
+			}
+		}
+	}
+
+	if r.QuietHours != nil {
+		if err := r.QuietHours.Validate(); err != nil {
+			errors = append(errors, validation.ValidationError{
+				Field:   "quiet_hours",
+				Message: "Invalid quiet hours: " + err.Error(),
+			})
+		}
+	}
+
+	if r.Delivery != "" && r.Delivery != commons.NotificationDeliveryImmediate && r.Delivery != commons.NotificationDeliveryDigest {
+		errors = append(errors, validation.ValidationError{
+			Field:   "delivery",
+			Message: "Delivery must be immediate or digest",
+		})
+	}
+
+	if r.DigestFrequency != "" && r.DigestFrequency != commons.NotificationDigestDaily && r.DigestFrequency != commons.NotificationDigestWeekly {
+		errors = append(errors, validation.ValidationError{
+			Field:   "digest_frequency",
+			Message: "Digest frequency must be daily or weekly",
+		})
+	}
+
+	return errors
+}
+
+// @Summary Get notification preferences
+// @Description Retrieves which notifications the authenticated user gets on which channel, their quiet hours and whether they get notifications immediately or in digests
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} commons.NotificationPreferences
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/notification-preferences [get]
+func (h *NotificationPreferenceHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	preferences, err := h.notificationPreferenceService.GetPreferences(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get notification preferences", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    preferences,
+	})
+}
+
+// @Summary Update notification preferences
+// @Description Replaces the notification preferences of the authenticated user
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param input body UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {object} commons.NotificationPreferences
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/notification-preferences [put]
+func (h *NotificationPreferenceHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var input UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if validationErrors := input.Validate(); len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	preferences, err := h.notificationPreferenceService.UpdatePreferences(r.Context(), userID, notification_preference.UpdateInput{
+		Events:          input.Events,
+		QuietHours:      input.QuietHours,
+		Delivery:        input.Delivery,
+		DigestFrequency: input.DigestFrequency,
+	})
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to update notification preferences", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    preferences,
+	})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/phone"
+)
+
+// phoneNumberPattern matches phone numbers in E.164 format.
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+var verificationCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+type PhoneHandler struct {
+	*BaseHandler
+	phoneService *phone.Service
+}
+
+func NewPhoneHandler(base *BaseHandler, phoneService *phone.Service) *PhoneHandler {
+	return &PhoneHandler{
+		BaseHandler:  base,
+		phoneService: phoneService,
+	}
+}
+
+type UpdatePhoneRequest struct {
+	// PhoneNumber is in E.164 format.
+	PhoneNumber string `json:"phone_number" example:"+4915112345678"`
+}
+
+func (r *UpdatePhoneRequest) Validate() []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	if !phoneNumberPattern.MatchString(r.PhoneNumber) {
+		errors = append(errors, validation.ValidationError{
+			Field:   "phone_number",
+			Message: "Phone number must be in E.164 format, e.g. +4915112345678",
+		})
+	}
+
+	return errors
+}
+
+type VerifyPhoneRequest struct {
+	Code string `json:"code" example:"123456"`
+}
+
+func (r *VerifyPhoneRequest) Validate() []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	if !verificationCodePattern.MatchString(r.Code) {
+		errors = append(errors, validation.ValidationError{
+			Field:   "code",
+			Message: "Code must be 6 digits",
+		})
+	}
+
+	return errors
+}
+
+// @Summary Get phone number
+// @Description Retrieves the phone number of the authenticated user and whether it is verified
+// @Tags phone
+// @Produce json
+// @Success 200 {object} phone.PhoneResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/phone [get]
+func (h *PhoneHandler) GetPhone(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.phoneService.GetPhone(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get phone number", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Set phone number
+// @Description Sets the phone number of the authenticated user and texts it a verification code. SMS notifications are only sent once the number is verified
+// @Tags phone
+// @Accept json
+// @Produce json
+// @Param input body UpdatePhoneRequest true "Phone number"
+// @Success 200 {object} phone.PhoneResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/phone [put]
+func (h *PhoneHandler) UpdatePhone(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var input UpdatePhoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if validationErrors := input.Validate(); len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	response, err := h.phoneService.SetPhoneNumber(r.Context(), userID, input.PhoneNumber)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to set phone number", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Verify phone number
+// @Description Verifies the phone number of the authenticated user with the code texted to it
+// @Tags phone
+// @Accept json
+// @Produce json
+// @Param input body VerifyPhoneRequest true "Verification code"
+// @Success 200 {object} phone.PhoneResponse
+// @Failure 400 {object} ErrorResponse "Invalid or expired verification code"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/phone/verify [post]
+func (h *PhoneHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var input VerifyPhoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if validationErrors := input.Validate(); len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	response, err := h.phoneService.VerifyPhoneNumber(r.Context(), userID, input.Code)
+	if err != nil {
+		switch err {
+		case commons.ErrInvalidVerificationCode:
+			h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeInvalidVerificationCode, "Invalid or expired verification code", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to verify phone number", err.Error())
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Remove phone number
+// @Description Removes the phone number of the authenticated user, who then gets no more SMS
+// @Tags phone
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/phone [delete]
+func (h *PhoneHandler) DeletePhone(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	if err := h.phoneService.RemovePhoneNumber(r.Context(), userID); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to remove phone number", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/project"
+)
+
+type ProjectHandler struct {
+	*BaseHandler
+	projectService *project.Service
+}
+
+func NewProjectHandler(base *BaseHandler, projectService *project.Service) *ProjectHandler {
+	return &ProjectHandler{
+		BaseHandler:    base,
+		projectService: projectService,
+	}
+}
+
+// @Summary Get all projects
+// @Description Retrieves the projects the authenticated user is a member of, with their role
+// @Tags projects
+// @Produce json
+// @Success 200 {array} project.ProjectResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects [get]
+func (h *ProjectHandler) GetAllProjects(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	projects, err := h.projectService.ListProjects(r.Context(), userID)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to get projects")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    projects,
+	})
+}
+
+// @Summary Get a project by ID
+// @Description Retrieves a project the authenticated user is a member of
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} project.ProjectResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.projectService.GetProject(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to get project")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Create a project
+// @Description Creates a project; the authenticated user becomes its owner
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param input body project.CreateProjectInput true "Project details"
+// @Success 201 {object} project.ProjectResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects [post]
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	var input project.CreateProjectInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if errors := validateProjectFields(input.Name, input.Description, true); len(errors) > 0 {
+		h.respondWithValidationErrors(w, errors)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.projectService.CreateProject(r.Context(), userID, input)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to create project")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a project
+// @Description Updates the name and/or description of a project. Owners only
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param input body project.UpdateProjectInput true "Project update details"
+// @Success 200 {object} project.ProjectResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	var input project.UpdateProjectInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if errors := validateProjectFields(input.Name, input.Description, false); len(errors) > 0 {
+		h.respondWithValidationErrors(w, errors)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.projectService.UpdateProject(r.Context(), r.PathValue("id"), userID, input)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to update project")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a project
+// @Description Deletes a project together with its tasks. Owners only
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 "Project deleted successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	if err := h.projectService.DeleteProject(r.Context(), r.PathValue("id"), userID); err != nil {
+		h.respondWithProjectError(w, err, "Failed to delete project")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data: map[string]string{
+			"message": "Project deleted successfully",
+		},
+	})
+}
+
+// @Summary Get project members
+// @Description Retrieves the members of a project and their roles
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {array} project.MemberResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id}/members [get]
+func (h *ProjectHandler) GetProjectMembers(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	members, err := h.projectService.ListMembers(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to get project members")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    members,
+	})
+}
+
+// @Summary Add a project member
+// @Description Adds a user (by user_id or handle) to a project with a role, or changes the role of an existing member. Owners only
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param input body project.AddMemberInput true "Member details"
+// @Success 201 {object} project.MemberResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project or user not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id}/members [post]
+func (h *ProjectHandler) AddProjectMember(w http.ResponseWriter, r *http.Request) {
+	var input project.AddMemberInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	var errors []validation.ValidationError
+	if input.UserID == "" && input.Handle == "" {
+		errors = append(errors, validation.ValidationError{
+			Field:   "user_id",
+			Message: "Either user_id or handle is required",
+		})
+	}
+	if roleErr := validateProjectRole(input.Role); roleErr != nil {
+		errors = append(errors, *roleErr)
+	}
+	if len(errors) > 0 {
+		h.respondWithValidationErrors(w, errors)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	member, err := h.projectService.AddMember(r.Context(), r.PathValue("id"), userID, input)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to add project member")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, StandardResponse{
+		Success: true,
+		Data:    member,
+	})
+}
+
+// @Summary Change a member's role
+// @Description Changes the role of a project member. Owners only; the last owner cannot be demoted
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param userId path string true "Member user ID"
+// @Param input body project.UpdateMemberInput true "New role"
+// @Success 200 "Member updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project or member not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id}/members/{userId} [put]
+func (h *ProjectHandler) UpdateProjectMember(w http.ResponseWriter, r *http.Request) {
+	var input project.UpdateMemberInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if roleErr := validateProjectRole(input.Role); roleErr != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*roleErr})
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	err := h.projectService.UpdateMember(r.Context(), r.PathValue("id"), r.PathValue("userId"), userID, input)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to update project member")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data: map[string]string{
+			"message": "Member updated successfully",
+		},
+	})
+}
+
+// @Summary Remove a project member
+// @Description Removes a member from a project. Owners may remove anyone, members may leave; the last owner cannot be removed
+// @Tags projects
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param userId path string true "Member user ID"
+// @Success 200 "Member removed successfully"
+// @Failure 400 {object} ErrorResponse "Last owner"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project or member not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /projects/{id}/members/{userId} [delete]
+func (h *ProjectHandler) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	err := h.projectService.RemoveMember(r.Context(), r.PathValue("id"), r.PathValue("userId"), userID)
+	if err != nil {
+		h.respondWithProjectError(w, err, "Failed to remove project member")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data: map[string]string{
+			"message": "Member removed successfully",
+		},
+	})
+}
+
+func (h *ProjectHandler) respondWithProjectError(w http.ResponseWriter, err error, message string) {
+	switch err {
+	case commons.ErrNotFound:
+		h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Not found", "")
+	case commons.ErrForbidden:
+		h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+	case commons.ErrInvalidInput:
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid input", "A project must keep at least one owner")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, message, err.Error())
+	}
+}
+
+func validateProjectFields(name string, description string, nameRequired bool) []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	if nameRequired || name != "" {
+		if nameErr := validation.ValidateProjectName(strings.TrimSpace(name)); nameErr != nil {
+			errors = append(errors, *nameErr)
+		}
+	}
+
+	if descErr := validation.ValidateDescription(description); descErr != nil {
+		errors = append(errors, *descErr)
+	}
+
+	return errors
+}
+
+func validateProjectRole(role string) *validation.ValidationError {
+	if !commons.IsValidProjectRole(role) {
+		return &validation.ValidationError{
+			Field:   "role",
+			Message: "Invalid role. Must be one of: owner, editor, viewer",
+		}
+	}
+	return nil
+}
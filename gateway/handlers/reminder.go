@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/reminder"
+)
+
+type ReminderHandler struct {
+	*BaseHandler
+	reminderService *reminder.Service
+}
+
+func NewReminderHandler(base *BaseHandler, reminderService *reminder.Service) *ReminderHandler {
+	return &ReminderHandler{
+		BaseHandler:     base,
+		reminderService: reminderService,
+	}
+}
+
+type UpdateReminderPreferencesRequest struct {
+	// LeadTimeMinutes are the times before the due date at which to remind;
+	// 0 reminds when the task is overdue. An empty list restores the defaults.
+	LeadTimeMinutes []int `json:"lead_time_minutes" example:"1440,60,0"`
+}
+
+func (r *UpdateReminderPreferencesRequest) Validate() []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	if len(r.LeadTimeMinutes) > commons.MaxReminderLeadTimes {
+		errors = append(errors, validation.ValidationError{
+			Field:   "lead_time_minutes",
+			Message: fmt.Sprintf("At most %d lead times are allowed", commons.MaxReminderLeadTimes),
+		})
+	}
+
+	for _, leadTime := range r.LeadTimeMinutes {
+		if leadTime < 0 || leadTime > commons.MaxReminderLeadTimeMinutes {
+			errors = append(errors, validation.ValidationError{
+				Field:   "lead_time_minutes",
+				Message: fmt.Sprintf("Lead times must be between 0 and %d minutes", commons.MaxReminderLeadTimeMinutes),
+			})
+			break
+		}
+	}
+
+	return errors
+}
+
+// @Summary Get reminder preferences
+// @Description Retrieves when the authenticated user is reminded of the due dates of tasks they created or are assigned to
+// @Tags reminders
+// @Produce json
+// @Success 200 {object} reminder.PreferencesResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/reminder-preferences [get]
+func (h *ReminderHandler) GetReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	preferences, err := h.reminderService.GetPreferences(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get reminder preferences", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    preferences,
+	})
+}
+
+// @Summary Update reminder preferences
+// @Description Sets the lead times before the due date at which the authenticated user is reminded
+// @Tags reminders
+// @Accept json
+// @Produce json
+// @Param input body UpdateReminderPreferencesRequest true "Reminder lead times"
+// @Success 200 {object} reminder.PreferencesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/reminder-preferences [put]
+func (h *ReminderHandler) UpdateReminderPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	var input UpdateReminderPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if validationErrors := input.Validate(); len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	preferences, err := h.reminderService.UpdatePreferences(r.Context(), userID, input.LeadTimeMinutes)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to update reminder preferences", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    preferences,
+	})
+}
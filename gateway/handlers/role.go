@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/services/auth"
+)
+
+type RoleHandler struct {
+	*BaseHandler
+	authService *auth.Service
+}
+
+func NewRoleHandler(base *BaseHandler, authService *auth.Service) *RoleHandler {
+	return &RoleHandler{
+		BaseHandler: base,
+		authService: authService,
+	}
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UserRolesResponse struct {
+	UserID string   `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// @Summary Get all roles
+// @Description Retrieves every role with its permissions. Requires the admin permission
+// @Tags roles
+// @Produce json
+// @Success 200 {array} commons.Role
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /roles [get]
+func (h *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.authService.GetRoles(r.Context())
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to get roles", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    roles,
+	})
+}
+
+// @Summary Get a user's roles
+// @Description Retrieves the roles granted to a user. Requires the admin permission
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserRolesResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{id}/roles [get]
+func (h *RoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	roles, err := h.authService.GetUserRoles(r.Context(), userID)
+	if err != nil {
+		h.respondWithRoleError(w, err, "Failed to get user roles")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    UserRolesResponse{UserID: userID, Roles: roles},
+	})
+}
+
+// @Summary Grant a role to a user
+// @Description Grants a role to a user. Takes effect on the user's next sign-in or token refresh. Requires the admin permission
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param input body AssignRoleRequest true "Role to grant"
+// @Success 200 {object} UserRolesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User or role not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{id}/roles [post]
+func (h *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	var input AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if input.Role == "" {
+		h.respondWithValidationErrors(w, []validation.ValidationError{{
+			Field:   "role",
+			Message: "Role is required",
+		}})
+		return
+	}
+
+	userID := r.PathValue("id")
+
+	roles, err := h.authService.AssignRole(r.Context(), userID, input.Role)
+	if err != nil {
+		h.respondWithRoleError(w, err, "Failed to assign role")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    UserRolesResponse{UserID: userID, Roles: roles},
+	})
+}
+
+// @Summary Revoke a role from a user
+// @Description Revokes a role from a user. Takes effect on the user's next sign-in or token refresh. Requires the admin permission
+// @Tags roles
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role ID"
+// @Success 200 {object} UserRolesResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "User does not have the role"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/{id}/roles/{role} [delete]
+func (h *RoleHandler) RemoveUserRole(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	roles, err := h.authService.RemoveRole(r.Context(), userID, r.PathValue("role"))
+	if err != nil {
+		h.respondWithRoleError(w, err, "Failed to remove role")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    UserRolesResponse{UserID: userID, Roles: roles},
+	})
+}
+
+func (h *RoleHandler) respondWithRoleError(w http.ResponseWriter, err error, message string) {
+	switch err {
+	case commons.ErrNotFound:
+		h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Not found", "")
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, message, err.Error())
+	}
+}
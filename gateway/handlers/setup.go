@@ -7,11 +7,21 @@ import (
 )
 
 type Handlers struct {
-	Base              *BaseHandler
-	Auth              *AuthHandler
-	Task              *TaskHandler
-	InAppNotification *InAppNotificationHandler
-	TaskSystemEvent   *TaskSystemEventHandler
+	Base                   *BaseHandler
+	Auth                   *AuthHandler
+	Task                   *TaskHandler
+	InAppNotification      *InAppNotificationHandler
+	TaskSystemEvent        *TaskSystemEventHandler
+	Comment                *CommentHandler
+	Email                  *EmailHandler
+	Project                *ProjectHandler
+	Role                   *RoleHandler
+	Reminder               *ReminderHandler
+	NotificationPreference *NotificationPreferenceHandler
+	Phone                  *PhoneHandler
+	Webhook                *WebhookHandler
+	Stream                 *StreamHandler
+	Trace                  *TraceHandler
 }
 
 func NewHandlers(logger commons.Logger, services *services.Services) (*Handlers, error) {
@@ -32,11 +42,21 @@ func NewHandlers(logger commons.Logger, services *services.Services) (*Handlers,
 	}
 
 	return &Handlers{
-		Base:              baseHandler,
-		Auth:              NewAuthHandler(baseHandler, services.AuthService),
-		Task:              NewTaskHandler(baseHandler, services.TaskService),
-		TaskSystemEvent:   NewTaskSystemEventHandler(baseHandler, services.TaskSystemEventService),
-		InAppNotification: NewInAppNotificationHandler(baseHandler, services.InAppNotificationService),
+		Base:                   baseHandler,
+		Auth:                   NewAuthHandler(baseHandler, services.AuthService),
+		Task:                   NewTaskHandler(baseHandler, services.TaskService),
+		TaskSystemEvent:        NewTaskSystemEventHandler(baseHandler, services.TaskSystemEventService),
+		InAppNotification:      NewInAppNotificationHandler(baseHandler, services.InAppNotificationService),
+		Comment:                NewCommentHandler(baseHandler, services.CommentService),
+		Email:                  NewEmailHandler(baseHandler, services.EmailService),
+		Project:                NewProjectHandler(baseHandler, services.ProjectService),
+		Role:                   NewRoleHandler(baseHandler, services.AuthService),
+		Reminder:               NewReminderHandler(baseHandler, services.ReminderService),
+		NotificationPreference: NewNotificationPreferenceHandler(baseHandler, services.NotificationPreferenceService),
+		Phone:                  NewPhoneHandler(baseHandler, services.PhoneService),
+		Webhook:                NewWebhookHandler(baseHandler, services.WebhookService),
+		Stream:                 NewStreamHandler(baseHandler, services.StreamBroker),
+		Trace:                  NewTraceHandler(baseHandler, services.TraceService),
 	}, nil
 }
 
@@ -46,12 +66,32 @@ func NewHandlerWrapper(
 	task *TaskHandler,
 	inApp *InAppNotificationHandler,
 	taskSystem *TaskSystemEventHandler,
-	) *HandlerWrapper {
+	comment *CommentHandler,
+	email *EmailHandler,
+	project *ProjectHandler,
+	role *RoleHandler,
+	reminder *ReminderHandler,
+	notificationPreference *NotificationPreferenceHandler,
+	phone *PhoneHandler,
+	webhook *WebhookHandler,
+	stream *StreamHandler,
+	trace *TraceHandler,
+) *HandlerWrapper {
 	return &HandlerWrapper{
-		Base:              base,
-		Auth:              auth,
-		Task:              task,
-		InAppNotification: inApp,
-		TaskSystemEvent:   taskSystem,
+		Base:                   base,
+		Auth:                   auth,
+		Task:                   task,
+		InAppNotification:      inApp,
+		TaskSystemEvent:        taskSystem,
+		Comment:                comment,
+		Email:                  email,
+		Project:                project,
+		Role:                   role,
+		Reminder:               reminder,
+		NotificationPreference: notificationPreference,
+		Phone:                  phone,
+		Webhook:                webhook,
+		Stream:                 stream,
+		Trace:                  trace,
 	}
 }
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/stream"
+)
+
+const (
+	// streamHeartbeat keeps idle connections from being closed by proxies.
+	streamHeartbeat = 30 * time.Second
+	// streamRetry is the reconnect delay suggested to EventSource clients.
+	streamRetry = 3 * time.Second
+)
+
+type StreamHandler struct {
+	*BaseHandler
+	broker *stream.Broker
+}
+
+func NewStreamHandler(base *BaseHandler, broker *stream.Broker) *StreamHandler {
+	return &StreamHandler{
+		BaseHandler: base,
+		broker:      broker,
+	}
+}
+
+// @Summary Stream events
+// @Description Pushes the authenticated user's in-app notifications and the system events of the tasks they can see as server-sent events, named "notification" and "task-system-event". The token may be passed as the access_token query parameter. The stream ends when the token expires; events raised while disconnected are not replayed
+// @Tags stream
+// @Produce text/event-stream
+// @Param access_token query string false "Access token, for clients that cannot set headers"
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Streaming unsupported"
+// @Router /stream [get]
+func (h *StreamHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if err := controller.Flush(); err != nil {
+		h.logger.ErrorContext(r.Context(), "Streaming unsupported", "error", err)
+		return
+	}
+
+	subscription := h.broker.Subscribe(userID)
+	defer h.broker.Unsubscribe(subscription)
+
+	expired := tokenExpiry(r)
+	defer expired.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-expired.C:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				h.logger.ErrorContext(r.Context(), "Failed to encode stream event", "type", event.Type, "id", event.ID, "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// @Summary Stream events over a WebSocket
+// @Description Same events as /stream, sent as JSON messages {"id", "type", "data"} with a {"type": "ping"} heartbeat. The token may be passed as the access_token query parameter. The socket is closed when the token expires
+// @Tags stream
+// @Param access_token query string false "Access token, for clients that cannot set headers"
+// @Success 101 {string} string "Switching protocols"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Router /stream/ws [get]
+func (h *StreamHandler) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	server := websocket.Server{
+		// Browsers do not apply CORS to WebSockets, so pages of any origin may
+		// connect. Only the bearer token protects the socket: it is not a
+		// cookie, so such pages do not have it.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			h.serveWebSocket(conn, r, userID)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (h *StreamHandler) serveWebSocket(conn *websocket.Conn, r *http.Request, userID string) {
+	subscription := h.broker.Subscribe(userID)
+	defer h.broker.Unsubscribe(subscription)
+
+	// Clients are not expected to send anything; reading only tells us when
+	// they go away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+
+	expired := tokenExpiry(r)
+	defer expired.Stop()
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			return
+		case <-expired.C:
+			return
+		case <-heartbeat.C:
+			err = websocket.JSON.Send(conn, map[string]string{"type": "ping"})
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			err = websocket.JSON.Send(conn, event)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// tokenExpiry returns a timer that fires when the request's token expires,
+// so that streams do not outlive the token they were opened with.
+func tokenExpiry(r *http.Request) *time.Timer {
+	expiresAt := middleware.GetTokenExpiryFromContext(r)
+	if expiresAt.IsZero() {
+		timer := time.NewTimer(0)
+		timer.Stop()
+		return timer
+	}
+	return time.NewTimer(time.Until(expiresAt))
+}
@@ -2,7 +2,13 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sama/go-task-management/commons"
 	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
 	"sama/go-task-management/gateway/middleware"
 	task_system_event "sama/go-task-management/gateway/services/task_system_event"
 )
@@ -20,12 +26,22 @@ type GetAllTaskSystemEventsResponse struct {
 	Events []TaskSystemEventResponse `json:"events"`
 }
 
-// @Summary Get all task system events
-// @Description Retrieves all system events related to tasks
+// @Summary Get task system events
+// @Description Retrieves the system events of the tasks the authenticated user can see, or of all tasks with the events:read-all permission. Events are listed once their emit_at has passed, newest first, and paginated by cursor
 // @Tags system-events
 // @Accept json
 // @Produce json
+// @Param task_id query string false "Task ID"
+// @Param correlation_id query string false "Correlation ID"
+// @Param origin query string false "Origin, e.g. gateway or notification-service"
+// @Param action query string false "Action prefix, e.g. EMAIL_"
+// @Param from query string false "Only events emitted at or after this RFC3339 time"
+// @Param to query string false "Only events emitted at or before this RFC3339 time"
+// @Param order query string false "Sort direction by emit_at" Enums(asc, desc)
+// @Param cursor query string false "Cursor returned as meta.next_cursor by the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
 // @Success 200 {object} StandardResponse{data=GetAllTaskSystemEventsResponse}
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /task-system-events [get]
@@ -36,28 +52,29 @@ func (h *TaskSystemEventHandler) GetAllTaskSystemEvents(w http.ResponseWriter, r
 		return
 	}
 
-	events, err := h.taskEventService.GetAll()
-	if err != nil {
-		h.logger.Printf("Failed to get all task system events: %v", err)
-		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch system events", err.Error())
+	query, validationErrors := parseTaskSystemEventQuery(r)
+	if len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
 		return
 	}
 
-	if len(events) == 0 {
-		h.respondWithJSON(w, http.StatusOK, StandardResponse{
-			Success: true,
-			Data: GetAllTaskSystemEventsResponse{
-				Events: []TaskSystemEventResponse{},
-			},
-		})
+	readAll := commons.HasPermission(middleware.GetPermissionsFromContext(r), commons.PermissionEventsReadAll)
+	page, err := h.taskEventService.ListEvents(r.Context(), userID, readAll, query)
+	if err == commons.ErrInvalidInput {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid query parameters", "The cursor does not match the requested order")
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to get task system events", "error", err)
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch system events", err.Error())
 		return
 	}
 
 	response := GetAllTaskSystemEventsResponse{
-		Events: make([]TaskSystemEventResponse, len(events)),
+		Events: make([]TaskSystemEventResponse, len(page.Events)),
 	}
 
-	for i, event := range events {
+	for i, event := range page.Events {
 		response.Events[i] = TaskSystemEventResponse{
 			ID:            event.ID,
 			TaskId:        event.TaskId,
@@ -74,5 +91,63 @@ func (h *TaskSystemEventHandler) GetAllTaskSystemEvents(w http.ResponseWriter, r
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
 		Data:    response,
+		Meta: &MetaInfo{
+			PerPage:    query.Limit,
+			NextCursor: page.NextCursor,
+		},
 	})
 }
+
+func parseTaskSystemEventQuery(r *http.Request) (commons.TaskSystemEventQuery, []validation.ValidationError) {
+	var errors []validation.ValidationError
+	params := r.URL.Query()
+
+	query := commons.TaskSystemEventQuery{
+		TaskID:        params.Get("task_id"),
+		CorrelationID: params.Get("correlation_id"),
+		Origin:        params.Get("origin"),
+		ActionPrefix:  params.Get("action"),
+		SortDir:       strings.ToLower(params.Get("order")),
+		Cursor:        params.Get("cursor"),
+		Limit:         commons.DefaultTaskSystemEventPageSize,
+	}
+
+	parseTime := func(field string) *time.Time {
+		value := params.Get(field)
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errors = append(errors, validation.ValidationError{
+				Field:   field,
+				Message: "Must be an RFC3339 date-time",
+			})
+			return nil
+		}
+		return &t
+	}
+	query.EmittedAfter = parseTime("from")
+	query.EmittedBefore = parseTime("to")
+
+	if query.SortDir != "" && query.SortDir != commons.SortAsc && query.SortDir != commons.SortDesc {
+		errors = append(errors, validation.ValidationError{
+			Field:   "order",
+			Message: "Invalid order. Must be one of: asc, desc",
+		})
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > commons.MaxTaskSystemEventPageSize {
+			errors = append(errors, validation.ValidationError{
+				Field:   "limit",
+				Message: "Limit must be between 1 and 200",
+			})
+		} else {
+			query.Limit = n
+		}
+	}
+
+	return query, errors
+}
@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
-	"sync"
-	"log"
-	"context"
 
 	"sama/go-task-management/commons"
 	"sama/go-task-management/gateway/handlers/constants"
@@ -14,8 +14,6 @@ import (
 	"sama/go-task-management/gateway/middleware"
 	"sama/go-task-management/gateway/services/auth"
 	"sama/go-task-management/gateway/services/task"
-
-	"github.com/google/uuid"
 )
 
 type TaskHandler struct {
@@ -41,6 +39,7 @@ type GetTaskResponse struct {
 	UpdatedAt   time.Time                 `json:"updated_at"`
 	Creator     auth.UserResponse         `json:"creator"`
 	Assignee    *auth.UserResponse        `json:"assignee,omitempty"`
+	ProjectID   *string                   `json:"project_id,omitempty"`
 	Events      []TaskSystemEventResponse `json:"events"`
 }
 
@@ -55,6 +54,21 @@ type CreateTaskRequest struct {
 	Priority    int        `json:"priority"`
 	DueDate     time.Time  `json:"due_date"`
 	AssigneeID  *string    `json:"assignee_id,omitempty"`
+	ProjectID   *string    `json:"project_id,omitempty"`
+	// Recurrence makes the task the first occurrence of a recurring task,
+	// due at due_date.
+	Recurrence *RecurrenceRequest `json:"recurrence,omitempty"`
+}
+
+// RecurrenceRequest is the schedule of a recurring task.
+type RecurrenceRequest struct {
+	// RRule is an RFC 5545 RRULE with FREQ (DAILY, WEEKLY or MONTHLY),
+	// INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL.
+	RRule         string     `json:"rrule" example:"FREQ=WEEKLY;BYDAY=MO,TH"`
+	Timezone      string     `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Until         *time.Time `json:"until,omitempty"`
+	Count         int        `json:"count,omitempty"`
+	LeadTimeHours *int       `json:"lead_time_hours,omitempty"`
 }
 
 func (r *CreateTaskRequest) Validate() []validation.ValidationError {
@@ -103,6 +117,10 @@ type UpdateTaskRequest struct {
 	Priority    int        `json:"priority"`
 	DueDate     time.Time  `json:"due_date"`
 	AssigneeID  *string    `json:"assignee_id,omitempty"`
+	ProjectID   *string    `json:"project_id,omitempty"`
+	// Recurrence makes the task recurring, or with scope future changes the
+	// schedule from this occurrence on.
+	Recurrence *RecurrenceRequest `json:"recurrence,omitempty"`
 }
 
 func (r *UpdateTaskRequest) Validate() []validation.ValidationError {
@@ -148,6 +166,22 @@ type UpdateTaskResponse struct {
 	TaskId string `json:"task_id"`
 }
 
+// parseScope reads which occurrences of a recurring task a change applies
+// to; this occurrence only unless scope=future.
+func parseScope(r *http.Request) (string, *validation.ValidationError) {
+	switch scope := r.URL.Query().Get("scope"); scope {
+	case "", task.ScopeThis:
+		return task.ScopeThis, nil
+	case task.ScopeFuture:
+		return scope, nil
+	default:
+		return "", &validation.ValidationError{
+			Field:   "scope",
+			Message: "Invalid scope. Must be one of: this, future",
+		}
+	}
+}
+
 // @Summary Get a task by ID
 // @Description Retrieves a specific task by its ID
 // @Tags tasks
@@ -157,6 +191,7 @@ type UpdateTaskResponse struct {
 // @Success 200 {object} GetTaskResponse
 // @Failure 400 {object} ErrorResponse "Invalid task ID"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
 // @Failure 404 {object} ErrorResponse "Task not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /tasks/{id} [get]
@@ -180,6 +215,8 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Task not found", "")
 		case commons.ErrUnauthorized:
 			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
 		default:
 			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Internal server error", "")
 		}
@@ -193,12 +230,27 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get all tasks
-// @Description Retrieves all tasks for the authenticated user
+// @Description Retrieves the tasks the authenticated user created or is assigned to, filtered, sorted and paginated server-side
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Param status query string false "Comma-separated statuses (TODO, IN_PROGRESS, DONE)"
+// @Param priority_min query int false "Minimum priority (1-3)"
+// @Param priority_max query int false "Maximum priority (1-3)"
+// @Param due_after query string false "Only tasks due at or after this RFC3339 time"
+// @Param due_before query string false "Only tasks due at or before this RFC3339 time"
+// @Param assignee_id query string false "Assignee user ID"
+// @Param creator_id query string false "Creator user ID"
+// @Param project_id query string false "Project ID"
+// @Param q query string false "Free text search in title and description"
+// @Param sort query string false "Sort field" Enums(created_at, updated_at, due_date, priority, title)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param cursor query string false "Cursor returned as meta.next_cursor by the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
 // @Success 200 {object} GetAllTasksResponse
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Not a member of the project"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /tasks [get]
 func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
@@ -208,18 +260,145 @@ func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.taskService.GetAllTasks(r.Context(), userID)
+	query, validationErrors := parseTaskQuery(r)
+	if len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	page, err := h.taskService.ListTasks(r.Context(), userID, query)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch tasks", err.Error())
+		switch err {
+		case commons.ErrInvalidInput:
+			h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid query parameters", "The cursor does not match the requested sort")
+		case commons.ErrNotFound:
+			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Project not found", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch tasks", err.Error())
+		}
 		return
 	}
 
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
-		Data:    tasks,
+		Data:    page.Tasks,
+		Meta: &MetaInfo{
+			PerPage:    query.Limit,
+			NextCursor: page.NextCursor,
+		},
 	})
 }
 
+func parseTaskQuery(r *http.Request) (commons.TaskQuery, []validation.ValidationError) {
+	var errors []validation.ValidationError
+	params := r.URL.Query()
+
+	query := commons.TaskQuery{
+		AssigneeID: params.Get("assignee_id"),
+		CreatorID:  params.Get("creator_id"),
+		ProjectID:  params.Get("project_id"),
+		Search:     strings.TrimSpace(params.Get("q")),
+		SortBy:     params.Get("sort"),
+		SortDir:    strings.ToLower(params.Get("order")),
+		Cursor:     params.Get("cursor"),
+		Limit:      commons.DefaultTaskPageSize,
+	}
+
+	if status := params.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if s != constants.TaskStatusTodo && s != constants.TaskStatusInProgress && s != constants.TaskStatusDone {
+				errors = append(errors, validation.ValidationError{
+					Field:   "status",
+					Message: "Invalid status. Must be one of: TODO, IN_PROGRESS, DONE",
+				})
+				break
+			}
+			query.Statuses = append(query.Statuses, s)
+		}
+	}
+
+	parsePriority := func(field string) int {
+		value := params.Get(field)
+		if value == "" {
+			return 0
+		}
+		priority, err := strconv.Atoi(value)
+		if err != nil || priority < constants.TaskPriorityLow || priority > constants.TaskPriorityHigh {
+			errors = append(errors, validation.ValidationError{
+				Field:   field,
+				Message: "Priority must be between 1 and 3",
+			})
+			return 0
+		}
+		return priority
+	}
+	query.MinPriority = parsePriority("priority_min")
+	query.MaxPriority = parsePriority("priority_max")
+	if query.MinPriority != 0 && query.MaxPriority != 0 && query.MinPriority > query.MaxPriority {
+		errors = append(errors, validation.ValidationError{
+			Field:   "priority_min",
+			Message: "Must not be greater than priority_max",
+		})
+	}
+
+	parseTime := func(field string) *time.Time {
+		value := params.Get(field)
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errors = append(errors, validation.ValidationError{
+				Field:   field,
+				Message: "Must be an RFC3339 date-time",
+			})
+			return nil
+		}
+		return &t
+	}
+	query.DueAfter = parseTime("due_after")
+	query.DueBefore = parseTime("due_before")
+	if query.DueAfter != nil && query.DueBefore != nil && query.DueAfter.After(*query.DueBefore) {
+		errors = append(errors, validation.ValidationError{
+			Field:   "due_after",
+			Message: "Must not be after due_before",
+		})
+	}
+
+	switch query.SortBy {
+	case "", commons.TaskSortCreatedAt, commons.TaskSortUpdatedAt, commons.TaskSortDueDate, commons.TaskSortPriority, commons.TaskSortTitle:
+	default:
+		errors = append(errors, validation.ValidationError{
+			Field:   "sort",
+			Message: "Invalid sort. Must be one of: created_at, updated_at, due_date, priority, title",
+		})
+	}
+
+	if query.SortDir != "" && query.SortDir != commons.SortAsc && query.SortDir != commons.SortDesc {
+		errors = append(errors, validation.ValidationError{
+			Field:   "order",
+			Message: "Invalid order. Must be one of: asc, desc",
+		})
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > commons.MaxTaskPageSize {
+			errors = append(errors, validation.ValidationError{
+				Field:   "limit",
+				Message: "Limit must be between 1 and 200",
+			})
+		} else {
+			query.Limit = n
+		}
+	}
+
+	return query, errors
+}
+
 // @Summary Create a new task
 // @Description Creates a new task for the authenticated user
 // @Tags tasks
@@ -229,6 +408,8 @@ func (h *TaskHandler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 // @Success 201 {object} CreateTaskResponse
 // @Failure 400 {object} ErrorResponse "Invalid request payload"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /tasks [post]
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -247,83 +428,23 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	input.CreatorID = userID
 
 	task, err := h.taskService.CreateTask(r.Context(), input)
-	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to create task", err.Error())
+	if errors.Is(err, commons.ErrInvalidRecurrence) {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeInvalidRecurrence, "Invalid recurrence", err.Error())
 		return
 	}
-
-	correlationId := uuid.New().String()
-	var wg sync.WaitGroup
-
-  wg.Add(1)
-  go func() {
-    defer wg.Done()
-
-		_, err := h.taskEventService.Create(
-			task.ID,
-			correlationId,
-			"API Gateway",
-      "api:request:received",
-      "Task creation request received",
-			"{}", //marshallJson(params),
-			0,
-		)
-
-    if err != nil {
-      log.Printf("Error creating request event: %v", err)
-    }
-  }()
-
-	wg.Add(1)
-  go func() {
-    defer wg.Done()
-
-		_, err := h.taskEventService.Create(
-			task.ID,
-			correlationId,
-			"API Gateway",
-      "api:db:task-created",
-      "Task created in database",
-			"{}",
-			1,
-		)
-
-    if err != nil {
-      log.Printf("Error creating task created event: %v", err)
-    }
-  }()
-
-	wg.Add(1)
-  go func() {
-    defer wg.Done()
-    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-    defer cancel()
-
-		grpcErr := h.grpcService.SendNotification(ctx, commons.GRPCEvent{
-			TaskId:        task.ID,
-			CorrelationId: correlationId,
-			Types:         []string{"IN_APP", "EMAIL"},
-		})
-		if grpcErr != nil {
-			log.Printf("Failed to send notification: %v", grpcErr)
+	if err != nil {
+		switch err {
+		case commons.ErrNotFound:
+			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Project not found", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "Creating tasks in this project requires the editor role")
+		case commons.ErrInvalidInput:
+			h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid input", "The assignee must be a member of the project")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to create task", err.Error())
 		}
-
-		_, err := h.taskEventService.Create(
-			task.ID,
-			correlationId,
-			"API Gateway",
-      "api:event:task-created",
-      "Task created event emitted",
-			"{}",
-			2,
-		)
-
-		if err != nil {
-      log.Printf("Failed to create task created event: %v", err)
-    }
-  }()
-
-	wg.Wait()
+		return
+	}
 
 	h.respondWithJSON(w, http.StatusCreated, StandardResponse{
 		Success: true,
@@ -337,6 +458,7 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID"
+// @Param scope query string false "Occurrences of a recurring task to update (default this)" Enums(this, future)
 // @Param input body UpdateTaskRequest true "Task update details"
 // @Success 200 {object} UpdateTaskResponse
 // @Failure 400 {object} ErrorResponse "Invalid request payload"
@@ -366,33 +488,34 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	input.UserID = userID
 
+	scope, scopeErr := parseScope(r)
+	if scopeErr != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*scopeErr})
+		return
+	}
+	input.Scope = scope
+
 	task, err := h.taskService.UpdateTask(r.Context(), taskID, input)
+	if errors.Is(err, commons.ErrInvalidRecurrence) {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeInvalidRecurrence, "Invalid recurrence", err.Error())
+		return
+	}
 	if err != nil {
 		switch err {
 		case commons.ErrNotFound:
 			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Task not found", "")
 		case commons.ErrUnauthorized:
 			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+		case commons.ErrInvalidInput:
+			h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid input", "The assignee must be a member of the task's project")
 		default:
 			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to update task", err.Error())
 		}
 		return
 	}
 
-	correlationId := uuid.New().String()
-	_, errEvent := h.taskEventService.Create(
-		taskID,
-		correlationId,
-		"API Gateway",
-    "api:event:task-updated",
-    "Task updated event emitted",
-    "{}",
-		3,
-	)
-	if errEvent != nil {
-		log.Printf("Failed to create task updated event: %v", errEvent)
-	}
-
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
 		Data:    task,
@@ -400,11 +523,12 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete a task
-// @Description Deletes a task by its ID
+// @Description Deletes a task by its ID. With scope future, the later open occurrences of a recurring task are deleted too and no further ones are created.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path string true "Task ID"
+// @Param scope query string false "Occurrences of a recurring task to delete (default this)" Enums(this, future)
 // @Success 200 "Task deleted successfully"
 // @Failure 400 {object} ErrorResponse "Invalid task ID"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
@@ -425,33 +549,27 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.taskService.DeleteTask(r.Context(), taskID, userID)
+	scope, scopeErr := parseScope(r)
+	if scopeErr != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*scopeErr})
+		return
+	}
+
+	err := h.taskService.DeleteTask(r.Context(), taskID, userID, scope)
 	if err != nil {
 		switch err {
 		case commons.ErrNotFound:
 			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Task not found", "")
 		case commons.ErrUnauthorized:
 			h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
 		default:
 			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to delete task", err.Error())
 		}
 		return
 	}
 
-	correlationId := uuid.New().String()
-	_, errEvent := h.taskEventService.Create(
-		taskID,
-		correlationId,
-		"API Gateway",
-    "api:event:task-deleted",
-    "Task deleted event emitted",
-    "{}",
-		4,
-	)
-	if errEvent != nil {
-		log.Printf("Failed to create task deleted event: %v", errEvent)
-	}
-
 	h.respondWithJSON(w, http.StatusOK, StandardResponse{
 		Success: true,
 		Data: map[string]string{
@@ -459,3 +577,47 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// @Summary Get the schedule of a recurring task
+// @Description Retrieves the recurrence rule, time zone and next occurrence of the series a task belongs to
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} task.RecurrenceResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Task not found or not recurring"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /tasks/{id}/recurrence [get]
+func (h *TaskHandler) GetTaskRecurrence(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("id")
+	if taskID == "" {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Task ID is required", "")
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	series, err := h.taskService.GetRecurrence(r.Context(), taskID, userID)
+	if err != nil {
+		switch err {
+		case commons.ErrNotFound:
+			h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Task not found or not recurring", "")
+		case commons.ErrForbidden:
+			h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch recurrence", err.Error())
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    task.ToRecurrenceResponse(*series),
+	})
+}
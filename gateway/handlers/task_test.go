@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTaskQueryRanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantField string
+	}{
+		{"priority range", "priority_min=1&priority_max=3", ""},
+		{"single priority", "priority_min=2&priority_max=2", ""},
+		{"only a minimum priority", "priority_min=3", ""},
+		{"inverted priority range", "priority_min=3&priority_max=1", "priority_min"},
+		{"due range", "due_after=2025-01-01T00:00:00Z&due_before=2025-02-01T00:00:00Z", ""},
+		{"inverted due range", "due_after=2025-02-01T00:00:00Z&due_before=2025-01-01T00:00:00Z", "due_after"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errors := parseTaskQuery(httptest.NewRequest("GET", "/tasks?"+tt.query, nil))
+
+			switch {
+			case tt.wantField == "" && len(errors) > 0:
+				t.Fatalf("parseTaskQuery() errors = %+v, want none", errors)
+			case tt.wantField != "" && (len(errors) != 1 || errors[0].Field != tt.wantField):
+				t.Fatalf("parseTaskQuery() errors = %+v, want one for %s", errors, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/trace"
+)
+
+// defaultTraceWindow is how far back traces are listed without a from.
+const defaultTraceWindow = 24 * time.Hour
+
+var traceStatusFilters = []string{
+	commons.TraceStatusCompleted,
+	commons.TraceStatusInProgress,
+	commons.TraceStatusIncomplete,
+	commons.TraceStatusFailed,
+	commons.TraceFilterSlow,
+}
+
+type TraceHandler struct {
+	*BaseHandler
+	traceService *trace.Service
+}
+
+func NewTraceHandler(base *BaseHandler, traceService *trace.Service) *TraceHandler {
+	return &TraceHandler{
+		BaseHandler:  base,
+		traceService: traceService,
+	}
+}
+
+// @Summary Get all traces
+// @Description Lists traces, the system events sharing a correlation ID across services, of the tasks the authenticated user can see (all tasks with events:read-all), most recently started first. A trace is failed if a service recorded a failure, incomplete if an expected event never followed (e.g. an email was requested but has no delivery event), in_progress while such an event may still come, and completed otherwise
+// @Tags traces
+// @Produce json
+// @Param task_id query string false "Task ID"
+// @Param status query string false "Comma-separated statuses to keep (completed, in_progress, incomplete, failed, slow); a trace matching any of them is kept"
+// @Param slow_ms query int false "Duration in milliseconds above which a trace is slow (default 30000)"
+// @Param from query string false "Only traces started at or after this RFC3339 time (default 24 hours ago)"
+// @Param to query string false "Only traces started at or before this RFC3339 time"
+// @Param cursor query string false "Cursor returned as meta.next_cursor by the previous page"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Success 200 {array} commons.TraceSummary
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /traces [get]
+func (h *TraceHandler) GetAllTraces(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	query, validationErrors := parseTraceQuery(r)
+	if len(validationErrors) > 0 {
+		h.respondWithValidationErrors(w, validationErrors)
+		return
+	}
+
+	readAll := commons.HasPermission(middleware.GetPermissionsFromContext(r), commons.PermissionEventsReadAll)
+	page, err := h.traceService.ListTraces(r.Context(), userID, readAll, query)
+	if err == commons.ErrInvalidInput {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid query parameters", "Invalid cursor")
+		return
+	}
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch traces", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    page.Traces,
+		Meta: &MetaInfo{
+			PerPage:    query.Limit,
+			NextCursor: page.NextCursor,
+		},
+	})
+}
+
+// @Summary Get a trace
+// @Description Reconstructs the journey of a request across services from the system events sharing its correlation ID: an ordered timeline grouped by origin with durations, the gaps between events and failures. Only events of tasks the authenticated user can see are included (all with events:read-all), and only once their emit_at has passed
+// @Tags traces
+// @Produce json
+// @Param correlationId path string true "Correlation ID"
+// @Param slow_ms query int false "Duration in milliseconds above which the trace is slow (default 30000)"
+// @Success 200 {object} trace.TraceResponse
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Trace not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /traces/{correlationId} [get]
+func (h *TraceHandler) GetTrace(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	slowThreshold, validationError := parseSlowThreshold(r)
+	if validationError != nil {
+		h.respondWithValidationErrors(w, []validation.ValidationError{*validationError})
+		return
+	}
+
+	readAll := commons.HasPermission(middleware.GetPermissionsFromContext(r), commons.PermissionEventsReadAll)
+	response, err := h.traceService.GetTrace(r.Context(), userID, readAll, r.PathValue("correlationId"), slowThreshold)
+	if err == commons.ErrNotFound {
+		h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Trace not found", "")
+		return
+	}
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, "Failed to fetch trace", err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+func parseTraceQuery(r *http.Request) (commons.TraceQuery, []validation.ValidationError) {
+	var errors []validation.ValidationError
+	params := r.URL.Query()
+
+	query := commons.TraceQuery{
+		TaskID: params.Get("task_id"),
+		Cursor: params.Get("cursor"),
+		Limit:  commons.DefaultTracePageSize,
+	}
+
+	if status := params.Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			s = strings.TrimSpace(s)
+			if !slices.Contains(traceStatusFilters, s) {
+				errors = append(errors, validation.ValidationError{
+					Field:   "status",
+					Message: "Invalid status. Must be one of: " + strings.Join(traceStatusFilters, ", "),
+				})
+				break
+			}
+			query.Statuses = append(query.Statuses, s)
+		}
+	}
+
+	slowThreshold, validationError := parseSlowThreshold(r)
+	if validationError != nil {
+		errors = append(errors, *validationError)
+	}
+	query.SlowThreshold = slowThreshold
+
+	parseTime := func(field string) *time.Time {
+		value := params.Get(field)
+		if value == "" {
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			errors = append(errors, validation.ValidationError{
+				Field:   field,
+				Message: "Must be an RFC3339 date-time",
+			})
+			return nil
+		}
+		return &t
+	}
+	query.StartedAfter = parseTime("from")
+	query.StartedBefore = parseTime("to")
+	if params.Get("from") == "" {
+		from := time.Now().Add(-defaultTraceWindow)
+		query.StartedAfter = &from
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > commons.MaxTracePageSize {
+			errors = append(errors, validation.ValidationError{
+				Field:   "limit",
+				Message: "Limit must be between 1 and 200",
+			})
+		} else {
+			query.Limit = n
+		}
+	}
+
+	return query, errors
+}
+
+// parseSlowThreshold parses slow_ms, which is zero if not given.
+func parseSlowThreshold(r *http.Request) (time.Duration, *validation.ValidationError) {
+	value := r.URL.Query().Get("slow_ms")
+	if value == "" {
+		return 0, nil
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms < 1 {
+		return 0, &validation.ValidationError{
+			Field:   "slow_ms",
+			Message: "Must be a positive number of milliseconds",
+		}
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
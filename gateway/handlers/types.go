@@ -35,10 +35,11 @@ type ErrorInfo struct {
 }
 
 type MetaInfo struct {
-	Total       int `json:"total"`
-	Page        int `json:"page"`
-	PerPage     int `json:"per_page"`
-	TotalPages  int `json:"total_pages"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"per_page"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type ValidationError struct {
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"regexp"
+	"strings"
 )
 
 type ValidationError struct {
@@ -92,3 +93,39 @@ func ValidateDescription(description string) *ValidationError {
 
 	return nil
 }
+
+func ValidateCommentBody(body string) *ValidationError {
+	if strings.TrimSpace(body) == "" {
+		return &ValidationError{
+			Field:   "body",
+			Message: "Body is required",
+		}
+	}
+
+	if len(body) > 5000 {
+		return &ValidationError{
+			Field:   "body",
+			Message: "Body must be less than 5000 characters",
+		}
+	}
+
+	return nil
+}
+
+func ValidateProjectName(name string) *ValidationError {
+	if name == "" {
+		return &ValidationError{
+			Field:   "name",
+			Message: "Name is required",
+		}
+	}
+
+	if len(name) > 200 {
+		return &ValidationError{
+			Field:   "name",
+			Message: "Name must be less than 200 characters",
+		}
+	}
+
+	return nil
+}
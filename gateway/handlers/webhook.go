@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/handlers/constants"
+	"sama/go-task-management/gateway/handlers/validation"
+	"sama/go-task-management/gateway/middleware"
+	"sama/go-task-management/gateway/services/webhook"
+)
+
+const maxWebhookURLLength = 2048
+
+type WebhookHandler struct {
+	*BaseHandler
+	webhookService *webhook.Service
+}
+
+func NewWebhookHandler(base *BaseHandler, webhookService *webhook.Service) *WebhookHandler {
+	return &WebhookHandler{
+		BaseHandler:    base,
+		webhookService: webhookService,
+	}
+}
+
+// @Summary Get all webhooks
+// @Description Retrieves the webhooks the authenticated user registered
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} webhook.WebhookResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to get webhooks")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    webhooks,
+	})
+}
+
+// @Summary Create a webhook
+// @Description Registers an endpoint that task events are posted to. Without a project_id it gets the events of the tasks the authenticated user created or is assigned to; with one, those of the project's tasks (owners only). The response holds the secret deliveries are signed with; it is not shown again
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param input body webhook.CreateWebhookInput true "Webhook details"
+// @Success 201 {object} webhook.WebhookResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Forbidden"
+// @Failure 404 {object} ErrorResponse "Project not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input webhook.CreateWebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if errors := validateWebhookFields(&input.URL, input.Events, true); len(errors) > 0 {
+		h.respondWithValidationErrors(w, errors)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.webhookService.CreateWebhook(r.Context(), userID, input)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to create webhook")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Get a webhook by ID
+// @Description Retrieves a webhook the authenticated user registered
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} webhook.WebhookResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.webhookService.GetWebhook(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to get webhook")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Update a webhook
+// @Description Changes the URL or events of a webhook, or pauses it with active=false. Deliveries of paused webhooks wait until it is active again
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param input body webhook.UpdateWebhookInput true "Webhook update details"
+// @Success 200 {object} webhook.WebhookResponse
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var input webhook.UpdateWebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, constants.ErrCodeBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if errors := validateWebhookFields(input.URL, input.Events, false); len(errors) > 0 {
+		h.respondWithValidationErrors(w, errors)
+		return
+	}
+
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	response, err := h.webhookService.UpdateWebhook(r.Context(), r.PathValue("id"), userID, input)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to update webhook")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    response,
+	})
+}
+
+// @Summary Delete a webhook
+// @Description Deletes a webhook with its deliveries
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), r.PathValue("id"), userID); err != nil {
+		h.respondWithWebhookError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get the deliveries of a webhook
+// @Description Retrieves the latest 100 deliveries of a webhook, newest first, with their status and last response code
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {array} commons.WebhookDelivery
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    deliveries,
+	})
+}
+
+// @Summary Get a webhook delivery
+// @Description Retrieves a delivery of a webhook with its payload and every attempt to post it, including the response codes
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} commons.WebhookDelivery
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Delivery not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries/{deliveryId} [get]
+func (h *WebhookHandler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), r.PathValue("id"), r.PathValue("deliveryId"), userID)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to get webhook delivery")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, StandardResponse{
+		Success: true,
+		Data:    delivery,
+	})
+}
+
+// @Summary Redeliver a webhook delivery
+// @Description Posts a delivery again with its original payload, retrying like a new one
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} commons.WebhookDelivery
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Delivery not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserIDFromContext(r)
+	if userID == "" {
+		h.respondWithError(w, http.StatusUnauthorized, constants.ErrCodeUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), r.PathValue("id"), r.PathValue("deliveryId"), userID)
+	if err != nil {
+		h.respondWithWebhookError(w, err, "Failed to redeliver webhook delivery")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, StandardResponse{
+		Success: true,
+		Data:    delivery,
+	})
+}
+
+func (h *WebhookHandler) respondWithWebhookError(w http.ResponseWriter, err error, message string) {
+	switch err {
+	case commons.ErrNotFound:
+		h.respondWithError(w, http.StatusNotFound, constants.ErrCodeNotFound, "Not found", "")
+	case commons.ErrForbidden:
+		h.respondWithError(w, http.StatusForbidden, constants.ErrCodeForbidden, "Forbidden", "")
+	case commons.ErrWebhookURLNotAllowed:
+		h.respondWithValidationErrors(w, []validation.ValidationError{{
+			Field:   "url",
+			Message: "URL must resolve to a public address",
+		}})
+	default:
+		h.respondWithError(w, http.StatusInternalServerError, constants.ErrCodeInternal, message, err.Error())
+	}
+}
+
+// validateWebhookFields checks the URL and events of a webhook; nil or empty
+// ones are only rejected if required.
+func validateWebhookFields(rawURL *string, events []string, required bool) []validation.ValidationError {
+	var errors []validation.ValidationError
+
+	if rawURL != nil || required {
+		var value string
+		if rawURL != nil {
+			value = *rawURL
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || len(value) > maxWebhookURLLength {
+			errors = append(errors, validation.ValidationError{
+				Field:   "url",
+				Message: "URL must be an absolute http or https URL",
+			})
+		}
+	}
+
+	if len(events) == 0 && required {
+		errors = append(errors, validation.ValidationError{
+			Field:   "events",
+			Message: "At least one event is required",
+		})
+	}
+	for _, event := range events {
+		if !slices.Contains(commons.WebhookEvents, event) {
+			errors = append(errors, validation.ValidationError{
+				Field:   "events",
+				Message: "Invalid event " + event + ". Must be one of: " + strings.Join(commons.WebhookEvents, ", "),
+			})
+		}
+	}
+
+	return errors
+}
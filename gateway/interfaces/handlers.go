@@ -11,6 +11,8 @@ type AuthHandler interface {
 	SignUp(w http.ResponseWriter, r *http.Request)
 	SignOut(w http.ResponseWriter, r *http.Request)
 	RefreshToken(w http.ResponseWriter, r *http.Request)
+	ListSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
 	ForgotPassword(w http.ResponseWriter, r *http.Request)
 	ResetPassword(w http.ResponseWriter, r *http.Request)
 }
@@ -21,6 +23,31 @@ type TaskHandler interface {
 	CreateTask(w http.ResponseWriter, r *http.Request)
 	UpdateTask(w http.ResponseWriter, r *http.Request)
 	DeleteTask(w http.ResponseWriter, r *http.Request)
+	GetTaskRecurrence(w http.ResponseWriter, r *http.Request)
+}
+
+type ProjectHandler interface {
+	GetAllProjects(w http.ResponseWriter, r *http.Request)
+	GetProject(w http.ResponseWriter, r *http.Request)
+	CreateProject(w http.ResponseWriter, r *http.Request)
+	UpdateProject(w http.ResponseWriter, r *http.Request)
+	DeleteProject(w http.ResponseWriter, r *http.Request)
+	GetProjectMembers(w http.ResponseWriter, r *http.Request)
+	AddProjectMember(w http.ResponseWriter, r *http.Request)
+	UpdateProjectMember(w http.ResponseWriter, r *http.Request)
+	RemoveProjectMember(w http.ResponseWriter, r *http.Request)
+}
+
+type CommentHandler interface {
+	GetTaskComments(w http.ResponseWriter, r *http.Request)
+	CreateTaskComment(w http.ResponseWriter, r *http.Request)
+	UpdateTaskComment(w http.ResponseWriter, r *http.Request)
+	DeleteTaskComment(w http.ResponseWriter, r *http.Request)
+	GetTaskCommentEdits(w http.ResponseWriter, r *http.Request)
+}
+
+type EmailHandler interface {
+	GetTaskEmails(w http.ResponseWriter, r *http.Request)
 }
 
 type NotificationHandler interface {
@@ -33,10 +60,65 @@ type SystemEventHandler interface {
 	GetAllTaskSystemEvents(w http.ResponseWriter, r *http.Request)
 }
 
+type RoleHandler interface {
+	GetAllRoles(w http.ResponseWriter, r *http.Request)
+	GetUserRoles(w http.ResponseWriter, r *http.Request)
+	AssignUserRole(w http.ResponseWriter, r *http.Request)
+	RemoveUserRole(w http.ResponseWriter, r *http.Request)
+}
+
+type ReminderHandler interface {
+	GetReminderPreferences(w http.ResponseWriter, r *http.Request)
+	UpdateReminderPreferences(w http.ResponseWriter, r *http.Request)
+}
+
+type NotificationPreferenceHandler interface {
+	GetNotificationPreferences(w http.ResponseWriter, r *http.Request)
+	UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request)
+}
+
+type PhoneHandler interface {
+	GetPhone(w http.ResponseWriter, r *http.Request)
+	UpdatePhone(w http.ResponseWriter, r *http.Request)
+	VerifyPhone(w http.ResponseWriter, r *http.Request)
+	DeletePhone(w http.ResponseWriter, r *http.Request)
+}
+
+type WebhookHandler interface {
+	GetAllWebhooks(w http.ResponseWriter, r *http.Request)
+	CreateWebhook(w http.ResponseWriter, r *http.Request)
+	GetWebhook(w http.ResponseWriter, r *http.Request)
+	UpdateWebhook(w http.ResponseWriter, r *http.Request)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request)
+	GetWebhookDeliveries(w http.ResponseWriter, r *http.Request)
+	GetWebhookDelivery(w http.ResponseWriter, r *http.Request)
+	RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request)
+}
+
+type StreamHandler interface {
+	StreamEvents(w http.ResponseWriter, r *http.Request)
+	StreamEventsWebSocket(w http.ResponseWriter, r *http.Request)
+}
+
+type TraceHandler interface {
+	GetAllTraces(w http.ResponseWriter, r *http.Request)
+	GetTrace(w http.ResponseWriter, r *http.Request)
+}
+
 type Handler interface {
 	HealthHandler
 	AuthHandler
 	TaskHandler
+	ProjectHandler
+	CommentHandler
+	EmailHandler
 	NotificationHandler
 	SystemEventHandler
+	RoleHandler
+	ReminderHandler
+	NotificationPreferenceHandler
+	PhoneHandler
+	WebhookHandler
+	StreamHandler
+	TraceHandler
 }
@@ -36,6 +36,7 @@ import (
 
 	pb "sama/go-task-management/commons/api"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -64,25 +65,54 @@ type ValidationError struct {
 }
 
 func main() {
+	// Load .env file before the logger, which reads LOG_LEVEL and LOG_FORMAT
+	envErr := godotenv.Load()
+
 	// Initialize logger
-	logger := commons.NewLogger("[API] ")
+	logger := commons.NewLogger("gateway")
+	commons.SetDefaultLogger(logger)
+
+	if envErr != nil {
+		logger.Warn("Failed to load .env file", "error", envErr)
+	}
 
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		logger.Error("Warning: Error loading .env file:", err)
+	// Run database migrations sub-command
+	if commons.IsMigrateCommand(os.Args) {
+		if err := commons.RunMigrateCLI(os.Args[2:]); err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Grant the admin role sub-command
+	if isGrantAdminCommand(os.Args) {
+		if err := runGrantAdmin(context.Background(), os.Args[2:]); err != nil {
+			logger.Error("Failed to grant admin role", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("Granted admin role")
+		return
 	}
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		logger.Error("Failed to load configuration:", err)
+		logger.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize tracing
+	shutdownTracing, err := commons.InitTracing(context.Background(), "gateway")
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
 		os.Exit(1)
 	}
 
 	// Initialize database
 	db, err := commons.InitDB()
 	if err != nil {
-		logger.Error("Failed to initialize database:", err)
+		logger.Error("Failed to initialize database", "error", err)
 		os.Exit(1)
 	}
 
@@ -92,6 +122,20 @@ func main() {
 	taskSystemEventRepo := commons.NewPostgresTaskSystemEventRepository(db)
 	inAppNotificationRepo := commons.NewPostgresInAppNotificationRepository(db)
 	passwordResetTokenRepo := commons.NewPostgresPasswordResetTokenRepository(db)
+	taskCommentRepo := commons.NewPostgresTaskCommentRepository(db)
+	projectRepo := commons.NewPostgresProjectRepository(db)
+	roleRepo := commons.NewPostgresRoleRepository(db)
+	refreshTokenRepo := commons.NewPostgresRefreshTokenRepository(db)
+	emailRepo := commons.NewPostgresEmailRepository(db)
+	outboxRepo := commons.NewPostgresOutboxRepository(db)
+	taskSeriesRepo := commons.NewPostgresTaskSeriesRepository(db)
+	reminderRepo := commons.NewPostgresReminderRepository(db)
+	notificationPreferenceRepo := commons.NewPostgresNotificationPreferenceRepository(db)
+	phoneVerificationRepo := commons.NewPostgresPhoneVerificationRepository(db)
+	webhookRepo := commons.NewPostgresWebhookRepository(db)
+	traceRepo := commons.NewPostgresTraceRepository(db)
+	unitOfWork := commons.NewPostgresUnitOfWork(db)
+	streamListener := commons.NewPostgresStreamListener(commons.GetPostgresConnectionString())
 
 	// Initialize GRPC service client
 	ctx := context.Background()
@@ -99,9 +143,10 @@ func main() {
 		ctx,
 		cfg.NotificationServiceAddr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
 	if err != nil {
-		logger.Error("Failed to initialize notification service:", err)
+		logger.Error("Failed to initialize notification service", "error", err)
 		// os.Exit(1)
 	}
 	notificationServiceClient := pb.NewNotificationServiceClient(conn)
@@ -110,18 +155,33 @@ func main() {
 	services := services.NewServices(
 		logger,
 		os.Getenv("JWT_SECRET"),
+		cfg.PasswordResetURL,
 		userRepo,
 		taskRepo,
 		taskSystemEventRepo,
 		inAppNotificationRepo,
 		passwordResetTokenRepo,
+		taskCommentRepo,
+		projectRepo,
+		roleRepo,
+		refreshTokenRepo,
+		emailRepo,
+		outboxRepo,
+		taskSeriesRepo,
+		reminderRepo,
+		notificationPreferenceRepo,
+		phoneVerificationRepo,
+		webhookRepo,
+		traceRepo,
+		streamListener,
+		unitOfWork,
 		notificationServiceClient,
 	)
 
 	// Initialize handlers
 	h, err := handlers.NewHandlers(logger, services)
 	if err != nil {
-		logger.Error("Failed to initialize handlers:", err)
+		logger.Error("Failed to initialize handlers", "error", err)
 		os.Exit(1)
 	}
 
@@ -132,6 +192,16 @@ func main() {
 		h.Task,
 		h.InAppNotification,
 		h.TaskSystemEvent,
+		h.Comment,
+		h.Email,
+		h.Project,
+		h.Role,
+		h.Reminder,
+		h.NotificationPreference,
+		h.Phone,
+		h.Webhook,
+		h.Stream,
+		h.Trace,
 	)
 
 	// Initialize router
@@ -140,11 +210,14 @@ func main() {
 	// Create base middleware chain
 	baseChain := middleware.NewChain(
 		middleware.CorsMiddleware(middleware.DefaultCorsConfig()),
-		chiMiddleware.Logger,
-		chiMiddleware.Recoverer,
+		middleware.QueryTokenMiddleware(middleware.StreamingPaths...),
+		middleware.Tracing("gateway", middleware.StreamingPaths...),
+		middleware.Metrics(middleware.StreamingPaths...),
 		chiMiddleware.RequestID,
 		chiMiddleware.RealIP,
-		chiMiddleware.Timeout(60*time.Second),
+		middleware.LoggingMiddleware(logger),
+		chiMiddleware.Recoverer,
+		middleware.Timeout(cfg.RequestTimeout, middleware.StreamingPaths...),
 	)
 
 	// Get Chi router and apply base middleware
@@ -153,6 +226,8 @@ func main() {
 
 	// Register routes
 	authConfig := middleware.DefaultAuthConfig(os.Getenv("JWT_SECRET"))
+	authConfig.DenyList = refreshTokenRepo
+	authConfig.Permissions = roleRepo
 	router.RegisterRoutes(handlerWrapper, authConfig)
 
 	// Start server
@@ -160,12 +235,49 @@ func main() {
 		Addr:    fmt.Sprintf(":%d", cfg.Port),
 		Handler: r,
 	}
+	// Shutdown does not wait for open streams to end on their own
+	server.RegisterOnShutdown(services.StreamBroker.Close)
+
+	// Serve Prometheus metrics on their own listener
+	metricsServer := commons.StartMetricsServer(cfg.MetricsAddress)
+
+	// Purge expired refresh tokens and deny-listed access tokens
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := refreshTokenRepo.DeleteExpired(ctx); err != nil {
+				logger.ErrorContext(ctx, "Failed to delete expired tokens", "error", err)
+			}
+		}
+	}()
+
+	// Publish task events stored in the outbox to the notification service
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	defer stopRelay()
+	go services.OutboxRelay.Start(relayCtx)
+
+	// Create the occurrences of recurring tasks as they come due
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go services.TaskScheduler.Start(schedulerCtx)
+
+	// Post queued task events to the webhooks subscribed to them
+	webhookCtx, stopWebhooks := context.WithCancel(ctx)
+	defer stopWebhooks()
+	go services.WebhookDispatcher.Start(webhookCtx)
+
+	// Push new notifications and system events to connected clients
+	streamCtx, stopStream := context.WithCancel(ctx)
+	defer stopStream()
+	go services.StreamFeed.Start(streamCtx)
 
 	// Graceful shutdown
 	go func() {
-		logger.Infof("Server started on port %d", cfg.Port)
+		logger.Info("Server started", "port", cfg.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start server:", err)
+			logger.Error("Failed to start server", "error", err)
 			os.Exit(1)
 		}
 	}()
@@ -175,16 +287,26 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down server...")
+	logger.Info("Shutting down server")
+	stopRelay()
+	stopScheduler()
 
 	// Shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown:", err)
+		logger.Error("Server forced to shutdown", "error", err)
 		os.Exit(1)
 	}
 
-	logger.Info("Server exited properly")
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		logger.Error("Failed to stop metrics server", "error", err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+
+	logger.Info("Server exited")
 }
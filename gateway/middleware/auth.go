@@ -3,24 +3,59 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
+
+	"sama/go-task-management/commons"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type ContextKey string
 
-const UserIDKey ContextKey = "user_id"
+const (
+	UserIDKey      ContextKey = "user_id"
+	RolesKey       ContextKey = "roles"
+	PermissionsKey ContextKey = "permissions"
+	TokenIDKey     ContextKey = "token_id"
+	SessionIDKey   ContextKey = "session_id"
+	TokenExpiryKey ContextKey = "token_expiry"
+)
 
 type AuthClaims struct {
 	UserID string `json:"user_id"`
 	jwt.RegisteredClaims
 }
 
+// TokenDenyList reports whether an access token was revoked before it expired.
+type TokenDenyList interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// PermissionLoader returns the permissions granted to a user by their roles.
+type PermissionLoader interface {
+	GetUserPermissions(ctx context.Context, userID string) ([]string, error)
+}
+
+// StreamingPaths are the long-lived event stream endpoints. They take the
+// access token from the query as well and are not subject to the request
+// timeout.
+var StreamingPaths = []string{
+	"/api/v1/stream",
+	"/api/v1/stream/ws",
+}
+
 type AuthConfig struct {
 	JWTSecret     string
 	PublicPaths   []string
 	SwaggerPrefix string
+	// DenyList, when set, rejects revoked access tokens and tokens without a jti.
+	DenyList TokenDenyList
+	// Permissions, when set, loads the permissions of access tokens without
+	// a permissions claim, which were issued before permissions were
+	// embedded in them.
+	Permissions PermissionLoader
 }
 
 func DefaultAuthConfig(jwtSecret string) AuthConfig {
@@ -53,12 +88,14 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
+				RecordAuthFailure(AuthFailureMissingToken)
 				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
 				return
 			}
 
 			bearerToken := strings.Split(authHeader, " ")
 			if len(bearerToken) != 2 || strings.ToLower(bearerToken[0]) != "bearer" {
+				RecordAuthFailure(AuthFailureMalformedHeader)
 				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
 				return
 			}
@@ -72,6 +109,7 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 			})
 
 			if err != nil {
+				RecordAuthFailure(AuthFailureInvalidToken)
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
@@ -79,12 +117,55 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 			if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 				userID, ok := claims["sub"].(string)
 				if !ok {
+					RecordAuthFailure(AuthFailureInvalidToken)
 					http.Error(w, "Invalid token claims", http.StatusUnauthorized)
 					return
 				}
+				tokenID, _ := claims["jti"].(string)
+				if config.DenyList != nil {
+					if tokenID == "" {
+						RecordAuthFailure(AuthFailureInvalidToken)
+						http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+						return
+					}
+
+					revoked, err := config.DenyList.IsAccessTokenRevoked(r.Context(), tokenID)
+					if err != nil {
+						http.Error(w, "Failed to validate token", http.StatusInternalServerError)
+						return
+					}
+					if revoked {
+						RecordAuthFailure(AuthFailureRevokedToken)
+						http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+						return
+					}
+				}
+
+				permissions := claimStrings(claims, "permissions")
+				if _, ok := claims["permissions"]; !ok && config.Permissions != nil {
+					permissions, err = config.Permissions.GetUserPermissions(r.Context(), userID)
+					if err != nil {
+						http.Error(w, "Failed to load permissions", http.StatusInternalServerError)
+						return
+					}
+				}
+
+				sessionID, _ := claims["sid"].(string)
+				var expiresAt time.Time
+				if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+					expiresAt = exp.Time
+				}
+
 				ctx := context.WithValue(r.Context(), UserIDKey, userID)
+				ctx = commons.WithUserID(ctx, userID)
+				ctx = context.WithValue(ctx, RolesKey, claimStrings(claims, "roles"))
+				ctx = context.WithValue(ctx, PermissionsKey, permissions)
+				ctx = context.WithValue(ctx, TokenIDKey, tokenID)
+				ctx = context.WithValue(ctx, SessionIDKey, sessionID)
+				ctx = context.WithValue(ctx, TokenExpiryKey, expiresAt)
 				next.ServeHTTP(w, r.WithContext(ctx))
 			} else {
+				RecordAuthFailure(AuthFailureInvalidToken)
 				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
 				return
 			}
@@ -92,6 +173,37 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 	}
 }
 
+// QueryTokenMiddleware moves the access token of requests to paths from the
+// access_token query parameter to the Authorization header, for clients
+// such as EventSource and WebSocket that cannot set headers. It has to run
+// before request logging, so the token is not logged.
+func QueryTokenMiddleware(paths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			query := r.URL.Query()
+			token := query.Get("access_token")
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			query.Del("access_token")
+			r = r.Clone(r.Context())
+			r.URL.RawQuery = query.Encode()
+			r.RequestURI = r.URL.RequestURI()
+			if r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func isPublicPath(path string, config AuthConfig) bool {
 	if strings.HasPrefix(path, config.SwaggerPrefix) {
 		return true
@@ -114,3 +226,75 @@ func GetUserIDFromContext(r *http.Request) string {
 	}
 	return ""
 }
+
+func GetTokenIDFromContext(r *http.Request) string {
+	if tokenID, ok := r.Context().Value(TokenIDKey).(string); ok {
+		return tokenID
+	}
+	return ""
+}
+
+// GetSessionIDFromContext returns the session (refresh token family) the
+// access token was issued for.
+func GetSessionIDFromContext(r *http.Request) string {
+	if sessionID, ok := r.Context().Value(SessionIDKey).(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
+func GetTokenExpiryFromContext(r *http.Request) time.Time {
+	if expiresAt, ok := r.Context().Value(TokenExpiryKey).(time.Time); ok {
+		return expiresAt
+	}
+	return time.Time{}
+}
+
+// RequirePermission rejects requests whose token does not grant at least one
+// of the given permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := GetPermissionsFromContext(r)
+			for _, permission := range permissions {
+				if commons.HasPermission(granted, permission) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			RecordAuthFailure(AuthFailureForbidden)
+			http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		})
+	}
+}
+
+func GetRolesFromContext(r *http.Request) []string {
+	if roles, ok := r.Context().Value(RolesKey).([]string); ok {
+		return roles
+	}
+	return nil
+}
+
+func GetPermissionsFromContext(r *http.Request) []string {
+	if permissions, ok := r.Context().Value(PermissionsKey).([]string); ok {
+		return permissions
+	}
+	return nil
+}
+
+// claimStrings reads a string array claim. JSON decoding yields []interface{}.
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	values, ok := claims[name].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			result = append(result, str)
+		}
+	}
+	return result
+}
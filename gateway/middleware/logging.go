@@ -1,32 +1,48 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"time"
+
+	"sama/go-task-management/commons"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		responseWriter := &responseWriter{
-			ResponseWriter: w,
-		}
-
-		next.ServeHTTP(responseWriter, r)
-
-		duration := time.Since(start)
-		log.Printf(
-			"Method: %s | Path: %s | Status: %d | Duration: %v | IP: %s | User-Agent: %s",
-			r.Method,
-			r.URL.Path,
-			responseWriter.status,
-			duration,
-			r.RemoteAddr,
-			r.UserAgent(),
-		)
-	})
+// LoggingMiddleware logs every request once it is handled. It comes after
+// chi's RequestID middleware, whose request ID it adds to the context so
+// that the records logged while handling the request carry it.
+func LoggingMiddleware(logger commons.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ctx := r.Context()
+			if requestID := chiMiddleware.GetReqID(ctx); requestID != "" {
+				ctx = commons.WithRequestID(ctx, requestID)
+				r = r.WithContext(ctx)
+			}
+
+			responseWriter := &responseWriter{
+				ResponseWriter: w,
+			}
+
+			next.ServeHTTP(responseWriter, r)
+
+			status := responseWriter.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			logger.InfoContext(ctx, "Request handled",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"duration", time.Since(start),
+				"ip", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+			)
+		})
+	}
 }
 
 type responseWriter struct {
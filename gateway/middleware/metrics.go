@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Reasons requests are rejected for, the label of gateway_auth_failures_total.
+const (
+	AuthFailureMissingToken       = "missing_token"
+	AuthFailureMalformedHeader    = "malformed_header"
+	AuthFailureInvalidToken       = "invalid_token"
+	AuthFailureRevokedToken       = "revoked_token"
+	AuthFailureForbidden          = "forbidden"
+	AuthFailureInvalidCredentials = "invalid_credentials"
+	AuthFailureInvalidRefresh     = "invalid_refresh_token"
+	AuthFailureRefreshReused      = "refresh_token_reused"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	authFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_auth_failures_total",
+		Help: "Requests rejected for missing, invalid or insufficient credentials, by reason.",
+	}, []string{"reason"})
+)
+
+// RecordAuthFailure counts a request rejected for one of the AuthFailure
+// reasons.
+func RecordAuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// Metrics counts requests and measures their duration per route, labeled
+// with the route pattern rather than the path so that IDs do not create a
+// series each. Requests that match no route are labeled "unmatched". The
+// long-lived requests to streamingPaths are left out.
+func Metrics(streamingPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(streamingPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			recorder := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(recorder, r)
+
+			route := "unmatched"
+			if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+				route = routeContext.RoutePattern()
+			}
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+			httpRequests.With(labels).Inc()
+			httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
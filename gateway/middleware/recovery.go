@@ -2,16 +2,17 @@ package middleware
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"runtime/debug"
+
+	"sama/go-task-management/commons"
 )
 
 func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic: %v\nStack: %s", err, debug.Stack())
+				commons.DefaultLogger().ErrorContext(r.Context(), "Panic", "panic", err, "stack", string(debug.Stack()))
 
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
@@ -21,7 +22,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 				}
 
 				if err := json.NewEncoder(w).Encode(response); err != nil {
-					log.Printf("Error encoding error response: %v", err)
+					commons.DefaultLogger().ErrorContext(r.Context(), "Failed to encode error response", "error", err)
 				}
 			}
 		}()
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Timeout cancels the context of requests after timeout, except for the
+// long-lived requests to streamingPaths.
+func Timeout(timeout time.Duration, streamingPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := chiMiddleware.Timeout(timeout)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(streamingPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span for each request, continuing the trace of the
+// caller if it sent a traceparent header. Spans are named after the matched
+// route, e.g. "GET /api/v1/tasks/{id}", so that requests to the same
+// endpoint are grouped. The long-lived requests to streamingPaths are not
+// traced.
+func Tracing(serviceName string, streamingPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			// The route is only known once chi has matched it.
+			routeContext := chi.RouteContext(r.Context())
+			if routeContext == nil {
+				return
+			}
+			if pattern := routeContext.RoutePattern(); pattern != "" {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(attribute.String("http.route", pattern))
+			}
+		})
+
+		return otelhttp.NewHandler(named, serviceName,
+			otelhttp.WithFilter(func(r *http.Request) bool {
+				return !slices.Contains(streamingPaths, r.URL.Path)
+			}),
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				return r.Method
+			}),
+		)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"sama/go-task-management/commons"
 	"sama/go-task-management/gateway/interfaces"
 	"sama/go-task-management/gateway/middleware"
 
@@ -42,21 +43,87 @@ func (r *Router) RegisterRoutes(handler interfaces.Handler, authConfig middlewar
 
 		// Auth routes
 		router.Post("/api/v1/auth/signout", handler.SignOut)
+		router.Get("/api/v1/auth/sessions", handler.ListSessions)
+		router.Delete("/api/v1/auth/sessions/{id}", handler.RevokeSession)
 
 		// Task routes
-		router.Get("/api/v1/tasks/{id}", handler.GetTask)
-		router.Get("/api/v1/tasks", handler.GetAllTasks)
-		router.Post("/api/v1/tasks", handler.CreateTask)
-		router.Put("/api/v1/tasks/{id}", handler.UpdateTask)
-		router.Delete("/api/v1/tasks/{id}", handler.DeleteTask)
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/tasks/{id}", handler.GetTask)
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/tasks", handler.GetAllTasks)
+		router.With(middleware.RequirePermission(commons.PermissionTaskCreate)).Post("/api/v1/tasks", handler.CreateTask)
+		router.With(middleware.RequirePermission(commons.PermissionTaskUpdate)).Put("/api/v1/tasks/{id}", handler.UpdateTask)
+		router.With(middleware.RequirePermission(commons.PermissionTaskDelete)).Delete("/api/v1/tasks/{id}", handler.DeleteTask)
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/tasks/{id}/recurrence", handler.GetTaskRecurrence)
+
+		// Project routes
+		router.With(middleware.RequirePermission(commons.PermissionProjectRead)).Get("/api/v1/projects", handler.GetAllProjects)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Post("/api/v1/projects", handler.CreateProject)
+		router.With(middleware.RequirePermission(commons.PermissionProjectRead)).Get("/api/v1/projects/{id}", handler.GetProject)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Put("/api/v1/projects/{id}", handler.UpdateProject)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Delete("/api/v1/projects/{id}", handler.DeleteProject)
+		router.With(middleware.RequirePermission(commons.PermissionProjectRead)).Get("/api/v1/projects/{id}/members", handler.GetProjectMembers)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Post("/api/v1/projects/{id}/members", handler.AddProjectMember)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Put("/api/v1/projects/{id}/members/{userId}", handler.UpdateProjectMember)
+		router.With(middleware.RequirePermission(commons.PermissionProjectWrite)).Delete("/api/v1/projects/{id}/members/{userId}", handler.RemoveProjectMember)
+
+		// Task comment routes
+		router.With(middleware.RequirePermission(commons.PermissionCommentRead)).Get("/api/v1/tasks/{id}/comments", handler.GetTaskComments)
+		router.With(middleware.RequirePermission(commons.PermissionCommentWrite)).Post("/api/v1/tasks/{id}/comments", handler.CreateTaskComment)
+		router.With(middleware.RequirePermission(commons.PermissionCommentWrite)).Put("/api/v1/tasks/{id}/comments/{commentId}", handler.UpdateTaskComment)
+		router.With(middleware.RequirePermission(commons.PermissionCommentWrite)).Delete("/api/v1/tasks/{id}/comments/{commentId}", handler.DeleteTaskComment)
+		router.With(middleware.RequirePermission(commons.PermissionCommentRead)).Get("/api/v1/tasks/{id}/comments/{commentId}/edits", handler.GetTaskCommentEdits)
+
+		// Task email routes
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/tasks/{id}/emails", handler.GetTaskEmails)
 
 		// Notification routes
-		router.Get("/api/v1/notifications", handler.GetAllInAppNotifications)
-		router.Post("/api/v1/notifications/{id}/read", handler.UpdateOnRead)
-		router.Delete("/api/v1/notifications/{id}", handler.DeleteInAppNotification)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationRead)).Get("/api/v1/notifications", handler.GetAllInAppNotifications)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationRead)).Post("/api/v1/notifications/{id}/read", handler.UpdateOnRead)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationRead)).Delete("/api/v1/notifications/{id}", handler.DeleteInAppNotification)
+
+		// Reminder preference routes
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Get("/api/v1/users/me/reminder-preferences", handler.GetReminderPreferences)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Put("/api/v1/users/me/reminder-preferences", handler.UpdateReminderPreferences)
+
+		// Notification preference routes
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Get("/api/v1/users/me/notification-preferences", handler.GetNotificationPreferences)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Put("/api/v1/users/me/notification-preferences", handler.UpdateNotificationPreferences)
+
+		// Phone number routes
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Get("/api/v1/users/me/phone", handler.GetPhone)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Put("/api/v1/users/me/phone", handler.UpdatePhone)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Delete("/api/v1/users/me/phone", handler.DeletePhone)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationSettings)).Post("/api/v1/users/me/phone/verify", handler.VerifyPhone)
+
+		// Webhook routes
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Get("/api/v1/webhooks", handler.GetAllWebhooks)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Post("/api/v1/webhooks", handler.CreateWebhook)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Get("/api/v1/webhooks/{id}", handler.GetWebhook)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Put("/api/v1/webhooks/{id}", handler.UpdateWebhook)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Delete("/api/v1/webhooks/{id}", handler.DeleteWebhook)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Get("/api/v1/webhooks/{id}/deliveries", handler.GetWebhookDeliveries)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Get("/api/v1/webhooks/{id}/deliveries/{deliveryId}", handler.GetWebhookDelivery)
+		router.With(middleware.RequirePermission(commons.PermissionWebhookManage)).Post("/api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver", handler.RedeliverWebhookDelivery)
+
+		// Stream routes
+		router.With(middleware.RequirePermission(commons.PermissionNotificationRead)).Get("/api/v1/stream", handler.StreamEvents)
+		router.With(middleware.RequirePermission(commons.PermissionNotificationRead)).Get("/api/v1/stream/ws", handler.StreamEventsWebSocket)
 
 		// System event routes
-		router.Get("/api/v1/task-system-events", handler.GetAllTaskSystemEvents)
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/task-system-events", handler.GetAllTaskSystemEvents)
+
+		// Trace routes
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/traces", handler.GetAllTraces)
+		router.With(middleware.RequirePermission(commons.PermissionTaskRead)).Get("/api/v1/traces/{correlationId}", handler.GetTrace)
+
+		// Role management routes
+		router.Group(func(router chi.Router) {
+			router.Use(middleware.RequirePermission(commons.PermissionAdmin))
+
+			router.Get("/api/v1/roles", handler.GetAllRoles)
+			router.Get("/api/v1/users/{id}/roles", handler.GetUserRoles)
+			router.Post("/api/v1/users/{id}/roles", handler.AssignUserRole)
+			router.Delete("/api/v1/users/{id}/roles/{role}", handler.RemoveUserRole)
+		})
 	})
 }
 
@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"context"
+
 	"sama/go-task-management/commons"
 )
 
@@ -8,8 +10,8 @@ type InAppNotificationRepositoryAdapter struct {
 	commons.InAppNotificationRepositoryInterface
 }
 
-func (a *InAppNotificationRepositoryAdapter) Create(notification commons.InAppNotification) (commons.InAppNotification, error) {
-	created, err := a.InAppNotificationRepositoryInterface.Create(notification)
+func (a *InAppNotificationRepositoryAdapter) Create(ctx context.Context, notification commons.InAppNotification) (commons.InAppNotification, error) {
+	created, err := a.InAppNotificationRepositoryInterface.Create(ctx, notification)
 	if err != nil {
 		return commons.InAppNotification{}, err
 	}
@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"context"
+
 	"sama/go-task-management/commons"
 )
 
@@ -8,22 +10,22 @@ type TaskRepositoryAdapter struct {
 	commons.TaskRepositoryInterface
 }
 
-func (a *TaskRepositoryAdapter) GetByID(id string) (commons.Task, error) {
-	return a.TaskRepositoryInterface.GetByID(id)
+func (a *TaskRepositoryAdapter) GetByID(ctx context.Context, id string) (commons.Task, error) {
+	return a.TaskRepositoryInterface.GetByID(ctx, id)
 }
 
-func (a *TaskRepositoryAdapter) GetByUserID(userID string) ([]commons.Task, error) {
-	return a.TaskRepositoryInterface.GetByUserID(userID)
+func (a *TaskRepositoryAdapter) GetByUserID(ctx context.Context, userID string) ([]commons.Task, error) {
+	return a.TaskRepositoryInterface.GetByUserID(ctx, userID)
 }
 
-func (a *TaskRepositoryAdapter) Create(task commons.Task) (commons.Task, error) {
-	return a.TaskRepositoryInterface.Create(task)
+func (a *TaskRepositoryAdapter) Create(ctx context.Context, task commons.Task) (commons.Task, error) {
+	return a.TaskRepositoryInterface.Create(ctx, task)
 }
 
-func (a *TaskRepositoryAdapter) Update(task commons.Task) error {
-	return a.TaskRepositoryInterface.Update(task)
+func (a *TaskRepositoryAdapter) Update(ctx context.Context, task commons.Task) error {
+	return a.TaskRepositoryInterface.Update(ctx, task)
 }
 
-func (a *TaskRepositoryAdapter) Delete(id string) error {
-	return a.TaskRepositoryInterface.Delete(id)
+func (a *TaskRepositoryAdapter) Delete(ctx context.Context, id string) error {
+	return a.TaskRepositoryInterface.Delete(ctx, id)
 }
@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"context"
+
 	"sama/go-task-management/commons"
 )
 
@@ -8,12 +10,12 @@ type UserRepositoryAdapter struct {
 	commons.UserRepositoryInterface
 }
 
-func (a *UserRepositoryAdapter) Update(user commons.User) error {
-	_, err := a.UserRepositoryInterface.UpdatePassword(user.ID, user.HashedPassword, user.Salt)
+func (a *UserRepositoryAdapter) Update(ctx context.Context, user commons.User) error {
+	_, err := a.UserRepositoryInterface.UpdatePassword(ctx, user.ID, user.HashedPassword, user.Salt)
 	return err
 }
 
-func (a *UserRepositoryAdapter) UpdatePassword(userID string, hashedPassword string) error {
-	_, err := a.UserRepositoryInterface.UpdatePassword(userID, hashedPassword, "")
+func (a *UserRepositoryAdapter) UpdatePassword(ctx context.Context, userID string, hashedPassword string, salt string) error {
+	_, err := a.UserRepositoryInterface.UpdatePassword(ctx, userID, hashedPassword, salt)
 	return err
 }
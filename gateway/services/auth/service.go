@@ -3,28 +3,65 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
 	"time"
 
 	"sama/go-task-management/commons"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	accessTokenTTL   = time.Hour
+	refreshTokenTTL  = 7 * 24 * time.Hour
+	passwordResetTTL = 24 * time.Hour
+)
+
 type UserRepository interface {
-	GetByID(id string) (commons.User, error)
-	GetByEmail(email string) (commons.User, error)
-	Create(user commons.User) (commons.User, error)
-	Update(user commons.User) error
-	UpdatePassword(userID string, hashedPassword string) error
+	GetByID(ctx context.Context, id string) (commons.User, error)
+	GetByEmail(ctx context.Context, email string) (commons.User, error)
+	Create(ctx context.Context, user commons.User) (commons.User, error)
+	Update(ctx context.Context, user commons.User) error
+	UpdatePassword(ctx context.Context, userID string, hashedPassword string, salt string) error
 }
 
 type PasswordResetTokenRepository interface {
-	Create(token commons.PasswordResetToken) (commons.PasswordResetToken, error)
-	GetByToken(token string) (commons.PasswordResetToken, error)
-	MarkAsUsed(token string) error
-	DeleteExpired() error
+	Create(ctx context.Context, token commons.PasswordResetToken) (commons.PasswordResetToken, error)
+	GetByToken(ctx context.Context, token string) (commons.PasswordResetToken, error)
+	MarkAsUsed(ctx context.Context, token string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type RoleRepository interface {
+	GetRoles(ctx context.Context) ([]commons.Role, error)
+	GetUserRoles(ctx context.Context, userID string) ([]string, error)
+	GetUserPermissions(ctx context.Context, userID string) ([]string, error)
+	AssignRole(ctx context.Context, userID string, roleID string) error
+	RemoveRole(ctx context.Context, userID string, roleID string) error
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token commons.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (commons.RefreshToken, error)
+	Rotate(ctx context.Context, oldID string, next commons.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string, reason string) error
+	GetActiveSessions(ctx context.Context, userID string) ([]commons.Session, error)
+	RevokeAccessToken(ctx context.Context, jti string, userID string, expiresAt time.Time) error
+}
+
+// UnitOfWork writes a new user and their default role in one transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos commons.Repositories) error) error
+}
+
+type Notifier interface {
+	SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error
 }
 
 type Service struct {
@@ -32,20 +69,43 @@ type Service struct {
 	jwtSecret              string
 	userRepo               UserRepository
 	passwordResetTokenRepo PasswordResetTokenRepository
+	roleRepo               RoleRepository
+	refreshTokenRepo       RefreshTokenRepository
+	unitOfWork             UnitOfWork
+	notifier               Notifier
+	passwordResetURL       string
 }
 
-func NewService(logger commons.Logger, jwtSecret string, userRepo UserRepository, passwordResetTokenRepo PasswordResetTokenRepository) *Service {
+func NewService(
+	logger commons.Logger,
+	jwtSecret string,
+	userRepo UserRepository,
+	passwordResetTokenRepo PasswordResetTokenRepository,
+	roleRepo RoleRepository,
+	refreshTokenRepo RefreshTokenRepository,
+	unitOfWork UnitOfWork,
+	notifier Notifier,
+	passwordResetURL string,
+) *Service {
 	return &Service{
 		logger:                 logger,
 		jwtSecret:              jwtSecret,
 		userRepo:               userRepo,
 		passwordResetTokenRepo: passwordResetTokenRepo,
+		roleRepo:               roleRepo,
+		refreshTokenRepo:       refreshTokenRepo,
+		unitOfWork:             unitOfWork,
+		notifier:               notifier,
+		passwordResetURL:       passwordResetURL,
 	}
 }
 
 func (s *Service) SignUp(ctx context.Context, input SignUpInput) (*AuthResponse, error) {
-	_, err := s.userRepo.GetByEmail(input.Email)
-	if err == nil {
+	existing, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing.ID != "" {
 		return nil, commons.ErrEmailTaken
 	}
 
@@ -56,18 +116,30 @@ func (s *Service) SignUp(ctx context.Context, input SignUpInput) (*AuthResponse,
 		Handle:         input.Handle,
 		Email:          input.Email,
 		HashedPassword: hashedPassword,
-		Salt:          salt,
-		Status:        "ACTIVE",
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		Salt:           salt,
+		Status:         "ACTIVE",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
-	createdUser, err := s.userRepo.Create(user)
+	// A user without their default role would have no permissions at all.
+	var createdUser commons.User
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		var err error
+		createdUser, err = repos.Users.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		return repos.Roles.AssignRole(ctx, createdUser.ID, commons.DefaultUserRole)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	tokens := s.generateTokens(createdUser.ID)
+	tokens, roles, err := s.startSession(ctx, createdUser.ID, input.Client)
+	if err != nil {
+		return nil, err
+	}
 
 	return &AuthResponse{
 		User: UserResponse{
@@ -75,13 +147,14 @@ func (s *Service) SignUp(ctx context.Context, input SignUpInput) (*AuthResponse,
 			Handle: createdUser.Handle,
 			Email:  createdUser.Email,
 			Status: createdUser.Status,
+			Roles:  roles,
 		},
 		Token: &tokens,
 	}, nil
 }
 
 func (s *Service) SignIn(ctx context.Context, input SignInInput) (*AuthResponse, error) {
-	user, err := s.userRepo.GetByEmail(input.Email)
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
 		return nil, commons.ErrInvalidCredentials
 	}
@@ -90,7 +163,10 @@ func (s *Service) SignIn(ctx context.Context, input SignInInput) (*AuthResponse,
 		return nil, commons.ErrInvalidCredentials
 	}
 
-	tokens := s.generateTokens(user.ID)
+	tokens, roles, err := s.startSession(ctx, user.ID, input.Client)
+	if err != nil {
+		return nil, err
+	}
 
 	return &AuthResponse{
 		User: UserResponse{
@@ -98,46 +174,180 @@ func (s *Service) SignIn(ctx context.Context, input SignInInput) (*AuthResponse,
 			Handle: user.Handle,
 			Email:  user.Email,
 			Status: user.Status,
+			Roles:  roles,
 		},
 		Token: &tokens,
 	}, nil
 }
 
-func (s *Service) RefreshToken(ctx context.Context, userID string) (*TokenResponse, error) {
-	user, err := s.userRepo.GetByID(userID)
+// RefreshToken rotates a refresh token: the presented token is revoked and a
+// new access/refresh pair in the same session is returned. Presenting a token
+// that was already rotated is treated as theft and revokes the whole session.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*TokenResponse, error) {
+	stored, err := s.refreshTokenRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
 	if err != nil {
-		return nil, commons.ErrInvalidCredentials
+		if err == commons.ErrNotFound {
+			return nil, commons.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil {
+		if stored.RevokedReason == commons.TokenRevokedRotated {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, commons.ErrUnauthorized
+	}
+
+	if stored.ExpiresAt.Before(time.Now()) {
+		return nil, commons.ErrUnauthorized
+	}
+
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == "" {
+		return nil, commons.ErrUnauthorized
+	}
+
+	tokens, next, _, err := s.generateTokens(ctx, user.ID, stored.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.Rotate(ctx, stored.ID, next); err != nil {
+		if err == commons.ErrTokenReused {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
 	}
 
-	tokens := s.generateTokens(user.ID)
 	return &tokens, nil
 }
 
+// SignOut ends the session the access token belongs to and deny-lists the
+// access token itself.
+func (s *Service) SignOut(ctx context.Context, token AccessTokenInfo) error {
+	if token.SessionID != "" {
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, token.SessionID, commons.TokenRevokedSignOut); err != nil {
+			return err
+		}
+	}
+
+	if token.TokenID != "" {
+		return s.refreshTokenRepo.RevokeAccessToken(ctx, token.TokenID, token.UserID, token.ExpiresAt)
+	}
+
+	return nil
+}
+
+func (s *Service) ListSessions(ctx context.Context, userID string, currentSessionID string) ([]SessionResponse, error) {
+	sessions, err := s.refreshTokenRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = ToSessionResponse(session, session.ID == currentSessionID)
+	}
+
+	return response, nil
+}
+
+// RevokeSession signs userID out of one of their sessions.
+func (s *Service) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	sessions, err := s.refreshTokenRepo.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == sessionID {
+			return s.refreshTokenRepo.RevokeFamily(ctx, sessionID, commons.TokenRevokedByUser)
+		}
+	}
+
+	return commons.ErrNotFound
+}
+
+func (s *Service) revokeReusedFamily(ctx context.Context, token commons.RefreshToken) error {
+	s.logger.WarnContext(ctx, "Refresh token reuse detected, revoking session", "token_user_id", token.UserID, "family_id", token.FamilyID)
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID, commons.TokenRevokedReuseDetected); err != nil {
+		return err
+	}
+
+	return commons.ErrTokenReused
+}
+
+func (s *Service) GetRoles(ctx context.Context) ([]commons.Role, error) {
+	return s.roleRepo.GetRoles(ctx)
+}
+
+func (s *Service) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == "" {
+		return nil, commons.ErrNotFound
+	}
+
+	return s.roleRepo.GetUserRoles(ctx, userID)
+}
+
+// AssignRole grants roleID to userID. The change is reflected in the user's
+// tokens from their next sign-in or refresh.
+func (s *Service) AssignRole(ctx context.Context, userID string, roleID string) ([]string, error) {
+	if _, err := s.GetUserRoles(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.AssignRole(ctx, userID, roleID); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetUserRoles(ctx, userID)
+}
+
+func (s *Service) RemoveRole(ctx context.Context, userID string, roleID string) ([]string, error) {
+	if err := s.roleRepo.RemoveRole(ctx, userID, roleID); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.GetUserRoles(ctx, userID)
+}
+
 func (s *Service) ForgotPassword(ctx context.Context, input ForgotPasswordInput) error {
-	user, err := s.userRepo.GetByEmail(input.Email)
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
 		return commons.ErrNotFound
 	}
+	// Unknown addresses get the same answer as known ones, so the endpoint
+	// does not tell which addresses have an account.
+	if user.ID == "" {
+		return nil
+	}
 
 	token := commons.PasswordResetToken{
 		UserID:    user.ID,
 		Token:     generateToken(),
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
 		CreatedAt: time.Now(),
 	}
 
-	_, err = s.passwordResetTokenRepo.Create(token)
+	_, err = s.passwordResetTokenRepo.Create(ctx, token)
 	if err != nil {
 		return err
 	}
 
-	// TODO: Send email with reset link
-
-	return nil
+	return s.sendPasswordResetEmail(ctx, user, token)
 }
 
 func (s *Service) ResetPassword(ctx context.Context, input ResetPasswordInput) error {
-	resetToken, err := s.passwordResetTokenRepo.GetByToken(input.Token)
+	resetToken, err := s.passwordResetTokenRepo.GetByToken(ctx, input.Token)
 	if err != nil {
 		return commons.ErrNotFound
 	}
@@ -153,44 +363,116 @@ func (s *Service) ResetPassword(ctx context.Context, input ResetPasswordInput) e
 	salt := generateSalt()
 	hashedPassword := hashPassword(input.NewPassword, salt)
 
-	err = s.userRepo.UpdatePassword(resetToken.UserID, hashedPassword)
+	err = s.userRepo.UpdatePassword(ctx, resetToken.UserID, hashedPassword, salt)
 	if err != nil {
 		return err
 	}
 
-	err = s.passwordResetTokenRepo.MarkAsUsed(input.Token)
+	err = s.passwordResetTokenRepo.MarkAsUsed(ctx, input.Token)
 	if err != nil {
-		s.logger.Printf("Error marking token as used: %v", err)
+		s.logger.ErrorContext(ctx, "Failed to mark password reset token as used", "error", err)
 	}
 
 	return nil
 }
 
-func (s *Service) generateTokens(userID string) TokenResponse {
+// sendPasswordResetEmail hands the reset link to the notification service,
+// which queues it for the email service.
+func (s *Service) sendPasswordResetEmail(ctx context.Context, user commons.User, token commons.PasswordResetToken) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err := s.notifier.SendNotification(ctx, commons.GRPCEvent{
+		CorrelationId: uuid.New().String(),
+		Types:         []string{"EMAIL"},
+		Event:         commons.NotificationEventPasswordReset,
+		RecipientIDs:  []string{user.ID},
+		Title:         "Reset your password",
+		Message:       fmt.Sprintf("This link expires in %d hours.", int(passwordResetTTL.Hours())),
+		Link:          s.passwordResetURL + "?token=" + url.QueryEscape(token.Token),
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to send password reset email", "recipient_id", user.ID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// startSession issues the first tokens of a new session.
+func (s *Service) startSession(ctx context.Context, userID string, client ClientInfo) (TokenResponse, []string, error) {
+	tokens, refreshToken, roles, err := s.generateTokens(ctx, userID, uuid.New().String(), client)
+	if err != nil {
+		return TokenResponse{}, nil, err
+	}
+
+	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return TokenResponse{}, nil, err
+	}
+
+	return tokens, roles, nil
+}
+
+// generateTokens issues an access token and an opaque refresh token for a
+// session (refresh token family). The access token carries the user's current
+// roles and permissions, which are returned as well. The refresh token is
+// returned in the form it must be stored in.
+func (s *Service) generateTokens(ctx context.Context, userID string, sessionID string, client ClientInfo) (TokenResponse, commons.RefreshToken, []string, error) {
+	roles, err := s.roleRepo.GetUserRoles(ctx, userID)
+	if err != nil {
+		return TokenResponse{}, commons.RefreshToken{}, nil, err
+	}
+
+	permissions, err := s.roleRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return TokenResponse{}, commons.RefreshToken{}, nil, err
+	}
+
 	now := time.Now()
-	accessTokenExpiry := now.Add(time.Hour)
-	refreshTokenExpiry := now.Add(7 * 24 * time.Hour)
+	accessTokenID := uuid.New().String()
+	accessTokenExpiry := now.Add(accessTokenTTL)
 
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": accessTokenExpiry.Unix(),
-		"iat": now.Unix(),
+		"sub":         userID,
+		"jti":         accessTokenID,
+		"sid":         sessionID,
+		"exp":         accessTokenExpiry.Unix(),
+		"iat":         now.Unix(),
+		"roles":       roles,
+		"permissions": permissions,
 	})
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userID,
-		"exp": refreshTokenExpiry.Unix(),
-		"iat": now.Unix(),
-	})
+	signedAccessToken, err := accessToken.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return TokenResponse{}, commons.RefreshToken{}, nil, err
+	}
 
-	signedAccessToken, _ := accessToken.SignedString([]byte(s.jwtSecret))
-	signedRefreshToken, _ := refreshToken.SignedString([]byte(s.jwtSecret))
+	rawRefreshToken := generateToken()
+	refreshToken := commons.RefreshToken{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		FamilyID:        sessionID,
+		TokenHash:       hashRefreshToken(rawRefreshToken),
+		AccessTokenID:   accessTokenID,
+		AccessExpiresAt: accessTokenExpiry,
+		UserAgent:       client.UserAgent,
+		IPAddress:       client.IPAddress,
+		ExpiresAt:       now.Add(refreshTokenTTL),
+		CreatedAt:       now,
+	}
 
 	return TokenResponse{
 		AccessToken:  signedAccessToken,
-		RefreshToken: signedRefreshToken,
-		ExpiresIn:    int64(time.Until(accessTokenExpiry).Seconds()),
-	}
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    int64(accessTokenTTL.Seconds()),
+	}, refreshToken, roles, nil
+}
+
+// hashRefreshToken returns the form refresh tokens are stored and looked up
+// in, so a database leak does not expose usable tokens.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 func generateSalt() string {
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net/url"
+	"testing"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/services/adapters"
+
+	"github.com/google/uuid"
+)
+
+type fakeUserRepository struct {
+	commons.UserRepositoryInterface
+	users map[string]commons.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id string) (commons.User, error) {
+	return r.users[id], nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (commons.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return commons.User{}, nil
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, user commons.User) (commons.User, error) {
+	user.ID = uuid.New().String()
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, id string, hashedPassword string, salt string) (commons.User, error) {
+	user := r.users[id]
+	user.HashedPassword = hashedPassword
+	user.Salt = salt
+	r.users[id] = user
+	return user, nil
+}
+
+type fakeRoleRepository struct {
+	commons.RoleRepositoryInterface
+	roles     map[string][]string
+	assignErr error
+}
+
+func (r *fakeRoleRepository) AssignRole(ctx context.Context, userID string, roleID string) error {
+	if r.assignErr != nil {
+		return r.assignErr
+	}
+	r.roles[userID] = append(r.roles[userID], roleID)
+	return nil
+}
+
+func (r *fakeRoleRepository) GetUserRoles(ctx context.Context, userID string) ([]string, error) {
+	return r.roles[userID], nil
+}
+
+func (r *fakeRoleRepository) GetUserPermissions(ctx context.Context, userID string) ([]string, error) {
+	return nil, nil
+}
+
+type fakeRefreshTokenRepository struct {
+	RefreshTokenRepository
+}
+
+func (r *fakeRefreshTokenRepository) Create(ctx context.Context, token commons.RefreshToken) error {
+	return nil
+}
+
+type fakePasswordResetTokenRepository struct {
+	PasswordResetTokenRepository
+	tokens map[string]commons.PasswordResetToken
+}
+
+func (r *fakePasswordResetTokenRepository) Create(ctx context.Context, token commons.PasswordResetToken) (commons.PasswordResetToken, error) {
+	r.tokens[token.Token] = token
+	return token, nil
+}
+
+func (r *fakePasswordResetTokenRepository) GetByToken(ctx context.Context, token string) (commons.PasswordResetToken, error) {
+	resetToken, ok := r.tokens[token]
+	if !ok {
+		return commons.PasswordResetToken{}, commons.ErrNotFound
+	}
+	return resetToken, nil
+}
+
+func (r *fakePasswordResetTokenRepository) MarkAsUsed(ctx context.Context, token string) error {
+	resetToken := r.tokens[token]
+	resetToken.Used = true
+	r.tokens[token] = resetToken
+	return nil
+}
+
+type fakeNotifier struct {
+	events []commons.GRPCEvent
+}
+
+func (n *fakeNotifier) SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error {
+	n.events = append(n.events, grpcEvent)
+	return nil
+}
+
+// fakeUnitOfWork runs fn on the fake repositories and undoes its writes if
+// it fails, as a rolled back transaction would.
+type fakeUnitOfWork struct {
+	users *fakeUserRepository
+	roles *fakeRoleRepository
+}
+
+func (u *fakeUnitOfWork) Do(ctx context.Context, fn func(repos commons.Repositories) error) error {
+	users := maps.Clone(u.users.users)
+	roles := maps.Clone(u.roles.roles)
+
+	err := fn(commons.Repositories{Users: u.users, Roles: u.roles})
+	if err != nil {
+		u.users.users = users
+		u.roles.roles = roles
+	}
+	return err
+}
+
+type authFixture struct {
+	service     *Service
+	users       *fakeUserRepository
+	roles       *fakeRoleRepository
+	resetTokens *fakePasswordResetTokenRepository
+	notifier    *fakeNotifier
+}
+
+func newAuthFixture() authFixture {
+	users := &fakeUserRepository{users: make(map[string]commons.User)}
+	roles := &fakeRoleRepository{roles: make(map[string][]string)}
+	resetTokens := &fakePasswordResetTokenRepository{tokens: make(map[string]commons.PasswordResetToken)}
+	notifier := &fakeNotifier{}
+	service := NewService(
+		commons.NewLoggerWithOutput(io.Discard, "gateway"),
+		"secret",
+		&adapters.UserRepositoryAdapter{UserRepositoryInterface: users},
+		resetTokens,
+		roles,
+		&fakeRefreshTokenRepository{},
+		&fakeUnitOfWork{users: users, roles: roles},
+		notifier,
+		"http://localhost/reset-password",
+	)
+	return authFixture{service: service, users: users, roles: roles, resetTokens: resetTokens, notifier: notifier}
+}
+
+func TestSignUpAssignsTheDefaultRole(t *testing.T) {
+	fixture := newAuthFixture()
+
+	response, err := fixture.service.SignUp(context.Background(), SignUpInput{Handle: "ada", Email: "ada@example.com", Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("SignUp failed: %v", err)
+	}
+
+	if _, ok := fixture.users.users[response.User.ID]; !ok {
+		t.Fatalf("user %s was not stored", response.User.ID)
+	}
+	if roles := fixture.roles.roles[response.User.ID]; len(roles) != 1 || roles[0] != commons.DefaultUserRole {
+		t.Fatalf("user has roles %v, want [%s]", roles, commons.DefaultUserRole)
+	}
+	if response.Token == nil || response.Token.AccessToken == "" {
+		t.Fatalf("SignUp returned no access token")
+	}
+}
+
+func TestSignUpStoresNoUserWithoutTheDefaultRole(t *testing.T) {
+	fixture := newAuthFixture()
+	fixture.roles.assignErr = errors.New("role insert failed")
+
+	_, err := fixture.service.SignUp(context.Background(), SignUpInput{Handle: "ada", Email: "ada@example.com", Password: "correct horse"})
+	if !errors.Is(err, fixture.roles.assignErr) {
+		t.Fatalf("SignUp error = %v, want %v", err, fixture.roles.assignErr)
+	}
+	if len(fixture.users.users) != 0 {
+		t.Fatalf("stored %d users, want none", len(fixture.users.users))
+	}
+}
+
+func TestSignUpRejectsATakenEmail(t *testing.T) {
+	fixture := newAuthFixture()
+	input := SignUpInput{Handle: "ada", Email: "ada@example.com", Password: "correct horse"}
+	if _, err := fixture.service.SignUp(context.Background(), input); err != nil {
+		t.Fatalf("SignUp failed: %v", err)
+	}
+
+	input.Handle = "ada2"
+	if _, err := fixture.service.SignUp(context.Background(), input); err != commons.ErrEmailTaken {
+		t.Fatalf("second SignUp error = %v, want ErrEmailTaken", err)
+	}
+}
+
+func TestSignInAfterPasswordReset(t *testing.T) {
+	fixture := newAuthFixture()
+	ctx := context.Background()
+	if _, err := fixture.service.SignUp(ctx, SignUpInput{Handle: "ada", Email: "ada@example.com", Password: "old password"}); err != nil {
+		t.Fatalf("SignUp failed: %v", err)
+	}
+
+	if err := fixture.service.ForgotPassword(ctx, ForgotPasswordInput{Email: "ada@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword failed: %v", err)
+	}
+	if len(fixture.resetTokens.tokens) != 1 || len(fixture.notifier.events) != 1 {
+		t.Fatalf("ForgotPassword created %d tokens and sent %d emails, want 1 each", len(fixture.resetTokens.tokens), len(fixture.notifier.events))
+	}
+	link, err := url.Parse(fixture.notifier.events[0].Link)
+	if err != nil {
+		t.Fatalf("password reset link %q is invalid: %v", fixture.notifier.events[0].Link, err)
+	}
+	token := link.Query().Get("token")
+
+	if err := fixture.service.ResetPassword(ctx, ResetPasswordInput{Token: token, NewPassword: "new password"}); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+
+	if _, err := fixture.service.SignIn(ctx, SignInInput{Email: "ada@example.com", Password: "new password"}); err != nil {
+		t.Fatalf("SignIn with the new password failed: %v", err)
+	}
+	if _, err := fixture.service.SignIn(ctx, SignInInput{Email: "ada@example.com", Password: "old password"}); err != commons.ErrInvalidCredentials {
+		t.Fatalf("SignIn with the old password error = %v, want ErrInvalidCredentials", err)
+	}
+	if err := fixture.service.ResetPassword(ctx, ResetPasswordInput{Token: token, NewPassword: "other password"}); err != commons.ErrInvalidInput {
+		t.Fatalf("second ResetPassword error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestForgotPasswordForAnUnknownEmail(t *testing.T) {
+	fixture := newAuthFixture()
+
+	if err := fixture.service.ForgotPassword(context.Background(), ForgotPasswordInput{Email: "nobody@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword error = %v, want none", err)
+	}
+	if len(fixture.resetTokens.tokens) != 0 || len(fixture.notifier.events) != 0 {
+		t.Fatalf("ForgotPassword created %d tokens and sent %d emails, want none", len(fixture.resetTokens.tokens), len(fixture.notifier.events))
+	}
+}
@@ -1,14 +1,37 @@
 package auth
 
+import (
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
 type SignUpInput struct {
-	Handle   string `json:"handle"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Handle   string     `json:"handle"`
+	Email    string     `json:"email"`
+	Password string     `json:"password"`
+	Client   ClientInfo `json:"-"`
 }
 
 type SignInInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string     `json:"email"`
+	Password string     `json:"password"`
+	Client   ClientInfo `json:"-"`
+}
+
+// ClientInfo describes the client a session was started or refreshed from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// AccessTokenInfo holds the claims of the access token a request was
+// authenticated with.
+type AccessTokenInfo struct {
+	UserID    string
+	SessionID string
+	TokenID   string
+	ExpiresAt time.Time
 }
 
 type ForgotPasswordInput struct {
@@ -27,13 +50,36 @@ type TokenResponse struct {
 }
 
 type UserResponse struct {
-	ID     string `json:"id"`
-	Handle string `json:"handle"`
-	Email  string `json:"email"`
-	Status string `json:"status"`
+	ID     string   `json:"id"`
+	Handle string   `json:"handle"`
+	Email  string   `json:"email"`
+	Status string   `json:"status"`
+	Roles  []string `json:"roles,omitempty"`
 }
 
 type AuthResponse struct {
-	User  UserResponse  `json:"user"`
+	User  UserResponse   `json:"user"`
 	Token *TokenResponse `json:"token"`
 }
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func ToSessionResponse(session commons.Session, current bool) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    current,
+	}
+}
@@ -0,0 +1,331 @@
+package comment
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, comment commons.TaskComment) (commons.TaskComment, error)
+	GetByID(ctx context.Context, id string) (commons.TaskComment, error)
+	GetByTaskID(ctx context.Context, taskID string) ([]commons.TaskComment, error)
+	UpdateBody(ctx context.Context, id string, body string, editedBy string) (commons.TaskComment, error)
+	Delete(ctx context.Context, id string) error
+	GetEdits(ctx context.Context, commentID string) ([]commons.TaskCommentEdit, error)
+	AddMentions(ctx context.Context, commentID string, userIDs []string) error
+}
+
+// UnitOfWork runs the writes of a comment and its mentions in one
+// transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos commons.Repositories) error) error
+}
+
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (commons.Task, error)
+}
+
+type UserRepository interface {
+	GetByHandle(ctx context.Context, handle string) (commons.User, error)
+}
+
+type Authorizer interface {
+	AuthorizeTask(ctx context.Context, task commons.Task, userID string, requiredRole string) error
+}
+
+type Notifier interface {
+	SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error
+}
+
+// mentionRegex matches @handle tokens that are not part of a word or an email address.
+var mentionRegex = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+
+type Service struct {
+	logger      commons.Logger
+	commentRepo Repository
+	taskRepo    TaskRepository
+	userRepo    UserRepository
+	unitOfWork  UnitOfWork
+	authorizer  Authorizer
+	notifier    Notifier
+}
+
+func NewService(logger commons.Logger, commentRepo Repository, taskRepo TaskRepository, userRepo UserRepository, unitOfWork UnitOfWork, authorizer Authorizer, notifier Notifier) *Service {
+	return &Service{
+		logger:      logger,
+		commentRepo: commentRepo,
+		taskRepo:    taskRepo,
+		userRepo:    userRepo,
+		unitOfWork:  unitOfWork,
+		authorizer:  authorizer,
+		notifier:    notifier,
+	}
+}
+
+func (s *Service) ListComments(ctx context.Context, taskID string, userID string) ([]CommentResponse, error) {
+	if _, err := s.getAuthorizedTask(ctx, taskID, userID, commons.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCommentThreads(comments), nil
+}
+
+func (s *Service) CreateComment(ctx context.Context, taskID string, userID string, input CreateCommentInput) (*CommentResponse, error) {
+	task, err := s.getAuthorizedTask(ctx, taskID, userID, commons.ProjectRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ParentID != nil {
+		parent, err := s.commentRepo.GetByID(ctx, *input.ParentID)
+		if err != nil {
+			if err == commons.ErrNotFound {
+				return nil, commons.ErrInvalidInput
+			}
+			return nil, err
+		}
+		if parent.TaskID != taskID || parent.Deleted {
+			return nil, commons.ErrInvalidInput
+		}
+	}
+
+	now := time.Now()
+	var created commons.TaskComment
+	var mentioned []string
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		created, err = repos.TaskComments.Create(ctx, commons.TaskComment{
+			ID:        uuid.New().String(),
+			TaskID:    taskID,
+			AuthorID:  userID,
+			ParentID:  input.ParentID,
+			Body:      input.Body,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.recordMentions(ctx, repos.TaskComments, task, created, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	created.MentionedUserIDs = mentioned
+	s.notifyMentions(task, created, mentioned)
+
+	response := toCommentResponse(created)
+	return &response, nil
+}
+
+func (s *Service) UpdateComment(ctx context.Context, taskID string, commentID string, userID string, input UpdateCommentInput) (*CommentResponse, error) {
+	task, err := s.getAuthorizedTask(ctx, taskID, userID, commons.ProjectRoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getTaskComment(ctx, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.AuthorID != userID {
+		return nil, commons.ErrForbidden
+	}
+
+	var updated commons.TaskComment
+	var mentioned []string
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		updated, err = repos.TaskComments.UpdateBody(ctx, commentID, input.Body, userID)
+		if err != nil {
+			return err
+		}
+
+		mentioned, err = s.recordMentions(ctx, repos.TaskComments, task, updated, updated.MentionedUserIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	updated.MentionedUserIDs = append(updated.MentionedUserIDs, mentioned...)
+	s.notifyMentions(task, updated, mentioned)
+
+	response := toCommentResponse(updated)
+	return &response, nil
+}
+
+// DeleteComment soft deletes a comment. Authors may delete their own
+// comments, task owners any comment.
+func (s *Service) DeleteComment(ctx context.Context, taskID string, commentID string, userID string) error {
+	task, err := s.getAuthorizedTask(ctx, taskID, userID, commons.ProjectRoleViewer)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.getTaskComment(ctx, taskID, commentID)
+	if err != nil {
+		return err
+	}
+
+	requiredRole := commons.ProjectRoleOwner
+	if comment.AuthorID == userID {
+		requiredRole = commons.ProjectRoleEditor
+	}
+
+	if err := s.authorizer.AuthorizeTask(ctx, task, userID, requiredRole); err != nil {
+		return err
+	}
+
+	return s.commentRepo.Delete(ctx, commentID)
+}
+
+func (s *Service) GetCommentEdits(ctx context.Context, taskID string, commentID string, userID string) ([]CommentEditResponse, error) {
+	if _, err := s.getAuthorizedTask(ctx, taskID, userID, commons.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getTaskComment(ctx, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment.Deleted {
+		return nil, commons.ErrNotFound
+	}
+
+	edits, err := s.commentRepo.GetEdits(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]CommentEditResponse, len(edits))
+	for i, edit := range edits {
+		response[i] = toCommentEditResponse(edit)
+	}
+
+	return response, nil
+}
+
+// getAuthorizedTask returns the task if userID holds at least requiredRole on it.
+func (s *Service) getAuthorizedTask(ctx context.Context, taskID string, userID string, requiredRole string) (commons.Task, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return commons.Task{}, err
+	}
+
+	if err := s.authorizer.AuthorizeTask(ctx, task, userID, requiredRole); err != nil {
+		return commons.Task{}, err
+	}
+
+	return task, nil
+}
+
+func (s *Service) getTaskComment(ctx context.Context, taskID string, commentID string) (commons.TaskComment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return commons.TaskComment{}, err
+	}
+
+	if comment.TaskID != taskID {
+		return commons.TaskComment{}, commons.ErrNotFound
+	}
+
+	return comment, nil
+}
+
+// recordMentions resolves the @handles in the comment body and stores the
+// ones not already mentioned who can see the task through commentRepo. It
+// returns the newly mentioned user IDs, to be notified once the comment is
+// committed.
+func (s *Service) recordMentions(ctx context.Context, commentRepo Repository, task commons.Task, comment commons.TaskComment, alreadyMentioned []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, userID := range alreadyMentioned {
+		seen[userID] = true
+	}
+
+	var mentioned []string
+	for _, handle := range parseMentions(comment.Body) {
+		user, err := s.userRepo.GetByHandle(ctx, handle)
+		if err != nil {
+			return nil, err
+		}
+		if user.ID == "" || user.ID == comment.AuthorID || seen[user.ID] {
+			continue
+		}
+		// Users who cannot see the task are not notified about it.
+		if err := s.authorizer.AuthorizeTask(ctx, task, user.ID, commons.ProjectRoleViewer); err != nil {
+			if err == commons.ErrForbidden {
+				continue
+			}
+			return nil, err
+		}
+		seen[user.ID] = true
+		mentioned = append(mentioned, user.ID)
+	}
+
+	if len(mentioned) == 0 {
+		return nil, nil
+	}
+
+	if err := commentRepo.AddMentions(ctx, comment.ID, mentioned); err != nil {
+		return nil, err
+	}
+
+	return mentioned, nil
+}
+
+func (s *Service) notifyMentions(task commons.Task, comment commons.TaskComment, recipientIDs []string) {
+	if len(recipientIDs) == 0 {
+		return
+	}
+
+	event := commons.GRPCEvent{
+		TaskId:        task.ID,
+		CorrelationId: uuid.New().String(),
+		Event:         commons.NotificationEventTaskMentioned,
+		RecipientIDs:  recipientIDs,
+		Title:         fmt.Sprintf("You were mentioned on %q", task.Title),
+		Message:       comment.Body,
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(commons.WithCorrelationID(context.Background(), event.CorrelationId), 10*time.Second)
+		defer cancel()
+
+		if err := s.notifier.SendNotification(ctx, event); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to send mention notification", "comment_id", comment.ID, "error", err)
+		}
+	}()
+}
+
+// parseMentions returns the distinct handles mentioned in body, in order of
+// appearance.
+func parseMentions(body string) []string {
+	var handles []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionRegex.FindAllStringSubmatch(body, -1) {
+		// Trailing punctuation ends the sentence rather than the handle.
+		handle := strings.TrimRight(match[1], ".-")
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+
+	return handles
+}
@@ -0,0 +1,108 @@
+package comment
+
+import (
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type CreateCommentInput struct {
+	Body     string  `json:"body"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+type UpdateCommentInput struct {
+	Body string `json:"body"`
+}
+
+type CommentResponse struct {
+	ID               string            `json:"id"`
+	TaskID           string            `json:"task_id"`
+	AuthorID         string            `json:"author_id"`
+	ParentID         *string           `json:"parent_id,omitempty"`
+	Body             string            `json:"body"`
+	Edited           bool              `json:"edited"`
+	Deleted          bool              `json:"deleted"`
+	MentionedUserIDs []string          `json:"mentioned_user_ids"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	Replies          []CommentResponse `json:"replies"`
+}
+
+type CommentEditResponse struct {
+	ID           string    `json:"id"`
+	CommentID    string    `json:"comment_id"`
+	PreviousBody string    `json:"previous_body"`
+	EditedBy     string    `json:"edited_by"`
+	EditedAt     time.Time `json:"edited_at"`
+}
+
+func toCommentResponse(comment commons.TaskComment) CommentResponse {
+	response := CommentResponse{
+		ID:               comment.ID,
+		TaskID:           comment.TaskID,
+		AuthorID:         comment.AuthorID,
+		ParentID:         comment.ParentID,
+		Body:             comment.Body,
+		Edited:           comment.Edited,
+		Deleted:          comment.Deleted,
+		MentionedUserIDs: comment.MentionedUserIDs,
+		CreatedAt:        comment.CreatedAt,
+		UpdatedAt:        comment.UpdatedAt,
+		Replies:          []CommentResponse{},
+	}
+
+	// Deleted comments stay in the thread so replies keep their parent, but
+	// their content is no longer exposed.
+	if comment.Deleted {
+		response.Body = ""
+		response.MentionedUserIDs = []string{}
+	}
+
+	if response.MentionedUserIDs == nil {
+		response.MentionedUserIDs = []string{}
+	}
+
+	return response
+}
+
+// toCommentThreads nests replies under their parent comment. comments must be
+// ordered by creation time.
+func toCommentThreads(comments []commons.TaskComment) []CommentResponse {
+	children := make(map[string][]commons.TaskComment)
+	var roots []commons.TaskComment
+
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+
+	var build func(comment commons.TaskComment) CommentResponse
+	build = func(comment commons.TaskComment) CommentResponse {
+		response := toCommentResponse(comment)
+		for _, reply := range children[comment.ID] {
+			response.Replies = append(response.Replies, build(reply))
+		}
+		return response
+	}
+
+	threads := make([]CommentResponse, len(roots))
+	for i, root := range roots {
+		threads[i] = build(root)
+	}
+
+	return threads
+}
+
+func toCommentEditResponse(edit commons.TaskCommentEdit) CommentEditResponse {
+	return CommentEditResponse{
+		ID:           edit.ID,
+		CommentID:    edit.CommentID,
+		PreviousBody: edit.PreviousBody,
+		EditedBy:     edit.EditedBy,
+		EditedAt:     edit.EditedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"sama/go-task-management/commons"
+)
+
+type Repository interface {
+	GetByTaskID(ctx context.Context, taskID string) ([]commons.Email, error)
+}
+
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (commons.Task, error)
+}
+
+type Authorizer interface {
+	AuthorizeTask(ctx context.Context, task commons.Task, userID string, requiredRole string) error
+}
+
+type Service struct {
+	logger     commons.Logger
+	emailRepo  Repository
+	taskRepo   TaskRepository
+	authorizer Authorizer
+}
+
+func NewService(logger commons.Logger, emailRepo Repository, taskRepo TaskRepository, authorizer Authorizer) *Service {
+	return &Service{
+		logger:     logger,
+		emailRepo:  emailRepo,
+		taskRepo:   taskRepo,
+		authorizer: authorizer,
+	}
+}
+
+// ListTaskEmails returns the emails sent for a task, oldest first. Any
+// project member of the task may see them.
+func (s *Service) ListTaskEmails(ctx context.Context, taskID string, userID string) ([]EmailResponse, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, commons.ErrNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.authorizer.AuthorizeTask(ctx, task, userID, commons.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	emails, err := s.emailRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toEmailResponses(emails), nil
+}
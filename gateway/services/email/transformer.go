@@ -0,0 +1,52 @@
+package email
+
+import (
+	"encoding/json"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type EmailResponse struct {
+	ID                string          `json:"id"`
+	TaskID            *string         `json:"task_id,omitempty"`
+	CorrelationID     string          `json:"correlation_id"`
+	RecipientID       *string         `json:"recipient_id,omitempty"`
+	Recipient         string          `json:"recipient"`
+	Template          string          `json:"template"`
+	Payload           json.RawMessage `json:"payload" swaggertype:"object"`
+	Status            string          `json:"status"`
+	Attempts          int             `json:"attempts"`
+	LastError         string          `json:"last_error,omitempty"`
+	ProviderMessageID string          `json:"provider_message_id,omitempty"`
+	SentAt            *time.Time      `json:"sent_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+func toEmailResponse(email commons.Email) EmailResponse {
+	return EmailResponse{
+		ID:                email.ID,
+		TaskID:            email.TaskID,
+		CorrelationID:     email.CorrelationID,
+		RecipientID:       email.RecipientID,
+		Recipient:         email.Recipient,
+		Template:          email.Template,
+		Payload:           email.Payload,
+		Status:            email.Status,
+		Attempts:          email.Attempts,
+		LastError:         email.LastError,
+		ProviderMessageID: email.ProviderMessageID,
+		SentAt:            email.SentAt,
+		CreatedAt:         email.CreatedAt,
+		UpdatedAt:         email.UpdatedAt,
+	}
+}
+
+func toEmailResponses(emails []commons.Email) []EmailResponse {
+	responses := make([]EmailResponse, len(emails))
+	for i, email := range emails {
+		responses[i] = toEmailResponse(email)
+	}
+	return responses
+}
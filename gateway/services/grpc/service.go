@@ -15,7 +15,7 @@ type Service struct {
 	notificationServiceClient pb.NotificationServiceClient
 }
 
-func NewService( logger commons.Logger, notificationServiceClient pb.NotificationServiceClient) *Service {
+func NewService(logger commons.Logger, notificationServiceClient pb.NotificationServiceClient) *Service {
 	return &Service{
 		logger:                    logger,
 		notificationServiceClient: notificationServiceClient,
@@ -23,12 +23,18 @@ func NewService( logger commons.Logger, notificationServiceClient pb.Notificatio
 }
 
 func (s *Service) SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error {
-	s.logger.Info("GRPCService::Sending event", grpcEvent)
+	ctx = commons.WithCorrelationID(ctx, grpcEvent.CorrelationId)
+	s.logger.InfoContext(ctx, "Sending notification", "event", grpcEvent.Event, "types", grpcEvent.Types, "task_id", grpcEvent.TaskId, "recipients", len(grpcEvent.RecipientIDs))
 
 	notification := &pb.SendNotificationRequest{
 		TaskId:        grpcEvent.TaskId,
 		CorrelationId: grpcEvent.CorrelationId,
 		Types:         convertToNotificationTypes(grpcEvent.Types),
+		Event:         grpcEvent.Event,
+		RecipientIds:  grpcEvent.RecipientIDs,
+		Title:         grpcEvent.Title,
+		Message:       grpcEvent.Message,
+		Link:          grpcEvent.Link,
 	}
 
 	_, err := s.notificationServiceClient.SendNotification(
@@ -38,7 +44,7 @@ func (s *Service) SendNotification(ctx context.Context, grpcEvent commons.GRPCEv
 	)
 
 	if err != nil {
-		s.logger.Error("GRPCService::Failed to send notification", "error", err)
+		s.logger.ErrorContext(ctx, "Failed to send notification", "error", err)
 		return err
 	}
 
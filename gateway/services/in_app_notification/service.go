@@ -10,10 +10,10 @@ import (
 )
 
 type Repository interface {
-	Create(notification commons.InAppNotification) (commons.InAppNotification, error)
-	GetByUserID(userID string) ([]commons.InAppNotification, error)
-	UpdateOnRead(id string, isRead bool) error
-	Delete(id string) error
+	Create(ctx context.Context, notification commons.InAppNotification) (commons.InAppNotification, error)
+	GetByUserID(ctx context.Context, userID string) ([]commons.InAppNotification, error)
+	UpdateOnRead(ctx context.Context, id string, isRead bool) error
+	Delete(ctx context.Context, id string) error
 }
 
 type Service struct {
@@ -39,38 +39,38 @@ func (s *Service) CreateNotification(ctx context.Context, input CreateNotificati
 		UpdatedAt:   time.Now(),
 	}
 
-	createdNotification, err := s.notificationRepo.Create(notification)
+	createdNotification, err := s.notificationRepo.Create(ctx, notification)
 	if err != nil {
 		return nil, err
 	}
 
-	response := toNotificationResponse(createdNotification)
+	response := ToNotificationResponse(createdNotification)
 	return &response, nil
 }
 
 func (s *Service) GetUserNotifications(ctx context.Context, userID string) ([]NotificationResponse, error) {
-	notifications, err := s.notificationRepo.GetByUserID(userID)
+	notifications, err := s.notificationRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	response := make([]NotificationResponse, len(notifications))
 	for i, notification := range notifications {
-		response[i] = toNotificationResponse(notification)
+		response[i] = ToNotificationResponse(notification)
 	}
 
 	return response, nil
 }
 
 func (s *Service) MarkNotificationAsRead(ctx context.Context, notificationID string, userID string) error {
-	notifications, err := s.notificationRepo.GetByUserID(userID)
+	notifications, err := s.notificationRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
 	for _, notification := range notifications {
 		if notification.ID == notificationID {
-			return s.notificationRepo.UpdateOnRead(notificationID, true)
+			return s.notificationRepo.UpdateOnRead(ctx, notificationID, true)
 		}
 	}
 
@@ -78,14 +78,14 @@ func (s *Service) MarkNotificationAsRead(ctx context.Context, notificationID str
 }
 
 func (s *Service) DeleteNotification(ctx context.Context, notificationID string, userID string) error {
-	notifications, err := s.notificationRepo.GetByUserID(userID)
+	notifications, err := s.notificationRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
 
 	for _, notification := range notifications {
 		if notification.ID == notificationID {
-			return s.notificationRepo.Delete(notificationID)
+			return s.notificationRepo.Delete(ctx, notificationID)
 		}
 	}
 
@@ -20,7 +20,7 @@ type NotificationResponse struct {
 	UpdatedAt   string `json:"updated_at"`
 }
 
-func toNotificationResponse(notification commons.InAppNotification) NotificationResponse {
+func ToNotificationResponse(notification commons.InAppNotification) NotificationResponse {
 	return NotificationResponse{
 		ID:        notification.ID,
 		UserID:    notification.UserID,
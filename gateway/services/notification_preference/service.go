@@ -0,0 +1,63 @@
+package notification_preference
+
+import (
+	"context"
+
+	"sama/go-task-management/commons"
+)
+
+type Repository interface {
+	Get(ctx context.Context, userID string) (commons.NotificationPreferences, error)
+	Save(ctx context.Context, preferences commons.NotificationPreferences) error
+}
+
+// UpdateInput replaces the notification preferences of a user. Events and
+// channels left out keep their defaults; nil quiet hours turn them off, an
+// empty delivery means immediate and an empty digest frequency daily.
+type UpdateInput struct {
+	Events          map[string]map[string]bool
+	QuietHours      *commons.QuietHours
+	Delivery        string
+	DigestFrequency string
+}
+
+type Service struct {
+	logger         commons.Logger
+	preferenceRepo Repository
+}
+
+func NewService(logger commons.Logger, preferenceRepo Repository) *Service {
+	return &Service{
+		logger:         logger,
+		preferenceRepo: preferenceRepo,
+	}
+}
+
+// GetPreferences returns the notification preferences of a user, with
+// defaults for everything the user has not chosen.
+func (s *Service) GetPreferences(ctx context.Context, userID string) (commons.NotificationPreferences, error) {
+	return s.preferenceRepo.Get(ctx, userID)
+}
+
+// UpdatePreferences replaces the notification preferences of a user.
+func (s *Service) UpdatePreferences(ctx context.Context, userID string, input UpdateInput) (commons.NotificationPreferences, error) {
+	preferences := commons.DefaultNotificationPreferences(userID)
+	for event, channels := range input.Events {
+		for channel, enabled := range channels {
+			preferences.Events[event][channel] = enabled
+		}
+	}
+	preferences.QuietHours = input.QuietHours
+	if input.Delivery != "" {
+		preferences.Delivery = input.Delivery
+	}
+	if input.DigestFrequency != "" {
+		preferences.DigestFrequency = input.DigestFrequency
+	}
+
+	if err := s.preferenceRepo.Save(ctx, preferences); err != nil {
+		return commons.NotificationPreferences{}, err
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
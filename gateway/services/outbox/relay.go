@@ -0,0 +1,259 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("sama/go-task-management/gateway/services/outbox")
+
+const (
+	pollInterval = 2 * time.Second
+	batchSize    = 50
+	// lease is how long a claimed event is left alone before another relay
+	// may pick it up again, e.g. because this one crashed.
+	lease          = time.Minute
+	publishTimeout = 10 * time.Second
+	maxAttempts    = 10
+	baseBackoff    = 5 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+type Repository interface {
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]commons.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+	MarkFailed(ctx context.Context, id string, lastError string) error
+}
+
+type Notifier interface {
+	SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error
+}
+
+type EventRepository interface {
+	Create(ctx context.Context, systemEvent commons.TaskSystemEvent, delay int) (commons.TaskSystemEvent, error)
+}
+
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (commons.Task, error)
+}
+
+type WebhookRepository interface {
+	Enqueue(ctx context.Context, event commons.WebhookEvent) (int, error)
+}
+
+// emittedEvents are the system events recorded once a task event has been
+// published.
+var emittedEvents = map[string]struct {
+	action  string
+	message string
+	delay   int
+}{
+	commons.TaskEventCreated: {"api:event:task-created", "Task created event emitted", 2},
+	commons.TaskEventUpdated: {"api:event:task-updated", "Task updated event emitted", 3},
+	commons.TaskEventDeleted: {"api:event:task-deleted", "Task deleted event emitted", 4},
+}
+
+// Relay publishes the events stored in the outbox to the notification
+// service and queues them for the webhooks subscribed to them. Delivery is
+// at least once: an event is only marked published after the notification
+// service accepted it, so it may be sent again if the relay stops in
+// between.
+type Relay struct {
+	logger      commons.Logger
+	outboxRepo  Repository
+	notifier    Notifier
+	eventRepo   EventRepository
+	taskRepo    TaskRepository
+	webhookRepo WebhookRepository
+}
+
+func NewRelay(logger commons.Logger, outboxRepo Repository, notifier Notifier, eventRepo EventRepository, taskRepo TaskRepository, webhookRepo WebhookRepository) *Relay {
+	return &Relay{
+		logger:      logger,
+		outboxRepo:  outboxRepo,
+		notifier:    notifier,
+		eventRepo:   eventRepo,
+		taskRepo:    taskRepo,
+		webhookRepo: webhookRepo,
+	}
+}
+
+// Start publishes due events until ctx is canceled.
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) publishDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := r.outboxRepo.ClaimDue(ctx, batchSize, lease)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "Failed to claim outbox events", "error", err)
+			return
+		}
+
+		for _, event := range events {
+			r.handle(ctx, event)
+		}
+
+		if len(events) < batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) handle(ctx context.Context, event commons.OutboxEvent) {
+	ctx = commons.WithCorrelationID(ctx, event.CorrelationID)
+	// Publishing continues the trace of the request that raised the event.
+	ctx, span := tracer.Start(commons.ExtractTraceContext(ctx, event.TraceContext), "outbox publish "+event.EventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("outbox.event_id", event.ID),
+			attribute.String("task.id", event.AggregateID),
+			attribute.String("correlation_id", event.CorrelationID),
+			attribute.Int("outbox.attempt", event.Attempts),
+		),
+	)
+	defer span.End()
+
+	err := r.publish(ctx, event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	// Record the outcome even if the relay is stopping meanwhile.
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		if err := r.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to mark outbox event published", "event_id", event.ID, "error", err)
+		}
+		return
+	}
+
+	if event.Attempts >= maxAttempts {
+		r.logger.ErrorContext(ctx, "Giving up on outbox event", "event_id", event.ID, "attempts", event.Attempts, "error", err)
+		if err := r.outboxRepo.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to mark outbox event failed", "event_id", event.ID, "error", err)
+		}
+		return
+	}
+
+	delay := commons.Backoff(event.Attempts, baseBackoff, maxBackoff)
+	r.logger.WarnContext(ctx, "Failed to publish outbox event, retrying", "event_id", event.ID, "attempt", event.Attempts, "retry_in", delay, "error", err)
+	if err := r.outboxRepo.MarkRetry(ctx, event.ID, err.Error(), time.Now().Add(delay)); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reschedule outbox event", "event_id", event.ID, "error", err)
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, event commons.OutboxEvent) error {
+	emitted, ok := emittedEvents[event.EventType]
+	if event.AggregateType != commons.OutboxAggregateTask || !ok {
+		return fmt.Errorf("unknown outbox event %s/%s", event.AggregateType, event.EventType)
+	}
+
+	var payload commons.TaskEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid outbox payload: %w", err)
+	}
+
+	// Webhook deliveries are queued first: queueing them again on a retry is
+	// a no-op, sending the notification again is not.
+	if err := r.queueWebhooks(ctx, event, payload); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	if payload.NotificationEvent != "" || len(payload.NotificationTypes) > 0 {
+		sendCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		defer cancel()
+
+		err := r.notifier.SendNotification(sendCtx, commons.GRPCEvent{
+			TaskId:        event.AggregateID,
+			CorrelationId: event.CorrelationID,
+			Types:         payload.NotificationTypes,
+			Event:         payload.NotificationEvent,
+			RecipientIDs:  payload.RecipientIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+	}
+
+	// The event is out; a missing system event is not worth sending it twice.
+	_, err := r.eventRepo.Create(ctx, commons.TaskSystemEvent{
+		TaskId:        event.AggregateID,
+		CorrelationId: event.CorrelationID,
+		Origin:        "API Gateway",
+		Action:        emitted.action,
+		Message:       emitted.message,
+		JsonData:      "{}",
+	}, emitted.delay)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to create task system event", "action", emitted.action, "error", err)
+	}
+
+	return nil
+}
+
+// queueWebhooks queues the event, and task.assigned if the task got a new
+// assignee, for the webhooks subscribed to them.
+func (r *Relay) queueWebhooks(ctx context.Context, event commons.OutboxEvent, payload commons.TaskEventPayload) error {
+	task, err := r.taskRepo.GetByID(ctx, event.AggregateID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.ID == "" {
+		// Hard deleted tasks are only known by their ID.
+		task = commons.Task{ID: event.AggregateID, Deleted: true}
+	}
+
+	userIDs := []string{task.CreatorID}
+	if task.AssigneeID != nil {
+		userIDs = append(userIDs, *task.AssigneeID)
+	}
+
+	webhookEvent := commons.WebhookEvent{
+		ID:        event.ID,
+		Type:      event.EventType,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserIDs:   userIDs,
+		Data:      commons.WebhookTaskData{Task: task},
+		CreatedAt: event.CreatedAt,
+	}
+	if _, err := r.webhookRepo.Enqueue(ctx, webhookEvent); err != nil {
+		return err
+	}
+
+	if payload.NotificationEvent == commons.NotificationEventTaskAssigned {
+		webhookEvent.ID = uuid.NewSHA1(uuid.NameSpaceOID, []byte(event.ID+"/"+commons.WebhookEventTaskAssigned)).String()
+		webhookEvent.Type = commons.WebhookEventTaskAssigned
+		if _, err := r.webhookRepo.Enqueue(ctx, webhookEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type retry struct {
+	attempts      int
+	nextAttemptAt time.Time
+}
+
+// fakeRepository holds a single event and claims it whenever it is pending,
+// as if the clock had moved past its next attempt.
+type fakeRepository struct {
+	event     commons.OutboxEvent
+	retries   []retry
+	failed    bool
+	published bool
+}
+
+func (r *fakeRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]commons.OutboxEvent, error) {
+	if r.event.Status != commons.OutboxStatusPending {
+		return nil, nil
+	}
+	r.event.Attempts++
+	return []commons.OutboxEvent{r.event}, nil
+}
+
+func (r *fakeRepository) MarkPublished(ctx context.Context, id string) error {
+	r.event.Status = commons.OutboxStatusPublished
+	r.published = true
+	return nil
+}
+
+func (r *fakeRepository) MarkRetry(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	r.event.LastError = lastError
+	r.retries = append(r.retries, retry{attempts: r.event.Attempts, nextAttemptAt: nextAttemptAt})
+	return nil
+}
+
+func (r *fakeRepository) MarkFailed(ctx context.Context, id string, lastError string) error {
+	r.event.Status = commons.OutboxStatusFailed
+	r.event.LastError = lastError
+	r.failed = true
+	return nil
+}
+
+// fakeNotifier fails the first failures notifications it is sent.
+type fakeNotifier struct {
+	failures int
+	sent     int
+}
+
+func (n *fakeNotifier) SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error {
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("notification service unavailable")
+	}
+	n.sent++
+	return nil
+}
+
+type fakeEventRepository struct{}
+
+func (r fakeEventRepository) Create(ctx context.Context, systemEvent commons.TaskSystemEvent, delay int) (commons.TaskSystemEvent, error) {
+	return systemEvent, nil
+}
+
+type fakeTaskRepository struct{}
+
+func (r fakeTaskRepository) GetByID(ctx context.Context, id string) (commons.Task, error) {
+	return commons.Task{ID: id, CreatorID: "creator"}, nil
+}
+
+type fakeWebhookRepository struct{}
+
+func (r fakeWebhookRepository) Enqueue(ctx context.Context, event commons.WebhookEvent) (int, error) {
+	return 0, nil
+}
+
+func newTestRelay(t *testing.T, notifier *fakeNotifier) (*Relay, *fakeRepository) {
+	payload, err := json.Marshal(commons.TaskEventPayload{
+		NotificationEvent: commons.NotificationEventTaskAssigned,
+		RecipientIDs:      []string{"assignee"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &fakeRepository{event: commons.OutboxEvent{
+		ID:            "event",
+		AggregateType: commons.OutboxAggregateTask,
+		AggregateID:   "task",
+		EventType:     commons.TaskEventUpdated,
+		Payload:       payload,
+		Status:        commons.OutboxStatusPending,
+	}}
+	relay := NewRelay(commons.NewLoggerWithOutput(io.Discard, "gateway"), repo, notifier, fakeEventRepository{}, fakeTaskRepository{}, fakeWebhookRepository{})
+	return relay, repo
+}
+
+func TestRelayRetriesFailedPublishes(t *testing.T) {
+	relay, repo := newTestRelay(t, &fakeNotifier{failures: 2})
+
+	for range 3 {
+		before := time.Now()
+		relay.publishDue(context.Background())
+		after := time.Now()
+
+		if len(repo.retries) == 0 || repo.published {
+			continue
+		}
+		last := repo.retries[len(repo.retries)-1]
+		delay := commons.Backoff(last.attempts, baseBackoff, maxBackoff)
+		if last.nextAttemptAt.Before(before.Add(delay)) || last.nextAttemptAt.After(after.Add(delay)) {
+			t.Fatalf("attempt %d retries at %v, want %v from now", last.attempts, last.nextAttemptAt, delay)
+		}
+	}
+
+	if len(repo.retries) != 2 || repo.retries[0].attempts != 1 || repo.retries[1].attempts != 2 {
+		t.Fatalf("retries = %+v, want attempts 1 and 2", repo.retries)
+	}
+	if !repo.published || repo.failed {
+		t.Fatalf("published = %v, failed = %v, want the third attempt published", repo.published, repo.failed)
+	}
+}
+
+func TestRelayGivesUpAfterMaxAttempts(t *testing.T) {
+	notifier := &fakeNotifier{failures: maxAttempts + 1}
+	relay, repo := newTestRelay(t, notifier)
+
+	for range maxAttempts + 1 {
+		relay.publishDue(context.Background())
+	}
+
+	if len(repo.retries) != maxAttempts-1 {
+		t.Fatalf("retried %d times, want %d", len(repo.retries), maxAttempts-1)
+	}
+	if !repo.failed || repo.event.Attempts != maxAttempts {
+		t.Fatalf("failed = %v after %d attempts, want failed after %d", repo.failed, repo.event.Attempts, maxAttempts)
+	}
+	if repo.event.LastError == "" {
+		t.Fatalf("the failed event has no last error")
+	}
+	if notifier.sent != 0 || repo.published {
+		t.Fatalf("sent %d notifications, want none", notifier.sent)
+	}
+}
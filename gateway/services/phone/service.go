@@ -0,0 +1,168 @@
+package phone
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+const (
+	verificationCodeTTL     = 10 * time.Minute
+	maxVerificationAttempts = 5
+	verificationCodeDigits  = 6
+)
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id string) (commons.User, error)
+	SetPhoneNumber(ctx context.Context, id string, phoneNumber string) error
+	MarkPhoneVerified(ctx context.Context, id string, phoneNumber string) (bool, error)
+}
+
+type VerificationRepository interface {
+	Save(ctx context.Context, verification commons.PhoneVerification) error
+	ClaimAttempt(ctx context.Context, userID string, maxAttempts int) (commons.PhoneVerification, error)
+	Delete(ctx context.Context, userID string) error
+}
+
+type Notifier interface {
+	SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error
+}
+
+type Service struct {
+	logger           commons.Logger
+	userRepo         UserRepository
+	verificationRepo VerificationRepository
+	notifier         Notifier
+}
+
+func NewService(logger commons.Logger, userRepo UserRepository, verificationRepo VerificationRepository, notifier Notifier) *Service {
+	return &Service{
+		logger:           logger,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		notifier:         notifier,
+	}
+}
+
+// GetPhone returns the phone number of a user and whether it is verified.
+func (s *Service) GetPhone(ctx context.Context, userID string) (PhoneResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return PhoneResponse{}, err
+	}
+	if user.ID == "" {
+		return PhoneResponse{}, commons.ErrNotFound
+	}
+
+	return PhoneResponse{
+		PhoneNumber: user.PhoneNumber,
+		Verified:    user.PhoneVerifiedAt != nil,
+		VerifiedAt:  user.PhoneVerifiedAt,
+	}, nil
+}
+
+// SetPhoneNumber replaces the phone number of a user and texts it a
+// verification code. SMS notifications are only sent to the number once it
+// is verified. Setting the same number again sends a new code.
+func (s *Service) SetPhoneNumber(ctx context.Context, userID string, phoneNumber string) (PhoneResponse, error) {
+	code, err := generateVerificationCode()
+	if err != nil {
+		return PhoneResponse{}, err
+	}
+
+	if err := s.userRepo.SetPhoneNumber(ctx, userID, phoneNumber); err != nil {
+		return PhoneResponse{}, err
+	}
+
+	err = s.verificationRepo.Save(ctx, commons.PhoneVerification{
+		UserID:      userID,
+		PhoneNumber: phoneNumber,
+		CodeHash:    hashVerificationCode(code),
+		ExpiresAt:   time.Now().Add(verificationCodeTTL),
+	})
+	if err != nil {
+		return PhoneResponse{}, err
+	}
+
+	err = s.notifier.SendNotification(ctx, commons.GRPCEvent{
+		CorrelationId: uuid.New().String(),
+		Types:         []string{commons.NotificationChannelSMS},
+		Event:         commons.NotificationEventPhoneVerification,
+		RecipientIDs:  []string{userID},
+		Message:       fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(verificationCodeTTL.Minutes())),
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to send phone verification code", "error", err)
+		return PhoneResponse{}, err
+	}
+
+	return s.GetPhone(ctx, userID)
+}
+
+// VerifyPhoneNumber checks the code sent to the phone number of a user and
+// marks the number verified. After maxVerificationAttempts wrong codes, a
+// new code has to be requested.
+func (s *Service) VerifyPhoneNumber(ctx context.Context, userID string, code string) (PhoneResponse, error) {
+	verification, err := s.verificationRepo.ClaimAttempt(ctx, userID, maxVerificationAttempts)
+	if err == commons.ErrNotFound {
+		return PhoneResponse{}, commons.ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return PhoneResponse{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashVerificationCode(code)), []byte(verification.CodeHash)) != 1 {
+		return PhoneResponse{}, commons.ErrInvalidVerificationCode
+	}
+
+	verified, err := s.userRepo.MarkPhoneVerified(ctx, userID, verification.PhoneNumber)
+	if err != nil {
+		return PhoneResponse{}, err
+	}
+	if !verified {
+		// The phone number changed after the code was sent.
+		return PhoneResponse{}, commons.ErrInvalidVerificationCode
+	}
+
+	if err := s.verificationRepo.Delete(ctx, userID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to delete phone verification", "error", err)
+	}
+
+	return s.GetPhone(ctx, userID)
+}
+
+// RemovePhoneNumber removes the phone number of a user, so they get no more
+// SMS.
+func (s *Service) RemovePhoneNumber(ctx context.Context, userID string) error {
+	if err := s.userRepo.SetPhoneNumber(ctx, userID, ""); err != nil {
+		return err
+	}
+	return s.verificationRepo.Delete(ctx, userID)
+}
+
+func generateVerificationCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < verificationCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", verificationCodeDigits, n), nil
+}
+
+func hashVerificationCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,130 @@
+package phone
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type fakeUserRepository struct {
+	user commons.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id string) (commons.User, error) {
+	return r.user, nil
+}
+
+func (r *fakeUserRepository) SetPhoneNumber(ctx context.Context, id string, phoneNumber string) error {
+	r.user.PhoneNumber = phoneNumber
+	r.user.PhoneVerifiedAt = nil
+	return nil
+}
+
+func (r *fakeUserRepository) MarkPhoneVerified(ctx context.Context, id string, phoneNumber string) (bool, error) {
+	if r.user.PhoneNumber != phoneNumber {
+		return false, nil
+	}
+	now := time.Now()
+	r.user.PhoneVerifiedAt = &now
+	return true, nil
+}
+
+type fakeVerificationRepository struct {
+	verification *commons.PhoneVerification
+}
+
+func (r *fakeVerificationRepository) Save(ctx context.Context, verification commons.PhoneVerification) error {
+	r.verification = &verification
+	return nil
+}
+
+func (r *fakeVerificationRepository) ClaimAttempt(ctx context.Context, userID string, maxAttempts int) (commons.PhoneVerification, error) {
+	if r.verification == nil || r.verification.Attempts >= maxAttempts || !r.verification.ExpiresAt.After(time.Now()) {
+		return commons.PhoneVerification{}, commons.ErrNotFound
+	}
+	r.verification.Attempts++
+	return *r.verification, nil
+}
+
+func (r *fakeVerificationRepository) Delete(ctx context.Context, userID string) error {
+	r.verification = nil
+	return nil
+}
+
+type fakeNotifier struct {
+	events []commons.GRPCEvent
+}
+
+func (n *fakeNotifier) SendNotification(ctx context.Context, grpcEvent commons.GRPCEvent) error {
+	n.events = append(n.events, grpcEvent)
+	return nil
+}
+
+// sendCode sets the phone number of the user and returns the code texted to it.
+func sendCode(t *testing.T, service *Service, notifier *fakeNotifier) string {
+	t.Helper()
+	if _, err := service.SetPhoneNumber(context.Background(), "user", "+4915112345678"); err != nil {
+		t.Fatalf("SetPhoneNumber failed: %v", err)
+	}
+
+	var code string
+	if _, err := fmt.Sscanf(notifier.events[len(notifier.events)-1].Message, "Your verification code is %6s.", &code); err != nil {
+		t.Fatalf("no code in %q: %v", notifier.events[len(notifier.events)-1].Message, err)
+	}
+	return code
+}
+
+func TestVerifyPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		wrongGuesses int
+		wantVerified bool
+	}{
+		{"right code", 0, true},
+		{"right code after wrong ones", maxVerificationAttempts - 1, true},
+		{"right code after all attempts are used", maxVerificationAttempts, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := &fakeNotifier{}
+			service := NewService(commons.NewLoggerWithOutput(io.Discard, "gateway"), &fakeUserRepository{user: commons.User{ID: "user"}}, &fakeVerificationRepository{}, notifier)
+			code := sendCode(t, service, notifier)
+
+			for range tt.wrongGuesses {
+				if _, err := service.VerifyPhoneNumber(context.Background(), "user", "wrong"); err != commons.ErrInvalidVerificationCode {
+					t.Fatalf("VerifyPhoneNumber with a wrong code error = %v, want ErrInvalidVerificationCode", err)
+				}
+			}
+
+			response, err := service.VerifyPhoneNumber(context.Background(), "user", code)
+			if !tt.wantVerified {
+				if err != commons.ErrInvalidVerificationCode {
+					t.Fatalf("VerifyPhoneNumber error = %v, want ErrInvalidVerificationCode", err)
+				}
+				return
+			}
+			if err != nil || !response.Verified {
+				t.Fatalf("VerifyPhoneNumber = %+v, %v, want the number verified", response, err)
+			}
+		})
+	}
+}
+
+func TestVerifyPhoneNumberWithANewCode(t *testing.T) {
+	notifier := &fakeNotifier{}
+	service := NewService(commons.NewLoggerWithOutput(io.Discard, "gateway"), &fakeUserRepository{user: commons.User{ID: "user"}}, &fakeVerificationRepository{}, notifier)
+	sendCode(t, service, notifier)
+	for range maxVerificationAttempts {
+		service.VerifyPhoneNumber(context.Background(), "user", "wrong")
+	}
+
+	code := sendCode(t, service, notifier)
+	if response, err := service.VerifyPhoneNumber(context.Background(), "user", code); err != nil || !response.Verified {
+		t.Fatalf("VerifyPhoneNumber with a new code = %+v, %v, want the number verified", response, err)
+	}
+}
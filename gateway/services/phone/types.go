@@ -0,0 +1,9 @@
+package phone
+
+import "time"
+
+type PhoneResponse struct {
+	PhoneNumber string     `json:"phone_number,omitempty" example:"+4915112345678"`
+	Verified    bool       `json:"verified"`
+	VerifiedAt  *time.Time `json:"verified_at,omitempty"`
+}
@@ -0,0 +1,298 @@
+package project
+
+import (
+	"context"
+	"strings"
+
+	"sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, project commons.Project, ownerID string) (commons.Project, error)
+	GetByID(ctx context.Context, id string) (commons.Project, error)
+	GetByUserID(ctx context.Context, userID string) ([]commons.Project, error)
+	Update(ctx context.Context, project commons.Project) error
+	Delete(ctx context.Context, id string) error
+	GetMember(ctx context.Context, projectID string, userID string) (commons.ProjectMember, error)
+	GetMembers(ctx context.Context, projectID string) ([]commons.ProjectMember, error)
+	AddMember(ctx context.Context, member commons.ProjectMember) (commons.ProjectMember, error)
+	UpdateMemberRole(ctx context.Context, projectID string, userID string, role string) error
+	RemoveMember(ctx context.Context, projectID string, userID string) error
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id string) (commons.User, error)
+	GetByHandle(ctx context.Context, handle string) (commons.User, error)
+}
+
+type Service struct {
+	logger      commons.Logger
+	projectRepo Repository
+	userRepo    UserRepository
+}
+
+func NewService(logger commons.Logger, projectRepo Repository, userRepo UserRepository) *Service {
+	return &Service{
+		logger:      logger,
+		projectRepo: projectRepo,
+		userRepo:    userRepo,
+	}
+}
+
+func (s *Service) ListProjects(ctx context.Context, userID string) ([]ProjectResponse, error) {
+	projects, err := s.projectRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]ProjectResponse, len(projects))
+	for i, project := range projects {
+		response[i] = toProjectResponse(project)
+	}
+
+	return response, nil
+}
+
+func (s *Service) CreateProject(ctx context.Context, userID string, input CreateProjectInput) (*ProjectResponse, error) {
+	created, err := s.projectRepo.Create(ctx, commons.Project{
+		ID:          uuid.New().String(),
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		CreatorID:   userID,
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toProjectResponse(created)
+	return &response, nil
+}
+
+func (s *Service) GetProject(ctx context.Context, projectID string, userID string) (*ProjectResponse, error) {
+	member, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	project.Role = member.Role
+
+	response := toProjectResponse(project)
+	return &response, nil
+}
+
+func (s *Service) UpdateProject(ctx context.Context, projectID string, userID string, input UpdateProjectInput) (*ProjectResponse, error) {
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleOwner); err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name := strings.TrimSpace(input.Name); name != "" {
+		project.Name = name
+	}
+	if input.Description != "" {
+		project.Description = input.Description
+	}
+
+	if err := s.projectRepo.Update(ctx, project); err != nil {
+		return nil, err
+	}
+
+	return s.GetProject(ctx, projectID, userID)
+}
+
+// DeleteProject soft deletes the project and all of its tasks.
+func (s *Service) DeleteProject(ctx context.Context, projectID string, userID string) error {
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleOwner); err != nil {
+		return err
+	}
+
+	return s.projectRepo.Delete(ctx, projectID)
+}
+
+func (s *Service) ListMembers(ctx context.Context, projectID string, userID string) ([]MemberResponse, error) {
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleViewer); err != nil {
+		return nil, err
+	}
+
+	members, err := s.projectRepo.GetMembers(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]MemberResponse, len(members))
+	for i, member := range members {
+		user, err := s.userRepo.GetByID(ctx, member.UserID)
+		if err != nil {
+			return nil, err
+		}
+		response[i] = toMemberResponse(member, user)
+	}
+
+	return response, nil
+}
+
+func (s *Service) AddMember(ctx context.Context, projectID string, userID string, input AddMemberInput) (*MemberResponse, error) {
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleOwner); err != nil {
+		return nil, err
+	}
+
+	if !commons.IsValidProjectRole(input.Role) {
+		return nil, commons.ErrInvalidInput
+	}
+
+	var user commons.User
+	var err error
+	if input.UserID != "" {
+		user, err = s.userRepo.GetByID(ctx, input.UserID)
+	} else {
+		user, err = s.userRepo.GetByHandle(ctx, strings.TrimPrefix(input.Handle, "@"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == "" {
+		return nil, commons.ErrNotFound
+	}
+
+	if existing, err := s.projectRepo.GetMember(ctx, projectID, user.ID); err == nil {
+		if err := s.ensureOwnerRemains(ctx, projectID, existing, input.Role); err != nil {
+			return nil, err
+		}
+	} else if err != commons.ErrNotFound {
+		return nil, err
+	}
+
+	member, err := s.projectRepo.AddMember(ctx, commons.ProjectMember{
+		ProjectID: projectID,
+		UserID:    user.ID,
+		Role:      input.Role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toMemberResponse(member, user)
+	return &response, nil
+}
+
+func (s *Service) UpdateMember(ctx context.Context, projectID string, memberID string, userID string, input UpdateMemberInput) error {
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, commons.ProjectRoleOwner); err != nil {
+		return err
+	}
+
+	if !commons.IsValidProjectRole(input.Role) {
+		return commons.ErrInvalidInput
+	}
+
+	member, err := s.projectRepo.GetMember(ctx, projectID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureOwnerRemains(ctx, projectID, member, input.Role); err != nil {
+		return err
+	}
+
+	return s.projectRepo.UpdateMemberRole(ctx, projectID, memberID, input.Role)
+}
+
+// RemoveMember removes memberID from the project. Owners may remove anyone,
+// every member may remove themselves.
+func (s *Service) RemoveMember(ctx context.Context, projectID string, memberID string, userID string) error {
+	requiredRole := commons.ProjectRoleOwner
+	if memberID == userID {
+		requiredRole = commons.ProjectRoleViewer
+	}
+
+	if _, err := s.AuthorizeProject(ctx, projectID, userID, requiredRole); err != nil {
+		return err
+	}
+
+	member, err := s.projectRepo.GetMember(ctx, projectID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureOwnerRemains(ctx, projectID, member, ""); err != nil {
+		return err
+	}
+
+	return s.projectRepo.RemoveMember(ctx, projectID, memberID)
+}
+
+// AuthorizeProject returns the membership of userID in the project if it
+// grants at least requiredRole. Non-members get ErrForbidden.
+func (s *Service) AuthorizeProject(ctx context.Context, projectID string, userID string, requiredRole string) (commons.ProjectMember, error) {
+	member, err := s.projectRepo.GetMember(ctx, projectID, userID)
+	if err != nil {
+		if err == commons.ErrNotFound {
+			if _, err := s.projectRepo.GetByID(ctx, projectID); err != nil {
+				return commons.ProjectMember{}, err
+			}
+			return commons.ProjectMember{}, commons.ErrForbidden
+		}
+		return commons.ProjectMember{}, err
+	}
+
+	if !commons.ProjectRoleAtLeast(member.Role, requiredRole) {
+		return commons.ProjectMember{}, commons.ErrForbidden
+	}
+
+	return member, nil
+}
+
+// AuthorizeTask checks that userID holds at least requiredRole on the task.
+// Project tasks use project membership. Personal tasks keep the original
+// rules: the creator is their owner and the assignee may view and edit them.
+func (s *Service) AuthorizeTask(ctx context.Context, task commons.Task, userID string, requiredRole string) error {
+	if task.ProjectID != nil {
+		_, err := s.AuthorizeProject(ctx, *task.ProjectID, userID, requiredRole)
+		return err
+	}
+
+	if userID == task.CreatorID {
+		return nil
+	}
+
+	if requiredRole != commons.ProjectRoleOwner && task.AssigneeID != nil && *task.AssigneeID == userID {
+		return nil
+	}
+
+	return commons.ErrForbidden
+}
+
+// ensureOwnerRemains rejects changing member to newRole ("" for removal) when
+// that would leave the project without an owner.
+func (s *Service) ensureOwnerRemains(ctx context.Context, projectID string, member commons.ProjectMember, newRole string) error {
+	if member.Role != commons.ProjectRoleOwner || newRole == commons.ProjectRoleOwner {
+		return nil
+	}
+
+	members, err := s.projectRepo.GetMembers(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	for _, m := range members {
+		if m.Role == commons.ProjectRoleOwner {
+			owners++
+		}
+	}
+
+	if owners <= 1 {
+		return commons.ErrInvalidInput
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package project
+
+import (
+	"time"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/services/auth"
+)
+
+type CreateProjectInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type UpdateProjectInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AddMemberInput identifies the user to add either by ID or by handle.
+type AddMemberInput struct {
+	UserID string `json:"user_id,omitempty"`
+	Handle string `json:"handle,omitempty"`
+	Role   string `json:"role"`
+}
+
+type UpdateMemberInput struct {
+	Role string `json:"role"`
+}
+
+type ProjectResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatorID   string    `json:"creator_id"`
+	Role        string    `json:"role,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type MemberResponse struct {
+	User      auth.UserResponse `json:"user"`
+	Role      string            `json:"role"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func toProjectResponse(project commons.Project) ProjectResponse {
+	return ProjectResponse{
+		ID:          project.ID,
+		Name:        project.Name,
+		Description: project.Description,
+		CreatorID:   project.CreatorID,
+		Role:        project.Role,
+		CreatedAt:   project.CreatedAt,
+		UpdatedAt:   project.UpdatedAt,
+	}
+}
+
+func toMemberResponse(member commons.ProjectMember, user commons.User) MemberResponse {
+	return MemberResponse{
+		User:      auth.ToUserResponse(user),
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+		UpdatedAt: member.UpdatedAt,
+	}
+}
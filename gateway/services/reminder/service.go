@@ -0,0 +1,54 @@
+package reminder
+
+import (
+	"context"
+	"slices"
+
+	"sama/go-task-management/commons"
+)
+
+type Repository interface {
+	GetLeadTimes(ctx context.Context, userID string) ([]int, error)
+	SetLeadTimes(ctx context.Context, userID string, leadTimes []int) error
+}
+
+type Service struct {
+	logger       commons.Logger
+	reminderRepo Repository
+}
+
+func NewService(logger commons.Logger, reminderRepo Repository) *Service {
+	return &Service{
+		logger:       logger,
+		reminderRepo: reminderRepo,
+	}
+}
+
+// GetPreferences returns the reminder lead times of a user, or the defaults
+// if the user has not chosen any.
+func (s *Service) GetPreferences(ctx context.Context, userID string) (PreferencesResponse, error) {
+	leadTimes, err := s.reminderRepo.GetLeadTimes(ctx, userID)
+	if err != nil {
+		return PreferencesResponse{}, err
+	}
+
+	if len(leadTimes) == 0 {
+		return PreferencesResponse{LeadTimeMinutes: commons.DefaultReminderLeadTimes, Default: true}, nil
+	}
+	return PreferencesResponse{LeadTimeMinutes: leadTimes}, nil
+}
+
+// UpdatePreferences replaces the reminder lead times of a user. An empty
+// list restores the defaults.
+func (s *Service) UpdatePreferences(ctx context.Context, userID string, leadTimes []int) (PreferencesResponse, error) {
+	leadTimes = slices.Clone(leadTimes)
+	slices.Sort(leadTimes)
+	leadTimes = slices.Compact(leadTimes)
+	slices.Reverse(leadTimes)
+
+	if err := s.reminderRepo.SetLeadTimes(ctx, userID, leadTimes); err != nil {
+		return PreferencesResponse{}, err
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
@@ -0,0 +1,9 @@
+package reminder
+
+type PreferencesResponse struct {
+	// LeadTimeMinutes are the times before the due date at which the user is
+	// reminded, largest first; 0 reminds when the task is overdue.
+	LeadTimeMinutes []int `json:"lead_time_minutes"`
+	// Default is true while the user has not chosen lead times.
+	Default bool `json:"default"`
+}
@@ -4,47 +4,111 @@ import (
 	"sama/go-task-management/commons"
 	"sama/go-task-management/gateway/services/adapters"
 	"sama/go-task-management/gateway/services/auth"
+	"sama/go-task-management/gateway/services/comment"
+	"sama/go-task-management/gateway/services/email"
 	"sama/go-task-management/gateway/services/grpc"
 	"sama/go-task-management/gateway/services/in_app_notification"
+	"sama/go-task-management/gateway/services/notification_preference"
+	"sama/go-task-management/gateway/services/outbox"
+	"sama/go-task-management/gateway/services/phone"
+	"sama/go-task-management/gateway/services/project"
+	"sama/go-task-management/gateway/services/reminder"
+	"sama/go-task-management/gateway/services/stream"
 	"sama/go-task-management/gateway/services/task"
 	"sama/go-task-management/gateway/services/task_system_event"
+	"sama/go-task-management/gateway/services/trace"
+	"sama/go-task-management/gateway/services/webhook"
 
 	pb "sama/go-task-management/commons/api"
 )
 
 type Services struct {
-	AuthService              *auth.Service
-	TaskService              *task.Service
-	TaskSystemEventService   *task_system_event.Service
-	InAppNotificationService *in_app_notification.Service
-	GrpcService              *grpc.Service
+	AuthService                   *auth.Service
+	TaskService                   *task.Service
+	TaskSystemEventService        *task_system_event.Service
+	InAppNotificationService      *in_app_notification.Service
+	GrpcService                   *grpc.Service
+	CommentService                *comment.Service
+	EmailService                  *email.Service
+	ProjectService                *project.Service
+	OutboxRelay                   *outbox.Relay
+	TaskScheduler                 *task.Scheduler
+	ReminderService               *reminder.Service
+	NotificationPreferenceService *notification_preference.Service
+	PhoneService                  *phone.Service
+	WebhookService                *webhook.Service
+	WebhookDispatcher             *webhook.Dispatcher
+	StreamBroker                  *stream.Broker
+	StreamFeed                    *stream.Feed
+	TraceService                  *trace.Service
 }
 
 func NewServices(
 	logger commons.Logger,
 	jwtSecret string,
+	passwordResetURL string,
 	userRepo commons.UserRepositoryInterface,
 	taskRepo commons.TaskRepositoryInterface,
 	taskSystemEventRepo commons.TaskSystemEventRepositoryInterface,
 	inAppNotificationRepo commons.InAppNotificationRepositoryInterface,
 	passwordResetTokenRepo commons.PasswordResetTokenRepositoryInterface,
+	taskCommentRepo commons.TaskCommentRepositoryInterface,
+	projectRepo commons.ProjectRepositoryInterface,
+	roleRepo commons.RoleRepositoryInterface,
+	refreshTokenRepo commons.RefreshTokenRepositoryInterface,
+	emailRepo commons.EmailRepositoryInterface,
+	outboxRepo commons.OutboxRepositoryInterface,
+	taskSeriesRepo commons.TaskSeriesRepositoryInterface,
+	reminderRepo commons.ReminderRepositoryInterface,
+	notificationPreferenceRepo commons.NotificationPreferenceRepositoryInterface,
+	phoneVerificationRepo commons.PhoneVerificationRepositoryInterface,
+	webhookRepo commons.WebhookRepositoryInterface,
+	traceRepo commons.TraceRepositoryInterface,
+	streamListener stream.Listener,
+	unitOfWork commons.UnitOfWork,
 	notificationServiceClient pb.NotificationServiceClient,
 ) *Services {
 	userAdapter := &adapters.UserRepositoryAdapter{UserRepositoryInterface: userRepo}
 	taskAdapter := &adapters.TaskRepositoryAdapter{TaskRepositoryInterface: taskRepo}
 	inAppNotificationAdapter := &adapters.InAppNotificationRepositoryAdapter{InAppNotificationRepositoryInterface: inAppNotificationRepo}
 
-	authService := auth.NewService(logger, jwtSecret, userAdapter, passwordResetTokenRepo)
+	grpcService := grpc.NewService(logger, notificationServiceClient)
+	authService := auth.NewService(logger, jwtSecret, userAdapter, passwordResetTokenRepo, roleRepo, refreshTokenRepo, unitOfWork, grpcService, passwordResetURL)
 	inAppNotificationService := in_app_notification.NewService(logger, inAppNotificationAdapter)
-	taskService := task.NewService(logger, taskAdapter, userAdapter)
+	projectService := project.NewService(logger, projectRepo, userAdapter)
+	taskService := task.NewService(logger, taskAdapter, taskSeriesRepo, userAdapter, unitOfWork, projectService)
 	taskSystemEventService := task_system_event.NewService(logger, taskSystemEventRepo)
-	grpcService := grpc.NewService(logger, notificationServiceClient)
+	commentService := comment.NewService(logger, taskCommentRepo, taskAdapter, userAdapter, unitOfWork, projectService, grpcService)
+	emailService := email.NewService(logger, emailRepo, taskAdapter, projectService)
+	outboxRelay := outbox.NewRelay(logger, outboxRepo, grpcService, taskSystemEventRepo, taskAdapter, webhookRepo)
+	taskScheduler := task.NewScheduler(logger, unitOfWork)
+	reminderService := reminder.NewService(logger, reminderRepo)
+	notificationPreferenceService := notification_preference.NewService(logger, notificationPreferenceRepo)
+	phoneService := phone.NewService(logger, userAdapter, phoneVerificationRepo, grpcService)
+	webhookService := webhook.NewService(logger, webhookRepo, projectService)
+	webhookDispatcher := webhook.NewDispatcher(logger, webhookRepo)
+	streamBroker := stream.NewBroker(logger)
+	traceService := trace.NewService(logger, traceRepo)
+	streamFeed := stream.NewFeed(logger, streamListener, streamBroker, inAppNotificationRepo, taskSystemEventRepo, taskAdapter, projectRepo)
 
 	return &Services{
-		AuthService:              authService,
-		TaskService:              taskService,
-		TaskSystemEventService:   taskSystemEventService,
-		InAppNotificationService: inAppNotificationService,
-		GrpcService:              grpcService,
+		AuthService:                   authService,
+		TaskService:                   taskService,
+		TaskSystemEventService:        taskSystemEventService,
+		InAppNotificationService:      inAppNotificationService,
+		GrpcService:                   grpcService,
+		CommentService:                commentService,
+		EmailService:                  emailService,
+		ProjectService:                projectService,
+		OutboxRelay:                   outboxRelay,
+		TaskScheduler:                 taskScheduler,
+		ReminderService:               reminderService,
+		NotificationPreferenceService: notificationPreferenceService,
+		PhoneService:                  phoneService,
+		WebhookService:                webhookService,
+		WebhookDispatcher:             webhookDispatcher,
+		StreamBroker:                  streamBroker,
+		StreamFeed:                    streamFeed,
+		TraceService:                  traceService,
 	}
 }
@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"sync"
+
+	"sama/go-task-management/commons"
+)
+
+// Types of the events pushed to clients.
+const (
+	EventTypeNotification    = "notification"
+	EventTypeTaskSystemEvent = "task-system-event"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind before
+// it is dropped.
+const subscriptionBuffer = 64
+
+// Event is one event pushed to a client.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// Subscription receives the events of one user on one connection.
+type Subscription struct {
+	userID string
+	events chan Event
+}
+
+// Events is closed when the subscriber is dropped for falling behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Broker fans events out to the subscriptions of their users, in process.
+// Clients connected to another gateway instance get them from that
+// instance's own broker.
+type Broker struct {
+	logger      commons.Logger
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewBroker(logger commons.Logger) *Broker {
+	return &Broker{
+		logger:      logger,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) Subscribe(userID string) *Subscription {
+	subscription := &Subscription{
+		userID: userID,
+		events: make(chan Event, subscriptionBuffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(subscription)
+}
+
+// Idle reports whether nobody is connected.
+func (b *Broker) Idle() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers) == 0
+}
+
+// Publish sends event to every subscription of the given users. Subscribers
+// whose buffer is full are dropped rather than blocking everyone else; they
+// are expected to reconnect.
+func (b *Broker) Publish(userIDs []string, event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, userID := range userIDs {
+		for subscription := range b.subscribers[userID] {
+			select {
+			case subscription.events <- event:
+			default:
+				b.logger.Warn("Dropping stream subscriber that fell behind", "subscriber_id", userID, "events_behind", subscriptionBuffer)
+				b.remove(subscription)
+			}
+		}
+	}
+}
+
+// Close drops every subscription, ending their streams.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscriptions := range b.subscribers {
+		for subscription := range subscriptions {
+			b.remove(subscription)
+		}
+	}
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(subscription *Subscription) {
+	subscriptions := b.subscribers[subscription.userID]
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+	delete(subscriptions, subscription)
+	close(subscription.events)
+	if len(subscriptions) == 0 {
+		delete(b.subscribers, subscription.userID)
+	}
+}
@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"sama/go-task-management/commons"
+)
+
+func newTestBroker() *Broker {
+	return NewBroker(commons.NewLoggerWithOutput(io.Discard, "gateway"))
+}
+
+// received drains the buffered events of subscription and reports whether
+// it was closed.
+func received(subscription *Subscription) ([]string, bool) {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func TestBrokerPublishFansOutPerUser(t *testing.T) {
+	broker := newTestBroker()
+	ada := broker.Subscribe("ada")
+	adaElsewhere := broker.Subscribe("ada")
+	grace := broker.Subscribe("grace")
+
+	broker.Publish([]string{"ada"}, Event{ID: "1"})
+	broker.Publish([]string{"ada", "grace"}, Event{ID: "2"})
+	broker.Publish([]string{"nobody"}, Event{ID: "3"})
+
+	tests := []struct {
+		name         string
+		subscription *Subscription
+		want         string
+	}{
+		{"ada", ada, "[1 2]"},
+		{"ada elsewhere", adaElsewhere, "[1 2]"},
+		{"grace", grace, "[2]"},
+	}
+	for _, tt := range tests {
+		ids, closed := received(tt.subscription)
+		if got := fmt.Sprint(ids); got != tt.want || closed {
+			t.Errorf("%s received %s, closed = %v, want %s", tt.name, got, closed, tt.want)
+		}
+	}
+}
+
+func TestBrokerDropsSubscribersThatFallBehind(t *testing.T) {
+	broker := newTestBroker()
+	slow := broker.Subscribe("ada")
+	fast := broker.Subscribe("ada")
+
+	for i := range subscriptionBuffer + 1 {
+		broker.Publish([]string{"ada"}, Event{ID: "event"})
+		if ids, _ := received(fast); len(ids) != 1 {
+			t.Fatalf("fast subscriber received %d events after event %d, want 1", len(ids), i)
+		}
+	}
+
+	ids, closed := received(slow)
+	if len(ids) != subscriptionBuffer || !closed {
+		t.Fatalf("slow subscriber received %d events, closed = %v, want %d and closed", len(ids), closed, subscriptionBuffer)
+	}
+
+	broker.Publish([]string{"ada"}, Event{ID: "after"})
+	if ids, closed := received(fast); len(ids) != 1 || closed {
+		t.Fatalf("fast subscriber received %d events, closed = %v, want 1 and open", len(ids), closed)
+	}
+
+	// Unsubscribing after being dropped, as the handlers do, is harmless.
+	broker.Unsubscribe(slow)
+	broker.Unsubscribe(fast)
+	if !broker.Idle() {
+		t.Fatalf("broker is not idle after everyone unsubscribed")
+	}
+}
+
+func TestBrokerCloseEndsEverySubscription(t *testing.T) {
+	broker := newTestBroker()
+	subscriptions := []*Subscription{broker.Subscribe("ada"), broker.Subscribe("ada"), broker.Subscribe("grace")}
+	broker.Publish([]string{"ada"}, Event{ID: "1"})
+
+	broker.Close()
+
+	for i, subscription := range subscriptions {
+		if _, closed := received(subscription); !closed {
+			t.Errorf("subscription %d is still open", i)
+		}
+		broker.Unsubscribe(subscription)
+	}
+	if !broker.Idle() {
+		t.Fatalf("broker is not idle after Close")
+	}
+}
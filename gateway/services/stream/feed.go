@@ -0,0 +1,179 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"sama/go-task-management/commons"
+	"sama/go-task-management/gateway/services/in_app_notification"
+)
+
+const (
+	loadTimeout    = 5 * time.Second
+	restartBackoff = 10 * time.Second
+)
+
+type Listener interface {
+	Listen(ctx context.Context, handle func(notice commons.StreamEventNotice)) error
+}
+
+type NotificationRepository interface {
+	GetByID(ctx context.Context, id string) (commons.InAppNotification, error)
+}
+
+type EventRepository interface {
+	GetByID(ctx context.Context, id string) (commons.TaskSystemEvent, error)
+}
+
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (commons.Task, error)
+}
+
+type ProjectRepository interface {
+	GetMembers(ctx context.Context, projectID string) ([]commons.ProjectMember, error)
+}
+
+// Feed publishes the in-app notifications and task system events announced
+// by Postgres to the broker. Notifications go to their user, system events
+// to everyone who can see their task, once their emit_at has come.
+type Feed struct {
+	logger           commons.Logger
+	listener         Listener
+	broker           *Broker
+	notificationRepo NotificationRepository
+	eventRepo        EventRepository
+	taskRepo         TaskRepository
+	projectRepo      ProjectRepository
+}
+
+func NewFeed(
+	logger commons.Logger,
+	listener Listener,
+	broker *Broker,
+	notificationRepo NotificationRepository,
+	eventRepo EventRepository,
+	taskRepo TaskRepository,
+	projectRepo ProjectRepository,
+) *Feed {
+	return &Feed{
+		logger:           logger,
+		listener:         listener,
+		broker:           broker,
+		notificationRepo: notificationRepo,
+		eventRepo:        eventRepo,
+		taskRepo:         taskRepo,
+		projectRepo:      projectRepo,
+	}
+}
+
+// Start feeds the broker until ctx is canceled.
+func (f *Feed) Start(ctx context.Context) {
+	for {
+		err := f.listener.Listen(ctx, func(notice commons.StreamEventNotice) {
+			f.handle(ctx, notice)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		f.logger.ErrorContext(ctx, "Stream listener stopped, restarting", "restart_in", restartBackoff, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartBackoff):
+		}
+	}
+}
+
+func (f *Feed) handle(ctx context.Context, notice commons.StreamEventNotice) {
+	// Nothing is loaded for nobody.
+	if f.broker.Idle() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, loadTimeout)
+	defer cancel()
+
+	var err error
+	switch notice.Table {
+	case "in_app_notifications":
+		err = f.publishNotification(ctx, notice.ID)
+	case "task_system_events":
+		err = f.publishTaskSystemEvent(ctx, notice.ID)
+	default:
+		f.logger.WarnContext(ctx, "Ignoring stream event notice", "table", notice.Table)
+	}
+	if err != nil {
+		f.logger.ErrorContext(ctx, "Failed to publish stream event", "table", notice.Table, "id", notice.ID, "error", err)
+	}
+}
+
+func (f *Feed) publishNotification(ctx context.Context, id string) error {
+	notification, err := f.notificationRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	f.broker.Publish([]string{notification.UserID}, Event{
+		ID:   notification.ID,
+		Type: EventTypeNotification,
+		Data: in_app_notification.ToNotificationResponse(notification),
+	})
+	return nil
+}
+
+// publishTaskSystemEvent publishes a system event to the users who can see
+// its task, delayed until its emit_at.
+func (f *Feed) publishTaskSystemEvent(ctx context.Context, id string) error {
+	event, err := f.eventRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	task, err := f.taskRepo.GetByID(ctx, event.TaskId)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := f.taskAudience(ctx, task)
+	if err != nil {
+		return err
+	}
+
+	publish := func() {
+		f.broker.Publish(recipients, Event{
+			ID:   event.ID,
+			Type: EventTypeTaskSystemEvent,
+			Data: event,
+		})
+	}
+	if delay := time.Until(event.EmitAt); delay > 0 {
+		time.AfterFunc(delay, publish)
+	} else {
+		publish()
+	}
+	return nil
+}
+
+// taskAudience returns the users who can see a task: the members of its
+// project, or the creator and assignee of a personal task.
+func (f *Feed) taskAudience(ctx context.Context, task commons.Task) ([]string, error) {
+	if task.ProjectID != nil {
+		members, err := f.projectRepo.GetMembers(ctx, *task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		userIDs := make([]string, len(members))
+		for i, member := range members {
+			userIDs[i] = member.UserID
+		}
+		return userIDs, nil
+	}
+
+	userIDs := []string{task.CreatorID}
+	if task.AssigneeID != nil && *task.AssigneeID != "" && !slices.Contains(userIDs, *task.AssigneeID) {
+		userIDs = append(userIDs, *task.AssigneeID)
+	}
+	return userIDs, nil
+}
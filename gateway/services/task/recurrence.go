@@ -0,0 +1,277 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultLeadTime is how long before it is due an occurrence of a
+	// recurring task is created, unless the recurrence sets a lead time.
+	defaultLeadTime  = 24 * time.Hour
+	maxLeadTimeHours = 31 * 24
+	statusTodo       = "TODO"
+	statusDone       = "DONE"
+)
+
+// parseRecurrence turns a recurrence input into the rule, time zone and lead
+// time of a series. Errors wrap commons.ErrInvalidRecurrence.
+func parseRecurrence(input RecurrenceInput) (commons.RecurrenceRule, *time.Location, time.Duration, error) {
+	rule, err := commons.ParseRecurrenceRule(input.RRule)
+	if err != nil {
+		return commons.RecurrenceRule{}, nil, 0, err
+	}
+
+	if input.Count < 0 {
+		return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("count must not be negative")
+	}
+	if input.Count > 0 {
+		if rule.Count > 0 {
+			return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("count is already set by the rule")
+		}
+		rule.Count = input.Count
+	}
+	if input.Until != nil {
+		if rule.Until != nil {
+			return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("until is already set by the rule")
+		}
+		until := input.Until.UTC()
+		rule.Until = &until
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("count and until cannot be combined")
+	}
+
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("unknown timezone %q", timezone)
+	}
+
+	leadTime := defaultLeadTime
+	if input.LeadTimeHours != nil {
+		if *input.LeadTimeHours < 0 || *input.LeadTimeHours > maxLeadTimeHours {
+			return commons.RecurrenceRule{}, nil, 0, invalidRecurrence("lead_time_hours must be between 0 and %d", maxLeadTimeHours)
+		}
+		leadTime = time.Duration(*input.LeadTimeHours) * time.Hour
+	}
+
+	return rule, location, leadTime, nil
+}
+
+func invalidRecurrence(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", commons.ErrInvalidRecurrence, fmt.Sprintf(format, args...))
+}
+
+// newSeries builds the series of a recurring task whose first occurrence is
+// task, due at its due date.
+func newSeries(task commons.Task, rule commons.RecurrenceRule, location *time.Location, leadTime time.Duration) (commons.TaskSeries, error) {
+	if task.DueDate.IsZero() {
+		return commons.TaskSeries{}, invalidRecurrence("a recurring task needs a due date")
+	}
+
+	series := commons.TaskSeries{
+		ID:              uuid.New().String(),
+		Rule:            rule.String(),
+		Timezone:        location.String(),
+		StartsAt:        task.DueDate.UTC(),
+		LeadTime:        leadTime,
+		Title:           task.Title,
+		Description:     task.Description,
+		Priority:        task.Priority,
+		CreatorID:       task.CreatorID,
+		AssigneeID:      task.AssigneeID,
+		ProjectID:       task.ProjectID,
+		OccurrenceCount: 1,
+	}
+
+	next, err := nextOccurrence(series, series.StartsAt)
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+	series.NextOccurrenceAt = next
+
+	return series, nil
+}
+
+// nextOccurrence returns the first occurrence of series after t, or nil if
+// the series has ended.
+func nextOccurrence(series commons.TaskSeries, t time.Time) (*time.Time, error) {
+	rule, err := commons.ParseRecurrenceRule(series.Rule)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Count > 0 && series.OccurrenceCount >= rule.Count {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	next, ok := rule.After(series.StartsAt.In(location), t.In(location))
+	if !ok {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// startSeries makes task the first occurrence of a new series. The series
+// is stored before the task, which references it.
+func startSeries(ctx context.Context, repos commons.Repositories, task *commons.Task, input RecurrenceInput) (commons.TaskSeries, error) {
+	rule, location, leadTime, err := parseRecurrence(input)
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+	series, err := newSeries(*task, rule, location, leadTime)
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+
+	series, err = repos.TaskSeries.Create(ctx, series)
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+
+	occurrenceAt := series.StartsAt
+	task.SeriesID = &series.ID
+	task.OccurrenceAt = &occurrenceAt
+	return series, nil
+}
+
+// materialize creates the task of the next occurrence of series and moves
+// the series on to the occurrence after it.
+func materialize(ctx context.Context, repos commons.Repositories, series commons.TaskSeries) (commons.TaskSeries, error) {
+	occurrenceAt := *series.NextOccurrenceAt
+	now := time.Now()
+	task := commons.Task{
+		ID:           uuid.New().String(),
+		Title:        series.Title,
+		Description:  series.Description,
+		Status:       statusTodo,
+		Priority:     series.Priority,
+		DueDate:      occurrenceAt,
+		CreatorID:    series.CreatorID,
+		AssigneeID:   series.AssigneeID,
+		ProjectID:    series.ProjectID,
+		SeriesID:     &series.ID,
+		OccurrenceAt: &occurrenceAt,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	correlationID := uuid.New().String()
+	ctx = commons.WithCorrelationID(ctx, correlationID)
+	outboxEvent, err := newOutboxEvent(task.ID, correlationID, commons.TaskEventCreated, commons.TaskEventPayload{
+		NotificationEvent: commons.NotificationEventTaskCreated,
+	})
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+
+	if _, err := repos.Tasks.Create(ctx, task); err != nil {
+		return commons.TaskSeries{}, err
+	}
+	if err := repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent}); err != nil {
+		return commons.TaskSeries{}, err
+	}
+	if _, err := repos.TaskSystemEvents.Create(ctx, newSystemEvent(task.ID, correlationID, "api:db:occurrence-created", "Occurrence of recurring task created in database"), 1); err != nil {
+		return commons.TaskSeries{}, err
+	}
+
+	return advanceSeries(ctx, repos, series, occurrenceAt)
+}
+
+// advanceSeries counts the occurrence at occurrenceAt as used up and moves
+// series on to the occurrence after it.
+func advanceSeries(ctx context.Context, repos commons.Repositories, series commons.TaskSeries, occurrenceAt time.Time) (commons.TaskSeries, error) {
+	series.OccurrenceCount++
+	next, err := nextOccurrence(series, occurrenceAt)
+	if err != nil {
+		return commons.TaskSeries{}, err
+	}
+	series.NextOccurrenceAt = next
+
+	return series, repos.TaskSeries.Update(ctx, series)
+}
+
+// occurrenceDone creates the next occurrence of a series early once none of
+// its occurrences is left open.
+func occurrenceDone(ctx context.Context, repos commons.Repositories, seriesID string) error {
+	series, err := repos.TaskSeries.GetForUpdate(ctx, seriesID)
+	if err != nil {
+		return err
+	}
+	if series.NextOccurrenceAt == nil {
+		return nil
+	}
+
+	occurrences, err := repos.Tasks.ListOccurrences(ctx, series.ID, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.Status != statusDone {
+			return nil
+		}
+	}
+
+	_, err = materialize(ctx, repos, series)
+	return err
+}
+
+// endSeries stops series from creating occurrences and deletes its open
+// occurrences scheduled at or after from, except the task keep.
+func endSeries(ctx context.Context, repos commons.Repositories, series commons.TaskSeries, from time.Time, keep string) ([]commons.Task, error) {
+	series.NextOccurrenceAt = nil
+	if err := repos.TaskSeries.Update(ctx, series); err != nil {
+		return nil, err
+	}
+
+	occurrences, err := repos.Tasks.ListOccurrences(ctx, series.ID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []commons.Task
+	for _, occurrence := range occurrences {
+		if occurrence.ID == keep || occurrence.Status == statusDone {
+			continue
+		}
+		if err := deleteTask(ctx, repos, occurrence.ID); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, occurrence)
+	}
+
+	return deleted, nil
+}
+
+func deleteTask(ctx context.Context, repos commons.Repositories, taskID string) error {
+	outboxEvent, err := newOutboxEvent(taskID, uuid.New().String(), commons.TaskEventDeleted, commons.TaskEventPayload{})
+	if err != nil {
+		return err
+	}
+	if err := repos.Tasks.Delete(ctx, taskID); err != nil {
+		return err
+	}
+	return repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent})
+}
+
+// occurrenceTime is when task is scheduled within its series.
+func occurrenceTime(task commons.Task) time.Time {
+	if task.OccurrenceAt != nil {
+		return *task.OccurrenceAt
+	}
+	return task.DueDate
+}
@@ -0,0 +1,100 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+const (
+	schedulerInterval  = time.Minute
+	schedulerBatchSize = 50
+	// maxSkippedOccurrences bounds how many missed occurrences of one series
+	// are skipped per run, e.g. after the scheduler was down for long.
+	maxSkippedOccurrences = 1000
+)
+
+// Scheduler creates the occurrences of recurring tasks once their lead time
+// before the due date has started. Schedulers of several gateways share the
+// work: a series is claimed by one of them at a time.
+type Scheduler struct {
+	logger     commons.Logger
+	unitOfWork UnitOfWork
+}
+
+func NewScheduler(logger commons.Logger, unitOfWork UnitOfWork) *Scheduler {
+	return &Scheduler{
+		logger:     logger,
+		unitOfWork: unitOfWork,
+	}
+}
+
+// Start creates due occurrences until ctx is canceled.
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.materializeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) materializeDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		var claimed int
+		err := s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+			now := time.Now().UTC()
+			due, err := repos.TaskSeries.ClaimDue(ctx, now, schedulerBatchSize)
+			if err != nil {
+				return err
+			}
+			claimed = len(due)
+
+			for _, series := range due {
+				if err := s.materialize(ctx, repos, series, now); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Failed to create occurrences of recurring tasks", "error", err)
+			return
+		}
+
+		if claimed < schedulerBatchSize {
+			return
+		}
+	}
+}
+
+// materialize creates the next occurrence of series. Occurrences that were
+// missed are skipped, so only the latest one that is already due is created.
+func (s *Scheduler) materialize(ctx context.Context, repos commons.Repositories, series commons.TaskSeries, now time.Time) error {
+	for skipped := 0; skipped < maxSkippedOccurrences; skipped++ {
+		// The skipped occurrence counts against COUNT, as if it was created.
+		advanced := series
+		advanced.OccurrenceCount++
+		next, err := nextOccurrence(advanced, *series.NextOccurrenceAt)
+		if err != nil {
+			return err
+		}
+		if next == nil || next.After(now) {
+			break
+		}
+
+		s.logger.InfoContext(ctx, "Skipping missed occurrence of task series", "occurrence_at", series.NextOccurrenceAt.Format(time.RFC3339), "series_id", series.ID)
+		advanced.NextOccurrenceAt = next
+		series = advanced
+	}
+
+	_, err := materialize(ctx, repos, series)
+	return err
+}
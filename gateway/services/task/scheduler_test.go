@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type fakeTaskRepository struct {
+	commons.TaskRepositoryInterface
+	created     []commons.Task
+	updated     []commons.Task
+	occurrences []commons.Task
+}
+
+func (r *fakeTaskRepository) Create(ctx context.Context, task commons.Task) (commons.Task, error) {
+	r.created = append(r.created, task)
+	return task, nil
+}
+
+func (r *fakeTaskRepository) Update(ctx context.Context, task commons.Task) error {
+	r.updated = append(r.updated, task)
+	return nil
+}
+
+func (r *fakeTaskRepository) ListOccurrences(ctx context.Context, seriesID string, from time.Time) ([]commons.Task, error) {
+	return r.occurrences, nil
+}
+
+type fakeOutboxRepository struct {
+	commons.OutboxRepositoryInterface
+	added []commons.OutboxEvent
+}
+
+func (r *fakeOutboxRepository) Add(ctx context.Context, events []commons.OutboxEvent) error {
+	r.added = append(r.added, events...)
+	return nil
+}
+
+type fakeTaskSystemEventRepository struct {
+	commons.TaskSystemEventRepositoryInterface
+}
+
+func (r *fakeTaskSystemEventRepository) Create(ctx context.Context, systemEvent commons.TaskSystemEvent, delay int) (commons.TaskSystemEvent, error) {
+	return systemEvent, nil
+}
+
+type fakeTaskSeriesRepository struct {
+	commons.TaskSeriesRepositoryInterface
+	series  commons.TaskSeries
+	updated []commons.TaskSeries
+}
+
+func (r *fakeTaskSeriesRepository) GetForUpdate(ctx context.Context, id string) (commons.TaskSeries, error) {
+	return r.series, nil
+}
+
+func (r *fakeTaskSeriesRepository) Update(ctx context.Context, series commons.TaskSeries) error {
+	r.updated = append(r.updated, series)
+	return nil
+}
+
+func TestSchedulerMaterializeSkipsMissedOccurrences(t *testing.T) {
+	day := func(day int) time.Time {
+		return time.Date(2025, 1, day, 9, 0, 0, 0, time.UTC)
+	}
+	now := time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		rule      string
+		wantDue   time.Time
+		wantCount int
+		wantNext  *time.Time
+	}{
+		{"creates the latest due occurrence", "FREQ=DAILY", day(5), 5, ptr(day(6))},
+		{"stops at the last occurrence", "FREQ=DAILY;COUNT=3", day(3), 3, nil},
+		{"creates the last occurrence once due", "FREQ=DAILY;COUNT=5", day(5), 5, nil},
+		{"stops at until", "FREQ=DAILY;UNTIL=20250104T090000Z", day(4), 4, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := &fakeTaskRepository{}
+			seriesRepo := &fakeTaskSeriesRepository{}
+			repos := commons.Repositories{
+				Tasks:            tasks,
+				Outbox:           &fakeOutboxRepository{},
+				TaskSystemEvents: &fakeTaskSystemEventRepository{},
+				TaskSeries:       seriesRepo,
+			}
+			series := commons.TaskSeries{
+				ID:               "series",
+				Rule:             tt.rule,
+				Timezone:         "UTC",
+				StartsAt:         day(1),
+				OccurrenceCount:  1,
+				NextOccurrenceAt: ptr(day(2)),
+			}
+			scheduler := NewScheduler(commons.NewLoggerWithOutput(io.Discard, "gateway"), nil)
+
+			if err := scheduler.materialize(context.Background(), repos, series, now); err != nil {
+				t.Fatalf("materialize failed: %v", err)
+			}
+
+			if len(tasks.created) != 1 {
+				t.Fatalf("created %d tasks, want 1", len(tasks.created))
+			}
+			if due := tasks.created[0].DueDate; !due.Equal(tt.wantDue) {
+				t.Fatalf("created task due %v, want %v", due, tt.wantDue)
+			}
+
+			if len(seriesRepo.updated) != 1 {
+				t.Fatalf("updated the series %d times, want 1", len(seriesRepo.updated))
+			}
+			updated := seriesRepo.updated[0]
+			if updated.OccurrenceCount != tt.wantCount {
+				t.Fatalf("occurrence count = %d, want %d", updated.OccurrenceCount, tt.wantCount)
+			}
+			switch {
+			case tt.wantNext == nil && updated.NextOccurrenceAt != nil:
+				t.Fatalf("next occurrence = %v, want none", *updated.NextOccurrenceAt)
+			case tt.wantNext != nil && (updated.NextOccurrenceAt == nil || !updated.NextOccurrenceAt.Equal(*tt.wantNext)):
+				t.Fatalf("next occurrence = %v, want %v", updated.NextOccurrenceAt, *tt.wantNext)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"sama/go-task-management/commons"
@@ -10,61 +11,115 @@ import (
 )
 
 type Repository interface {
-	GetByID(id string) (commons.Task, error)
-	GetAll() ([]commons.Task, error)
-	Create(task commons.Task) (commons.Task, error)
-	Update(task commons.Task) error
-	Delete(id string) error
+	GetByID(ctx context.Context, id string) (commons.Task, error)
+	List(ctx context.Context, query commons.TaskQuery) (commons.TaskPage, error)
+	Create(ctx context.Context, task commons.Task) (commons.Task, error)
+	Update(ctx context.Context, task commons.Task) error
+	Delete(ctx context.Context, id string) error
+}
+
+// UnitOfWork runs the writes of a task change and its events in one
+// transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos commons.Repositories) error) error
+}
+
+// SeriesRepository reads the schedules of recurring tasks.
+type SeriesRepository interface {
+	GetByID(ctx context.Context, id string) (commons.TaskSeries, error)
 }
 
 type UserRepository interface {
-	GetByID(id string) (commons.User, error)
+	GetByID(ctx context.Context, id string) (commons.User, error)
+}
+
+// Authorizer decides what a user may do with a task based on project
+// membership.
+type Authorizer interface {
+	AuthorizeProject(ctx context.Context, projectID string, userID string, requiredRole string) (commons.ProjectMember, error)
+	AuthorizeTask(ctx context.Context, task commons.Task, userID string, requiredRole string) error
 }
 
 type Service struct {
-	logger   commons.Logger
-	taskRepo Repository
-	userRepo UserRepository
+	logger     commons.Logger
+	taskRepo   Repository
+	seriesRepo SeriesRepository
+	userRepo   UserRepository
+	unitOfWork UnitOfWork
+	authorizer Authorizer
 }
 
-func NewService(logger commons.Logger, taskRepo Repository, userRepo UserRepository) *Service {
+func NewService(logger commons.Logger, taskRepo Repository, seriesRepo SeriesRepository, userRepo UserRepository, unitOfWork UnitOfWork, authorizer Authorizer) *Service {
 	return &Service{
-		logger:   logger,
-		taskRepo: taskRepo,
-		userRepo: userRepo,
+		logger:     logger,
+		taskRepo:   taskRepo,
+		seriesRepo: seriesRepo,
+		userRepo:   userRepo,
+		unitOfWork: unitOfWork,
+		authorizer: authorizer,
 	}
 }
 
 func (s *Service) GetTask(ctx context.Context, taskID string, userID string) (*commons.Task, error) {
-	task, err := s.taskRepo.GetByID(taskID)
+	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	if userID != task.CreatorID && (task.AssigneeID == nil || *task.AssigneeID != userID) {
-		return nil, commons.ErrForbidden
+	if err := s.authorizer.AuthorizeTask(ctx, task, userID, commons.ProjectRoleViewer); err != nil {
+		return nil, err
 	}
 
 	return &task, nil
 }
 
-func (s *Service) GetAllTasks(ctx context.Context, userID string) ([]commons.Task, error) {
-	tasks, err := s.taskRepo.GetAll()
+// GetRecurrence returns the schedule of a recurring task, or ErrNotFound if
+// the task does not recur.
+func (s *Service) GetRecurrence(ctx context.Context, taskID string, userID string) (*commons.TaskSeries, error) {
+	task, err := s.GetTask(ctx, taskID, userID)
 	if err != nil {
 		return nil, err
 	}
+	if task.SeriesID == nil {
+		return nil, commons.ErrNotFound
+	}
 
-	userTasks := make([]commons.Task, 0)
-	for _, task := range tasks {
-		if task.CreatorID == userID || (task.AssigneeID != nil && *task.AssigneeID == userID) {
-			userTasks = append(userTasks, task)
+	series, err := s.seriesRepo.GetByID(ctx, *task.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+func (s *Service) ListTasks(ctx context.Context, userID string, query commons.TaskQuery) (*commons.TaskPage, error) {
+	query.VisibleTo = userID
+
+	if query.ProjectID != "" {
+		if _, err := s.authorizer.AuthorizeProject(ctx, query.ProjectID, userID, commons.ProjectRoleViewer); err != nil {
+			return nil, err
 		}
 	}
 
-	return userTasks, nil
+	page, err := s.taskRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
 }
 
 func (s *Service) CreateTask(ctx context.Context, input CreateTaskInput) (*commons.Task, error) {
+	if input.ProjectID != nil {
+		if _, err := s.authorizer.AuthorizeProject(ctx, *input.ProjectID, input.CreatorID, commons.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.validateAssignee(ctx, input.ProjectID, input.AssigneeID); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	task := commons.Task{
 		ID:          uuid.New().String(),
@@ -75,11 +130,44 @@ func (s *Service) CreateTask(ctx context.Context, input CreateTaskInput) (*commo
 		DueDate:     input.DueDate,
 		CreatorID:   input.CreatorID,
 		AssigneeID:  input.AssigneeID,
+		ProjectID:   input.ProjectID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
 
-	createdTask, err := s.taskRepo.Create(task)
+	correlationID := uuid.New().String()
+	ctx = commons.WithCorrelationID(ctx, correlationID)
+	outboxEvent, err := newOutboxEvent(task.ID, correlationID, commons.TaskEventCreated, commons.TaskEventPayload{
+		NotificationEvent: commons.NotificationEventTaskCreated,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var createdTask commons.Task
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		if input.Recurrence != nil {
+			if _, err := startSeries(ctx, repos, &task, *input.Recurrence); err != nil {
+				return err
+			}
+		}
+
+		createdTask, err = repos.Tasks.Create(ctx, task)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent}); err != nil {
+			return err
+		}
+
+		if _, err := repos.TaskSystemEvents.Create(ctx, newSystemEvent(task.ID, correlationID, "api:request:received", "Task creation request received"), 0); err != nil {
+			return err
+		}
+
+		_, err := repos.TaskSystemEvents.Create(ctx, newSystemEvent(task.ID, correlationID, "api:db:task-created", "Task created in database"), 1)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -88,52 +176,282 @@ func (s *Service) CreateTask(ctx context.Context, input CreateTaskInput) (*commo
 }
 
 func (s *Service) UpdateTask(ctx context.Context, taskID string, input UpdateTaskInput) (*commons.Task, error) {
-	task, err := s.taskRepo.GetByID(taskID)
+	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
 		return nil, err
 	}
 
-	if input.UserID != task.CreatorID && (task.AssigneeID == nil || *task.AssigneeID != input.UserID) {
-		return nil, commons.ErrForbidden
+	if err := s.authorizer.AuthorizeTask(ctx, task, input.UserID, commons.ProjectRoleEditor); err != nil {
+		return nil, err
 	}
 
-	if input.Title != "" {
-		task.Title = input.Title
+	if input.ProjectID != nil && (task.ProjectID == nil || *task.ProjectID != *input.ProjectID) {
+		// Moving a task requires owning it and being able to create tasks in the target project.
+		if err := s.authorizer.AuthorizeTask(ctx, task, input.UserID, commons.ProjectRoleOwner); err != nil {
+			return nil, err
+		}
+		if _, err := s.authorizer.AuthorizeProject(ctx, *input.ProjectID, input.UserID, commons.ProjectRoleEditor); err != nil {
+			return nil, err
+		}
+		task.ProjectID = input.ProjectID
 	}
-	if input.Description != "" {
-		task.Description = input.Description
+
+	if task.SeriesID != nil && input.Recurrence != nil && input.Scope != ScopeFuture {
+		return nil, invalidRecurrence("the schedule of a recurring task can only be changed for all future occurrences")
 	}
+
+	wasDone := task.Status == statusDone
+	previousStatus := task.Status
+	previousAssigneeID := task.AssigneeID
+	rescheduled := !input.DueDate.IsZero() && !input.DueDate.Equal(task.DueDate)
+
+	applyTemplateChanges(&task, input)
 	if input.Status != "" {
 		task.Status = input.Status
 	}
-	if input.Priority != 0 {
-		task.Priority = input.Priority
-	}
 	if !input.DueDate.IsZero() {
 		task.DueDate = input.DueDate
 	}
-	if input.AssigneeID != nil {
-		task.AssigneeID = input.AssigneeID
+
+	if err := s.validateAssignee(ctx, task.ProjectID, task.AssigneeID); err != nil {
+		return nil, err
 	}
 
 	task.UpdatedAt = time.Now()
 
-	if err := s.taskRepo.Update(task); err != nil {
+	outboxEvent, err := newOutboxEvent(task.ID, uuid.New().String(), commons.TaskEventUpdated, updatePayload(task, previousStatus, previousAssigneeID))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		switch {
+		case task.SeriesID == nil && input.Recurrence != nil:
+			if _, err := startSeries(ctx, repos, &task, *input.Recurrence); err != nil {
+				return err
+			}
+		case task.SeriesID != nil && input.Scope == ScopeFuture:
+			if err := s.updateFutureOccurrences(ctx, repos, &task, input, rescheduled); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Tasks.Update(ctx, task); err != nil {
+			return err
+		}
+		if err := repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent}); err != nil {
+			return err
+		}
+
+		if task.SeriesID != nil && task.Status == statusDone && !wasDone {
+			return occurrenceDone(ctx, repos, *task.SeriesID)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &task, nil
 }
 
-func (s *Service) DeleteTask(ctx context.Context, taskID string, userID string) error {
-	task, err := s.taskRepo.GetByID(taskID)
+// applyTemplateChanges applies the fields of an update that occurrences of
+// a recurring task share.
+func applyTemplateChanges(task *commons.Task, input UpdateTaskInput) {
+	if input.Title != "" {
+		task.Title = input.Title
+	}
+	if input.Description != "" {
+		task.Description = input.Description
+	}
+	if input.Priority != 0 {
+		task.Priority = input.Priority
+	}
+	if input.AssigneeID != nil {
+		task.AssigneeID = input.AssigneeID
+	}
+	if input.ProjectID != nil {
+		task.ProjectID = input.ProjectID
+	}
+}
+
+// updateFutureOccurrences applies an update of task to the occurrences of
+// its series after it. A new schedule or due date splits the series: the
+// old one ends before task and a new one starts with it.
+func (s *Service) updateFutureOccurrences(ctx context.Context, repos commons.Repositories, task *commons.Task, input UpdateTaskInput, rescheduled bool) error {
+	series, err := repos.TaskSeries.GetForUpdate(ctx, *task.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	if input.Recurrence == nil && !rescheduled {
+		series.Title = task.Title
+		series.Description = task.Description
+		series.Priority = task.Priority
+		series.AssigneeID = task.AssigneeID
+		series.ProjectID = task.ProjectID
+		if err := repos.TaskSeries.Update(ctx, series); err != nil {
+			return err
+		}
+
+		occurrences, err := repos.Tasks.ListOccurrences(ctx, series.ID, occurrenceTime(*task))
+		if err != nil {
+			return err
+		}
+		for _, occurrence := range occurrences {
+			if occurrence.ID == task.ID || occurrence.Status == statusDone {
+				continue
+			}
+			previousStatus := occurrence.Status
+			previousAssigneeID := occurrence.AssigneeID
+			applyTemplateChanges(&occurrence, input)
+			occurrence.UpdatedAt = task.UpdatedAt
+			outboxEvent, err := newOutboxEvent(occurrence.ID, uuid.New().String(), commons.TaskEventUpdated, updatePayload(occurrence, previousStatus, previousAssigneeID))
+			if err != nil {
+				return err
+			}
+			if err := repos.Tasks.Update(ctx, occurrence); err != nil {
+				return err
+			}
+			if err := repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	recurrence := RecurrenceInput{Timezone: series.Timezone}
+	if input.Recurrence != nil {
+		recurrence = *input.Recurrence
+	} else {
+		rule, err := commons.ParseRecurrenceRule(series.Rule)
+		if err != nil {
+			return err
+		}
+		leadTimeHours := int(series.LeadTime.Hours())
+		recurrence.LeadTimeHours = &leadTimeHours
+		recurrence.RRule = rule.String()
+	}
+
+	deleted, err := endSeries(ctx, repos, series, occurrenceTime(*task), task.ID)
 	if err != nil {
 		return err
 	}
 
-	if userID != task.CreatorID {
-		return commons.ErrForbidden
+	if input.Recurrence == nil {
+		// The new series gets the occurrences the old one had left.
+		rule, err := commons.ParseRecurrenceRule(recurrence.RRule)
+		if err != nil {
+			return err
+		}
+		if rule.Count > 0 {
+			rule.Count = max(rule.Count-series.OccurrenceCount+1+len(deleted), 1)
+			recurrence.RRule = rule.String()
+		}
+	}
+
+	_, err = startSeries(ctx, repos, task, recurrence)
+	return err
+}
+
+// DeleteTask deletes a task. With scope future, the later open occurrences
+// of a recurring task are deleted as well and no further ones are created.
+func (s *Service) DeleteTask(ctx context.Context, taskID string, userID string, scope string) error {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	// Owners may delete any task, editors only the ones they created.
+	if err := s.authorizer.AuthorizeTask(ctx, task, userID, commons.ProjectRoleOwner); err != nil {
+		if err != commons.ErrForbidden || task.CreatorID != userID {
+			return err
+		}
+		if err := s.authorizer.AuthorizeTask(ctx, task, userID, commons.ProjectRoleEditor); err != nil {
+			return err
+		}
+	}
+
+	outboxEvent, err := newOutboxEvent(taskID, uuid.New().String(), commons.TaskEventDeleted, commons.TaskEventPayload{})
+	if err != nil {
+		return err
+	}
+
+	return s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		if task.SeriesID != nil && scope == ScopeFuture {
+			series, err := repos.TaskSeries.GetForUpdate(ctx, *task.SeriesID)
+			if err != nil {
+				return err
+			}
+			if _, err := endSeries(ctx, repos, series, occurrenceTime(task), task.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.Tasks.Delete(ctx, taskID); err != nil {
+			return err
+		}
+		return repos.Outbox.Add(ctx, []commons.OutboxEvent{outboxEvent})
+	})
+}
+
+// newOutboxEvent builds a task event for the outbox. The outbox relay
+// publishes it once the unit of work that stores it has committed.
+// updatePayload notifies the new assignee of a task, or otherwise its
+// creator and assignee if its status changed.
+func updatePayload(task commons.Task, previousStatus string, previousAssigneeID *string) commons.TaskEventPayload {
+	if task.AssigneeID != nil && *task.AssigneeID != "" && (previousAssigneeID == nil || *previousAssigneeID != *task.AssigneeID) {
+		return commons.TaskEventPayload{
+			NotificationEvent: commons.NotificationEventTaskAssigned,
+			RecipientIDs:      []string{*task.AssigneeID},
+		}
+	}
+	if task.Status != previousStatus {
+		return commons.TaskEventPayload{NotificationEvent: commons.NotificationEventTaskStatusChanged}
+	}
+	return commons.TaskEventPayload{}
+}
+
+func newOutboxEvent(taskID string, correlationID string, eventType string, payload commons.TaskEventPayload) (commons.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return commons.OutboxEvent{}, err
+	}
+
+	return commons.OutboxEvent{
+		ID:            uuid.New().String(),
+		AggregateType: commons.OutboxAggregateTask,
+		AggregateID:   taskID,
+		CorrelationID: correlationID,
+		EventType:     eventType,
+		Payload:       data,
+	}, nil
+}
+
+func newSystemEvent(taskID string, correlationID string, action string, message string) commons.TaskSystemEvent {
+	return commons.TaskSystemEvent{
+		TaskId:        taskID,
+		CorrelationId: correlationID,
+		Origin:        "API Gateway",
+		Action:        action,
+		Message:       message,
+		JsonData:      "{}",
+	}
+}
+
+// validateAssignee ensures the assignee of a project task is a member of
+// that project.
+func (s *Service) validateAssignee(ctx context.Context, projectID *string, assigneeID *string) error {
+	if projectID == nil || assigneeID == nil || *assigneeID == "" {
+		return nil
+	}
+
+	if _, err := s.authorizer.AuthorizeProject(ctx, *projectID, *assigneeID, commons.ProjectRoleViewer); err != nil {
+		if err == commons.ErrForbidden {
+			return commons.ErrInvalidInput
+		}
+		return err
 	}
 
-	return s.taskRepo.Delete(taskID)
+	return nil
 }
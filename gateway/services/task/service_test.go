@@ -0,0 +1,62 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+func TestUpdateFutureOccurrencesNotifiesNewAssignees(t *testing.T) {
+	due := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	occurrence := func(id string, status string, assigneeID *string, day int) commons.Task {
+		return commons.Task{ID: id, SeriesID: ptr("series"), Status: status, AssigneeID: assigneeID, DueDate: due.AddDate(0, 0, day)}
+	}
+
+	task := occurrence("updated", "todo", ptr("bob"), 0)
+	tasks := &fakeTaskRepository{occurrences: []commons.Task{
+		task,
+		occurrence("done", statusDone, ptr("ada"), 1),
+		occurrence("reassigned", "todo", ptr("ada"), 2),
+		occurrence("unassigned", "todo", nil, 3),
+		occurrence("unchanged", "todo", ptr("bob"), 4),
+	}}
+	outbox := &fakeOutboxRepository{}
+	repos := commons.Repositories{
+		Tasks:      tasks,
+		Outbox:     outbox,
+		TaskSeries: &fakeTaskSeriesRepository{series: commons.TaskSeries{ID: "series"}},
+	}
+	service := NewService(commons.NewLoggerWithOutput(io.Discard, "gateway"), nil, nil, nil, nil, nil)
+
+	err := service.updateFutureOccurrences(context.Background(), repos, &task, UpdateTaskInput{Title: "Standup", AssigneeID: ptr("bob")}, false)
+	if err != nil {
+		t.Fatalf("updateFutureOccurrences failed: %v", err)
+	}
+
+	want := map[string]commons.TaskEventPayload{
+		"reassigned": {NotificationEvent: commons.NotificationEventTaskAssigned, RecipientIDs: []string{"bob"}},
+		"unassigned": {NotificationEvent: commons.NotificationEventTaskAssigned, RecipientIDs: []string{"bob"}},
+		"unchanged":  {},
+	}
+	if len(tasks.updated) != len(want) || len(outbox.added) != len(want) {
+		t.Fatalf("updated %d occurrences with %d events, want %d each", len(tasks.updated), len(outbox.added), len(want))
+	}
+	for _, event := range outbox.added {
+		var payload commons.TaskEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			t.Fatalf("event of %s has an invalid payload: %v", event.AggregateID, err)
+		}
+		wantPayload, ok := want[event.AggregateID]
+		if !ok {
+			t.Fatalf("got an event for %s, want none", event.AggregateID)
+		}
+		if !reflect.DeepEqual(payload, wantPayload) {
+			t.Errorf("event of %s has payload %+v, want %+v", event.AggregateID, payload, wantPayload)
+		}
+	}
+}
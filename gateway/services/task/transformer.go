@@ -17,6 +17,7 @@ type TaskResponse struct {
 	UpdatedAt   time.Time                 `json:"updated_at"`
 	Creator     auth.UserResponse         `json:"creator"`
 	Assignee    *auth.UserResponse        `json:"assignee,omitempty"`
+	ProjectID   *string                   `json:"project_id,omitempty"`
 	Events      []TaskSystemEventResponse `json:"events"`
 }
 
@@ -42,6 +43,7 @@ func ToTaskResponse(task commons.Task, creator commons.User, assignee *commons.U
 		CreatedAt:   task.CreatedAt,
 		UpdatedAt:   task.UpdatedAt,
 		Creator:     auth.ToUserResponse(creator),
+		ProjectID:   task.ProjectID,
 		Events:      make([]TaskSystemEventResponse, len(task.Events)),
 	}
 
@@ -88,3 +90,27 @@ func ToTaskListResponse(tasks []commons.Task, users map[string]commons.User) Tas
 
 	return response
 }
+
+type RecurrenceResponse struct {
+	SeriesID         string     `json:"series_id"`
+	RRule            string     `json:"rrule"`
+	Timezone         string     `json:"timezone"`
+	StartsAt         time.Time  `json:"starts_at"`
+	LeadTimeHours    int        `json:"lead_time_hours"`
+	OccurrenceCount  int        `json:"occurrence_count"`
+	NextOccurrenceAt *time.Time `json:"next_occurrence_at,omitempty"`
+	Ended            bool       `json:"ended"`
+}
+
+func ToRecurrenceResponse(series commons.TaskSeries) RecurrenceResponse {
+	return RecurrenceResponse{
+		SeriesID:         series.ID,
+		RRule:            series.Rule,
+		Timezone:         series.Timezone,
+		StartsAt:         series.StartsAt,
+		LeadTimeHours:    int(series.LeadTime.Hours()),
+		OccurrenceCount:  series.OccurrenceCount,
+		NextOccurrenceAt: series.NextOccurrenceAt,
+		Ended:            series.NextOccurrenceAt == nil,
+	}
+}
@@ -5,23 +5,51 @@ import (
 )
 
 type CreateTaskInput struct {
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	Priority    int        `json:"priority"`
-	DueDate     time.Time  `json:"due_date"`
-	CreatorID   string     `json:"creator_id"`
-	AssigneeID  *string    `json:"assignee_id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Priority    int       `json:"priority"`
+	DueDate     time.Time `json:"due_date"`
+	CreatorID   string    `json:"creator_id"`
+	AssigneeID  *string   `json:"assignee_id,omitempty"`
+	ProjectID   *string   `json:"project_id,omitempty"`
+	// Recurrence makes the task the first occurrence of a recurring task.
+	Recurrence *RecurrenceInput `json:"recurrence,omitempty"`
 }
 
 type UpdateTaskInput struct {
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	Priority    int        `json:"priority"`
-	DueDate     time.Time  `json:"due_date"`
-	AssigneeID  *string    `json:"assignee_id,omitempty"`
-	UserID      string     `json:"user_id"` // The ID of the user making the update
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Priority    int       `json:"priority"`
+	DueDate     time.Time `json:"due_date"`
+	AssigneeID  *string   `json:"assignee_id,omitempty"`
+	ProjectID   *string   `json:"project_id,omitempty"`
+	UserID      string    `json:"user_id"` // The ID of the user making the update
+	// Scope selects which occurrences of a recurring task are changed.
+	Scope string `json:"-"`
+	// Recurrence makes a task recurring, or changes the schedule of a
+	// recurring task from this occurrence on (scope future).
+	Recurrence *RecurrenceInput `json:"recurrence,omitempty"`
+}
+
+// Scopes of changes to an occurrence of a recurring task.
+const (
+	ScopeThis   = "this"
+	ScopeFuture = "future"
+)
+
+// RecurrenceInput describes the schedule of a recurring task. RRule is an
+// RFC 5545 RRULE subset, e.g. "FREQ=WEEKLY;BYDAY=MO". Until and Count end
+// the series and may be given here instead of in the rule.
+type RecurrenceInput struct {
+	RRule    string     `json:"rrule"`
+	Timezone string     `json:"timezone,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+	Count    int        `json:"count,omitempty"`
+	// LeadTimeHours is how long before it is due an occurrence is created
+	// (default 24, at most 744).
+	LeadTimeHours *int `json:"lead_time_hours,omitempty"`
 }
 
 type ValidationError struct {
@@ -1,6 +1,7 @@
 package task_system_event
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -10,8 +11,8 @@ import (
 )
 
 type TaskSystemEventRepository interface {
-	GetAll() ([]commons.TaskSystemEvent, error)
-	Create(event commons.TaskSystemEvent, delay int) (commons.TaskSystemEvent, error)
+	List(ctx context.Context, query commons.TaskSystemEventQuery) (commons.TaskSystemEventPage, error)
+	Create(ctx context.Context, event commons.TaskSystemEvent, delay int) (commons.TaskSystemEvent, error)
 }
 
 type Service struct {
@@ -26,16 +27,23 @@ func NewService(logger commons.Logger, taskSystemEventRepository TaskSystemEvent
 	}
 }
 
-func (s *Service) GetAll() ([]commons.TaskSystemEvent, error) {
-	events, err := s.taskSystemEventRepository.GetAll()
+// ListEvents returns the emitted events of the tasks userID can see, or of
+// all tasks when readAll is set.
+func (s *Service) ListEvents(ctx context.Context, userID string, readAll bool, query commons.TaskSystemEventQuery) (*commons.TaskSystemEventPage, error) {
+	query.VisibleTo = userID
+	if readAll {
+		query.VisibleTo = ""
+	}
+
+	page, err := s.taskSystemEventRepository.List(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
-	return events, nil
+	return &page, nil
 }
 
-func (s *Service) Create(taskId, correlationId, origin, action, message string, data interface{}, delay int) (*commons.TaskSystemEvent, error) {
+func (s *Service) Create(ctx context.Context, taskId, correlationId, origin, action, message string, data interface{}, delay int) (*commons.TaskSystemEvent, error) {
 	var jsonData string
 	if data != nil {
 		if jsonBytes, err := json.Marshal(data); err == nil {
@@ -57,7 +65,7 @@ func (s *Service) Create(taskId, correlationId, origin, action, message string,
 		CreatedAt:     now,
 	}
 
-	createdEvent, err := s.taskSystemEventRepository.Create(event, delay)
+	createdEvent, err := s.taskSystemEventRepository.Create(ctx, event, delay)
 
 	if err != nil {
 		return nil, err
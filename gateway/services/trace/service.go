@@ -0,0 +1,62 @@
+package trace
+
+import (
+	"context"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+type Repository interface {
+	GetEvents(ctx context.Context, correlationID string, visibleTo string) ([]commons.TaskSystemEvent, error)
+	List(ctx context.Context, query commons.TraceQuery) (commons.TracePage, error)
+}
+
+type Service struct {
+	logger    commons.Logger
+	traceRepo Repository
+}
+
+func NewService(logger commons.Logger, traceRepo Repository) *Service {
+	return &Service{
+		logger:    logger,
+		traceRepo: traceRepo,
+	}
+}
+
+// GetTrace returns the timeline of the events with correlationID that userID
+// can see, or of all of them when readAll is set. A trace with no visible
+// event is not found.
+func (s *Service) GetTrace(ctx context.Context, userID string, readAll bool, correlationID string, slowThreshold time.Duration) (*TraceResponse, error) {
+	visibleTo := userID
+	if readAll {
+		visibleTo = ""
+	}
+	if slowThreshold <= 0 {
+		slowThreshold = commons.DefaultTraceSlowThreshold
+	}
+
+	events, err := s.traceRepo.GetEvents(ctx, correlationID, visibleTo)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := toTraceResponse(events, slowThreshold)
+	return &trace, nil
+}
+
+// ListTraces returns the traces of the tasks userID can see, or of all tasks
+// when readAll is set.
+func (s *Service) ListTraces(ctx context.Context, userID string, readAll bool, query commons.TraceQuery) (*commons.TracePage, error) {
+	query.VisibleTo = userID
+	if readAll {
+		query.VisibleTo = ""
+	}
+
+	page, err := s.traceRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
@@ -0,0 +1,117 @@
+package trace
+
+import (
+	"encoding/json"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+// TraceResponse is the timeline of a trace, its events grouped by the
+// service they came from. Durations and gaps are measured between the times
+// events were recorded, not their emit_at.
+type TraceResponse struct {
+	CorrelationID string    `json:"correlation_id"`
+	TaskID        string    `json:"task_id"`
+	StartedAt     time.Time `json:"started_at"`
+	EndedAt       time.Time `json:"ended_at"`
+	DurationMs    int64     `json:"duration_ms"`
+	// LongestGapMs is the longest time between two consecutive events.
+	LongestGapMs int64    `json:"longest_gap_ms"`
+	Status       string   `json:"status" example:"completed"`
+	Slow         bool     `json:"slow"`
+	Failures     int      `json:"failures"`
+	Missing      []string `json:"missing"`
+	// Origins are ordered by their first event.
+	Origins []OriginResponse `json:"origins"`
+}
+
+type OriginResponse struct {
+	Origin     string    `json:"origin" example:"Notification Service"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	DurationMs int64     `json:"duration_ms"`
+	// GapMs is the time between the event before the origin's first one and
+	// that first event, e.g. how long a hand-off between services took.
+	GapMs  int64                `json:"gap_ms"`
+	Failed bool                 `json:"failed"`
+	Events []TraceEventResponse `json:"events"`
+}
+
+type TraceEventResponse struct {
+	ID      string          `json:"id"`
+	Action  string          `json:"action"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	At      time.Time       `json:"at"`
+	EmitAt  time.Time       `json:"emit_at"`
+	// OffsetMs is the time since the first event of the trace.
+	OffsetMs int64 `json:"offset_ms"`
+	// GapMs is the time since the previous event of the trace.
+	GapMs  int64 `json:"gap_ms"`
+	Failed bool  `json:"failed"`
+}
+
+// toTraceResponse builds the timeline of a trace from its events, which are
+// in the order they happened.
+func toTraceResponse(events []commons.TaskSystemEvent, slowThreshold time.Duration) TraceResponse {
+	first, last := events[0], events[len(events)-1]
+	trace := TraceResponse{
+		CorrelationID: first.CorrelationId,
+		TaskID:        first.TaskId,
+		StartedAt:     first.CreatedAt,
+		EndedAt:       last.CreatedAt,
+		DurationMs:    last.CreatedAt.Sub(first.CreatedAt).Milliseconds(),
+		Slow:          last.CreatedAt.Sub(first.CreatedAt) > slowThreshold,
+		Origins:       []OriginResponse{},
+	}
+
+	actions := make([]string, len(events))
+	origins := map[string]int{}
+	previous := first.CreatedAt
+	for i, event := range events {
+		actions[i] = event.Action
+		gap := event.CreatedAt.Sub(previous).Milliseconds()
+		trace.LongestGapMs = max(trace.LongestGapMs, gap)
+
+		response := TraceEventResponse{
+			ID:       event.ID,
+			Action:   event.Action,
+			Message:  event.Message,
+			At:       event.CreatedAt,
+			EmitAt:   event.EmitAt,
+			OffsetMs: event.CreatedAt.Sub(first.CreatedAt).Milliseconds(),
+			GapMs:    gap,
+			Failed:   commons.IsFailureAction(event.Action),
+		}
+		if json.Valid([]byte(event.JsonData)) {
+			response.Data = json.RawMessage(event.JsonData)
+		}
+		if response.Failed {
+			trace.Failures++
+		}
+
+		index, ok := origins[event.Origin]
+		if !ok {
+			index = len(trace.Origins)
+			origins[event.Origin] = index
+			trace.Origins = append(trace.Origins, OriginResponse{
+				Origin:    event.Origin,
+				StartedAt: event.CreatedAt,
+				GapMs:     gap,
+				Events:    []TraceEventResponse{},
+			})
+		}
+		origin := &trace.Origins[index]
+		origin.EndedAt = event.CreatedAt
+		origin.DurationMs = origin.EndedAt.Sub(origin.StartedAt).Milliseconds()
+		origin.Failed = origin.Failed || response.Failed
+		origin.Events = append(origin.Events, response)
+
+		previous = event.CreatedAt
+	}
+
+	trace.Missing = commons.MissingTraceOutcomes(actions)
+	trace.Status = commons.TraceStatus(trace.Failures > 0, trace.Missing, trace.EndedAt)
+	return trace
+}
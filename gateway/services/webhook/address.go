@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"sama/go-task-management/commons"
+)
+
+// blockedPrefixes are the ranges not covered by the netip.Addr predicates
+// that webhooks must not reach either.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, broadcast included
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which maps to IPv4
+}
+
+// publicAddr reports whether webhooks may be posted to addr: loopback,
+// private, link-local, multicast and reserved addresses are refused so that
+// webhooks cannot reach the services next to the gateway or the cloud
+// metadata endpoint.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURL returns commons.ErrWebhookURLNotAllowed unless every address the
+// host of rawURL resolves to is public.
+func checkURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return commons.ErrInvalidInput
+	}
+
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !publicAddr(addr) {
+			return commons.ErrWebhookURLNotAllowed
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return commons.ErrWebhookURLNotAllowed
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr) {
+			return commons.ErrWebhookURLNotAllowed
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to addresses that are not public. It runs
+// after DNS resolution, so a host that resolved to a public address when the
+// webhook was registered cannot be pointed at an internal one later.
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid webhook address %s: %w", address, err)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+	}
+	return nil
+}
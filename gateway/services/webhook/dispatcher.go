@@ -0,0 +1,206 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+const (
+	pollInterval = 2 * time.Second
+	batchSize    = 20
+	// lease is how long a claimed delivery is left alone before another
+	// dispatcher may pick it up again; it must outlast requestTimeout.
+	lease          = time.Minute
+	requestTimeout = 10 * time.Second
+	maxAttempts    = 8
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = time.Hour
+	// maxResponseBody is how much of a response body is read to measure
+	// its length.
+	maxResponseBody = 64 << 10
+)
+
+// Headers sent with every delivery. The signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook's secret,
+// prefixed with "sha256=".
+const (
+	HeaderWebhookID = "X-Webhook-Id"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type DeliveryRepository interface {
+	GetByID(ctx context.Context, id string) (commons.Webhook, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]commons.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, attempt commons.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+}
+
+// Dispatcher posts the queued webhook deliveries to their endpoints. A
+// delivery succeeds on any 2xx response; anything else is retried with
+// exponential backoff until maxAttempts. Like the outbox relay it delivers
+// at least once, so endpoints should dedupe on the event ID.
+type Dispatcher struct {
+	logger       commons.Logger
+	deliveryRepo DeliveryRepository
+	client       *http.Client
+}
+
+func NewDispatcher(logger commons.Logger, deliveryRepo DeliveryRepository) *Dispatcher {
+	return &Dispatcher{
+		logger:       logger,
+		deliveryRepo: deliveryRepo,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: newTransport(),
+			// Redirects are not followed; the endpoint has to be updated.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// newTransport returns a transport that only connects to public addresses
+// and ignores proxy settings, which would bypass that check.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout:   requestTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   dialControl,
+	}).DialContext
+	return transport
+}
+
+// Start posts due deliveries until ctx is canceled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.deliveryRepo.ClaimDueDeliveries(ctx, batchSize, lease)
+		if err != nil {
+			d.logger.ErrorContext(ctx, "Failed to claim webhook deliveries", "error", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.handle(ctx, delivery)
+		}
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) handle(ctx context.Context, delivery commons.WebhookDelivery) {
+	attempt := commons.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+	}
+
+	webhook, err := d.deliveryRepo.GetByID(ctx, delivery.WebhookID)
+	if err == commons.ErrNotFound {
+		// The webhook was deleted, and its deliveries with it.
+		return
+	}
+	if err == nil {
+		started := time.Now()
+		attempt.ResponseStatus, attempt.ResponseLength, err = d.post(ctx, webhook, delivery)
+		attempt.DurationMs = int(time.Since(started).Milliseconds())
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+
+	status, nextAttemptAt := commons.WebhookDeliveryStatusDelivered, time.Now()
+	switch {
+	case err == nil:
+		d.logger.InfoContext(ctx, "Delivered webhook event", "event_type", delivery.EventType, "event_id", delivery.EventID, "webhook_id", delivery.WebhookID)
+	case delivery.Attempts >= maxAttempts:
+		status = commons.WebhookDeliveryStatusFailed
+		d.logger.ErrorContext(ctx, "Giving up on webhook delivery", "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+	default:
+		status = commons.WebhookDeliveryStatusPending
+		delay := commons.Backoff(delivery.Attempts, baseBackoff, maxBackoff)
+		nextAttemptAt = nextAttemptAt.Add(delay)
+		d.logger.WarnContext(ctx, "Failed to deliver webhook delivery, retrying", "delivery_id", delivery.ID, "attempt", delivery.Attempts, "retry_in", delay, "error", err)
+	}
+
+	// Record the outcome even if the dispatcher is stopping meanwhile.
+	if err := d.deliveryRepo.RecordAttempt(context.WithoutCancel(ctx), attempt, status, nextAttemptAt); err != nil {
+		d.logger.ErrorContext(ctx, "Failed to record attempt of webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// post sends a delivery to its webhook and returns the response status and
+// the length of the response body, up to maxResponseBody. The body itself is
+// not kept: it could be anything the endpoint returns. Responses other than
+// 2xx are errors.
+func (d *Dispatcher) post(ctx context.Context, webhook commons.Webhook, delivery commons.WebhookDelivery) (*int, *int, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "go-task-management-webhooks")
+	request.Header.Set(HeaderWebhookID, webhook.ID)
+	request.Header.Set(HeaderDelivery, delivery.ID)
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	read, _ := io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBody))
+	length := int(read)
+	status := response.StatusCode
+	if status < 200 || status > 299 {
+		return &status, &length, fmt.Errorf("webhook responded with status %d", status)
+	}
+	return &status, &length, nil
+}
+
+// Sign returns the signature header of a delivery body sent at timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
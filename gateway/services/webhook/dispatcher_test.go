@@ -0,0 +1,19 @@
+package webhook
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	// Computed with:
+	// printf '1700000000.{"event":"task.created"}' | openssl dgst -sha256 -hmac whsec_test
+	want := "sha256=aabc548901ea3b50be05eb85dc114164830b27c602dcb16a1623b007eff48c20"
+
+	if got := Sign("whsec_test", "1700000000", []byte(`{"event":"task.created"}`)); got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if got := Sign("other_secret", "1700000000", []byte(`{"event":"task.created"}`)); got == want {
+		t.Fatalf("Sign() with another secret = %q, want a different signature", got)
+	}
+	if got := Sign("whsec_test", "1700000001", []byte(`{"event":"task.created"}`)); got == want {
+		t.Fatalf("Sign() at another timestamp = %q, want a different signature", got)
+	}
+}
@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"sama/go-task-management/commons"
+)
+
+// maxDeliveries is how many deliveries of a webhook are listed.
+const maxDeliveries = 100
+
+type Repository interface {
+	Create(ctx context.Context, webhook commons.Webhook) (commons.Webhook, error)
+	GetByID(ctx context.Context, id string) (commons.Webhook, error)
+	ListByUser(ctx context.Context, userID string) ([]commons.Webhook, error)
+	Update(ctx context.Context, webhook commons.Webhook) (commons.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]commons.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id string) (commons.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id string) (commons.WebhookDelivery, error)
+}
+
+type ProjectAuthorizer interface {
+	AuthorizeProject(ctx context.Context, projectID string, userID string, requiredRole string) (commons.ProjectMember, error)
+}
+
+type Service struct {
+	logger      commons.Logger
+	webhookRepo Repository
+	authorizer  ProjectAuthorizer
+}
+
+func NewService(logger commons.Logger, webhookRepo Repository, authorizer ProjectAuthorizer) *Service {
+	return &Service{
+		logger:      logger,
+		webhookRepo: webhookRepo,
+		authorizer:  authorizer,
+	}
+}
+
+// ListWebhooks returns the webhooks a user registered.
+func (s *Service) ListWebhooks(ctx context.Context, userID string) ([]WebhookResponse, error) {
+	webhooks, err := s.webhookRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]WebhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		response[i] = toWebhookResponse(webhook)
+	}
+	return response, nil
+}
+
+// CreateWebhook registers a webhook with a new secret, which is returned
+// only here. Project webhooks may only be registered by project owners, and
+// URLs have to resolve to public addresses.
+func (s *Service) CreateWebhook(ctx context.Context, userID string, input CreateWebhookInput) (*WebhookResponse, error) {
+	if input.ProjectID != nil {
+		if _, err := s.authorizer.AuthorizeProject(ctx, *input.ProjectID, userID, commons.ProjectRoleOwner); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkURL(ctx, input.URL); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.webhookRepo.Create(ctx, commons.Webhook{
+		UserID:    userID,
+		ProjectID: input.ProjectID,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		Active:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(created)
+	response.Secret = created.Secret
+	return &response, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, webhookID string, userID string) (*WebhookResponse, error) {
+	webhook, err := s.getOwnWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(webhook)
+	return &response, nil
+}
+
+func (s *Service) UpdateWebhook(ctx context.Context, webhookID string, userID string, input UpdateWebhookInput) (*WebhookResponse, error) {
+	webhook, err := s.getOwnWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := checkURL(ctx, *input.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *input.URL
+	}
+	if input.Events != nil {
+		webhook.Events = input.Events
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+
+	updated, err := s.webhookRepo.Update(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toWebhookResponse(updated)
+	return &response, nil
+}
+
+// DeleteWebhook removes a webhook; its pending deliveries are dropped.
+func (s *Service) DeleteWebhook(ctx context.Context, webhookID string, userID string) error {
+	if _, err := s.getOwnWebhook(ctx, webhookID, userID); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.Delete(ctx, webhookID)
+}
+
+// ListDeliveries returns the latest deliveries of a webhook, newest first.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID string, userID string) ([]commons.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.ListDeliveries(ctx, webhookID, maxDeliveries)
+}
+
+// GetDelivery returns a delivery of a webhook with the log of its attempts.
+func (s *Service) GetDelivery(ctx context.Context, webhookID string, deliveryID string, userID string) (commons.WebhookDelivery, error) {
+	if _, err := s.getOwnWebhook(ctx, webhookID, userID); err != nil {
+		return commons.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return commons.WebhookDelivery{}, err
+	}
+	if delivery.WebhookID != webhookID {
+		return commons.WebhookDelivery{}, commons.ErrNotFound
+	}
+	return delivery, nil
+}
+
+// Redeliver sends a delivery of a webhook again, with the payload it was
+// first sent with, whether it succeeded or not.
+func (s *Service) Redeliver(ctx context.Context, webhookID string, deliveryID string, userID string) (commons.WebhookDelivery, error) {
+	if _, err := s.GetDelivery(ctx, webhookID, deliveryID, userID); err != nil {
+		return commons.WebhookDelivery{}, err
+	}
+
+	return s.webhookRepo.Redeliver(ctx, deliveryID)
+}
+
+// getOwnWebhook returns a webhook registered by userID. The webhooks of
+// other users are not found.
+func (s *Service) getOwnWebhook(ctx context.Context, webhookID string, userID string) (commons.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return commons.Webhook{}, err
+	}
+
+	if webhook.UserID != userID {
+		return commons.Webhook{}, commons.ErrNotFound
+	}
+
+	return webhook, nil
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
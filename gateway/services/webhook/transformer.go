@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"time"
+
+	"sama/go-task-management/commons"
+)
+
+// CreateWebhookInput registers URL for events. Webhooks with a ProjectID get
+// the events of the project's tasks, the others those of the caller's tasks.
+type CreateWebhookInput struct {
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	ProjectID *string  `json:"project_id,omitempty"`
+}
+
+// UpdateWebhookInput changes a webhook; fields left out stay as they are.
+type UpdateWebhookInput struct {
+	URL    *string  `json:"url,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type WebhookResponse struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	ProjectID *string  `json:"project_id,omitempty"`
+	Active    bool     `json:"active"`
+	// Secret signs the deliveries. It is only returned when the webhook is
+	// created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toWebhookResponse(webhook commons.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		ProjectID: webhook.ProjectID,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
@@ -1,8 +1,24 @@
 package main
 
+import "time"
+
 const (
 	defaultRegion    = "us-east-1"
 	defaultQueueName = "go-email-service-queue"
+
+	defaultMetricsAddress = ":2112"
+
+	defaultRequestTimeoutSeconds   = 30
+	defaultReminderIntervalSeconds = 60
+	defaultDigestIntervalSeconds   = 300
+	defaultDigestHour              = 8
+	defaultDigestWeekday           = time.Monday
+	defaultDeferredIntervalSeconds = 60
+
+	defaultSmsProvider               = "fake"
+	defaultSmsTimeoutSeconds         = 10
+	defaultSmsRateLimit              = 5
+	defaultSmsRateLimitWindowSeconds = 3600
 )
 
 type Config struct {
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commons "sama/go-task-management/commons"
+)
+
+const (
+	// deferredBatchSize is how many deferred notifications are claimed at
+	// once.
+	deferredBatchSize = 100
+	// deferredLease is how long a claimed notification is left to its
+	// scheduler before another one may send it.
+	deferredLease = 5 * time.Minute
+)
+
+// DeferredNotificationScheduler sends the notifications held back during the
+// quiet hours of their recipients once these end. Each notification is sent
+// on its channel if the recipient still wants it then, and held back again
+// if they moved their quiet hours over it.
+type DeferredNotificationScheduler struct {
+	deferredRepository   commons.DeferredNotificationRepositoryInterface
+	preferenceRepository commons.NotificationPreferenceRepositoryInterface
+	dispatcher           *Dispatcher
+	interval             time.Duration
+}
+
+func NewDeferredNotificationScheduler(
+	deferredRepo commons.DeferredNotificationRepositoryInterface,
+	preferenceRepo commons.NotificationPreferenceRepositoryInterface,
+	dispatcher *Dispatcher,
+	interval time.Duration,
+) *DeferredNotificationScheduler {
+	return &DeferredNotificationScheduler{
+		deferredRepository:   deferredRepo,
+		preferenceRepository: preferenceRepo,
+		dispatcher:           dispatcher,
+		interval:             interval,
+	}
+}
+
+// Start sends due deferred notifications until ctx is canceled.
+func (s *DeferredNotificationScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DeferredNotificationScheduler) sendDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		notifications, err := s.deferredRepository.ClaimDue(ctx, deferredBatchSize, deferredLease)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to claim deferred notifications", "error", err)
+			return
+		}
+
+		for _, notification := range notifications {
+			notificationCtx := commons.WithCorrelationID(ctx, notification.CorrelationID)
+			if err := s.send(notificationCtx, notification); err != nil {
+				logger.ErrorContext(notificationCtx, "Failed to send deferred notification", "notification_id", notification.ID, "recipient_id", notification.UserID, "error", err)
+			}
+		}
+
+		if len(notifications) < deferredBatchSize {
+			return
+		}
+	}
+}
+
+// send sends a deferred notification and deletes it. If sending fails it is
+// left in place and sent again once its lease expires.
+func (s *DeferredNotificationScheduler) send(ctx context.Context, notification commons.DeferredNotification) error {
+	preferences, err := s.preferenceRepository.Get(ctx, notification.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	now := time.Now()
+	if deliverAt, ok := preferences.Defers(notification.Event, notification.Channel, now); ok {
+		if err := s.deferredRepository.Postpone(ctx, notification.ID, deliverAt); err != nil {
+			return fmt.Errorf("failed to postpone deferred notification: %w", err)
+		}
+		return nil
+	}
+
+	if preferences.Allows(notification.Event, notification.Channel, now) {
+		request := NotificationRequest{
+			CorrelationID: notification.CorrelationID,
+			Types:         []string{notification.Channel},
+			Event:         notification.Event,
+			RecipientIDs:  []string{notification.UserID},
+			Title:         notification.Title,
+			Message:       notification.Message,
+			Link:          notification.Link,
+			TaskAudience:  notification.TaskAudience,
+		}
+		if notification.TaskID != nil {
+			request.TaskID = *notification.TaskID
+		}
+		if err := s.dispatcher.process(ctx, request); err != nil {
+			return err
+		}
+	} else {
+		logger.InfoContext(ctx, "Dropping deferred notification: the recipient no longer wants it", "notification_id", notification.ID, "recipient_id", notification.UserID, "event", notification.Event, "channel", notification.Channel)
+	}
+
+	// The notification is out; only a failed delete sends it twice.
+	if err := s.deferredRepository.Delete(context.WithoutCancel(ctx), notification.ID); err != nil {
+		return fmt.Errorf("failed to delete deferred notification: %w", err)
+	}
+	return nil
+}
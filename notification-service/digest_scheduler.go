@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	commons "sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+// maxDigestEntries is how many notifications a digest email lists; the
+// remaining ones are only counted.
+const maxDigestEntries = 50
+
+// DigestSchedule is when digests are sent: daily ones every day at Hour
+// (UTC), weekly ones at Hour on Weekday.
+type DigestSchedule struct {
+	Hour    int
+	Weekday time.Weekday
+}
+
+// Period returns the latest digest period of frequency that ended at or
+// before now.
+func (s DigestSchedule) Period(frequency string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, 0, 0, 0, time.UTC)
+	if end.After(now) {
+		end = end.AddDate(0, 0, -1)
+	}
+
+	if frequency == commons.NotificationDigestWeekly {
+		for end.Weekday() != s.Weekday {
+			end = end.AddDate(0, 0, -1)
+		}
+		return end.AddDate(0, 0, -7), end
+	}
+	return end.AddDate(0, 0, -1), end
+}
+
+// DigestScheduler sends the users in digest mode one email per period with
+// their unread in-app notifications and the notifications held back for
+// them. Every digest is claimed in the database before it is sent and its
+// notifications are marked included, so none of them is sent twice.
+type DigestScheduler struct {
+	digestRepository commons.DigestRepositoryInterface
+	unitOfWork       commons.UnitOfWork
+	emailService     *EmailNotificationService
+	schedule         DigestSchedule
+	interval         time.Duration
+}
+
+func NewDigestScheduler(
+	digestRepo commons.DigestRepositoryInterface,
+	unitOfWork commons.UnitOfWork,
+	emailService *EmailNotificationService,
+	schedule DigestSchedule,
+	interval time.Duration,
+) *DigestScheduler {
+	return &DigestScheduler{
+		digestRepository: digestRepo,
+		unitOfWork:       unitOfWork,
+		emailService:     emailService,
+		schedule:         schedule,
+		interval:         interval,
+	}
+}
+
+// Start sends due digests until ctx is canceled.
+func (s *DigestScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DigestScheduler) sendDue(ctx context.Context) {
+	now := time.Now()
+	for _, frequency := range []string{commons.NotificationDigestDaily, commons.NotificationDigestWeekly} {
+		userIDs, err := s.digestRepository.ListUsers(ctx, frequency)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to list users for digests", "frequency", frequency, "error", err)
+			return
+		}
+
+		periodStart, periodEnd := s.schedule.Period(frequency, now)
+		for _, userID := range userIDs {
+			if ctx.Err() != nil {
+				return
+			}
+
+			digest := commons.NotificationDigest{
+				ID:            uuid.New().String(),
+				UserID:        userID,
+				Frequency:     frequency,
+				PeriodStart:   periodStart,
+				PeriodEnd:     periodEnd,
+				CorrelationID: uuid.New().String(),
+				CreatedAt:     now,
+			}
+			digestCtx := commons.WithCorrelationID(ctx, digest.CorrelationID)
+			if err := s.send(digestCtx, digest); err != nil {
+				logger.ErrorContext(digestCtx, "Failed to send digest", "frequency", frequency, "recipient_id", userID, "error", err)
+			}
+		}
+	}
+}
+
+// send claims a digest, marks the notifications it includes and queues its
+// email. If queueing fails everything is rolled back, so it is tried again.
+// Digests without notifications are claimed but not sent.
+func (s *DigestScheduler) send(ctx context.Context, digest commons.NotificationDigest) error {
+	return s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		claimed, err := repos.Digests.Claim(ctx, digest)
+		if err != nil {
+			return fmt.Errorf("failed to claim digest: %w", err)
+		}
+		if !claimed {
+			return nil
+		}
+
+		items, err := repos.Digests.ListPendingItems(ctx, digest.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to list digest items: %w", err)
+		}
+		notifications, err := repos.Digests.ListUnreadNotifications(ctx, digest.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to list unread notifications: %w", err)
+		}
+		if len(items) == 0 && len(notifications) == 0 {
+			return nil
+		}
+
+		user, err := repos.Users.GetByID(ctx, digest.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.ID == "" || user.Email == "" {
+			logger.InfoContext(ctx, "Skipping digest: no email address", "frequency", digest.Frequency, "recipient_id", digest.UserID)
+			return nil
+		}
+
+		itemIDs := make([]string, 0, len(items))
+		entries := make([]commons.DigestEntry, 0, len(items)+len(notifications))
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.ID)
+			entry := commons.DigestEntry{Title: item.Title, Message: item.Message, CreatedAt: item.CreatedAt}
+			if item.TaskID != nil {
+				entry.TaskID = *item.TaskID
+			}
+			entries = append(entries, entry)
+		}
+
+		notificationIDs := make([]string, 0, len(notifications))
+		for _, notification := range notifications {
+			notificationIDs = append(notificationIDs, notification.ID)
+			entries = append(entries, commons.DigestEntry{
+				Title:     notification.Title,
+				Message:   notification.Description,
+				CreatedAt: notification.CreatedAt,
+			})
+		}
+
+		if err := repos.Digests.MarkIncluded(ctx, digest.ID, itemIDs, notificationIDs); err != nil {
+			return fmt.Errorf("failed to mark digest items included: %w", err)
+		}
+
+		return s.emailService.SendDigest(ctx, user, digest.CorrelationID, newDigestEmail(digest, entries))
+	})
+}
+
+// newDigestEmail lists the newest entries first, up to maxDigestEntries.
+func newDigestEmail(digest commons.NotificationDigest, entries []commons.DigestEntry) commons.DigestEmail {
+	slices.SortStableFunc(entries, func(a, b commons.DigestEntry) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	email := commons.DigestEmail{
+		Frequency:   digest.Frequency,
+		PeriodStart: digest.PeriodStart,
+		PeriodEnd:   digest.PeriodEnd,
+		Entries:     entries,
+	}
+	if len(entries) > maxDigestEntries {
+		email.Entries = entries[:maxDigestEntries]
+		email.More = len(entries) - maxDigestEntries
+	}
+	return email
+}
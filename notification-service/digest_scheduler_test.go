@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	commons "sama/go-task-management/commons"
+)
+
+func TestDigestSchedulePeriod(t *testing.T) {
+	// 10 March 2025 is a Monday.
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2025, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	schedule := DigestSchedule{Hour: 8, Weekday: time.Monday}
+
+	tests := []struct {
+		name      string
+		frequency string
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"daily before the hour", commons.NotificationDigestDaily, at(12, 7, 59), at(10, 8, 0), at(11, 8, 0)},
+		{"daily at the hour", commons.NotificationDigestDaily, at(12, 8, 0), at(11, 8, 0), at(12, 8, 0)},
+		{"daily after the hour", commons.NotificationDigestDaily, at(12, 23, 0), at(11, 8, 0), at(12, 8, 0)},
+		{"daily in another time zone", commons.NotificationDigestDaily, time.Date(2025, 3, 12, 9, 30, 0, 0, time.FixedZone("UTC+2", 2*60*60)), at(10, 8, 0), at(11, 8, 0)},
+		{"weekly before the hour on the weekday", commons.NotificationDigestWeekly, at(10, 7, 59), time.Date(2025, 2, 24, 8, 0, 0, 0, time.UTC), at(3, 8, 0)},
+		{"weekly at the hour on the weekday", commons.NotificationDigestWeekly, at(10, 8, 0), at(3, 8, 0), at(10, 8, 0)},
+		{"weekly later in the week", commons.NotificationDigestWeekly, at(13, 12, 0), at(3, 8, 0), at(10, 8, 0)},
+		{"weekly on the day before the weekday", commons.NotificationDigestWeekly, at(16, 23, 0), at(3, 8, 0), at(10, 8, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := schedule.Period(tt.frequency, tt.now)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Fatalf("Period() = %v - %v, want %v - %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestNewDigestEmail(t *testing.T) {
+	created := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	digest := commons.NotificationDigest{
+		Frequency:   commons.NotificationDigestDaily,
+		PeriodStart: created.AddDate(0, 0, -1),
+		PeriodEnd:   created,
+	}
+
+	tests := []struct {
+		entries     int
+		wantEntries int
+		wantMore    int
+	}{
+		{1, 1, 0},
+		{maxDigestEntries - 1, maxDigestEntries - 1, 0},
+		{maxDigestEntries, maxDigestEntries, 0},
+		{maxDigestEntries + 1, maxDigestEntries, 1},
+		{maxDigestEntries + 70, maxDigestEntries, 70},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d entries", tt.entries), func(t *testing.T) {
+			// The entries are oldest first, as the digest items are listed.
+			entries := make([]commons.DigestEntry, tt.entries)
+			for i := range entries {
+				entries[i] = commons.DigestEntry{Title: fmt.Sprint(i), CreatedAt: created.Add(-time.Duration(tt.entries-i) * time.Minute)}
+			}
+
+			email := newDigestEmail(digest, entries)
+			if len(email.Entries) != tt.wantEntries || email.More != tt.wantMore {
+				t.Fatalf("email lists %d entries and %d more, want %d and %d more", len(email.Entries), email.More, tt.wantEntries, tt.wantMore)
+			}
+			if newest := fmt.Sprint(tt.entries - 1); email.Entries[0].Title != newest {
+				t.Fatalf("first entry is %s, want the newest one, %s", email.Entries[0].Title, newest)
+			}
+			for i := 1; i < len(email.Entries); i++ {
+				if email.Entries[i].CreatedAt.After(email.Entries[i-1].CreatedAt) {
+					t.Fatalf("entry %d is newer than the one before it", i)
+				}
+			}
+			if email.Frequency != digest.Frequency || !email.PeriodStart.Equal(digest.PeriodStart) || !email.PeriodEnd.Equal(digest.PeriodEnd) {
+				t.Fatalf("email is for %s %v - %v, want the digest's period", email.Frequency, email.PeriodStart, email.PeriodEnd)
+			}
+		})
+	}
+}
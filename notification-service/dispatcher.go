@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	commons "sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+// eventTitles are the titles of digest items and SMS of events that come
+// without one, formatted with the task title.
+var eventTitles = map[string]string{
+	commons.NotificationEventTaskCreated:       "New task: %s",
+	commons.NotificationEventTaskAssigned:      "Assigned to you: %s",
+	commons.NotificationEventTaskStatusChanged: "Status changed: %s",
+}
+
+// Dispatcher sends notifications through the strategies of the channels
+// their recipients chose in their notification preferences. Events users
+// have no preferences for, such as password resets, go to the channels the
+// caller asked for. Sent task notifications are queued for the webhooks
+// subscribed to notification.sent.
+type Dispatcher struct {
+	strategies                []NotificationStrategy
+	taskRepository            commons.TaskRepositoryInterface
+	taskSystemEventRepository commons.TaskSystemEventRepositoryInterface
+	preferenceRepository      commons.NotificationPreferenceRepositoryInterface
+	digestRepository          commons.DigestRepositoryInterface
+	deferredRepository        commons.DeferredNotificationRepositoryInterface
+	webhookRepository         commons.WebhookRepositoryInterface
+}
+
+func NewDispatcher(
+	strategies []NotificationStrategy,
+	taskRepo commons.TaskRepositoryInterface,
+	taskSystemEventRepo commons.TaskSystemEventRepositoryInterface,
+	preferenceRepo commons.NotificationPreferenceRepositoryInterface,
+	digestRepo commons.DigestRepositoryInterface,
+	deferredRepo commons.DeferredNotificationRepositoryInterface,
+	webhookRepo commons.WebhookRepositoryInterface,
+) *Dispatcher {
+	return &Dispatcher{
+		strategies:                strategies,
+		taskRepository:            taskRepo,
+		taskSystemEventRepository: taskSystemEventRepo,
+		preferenceRepository:      preferenceRepo,
+		digestRepository:          digestRepo,
+		deferredRepository:        deferredRepo,
+		webhookRepository:         webhookRepo,
+	}
+}
+
+// Dispatch sends request to each of its recipients on the channels they
+// allow for its event. Without explicit recipients, the task's creator and
+// assignee are notified. Recipients in digest mode get it in their next
+// digest instead, and recipients in their quiet hours once these end.
+// Notifications nobody wants are dropped and recorded as a system event.
+func (d *Dispatcher) Dispatch(ctx context.Context, request NotificationRequest) error {
+	channels, ok := commons.NotificationEventChannels[request.Event]
+	if !ok {
+		return d.process(ctx, request)
+	}
+
+	request, preferences, err := d.recipientPreferences(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dispatched := false
+	for _, channel := range channels {
+		sent, err := d.dispatchOn(ctx, request, channel, preferences, now)
+		if err != nil {
+			return err
+		}
+		dispatched = dispatched || sent
+	}
+
+	if !dispatched {
+		return d.recordNotSent(ctx, request, request.RecipientIDs)
+	}
+	return nil
+}
+
+// DispatchOn is Dispatch for one of the channels of request's event, for
+// callers that record per channel what they sent. It reports whether any
+// recipient gets the notification on channel, now or later.
+func (d *Dispatcher) DispatchOn(ctx context.Context, request NotificationRequest, channel string) (bool, error) {
+	if !slices.Contains(commons.NotificationEventChannels[request.Event], channel) {
+		return false, fmt.Errorf("%s notifications are not sent on %s", request.Event, channel)
+	}
+
+	request, preferences, err := d.recipientPreferences(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	return d.dispatchOn(ctx, request, channel, preferences, time.Now())
+}
+
+// recipientPreferences returns request with its recipients, the task's
+// creator and assignee if it has none, and their notification preferences.
+func (d *Dispatcher) recipientPreferences(ctx context.Context, request NotificationRequest) (NotificationRequest, map[string]commons.NotificationPreferences, error) {
+	if len(request.RecipientIDs) == 0 {
+		task, err := d.taskRepository.GetByID(ctx, request.TaskID)
+		if err != nil {
+			return request, nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		request.RecipientIDs = taskRecipients(task)
+		request.TaskAudience = true
+	}
+
+	preferences := make(map[string]commons.NotificationPreferences, len(request.RecipientIDs))
+	for _, userID := range request.RecipientIDs {
+		userPreferences, err := d.preferenceRepository.Get(ctx, userID)
+		if err != nil {
+			return request, nil, fmt.Errorf("failed to get notification preferences of %s: %w", userID, err)
+		}
+		preferences[userID] = userPreferences
+	}
+	return request, preferences, nil
+}
+
+// dispatchOn sends request on channel to the recipients who allow it now,
+// holds it back for their digest or the end of their quiet hours for the
+// others, and reports whether any recipient gets it.
+func (d *Dispatcher) dispatchOn(ctx context.Context, request NotificationRequest, channel string, preferences map[string]commons.NotificationPreferences, now time.Time) (bool, error) {
+	var allowed, digested []string
+	deferred := make(map[string]time.Time)
+	for _, userID := range request.RecipientIDs {
+		switch {
+		case preferences[userID].Allows(request.Event, channel, now):
+			allowed = append(allowed, userID)
+		case digestChannel(preferences[userID], request.Event) == channel:
+			digested = append(digested, userID)
+		default:
+			if deliverAt, ok := preferences[userID].Defers(request.Event, channel, now); ok {
+				deferred[userID] = deliverAt
+			}
+		}
+	}
+
+	dispatched := false
+	if len(digested) > 0 {
+		if err := d.holdBack(ctx, request, digested); err != nil {
+			return false, err
+		}
+		dispatched = true
+	}
+	if len(deferred) > 0 {
+		if err := d.deferUntil(ctx, request, channel, deferred); err != nil {
+			return dispatched, err
+		}
+		dispatched = true
+	}
+	if len(allowed) == 0 {
+		return dispatched, nil
+	}
+
+	channelRequest := request
+	channelRequest.Types = []string{channel}
+	channelRequest.RecipientIDs = allowed
+	if err := d.process(ctx, channelRequest); err != nil {
+		return dispatched, err
+	}
+	return true, nil
+}
+
+// digestChannel returns the first channel on which preferences hold event
+// back for the digest, or "" if none does. A notification held back on
+// several channels is only put in the digest on this one, so it is in the
+// digest once.
+func digestChannel(preferences commons.NotificationPreferences, event string) string {
+	for _, channel := range commons.NotificationEventChannels[event] {
+		if preferences.Digests(event, channel) {
+			return channel
+		}
+	}
+	return ""
+}
+
+// deferUntil stores request on channel for each of the given users, to be
+// sent by the DeferredNotificationScheduler when their quiet hours end.
+func (d *Dispatcher) deferUntil(ctx context.Context, request NotificationRequest, channel string, deliverAt map[string]time.Time) error {
+	var taskID *string
+	if request.TaskID != "" {
+		taskID = &request.TaskID
+	}
+
+	for userID, at := range deliverAt {
+		err := d.deferredRepository.Add(ctx, commons.DeferredNotification{
+			UserID:        userID,
+			Channel:       channel,
+			Event:         request.Event,
+			TaskID:        taskID,
+			CorrelationID: request.CorrelationID,
+			Title:         request.Title,
+			Message:       request.Message,
+			Link:          request.Link,
+			TaskAudience:  request.TaskAudience,
+			DeliverAt:     at,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to defer notification for %s: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// recordNotSent records that none of the recipients of a task notification
+// wants it, so the task's history shows why nothing was sent.
+func (d *Dispatcher) recordNotSent(ctx context.Context, request NotificationRequest, recipients []string) error {
+	if request.TaskID == "" {
+		logger.InfoContext(ctx, "Notification not sent: no recipient wants it", "event", request.Event)
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]any{"event": request.Event, "recipient_ids": recipients})
+	if err != nil {
+		return err
+	}
+
+	_, err = d.taskSystemEventRepository.Create(ctx, commons.TaskSystemEvent{
+		TaskId:        request.TaskID,
+		CorrelationId: request.CorrelationID,
+		Origin:        "Notification Service",
+		Action:        "notification:event:not-sent",
+		Message:       "Notification not sent: no recipient wants it",
+		JsonData:      string(data),
+	}, 10)
+	if err != nil {
+		return fmt.Errorf("failed to create system event: %w", err)
+	}
+	return nil
+}
+
+// holdBack stores request as a digest item of each of the given users.
+func (d *Dispatcher) holdBack(ctx context.Context, request NotificationRequest, userIDs []string) error {
+	title, message, err := notificationText(ctx, d.taskRepository, request)
+	if err != nil {
+		return err
+	}
+
+	var taskID *string
+	if request.TaskID != "" {
+		taskID = &request.TaskID
+	}
+
+	for _, userID := range userIDs {
+		err := d.digestRepository.AddItem(ctx, commons.DigestItem{
+			UserID:  userID,
+			TaskID:  taskID,
+			Event:   request.Event,
+			Title:   title,
+			Message: message,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to hold back notification for the digest of %s: %w", userID, err)
+		}
+	}
+	return nil
+}
+
+// notificationText returns the title and message of request, made up from
+// its task if it has no title.
+func notificationText(ctx context.Context, taskRepository commons.TaskRepositoryInterface, request NotificationRequest) (string, string, error) {
+	if request.Title != "" {
+		return request.Title, request.Message, nil
+	}
+
+	task, err := taskRepository.GetByID(ctx, request.TaskID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get task: %w", err)
+	}
+
+	title, message := task.Title, request.Message
+	if format, ok := eventTitles[request.Event]; ok {
+		title = fmt.Sprintf(format, task.Title)
+	}
+	if message == "" && request.Event == commons.NotificationEventTaskStatusChanged {
+		message = "The status is now " + task.Status
+	}
+	return title, message, nil
+}
+
+// process runs every strategy that handles one of the request's types.
+func (d *Dispatcher) process(ctx context.Context, request NotificationRequest) error {
+	processed := false
+	for _, strategy := range d.strategies {
+		if strategy.CanProcess(request.Types) {
+			if err := strategy.Process(ctx, request); err != nil {
+				strategyFailures.WithLabelValues(strategy.Type(), request.Event).Inc()
+				return fmt.Errorf("failed to process notification: %w", err)
+			}
+			processed = true
+		}
+	}
+
+	if !processed {
+		return fmt.Errorf("no valid notification strategy found for types: %v", request.Types)
+	}
+
+	// The notification is out; failing now would only send it twice.
+	if err := d.queueWebhooks(ctx, request); err != nil {
+		logger.ErrorContext(ctx, "Failed to queue notification.sent webhooks", "error", err)
+	}
+	return nil
+}
+
+// queueWebhooks queues a sent task notification for the webhooks of its
+// recipients and of its task's project. The event carries no title or
+// message, only what was sent to whom.
+func (d *Dispatcher) queueWebhooks(ctx context.Context, request NotificationRequest) error {
+	if request.TaskID == "" {
+		return nil
+	}
+
+	task, err := d.taskRepository.GetByID(ctx, request.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+	recipients := request.RecipientIDs
+	if len(recipients) == 0 {
+		recipients = taskRecipients(task)
+	}
+
+	// The ID only depends on the notification, its channels and its
+	// recipients, so a redelivered notification is not queued twice while
+	// the ones deferred for each recipient are queued once each.
+	channels := strings.Join(request.Types, ",")
+	eventID := uuid.NewSHA1(uuid.NameSpaceOID, []byte(request.CorrelationID+"/"+request.Event+"/"+channels+"/"+strings.Join(recipients, ","))).String()
+
+	_, err = d.webhookRepository.Enqueue(ctx, commons.WebhookEvent{
+		ID:        eventID,
+		Type:      commons.WebhookEventNotificationSent,
+		TaskID:    task.ID,
+		ProjectID: task.ProjectID,
+		UserIDs:   recipients,
+		Data: commons.WebhookNotificationData{
+			TaskID:        task.ID,
+			CorrelationID: request.CorrelationID,
+			Event:         request.Event,
+			Channels:      request.Types,
+			RecipientIDs:  recipients,
+		},
+	})
+	return err
+}
+
+// taskRecipients returns the creator and the assignee of a task.
+func taskRecipients(task commons.Task) []string {
+	recipients := []string{task.CreatorID}
+	if task.AssigneeID != nil && *task.AssigneeID != "" && *task.AssigneeID != task.CreatorID {
+		recipients = append(recipients, *task.AssigneeID)
+	}
+	return recipients
+}
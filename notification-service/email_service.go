@@ -4,49 +4,69 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"sync"
 
 	commons "sama/go-task-management/commons"
 )
 
+// EmailEvent is the message put on the email queue. Type selects the email;
+// the remaining fields are set as that type requires.
 type EmailEvent struct {
-	TaskID        string `json:"taskId"`
-	CorrelationID string `json:"correlationId"`
+	Type          string   `json:"type"`
+	Event         string   `json:"event,omitempty"`
+	TaskID        string   `json:"taskId,omitempty"`
+	CorrelationID string   `json:"correlationId"`
+	RecipientIDs  []string `json:"recipientIds,omitempty"`
+	To            string   `json:"to,omitempty"`
+	Handle        string   `json:"handle,omitempty"`
+	Message       string   `json:"message,omitempty"`
+	Link          string   `json:"link,omitempty"`
+	// Digest is the content of a digest email.
+	Digest *commons.DigestEmail `json:"digest,omitempty"`
 }
 
 type EmailNotificationService struct {
 	taskRepository            commons.TaskRepositoryInterface
 	taskSystemEventRepository commons.TaskSystemEventRepositoryInterface
-	sqsClient                SQSClientInterface
+	userRepository            commons.UserRepositoryInterface
+	sqsClient                 SQSClientInterface
 }
 
 func NewEmailNotificationService(
 	taskRepo commons.TaskRepositoryInterface,
 	eventRepo commons.TaskSystemEventRepositoryInterface,
+	userRepo commons.UserRepositoryInterface,
 	sqsClient SQSClientInterface,
 ) *EmailNotificationService {
 	return &EmailNotificationService{
 		taskRepository:            taskRepo,
 		taskSystemEventRepository: eventRepo,
-		sqsClient:                sqsClient,
+		userRepository:            userRepo,
+		sqsClient:                 sqsClient,
 	}
 }
 
-func (s *EmailNotificationService) Handle(ctx context.Context, taskID, correlationID string, _ []string) error {
-	task, err := s.taskRepository.GetByID(taskID)
-	if err != nil {
+func (s *EmailNotificationService) Handle(ctx context.Context, request NotificationRequest) error {
+	if request.Event == commons.NotificationEventPasswordReset {
+		return s.sendPasswordResetEmail(ctx, request)
+	}
+
+	// The task's email_sent flag is set by the email service once an email
+	// has actually been delivered.
+	if _, err := s.taskRepository.GetByID(ctx, request.TaskID); err != nil {
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if err := s.processNotificationEvents(ctx, taskID, correlationID); err != nil {
+	if err := s.processNotificationEvents(ctx, request); err != nil {
 		return fmt.Errorf("failed to process notification events: %w", err)
 	}
 
-	return s.updateTaskStatus(ctx, &task)
+	return nil
 }
 
-func (s *EmailNotificationService) processNotificationEvents(ctx context.Context, taskID, correlationID string) error {
+func (s *EmailNotificationService) processNotificationEvents(ctx context.Context, request NotificationRequest) error {
+	taskID, correlationID := request.TaskID, request.CorrelationID
+
 	var wg sync.WaitGroup
 	errChan := make(chan error, 2)
 
@@ -57,7 +77,7 @@ func (s *EmailNotificationService) processNotificationEvents(ctx context.Context
 
 	go func() {
 		defer wg.Done()
-		if err := s.sendEmailNotification(ctx, taskID, correlationID); err != nil {
+		if err := s.sendEmailNotification(ctx, request); err != nil {
 			errChan <- err
 		}
 	}()
@@ -74,7 +94,7 @@ func (s *EmailNotificationService) processNotificationEvents(ctx context.Context
 	return nil
 }
 
-func (s *EmailNotificationService) createSystemEvent(_ context.Context, wg *sync.WaitGroup, errChan chan<- error,
+func (s *EmailNotificationService) createSystemEvent(ctx context.Context, wg *sync.WaitGroup, errChan chan<- error,
 	taskID, correlationID, origin, action, message string, priority int) {
 	defer wg.Done()
 
@@ -87,37 +107,91 @@ func (s *EmailNotificationService) createSystemEvent(_ context.Context, wg *sync
 		JsonData:      "{}",
 	}
 
-	_, err := s.taskSystemEventRepository.Create(event, priority)
+	_, err := s.taskSystemEventRepository.Create(ctx, event, priority)
 	if err != nil {
 		errChan <- fmt.Errorf("failed to create system event: %w", err)
 	}
 }
 
-func (s *EmailNotificationService) sendEmailNotification(ctx context.Context, taskID, correlationID string) error {
+func (s *EmailNotificationService) sendEmailNotification(ctx context.Context, request NotificationRequest) error {
 	emailEvent := EmailEvent{
-		TaskID:        taskID,
-		CorrelationID: correlationID,
+		Type:          commons.EmailMessageTypeTaskNotification,
+		Event:         request.Event,
+		TaskID:        request.TaskID,
+		CorrelationID: request.CorrelationID,
+		RecipientIDs:  request.RecipientIDs,
 	}
 
-	jsonBytes, err := json.Marshal(emailEvent)
+	if err := s.enqueue(ctx, emailEvent); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Queued task notification email", "task_id", request.TaskID)
+	return nil
+}
+
+// sendPasswordResetEmail queues the reset link for the single recipient of the
+// request. It is not tied to a task, so no task system events are recorded.
+func (s *EmailNotificationService) sendPasswordResetEmail(ctx context.Context, request NotificationRequest) error {
+	if len(request.RecipientIDs) != 1 || request.Link == "" {
+		return fmt.Errorf("password reset email requires one recipient and a link")
+	}
+
+	user, err := s.userRepository.GetByID(ctx, request.RecipientIDs[0])
 	if err != nil {
-		return fmt.Errorf("failed to marshal email event: %w", err)
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.ID == "" {
+		return fmt.Errorf("user %s not found", request.RecipientIDs[0])
 	}
 
-	if err := s.sqsClient.SendMessage(ctx, string(jsonBytes)); err != nil {
-		return fmt.Errorf("failed to send message to SQS: %w", err)
+	emailEvent := EmailEvent{
+		Type:          commons.EmailMessageTypePasswordReset,
+		CorrelationID: request.CorrelationID,
+		RecipientIDs:  []string{user.ID},
+		To:            user.Email,
+		Handle:        user.Handle,
+		Message:       request.Message,
+		Link:          request.Link,
+	}
+
+	if err := s.enqueue(ctx, emailEvent); err != nil {
+		return err
 	}
 
-	log.Printf("Successfully sent task notification to SQS queue for email processing: %s", taskID)
+	logger.InfoContext(ctx, "Queued password reset email", "recipient_id", user.ID)
 	return nil
 }
 
-func (s *EmailNotificationService) updateTaskStatus(_ context.Context, task *commons.Task) error {
-	task.EmailSent = true
+// SendDigest queues a digest email for user. Like password reset emails,
+// digests are not tied to a task, so no task system events are recorded.
+func (s *EmailNotificationService) SendDigest(ctx context.Context, user commons.User, correlationID string, digest commons.DigestEmail) error {
+	emailEvent := EmailEvent{
+		Type:          commons.EmailMessageTypeDigest,
+		CorrelationID: correlationID,
+		RecipientIDs:  []string{user.ID},
+		To:            user.Email,
+		Handle:        user.Handle,
+		Digest:        &digest,
+	}
+
+	if err := s.enqueue(ctx, emailEvent); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "Queued digest email", "recipient_id", user.ID)
+	return nil
+}
 
-	err := s.taskRepository.Update(*task)
+func (s *EmailNotificationService) enqueue(ctx context.Context, emailEvent EmailEvent) error {
+	jsonBytes, err := json.Marshal(emailEvent)
 	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+		return fmt.Errorf("failed to marshal email event: %w", err)
 	}
+
+	if err := s.sqsClient.SendMessage(ctx, string(jsonBytes)); err != nil {
+		return fmt.Errorf("failed to send message to SQS: %w", err)
+	}
+
 	return nil
 }
@@ -17,6 +17,10 @@ func (s *EmailNotificationStrategy) CanProcess(types []string) bool {
 	return slices.Contains(types, "EMAIL")
 }
 
-func (s *EmailNotificationStrategy) Process(ctx context.Context, taskID string, correlationID string, types []string) error {
-	return s.emailService.Handle(ctx, taskID, correlationID, types)
+func (s *EmailNotificationStrategy) Type() string {
+	return "EMAIL"
+}
+
+func (s *EmailNotificationStrategy) Process(ctx context.Context, request NotificationRequest) error {
+	return s.emailService.Handle(ctx, request)
 }
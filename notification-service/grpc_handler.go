@@ -2,31 +2,44 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"time"
 
+	commons "sama/go-task-management/commons"
 	pb "sama/go-task-management/commons/api"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type handler struct {
-	strategies []NotificationStrategy
+	dispatcher *Dispatcher
+	// requestTimeout caps every request; a caller's earlier deadline still
+	// applies.
+	requestTimeout time.Duration
 	pb.UnimplementedNotificationServiceServer
 }
 
 func NewGrpcHandler(
 	grpcServer *grpc.Server,
-	inAppService *InAppNotificationService,
-	emailService *EmailNotificationService,
+	dispatcher *Dispatcher,
+	requestTimeout time.Duration,
 ) *handler {
-	strategies := []NotificationStrategy{
+	handler := &handler{dispatcher: dispatcher, requestTimeout: requestTimeout}
+	pb.RegisterNotificationServiceServer(grpcServer, handler)
+	return handler
+}
+
+// NewNotificationStrategies returns the strategies of every channel the
+// service delivers notifications on.
+func NewNotificationStrategies(inAppService *InAppNotificationService, emailService *EmailNotificationService, smsService *SmsNotificationService) []NotificationStrategy {
+	return []NotificationStrategy{
 		NewInAppNotificationStrategy(inAppService),
 		NewEmailNotificationStrategy(emailService),
+		NewSmsNotificationStrategy(smsService),
 	}
-	handler := &handler{strategies: strategies}
-	pb.RegisterNotificationServiceServer(grpcServer, handler)
-	return handler
 }
 
 func (h *handler) validateAndHandleNotificationTypes(ctx context.Context, in *pb.SendNotificationRequest) error {
@@ -36,28 +49,41 @@ func (h *handler) validateAndHandleNotificationTypes(ctx context.Context, in *pb
 	}
 
 	if len(types) == 0 && len(in.Types) > 0 {
-		log.Println("Warning: Received notification request with empty type values")
+		logger.WarnContext(ctx, "Received notification request with empty type values")
 	}
 
-	processed := false
-	for _, strategy := range h.strategies {
-		if strategy.CanProcess(types) {
-			if err := strategy.Process(ctx, in.TaskId, in.CorrelationId, types); err != nil {
-				return fmt.Errorf("failed to process notification: %w", err)
-			}
-			processed = true
-		}
+	event := in.Event
+	if event == "" {
+		event = commons.NotificationEventTaskCreated
 	}
 
-	if !processed {
-		return fmt.Errorf("no valid notification strategy found for types: %v", types)
+	request := NotificationRequest{
+		TaskID:        in.TaskId,
+		CorrelationID: in.CorrelationId,
+		Types:         types,
+		Event:         event,
+		RecipientIDs:  in.RecipientIds,
+		Title:         in.Title,
+		Message:       in.Message,
+		Link:          in.Link,
 	}
 
-	return nil
+	return h.dispatcher.Dispatch(ctx, request)
 }
 
 func (h *handler) SendNotification(ctx context.Context, in *pb.SendNotificationRequest) (*pb.SendNotificationResponse, error) {
-	if err := h.validateAndHandleNotificationTypes(ctx, in); err != nil {
+	ctx, cancel := context.WithTimeout(commons.WithCorrelationID(ctx, in.CorrelationId), h.requestTimeout)
+	defer cancel()
+
+	// Lets the spans of a request be found from its system events.
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("task.id", in.TaskId),
+		attribute.String("correlation_id", in.CorrelationId),
+	)
+
+	err := h.validateAndHandleNotificationTypes(ctx, in)
+	recordNotificationRequest(in.Types, status.Code(err))
+	if err != nil {
 		return nil, err
 	}
 
@@ -65,3 +91,15 @@ func (h *handler) SendNotification(ctx context.Context, in *pb.SendNotificationR
 		Ack: "Notification sent",
 	}, nil
 }
+
+// recordNotificationRequest counts a SendNotification call once per type it
+// asked for, or as UNSPECIFIED if it asked for none.
+func recordNotificationRequest(types []pb.NotificationType, code codes.Code) {
+	if len(types) == 0 {
+		notificationRequests.WithLabelValues("UNSPECIFIED", code.String()).Inc()
+		return
+	}
+	for _, t := range types {
+		notificationRequests.WithLabelValues(t.String(), code.String()).Inc()
+	}
+}
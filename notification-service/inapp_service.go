@@ -3,47 +3,96 @@ package main
 import (
 	"context"
 	"fmt"
-	"sync"
 
 	commons "sama/go-task-management/commons"
 )
 
 type InAppNotificationService struct {
-	taskRepository              commons.TaskRepositoryInterface
-	taskSystemEventRepository   commons.TaskSystemEventRepositoryInterface
-	inAppNotificationRepository commons.InAppNotificationRepositoryInterface
+	taskRepository commons.TaskRepositoryInterface
+	unitOfWork     commons.UnitOfWork
 }
 
 func NewInAppNotificationService(
 	taskRepo commons.TaskRepositoryInterface,
-	eventRepo commons.TaskSystemEventRepositoryInterface,
-	notifRepo commons.InAppNotificationRepositoryInterface,
+	unitOfWork commons.UnitOfWork,
 ) *InAppNotificationService {
 	return &InAppNotificationService{
-		taskRepository:              taskRepo,
-		taskSystemEventRepository:   eventRepo,
-		inAppNotificationRepository: notifRepo,
+		taskRepository: taskRepo,
+		unitOfWork:     unitOfWork,
 	}
 }
 
-func (s *InAppNotificationService) Handle(ctx context.Context, taskID, correlationID string, _ []string) error {
-	task, err := s.taskRepository.GetByID(taskID)
+// Handle creates the notifications, their system event and, for task
+// notifications, the task's in_app_sent flag in one transaction.
+func (s *InAppNotificationService) Handle(ctx context.Context, request NotificationRequest) error {
+	task, err := s.taskRepository.GetByID(ctx, request.TaskID)
 	if err != nil {
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
-	if err := s.createInAppNotification(ctx, &task); err != nil {
-		return fmt.Errorf("failed to create in-app notification: %w", err)
+	return s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		if len(request.RecipientIDs) > 0 {
+			if err := s.createRecipientNotifications(ctx, repos, &task, request); err != nil {
+				return fmt.Errorf("failed to create in-app notification: %w", err)
+			}
+
+			if err := s.createSystemEvent(ctx, repos, request.TaskID, request.CorrelationID); err != nil {
+				return err
+			}
+
+			if request.TaskAudience {
+				if err := repos.Tasks.MarkInAppSent(ctx, task.ID); err != nil {
+					return fmt.Errorf("failed to update task status: %w", err)
+				}
+			}
+			return nil
+		}
+
+		if err := s.createInAppNotification(ctx, repos, &task); err != nil {
+			return fmt.Errorf("failed to create in-app notification: %w", err)
+		}
+
+		if err := s.createSystemEvent(ctx, repos, request.TaskID, request.CorrelationID); err != nil {
+			return err
+		}
+
+		if err := repos.Tasks.MarkInAppSent(ctx, task.ID); err != nil {
+			return fmt.Errorf("failed to update task status: %w", err)
+		}
+		return nil
+	})
+}
+
+// createRecipientNotifications notifies the recipients of a request (e.g.
+// users mentioned in a comment, or those of the task creator and assignee
+// whose preferences allow it). Without a title and message, the task's are
+// used.
+func (s *InAppNotificationService) createRecipientNotifications(ctx context.Context, repos commons.Repositories, task *commons.Task, request NotificationRequest) error {
+	title := request.Title
+	if title == "" {
+		title = task.Title
+	}
+	description := request.Message
+	if description == "" {
+		description = task.Description
 	}
 
-	if err := s.processNotificationEvents(ctx, taskID, correlationID); err != nil {
-		return fmt.Errorf("failed to process notification events: %w", err)
+	for _, recipientID := range request.RecipientIDs {
+		notification := commons.InAppNotification{
+			UserID:      recipientID,
+			Title:       title,
+			Description: description,
+		}
+
+		if _, err := repos.InAppNotifications.Create(ctx, notification); err != nil {
+			return fmt.Errorf("failed to create notification for %s: %w", recipientID, err)
+		}
 	}
 
-	return s.updateTaskStatus(ctx, &task)
+	return nil
 }
 
-func (s *InAppNotificationService) createInAppNotification(_ context.Context, task *commons.Task) error {
+func (s *InAppNotificationService) createInAppNotification(ctx context.Context, repos commons.Repositories, task *commons.Task) error {
 	// Create notification for the task creator
 	creatorNotification := commons.InAppNotification{
 		UserID:      task.CreatorID,
@@ -51,7 +100,7 @@ func (s *InAppNotificationService) createInAppNotification(_ context.Context, ta
 		Description: task.Description,
 	}
 
-	_, err := s.inAppNotificationRepository.Create(creatorNotification)
+	_, err := repos.InAppNotifications.Create(ctx, creatorNotification)
 	if err != nil {
 		return fmt.Errorf("failed to create notification for creator: %w", err)
 	}
@@ -64,7 +113,7 @@ func (s *InAppNotificationService) createInAppNotification(_ context.Context, ta
 			Description: task.Description,
 		}
 
-		_, err := s.inAppNotificationRepository.Create(assigneeNotification)
+		_, err := repos.InAppNotifications.Create(ctx, assigneeNotification)
 		if err != nil {
 			return fmt.Errorf("failed to create notification for assignee: %w", err)
 		}
@@ -73,52 +122,18 @@ func (s *InAppNotificationService) createInAppNotification(_ context.Context, ta
 	return nil
 }
 
-func (s *InAppNotificationService) processNotificationEvents(ctx context.Context, taskID, correlationID string) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, 1)
-
-	wg.Add(1)
-
-	go s.createSystemEvent(ctx, &wg, errChan, taskID, correlationID, "Notification Service",
-		"notification:db:in-app-notification-created", "In-app notification created in database", 6)
-
-	wg.Wait()
-	close(errChan)
-
-	for err := range errChan {
-		if err != nil {
-			return fmt.Errorf("error processing notification events: %w", err)
-		}
-	}
-
-	return nil
-}
-
-func (s *InAppNotificationService) createSystemEvent(_ context.Context, wg *sync.WaitGroup, errChan chan<- error,
-	taskID, correlationID, origin, action, message string, priority int) {
-	defer wg.Done()
-
+func (s *InAppNotificationService) createSystemEvent(ctx context.Context, repos commons.Repositories, taskID, correlationID string) error {
 	event := commons.TaskSystemEvent{
 		TaskId:        taskID,
 		CorrelationId: correlationID,
-		Origin:        origin,
-		Action:        action,
-		Message:       message,
+		Origin:        "Notification Service",
+		Action:        "notification:db:in-app-notification-created",
+		Message:       "In-app notification created in database",
 		JsonData:      "{}",
 	}
 
-	_, err := s.taskSystemEventRepository.Create(event, priority)
-	if err != nil {
-		errChan <- fmt.Errorf("failed to create system event: %w", err)
-	}
-}
-
-func (s *InAppNotificationService) updateTaskStatus(_ context.Context, task *commons.Task) error {
-	task.InAppSent = true
-
-	err := s.taskRepository.Update(*task)
-	if err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
+	if _, err := repos.TaskSystemEvents.Create(ctx, event, 6); err != nil {
+		return fmt.Errorf("failed to create system event: %w", err)
 	}
 	return nil
 }
@@ -17,6 +17,10 @@ func (s *InAppNotificationStrategy) CanProcess(types []string) bool {
 	return slices.Contains(types, "IN_APP")
 }
 
-func (s *InAppNotificationStrategy) Process(ctx context.Context, taskID string, correlationID string, types []string) error {
-	return s.inAppService.Handle(ctx, taskID, correlationID, types)
+func (s *InAppNotificationStrategy) Type() string {
+	return "IN_APP"
+}
+
+func (s *InAppNotificationStrategy) Process(ctx context.Context, request NotificationRequest) error {
+	return s.inAppService.Handle(ctx, request)
 }
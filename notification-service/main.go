@@ -2,43 +2,75 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	commons "sama/go-task-management/commons"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 )
 
 var grpcServerAddr = commons.GetEnv("NOTIFICATION_SERVICE_ADDRESS", "localhost:2000")
 
+var logger = commons.DefaultLogger()
+
 func main() {
+	commons.SetDefaultLogger(commons.NewLogger("notification-service"))
+
+	if commons.IsMigrateCommand(os.Args) {
+		if err := commons.RunMigrateCLI(os.Args[2:]); err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	grpcServer := grpc.NewServer()
+	shutdownTracing, err := commons.InitTracing(ctx, "notification-service")
+	if err != nil {
+		logger.Error("Failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+
+	metricsServer := commons.StartMetricsServer(commons.GetEnv("METRICS_ADDRESS", defaultMetricsAddress))
 
 	l, err := net.Listen("tcp", grpcServerAddr)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		logger.Error("Failed to listen", "address", grpcServerAddr, "error", err)
+		os.Exit(1)
 	}
 	defer l.Close()
 
 	dbConnection, err := commons.GetConnection()
 	if err != nil {
-		log.Fatalf("Failed to get database connection: %v", err)
+		logger.Error("Failed to get database connection", "error", err)
+		os.Exit(1)
 	}
 	defer dbConnection.Close()
 
 	taskRepository := commons.NewPostgresTaskRepository(dbConnection)
 	taskSystemEventRepository := commons.NewPostgresTaskSystemEventRepository(dbConnection)
-	inAppNotificationRepository := commons.NewPostgresInAppNotificationRepository(dbConnection)
+	userRepository := commons.NewPostgresUserRepository(dbConnection)
+	reminderRepository := commons.NewPostgresReminderRepository(dbConnection)
+	preferenceRepository := commons.NewPostgresNotificationPreferenceRepository(dbConnection)
+	digestRepository := commons.NewPostgresDigestRepository(dbConnection)
+	deferredRepository := commons.NewPostgresDeferredNotificationRepository(dbConnection)
+	smsRepository := commons.NewPostgresSmsRepository(dbConnection)
+	webhookRepository := commons.NewPostgresWebhookRepository(dbConnection)
+	unitOfWork := commons.NewPostgresUnitOfWork(dbConnection)
 
 	sqsClient, err := NewSQSClient(ctx, Config{
 		AWSEndpoint: commons.GetEnv("AWS_ENDPOINT", ""),
@@ -46,30 +78,159 @@ func main() {
 		QueueName:   commons.GetEnv("QUEUE_NAME", defaultQueueName),
 	})
 	if err != nil {
-		log.Fatalf("Failed to create SQS client: %v", err)
+		logger.Error("Failed to create SQS client", "error", err)
+		os.Exit(1)
 	}
 
-	inAppService := NewInAppNotificationService(taskRepository, taskSystemEventRepository, inAppNotificationRepository)
-	emailService := NewEmailNotificationService(taskRepository, taskSystemEventRepository, sqsClient)
+	inAppService := NewInAppNotificationService(taskRepository, unitOfWork)
+	emailService := NewEmailNotificationService(taskRepository, taskSystemEventRepository, userRepository, sqsClient)
 
-	NewGrpcHandler(grpcServer, inAppService, emailService)
+	smsProvider, err := newSmsProvider()
+	if err != nil {
+		logger.Error("Failed to create SMS provider", "error", err)
+		os.Exit(1)
+	}
+	smsRateLimit, err := strconv.Atoi(commons.GetEnv("SMS_RATE_LIMIT", strconv.Itoa(defaultSmsRateLimit)))
+	if err != nil || smsRateLimit < 1 {
+		logger.Error("Invalid SMS_RATE_LIMIT", "value", commons.GetEnv("SMS_RATE_LIMIT", ""))
+		os.Exit(1)
+	}
+	smsRateLimitWindow, err := strconv.Atoi(commons.GetEnv("SMS_RATE_LIMIT_WINDOW_SECONDS", strconv.Itoa(defaultSmsRateLimitWindowSeconds)))
+	if err != nil || smsRateLimitWindow < 1 {
+		logger.Error("Invalid SMS_RATE_LIMIT_WINDOW_SECONDS", "value", commons.GetEnv("SMS_RATE_LIMIT_WINDOW_SECONDS", ""))
+		os.Exit(1)
+	}
+	smsService := NewSmsNotificationService(
+		taskRepository,
+		taskSystemEventRepository,
+		userRepository,
+		smsRepository,
+		unitOfWork,
+		smsProvider,
+		smsRateLimit,
+		time.Duration(smsRateLimitWindow)*time.Second,
+	)
+
+	dispatcher := NewDispatcher(
+		NewNotificationStrategies(inAppService, emailService, smsService),
+		taskRepository,
+		taskSystemEventRepository,
+		preferenceRepository,
+		digestRepository,
+		deferredRepository,
+		webhookRepository,
+	)
+
+	requestTimeout, err := strconv.Atoi(commons.GetEnv("REQUEST_TIMEOUT_SECONDS", strconv.Itoa(defaultRequestTimeoutSeconds)))
+	if err != nil {
+		logger.Error("Invalid REQUEST_TIMEOUT_SECONDS", "error", err)
+		os.Exit(1)
+	}
 
-	log.Println("Notifications service started at", grpcServerAddr)
+	NewGrpcHandler(grpcServer, dispatcher, time.Duration(requestTimeout)*time.Second)
+
+	reminderInterval, err := strconv.Atoi(commons.GetEnv("REMINDER_INTERVAL_SECONDS", strconv.Itoa(defaultReminderIntervalSeconds)))
+	if err != nil || reminderInterval < 1 {
+		logger.Error("Invalid REMINDER_INTERVAL_SECONDS", "value", commons.GetEnv("REMINDER_INTERVAL_SECONDS", ""))
+		os.Exit(1)
+	}
+
+	reminderScheduler := NewReminderScheduler(
+		taskRepository,
+		taskSystemEventRepository,
+		reminderRepository,
+		unitOfWork,
+		dispatcher,
+		time.Duration(reminderInterval)*time.Second,
+	)
+	go reminderScheduler.Start(ctx)
+
+	digestInterval, err := strconv.Atoi(commons.GetEnv("DIGEST_INTERVAL_SECONDS", strconv.Itoa(defaultDigestIntervalSeconds)))
+	if err != nil || digestInterval < 1 {
+		logger.Error("Invalid DIGEST_INTERVAL_SECONDS", "value", commons.GetEnv("DIGEST_INTERVAL_SECONDS", ""))
+		os.Exit(1)
+	}
+	digestHour, err := strconv.Atoi(commons.GetEnv("DIGEST_HOUR", strconv.Itoa(defaultDigestHour)))
+	if err != nil || digestHour < 0 || digestHour > 23 {
+		logger.Error("Invalid DIGEST_HOUR", "value", commons.GetEnv("DIGEST_HOUR", ""))
+		os.Exit(1)
+	}
+	digestWeekday, err := strconv.Atoi(commons.GetEnv("DIGEST_WEEKDAY", strconv.Itoa(int(defaultDigestWeekday))))
+	if err != nil || digestWeekday < 0 || digestWeekday > 6 {
+		logger.Error("Invalid DIGEST_WEEKDAY", "value", commons.GetEnv("DIGEST_WEEKDAY", ""))
+		os.Exit(1)
+	}
+
+	digestScheduler := NewDigestScheduler(
+		digestRepository,
+		unitOfWork,
+		emailService,
+		DigestSchedule{Hour: digestHour, Weekday: time.Weekday(digestWeekday)},
+		time.Duration(digestInterval)*time.Second,
+	)
+	go digestScheduler.Start(ctx)
+
+	deferredInterval, err := strconv.Atoi(commons.GetEnv("DEFERRED_INTERVAL_SECONDS", strconv.Itoa(defaultDeferredIntervalSeconds)))
+	if err != nil || deferredInterval < 1 {
+		logger.Error("Invalid DEFERRED_INTERVAL_SECONDS", "value", commons.GetEnv("DEFERRED_INTERVAL_SECONDS", ""))
+		os.Exit(1)
+	}
+
+	deferredScheduler := NewDeferredNotificationScheduler(
+		deferredRepository,
+		preferenceRepository,
+		dispatcher,
+		time.Duration(deferredInterval)*time.Second,
+	)
+	go deferredScheduler.Start(ctx)
+
+	logger.Info("Notifications service started", "address", grpcServerAddr)
 
 	go func() {
 		if err := grpcServer.Serve(l); err != nil {
-			log.Printf("Failed to serve: %v", err)
+			logger.Error("Failed to serve", "error", err)
 			cancel()
 		}
 	}()
 
 	select {
 	case <-sigChan:
-		log.Println("Received shutdown signal")
+		logger.Info("Received shutdown signal")
 	case <-ctx.Done():
-		log.Println("Context cancelled")
+		logger.Info("Context cancelled")
 	}
 
 	grpcServer.GracefulStop()
-	log.Println("Server stopped gracefully")
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFlush()
+	if err := metricsServer.Shutdown(flushCtx); err != nil {
+		logger.Error("Failed to stop metrics server", "error", err)
+	}
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("Failed to flush traces", "error", err)
+	}
+	logger.Info("Server stopped")
+}
+
+// newSmsProvider returns the SMS provider selected by SMS_PROVIDER: "http"
+// for the HTTP API at SMS_PROVIDER_URL, or "fake" to only log that SMS were
+// sent.
+func newSmsProvider() (SmsProvider, error) {
+	switch provider := commons.GetEnv("SMS_PROVIDER", defaultSmsProvider); provider {
+	case "http":
+		url := commons.GetEnv("SMS_PROVIDER_URL", "")
+		if url == "" {
+			return nil, fmt.Errorf("SMS_PROVIDER_URL is required for the http SMS provider")
+		}
+		timeout, err := strconv.Atoi(commons.GetEnv("SMS_TIMEOUT_SECONDS", strconv.Itoa(defaultSmsTimeoutSeconds)))
+		if err != nil || timeout < 1 {
+			return nil, fmt.Errorf("invalid SMS_TIMEOUT_SECONDS: %s", commons.GetEnv("SMS_TIMEOUT_SECONDS", ""))
+		}
+		return NewHTTPSmsProvider(url, commons.GetEnv("SMS_PROVIDER_API_KEY", ""), commons.GetEnv("SMS_FROM", ""), time.Duration(timeout)*time.Second), nil
+	case "fake":
+		return NewFakeSmsProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_PROVIDER: %s", provider)
+	}
 }
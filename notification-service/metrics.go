@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	notificationRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notification_service_grpc_requests_total",
+		Help: "SendNotification calls, by requested notification type and gRPC status code.",
+	}, []string{"type", "code"})
+
+	strategyFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notification_service_strategy_failures_total",
+		Help: "Notifications a strategy failed to deliver, by notification type and event.",
+	}, []string{"type", "event"})
+
+	sqsSendDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "notification_service_sqs_send_duration_seconds",
+		Help:    "Time taken to send a message to the email queue, by outcome (success or error).",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"outcome"})
+)
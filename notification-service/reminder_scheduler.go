@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	commons "sama/go-task-management/commons"
+
+	"github.com/google/uuid"
+)
+
+// overdueWindow is how long after the due date an overdue reminder is still
+// sent, so tasks that were overdue long before the scheduler started are
+// left alone.
+const overdueWindow = 24 * time.Hour
+
+// ReminderScheduler reminds the creator and assignee of open tasks before
+// their due date, at the lead times each of them chose, and once the task is
+// overdue, on the channels their notification preferences allow. Every
+// reminder is claimed in the database before it is sent, so it fires once
+// even with several notification services running.
+type ReminderScheduler struct {
+	taskRepository            commons.TaskRepositoryInterface
+	taskSystemEventRepository commons.TaskSystemEventRepositoryInterface
+	reminderRepository        commons.ReminderRepositoryInterface
+	unitOfWork                commons.UnitOfWork
+	dispatcher                *Dispatcher
+	interval                  time.Duration
+}
+
+func NewReminderScheduler(
+	taskRepo commons.TaskRepositoryInterface,
+	taskSystemEventRepo commons.TaskSystemEventRepositoryInterface,
+	reminderRepo commons.ReminderRepositoryInterface,
+	unitOfWork commons.UnitOfWork,
+	dispatcher *Dispatcher,
+	interval time.Duration,
+) *ReminderScheduler {
+	return &ReminderScheduler{
+		taskRepository:            taskRepo,
+		taskSystemEventRepository: taskSystemEventRepo,
+		reminderRepository:        reminderRepo,
+		unitOfWork:                unitOfWork,
+		dispatcher:                dispatcher,
+		interval:                  interval,
+	}
+}
+
+// Start sends due reminders until ctx is canceled.
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) sendDue(ctx context.Context) {
+	now := time.Now().UTC()
+	tasks, err := s.taskRepository.ListOpenDueBetween(ctx, now.Add(-overdueWindow), now.Add(commons.MaxReminderLeadTimeMinutes*time.Minute))
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to list tasks for reminders", "error", err)
+		return
+	}
+
+	leadTimes := make(map[string][]int)
+	for _, task := range tasks {
+		for _, userID := range taskRecipients(task) {
+			if ctx.Err() != nil {
+				return
+			}
+
+			userLeadTimes, ok := leadTimes[userID]
+			if !ok {
+				userLeadTimes, err = s.reminderRepository.GetLeadTimes(ctx, userID)
+				if err != nil {
+					logger.ErrorContext(ctx, "Failed to get reminder lead times", "recipient_id", userID, "error", err)
+					continue
+				}
+				if len(userLeadTimes) == 0 {
+					userLeadTimes = commons.DefaultReminderLeadTimes
+				}
+				leadTimes[userID] = userLeadTimes
+			}
+
+			leadTime, ok := dueLeadTime(task.DueDate, userLeadTimes, now)
+			if !ok {
+				continue
+			}
+
+			s.send(ctx, task, userID, leadTime)
+		}
+	}
+}
+
+// dueLeadTime returns the lead time whose reminder is due now: the smallest
+// one that has been reached, or 0 once the task is overdue. Reminders with
+// larger lead times that were missed, e.g. because the task was created
+// shortly before its due date, are not sent anymore.
+func dueLeadTime(dueDate time.Time, leadTimes []int, now time.Time) (int, bool) {
+	found := false
+	best := 0
+	for _, leadTime := range leadTimes {
+		if dueDate.Add(-time.Duration(leadTime) * time.Minute).After(now) {
+			continue
+		}
+		if leadTime > 0 && !now.Before(dueDate) {
+			continue
+		}
+		if !found || leadTime < best {
+			best = leadTime
+			found = true
+		}
+	}
+	return best, found
+}
+
+// send sends a reminder on each channel of due-soon notifications.
+func (s *ReminderScheduler) send(ctx context.Context, task commons.Task, userID string, leadTime int) {
+	correlationID := uuid.New().String()
+	for _, channel := range commons.NotificationEventChannels[commons.NotificationEventTaskDueSoon] {
+		reminder := commons.TaskReminder{
+			TaskID:          task.ID,
+			UserID:          userID,
+			LeadTimeMinutes: leadTime,
+			DueDate:         task.DueDate,
+			Channel:         channel,
+			CorrelationID:   correlationID,
+			SentAt:          time.Now(),
+		}
+		if err := s.sendOn(ctx, task, reminder); err != nil {
+			logger.ErrorContext(ctx, "Failed to send reminder", "task_id", task.ID, "recipient_id", userID, "channel", channel, "error", err)
+		}
+	}
+}
+
+// sendOn claims a reminder on its channel and dispatches it there. Every
+// channel is claimed on its own, so if sending fails only the claim of that
+// channel is rolled back and tried again, and the others are not sent twice.
+// The reminder is recorded as a system event once its claim is committed.
+func (s *ReminderScheduler) sendOn(ctx context.Context, task commons.Task, reminder commons.TaskReminder) error {
+	title := "Due soon: " + task.Title
+	message := "Due on " + task.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST")
+	action := "notification:reminder:due-soon"
+	if reminder.LeadTimeMinutes == 0 {
+		title = "Overdue: " + task.Title
+		message = "Was due on " + task.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST")
+		action = "notification:reminder:overdue"
+	}
+
+	dispatched := false
+	err := s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		claimed, err := repos.Reminders.Claim(ctx, reminder)
+		if err != nil {
+			return fmt.Errorf("failed to claim reminder: %w", err)
+		}
+		if !claimed {
+			return nil
+		}
+
+		dispatched, err = s.dispatcher.DispatchOn(ctx, NotificationRequest{
+			TaskID:        task.ID,
+			CorrelationID: reminder.CorrelationID,
+			Event:         commons.NotificationEventTaskDueSoon,
+			RecipientIDs:  []string{reminder.UserID},
+			Title:         title,
+			Message:       message,
+		}, reminder.Channel)
+		if err != nil {
+			return fmt.Errorf("failed to dispatch reminder: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !dispatched {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"user_id":           reminder.UserID,
+		"lead_time_minutes": reminder.LeadTimeMinutes,
+		"channel":           reminder.Channel,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.taskSystemEventRepository.Create(ctx, commons.TaskSystemEvent{
+		TaskId:        task.ID,
+		CorrelationId: reminder.CorrelationID,
+		Origin:        "Notification Service",
+		Action:        action,
+		Message:       "Due date reminder sent",
+		JsonData:      string(data),
+	}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to record reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDueLeadTime(t *testing.T) {
+	due := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	leadTimes := []int{7 * 24 * 60, 24 * 60, 60, 0}
+
+	tests := []struct {
+		name      string
+		leadTimes []int
+		now       time.Time
+		want      int
+		wantOK    bool
+	}{
+		{"before the largest lead time", leadTimes, due.Add(-8 * 24 * time.Hour), 0, false},
+		{"at the largest lead time", leadTimes, due.Add(-7 * 24 * time.Hour), 7 * 24 * 60, true},
+		{"between two lead times", leadTimes, due.Add(-3 * 24 * time.Hour), 7 * 24 * 60, true},
+		{"at a smaller lead time", leadTimes, due.Add(-24 * time.Hour), 24 * 60, true},
+		{"just before a smaller lead time", leadTimes, due.Add(-time.Hour - time.Second), 24 * 60, true},
+		{"larger lead times already missed", leadTimes, due.Add(-30 * time.Minute), 60, true},
+		{"at the due date", leadTimes, due, 0, true},
+		{"overdue", leadTimes, due.Add(2 * time.Hour), 0, true},
+		{"overdue without an overdue reminder", []int{24 * 60, 60}, due.Add(time.Minute), 0, false},
+		{"lead times in any order", []int{0, 60, 24 * 60}, due.Add(-2 * time.Hour), 24 * 60, true},
+		{"no lead times", nil, due.Add(time.Hour), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := dueLeadTime(due, tt.leadTimes, tt.now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("dueLeadTime() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SmsProvider sends SMS. Send returns the provider's ID of the message.
+type SmsProvider interface {
+	Send(ctx context.Context, to string, body string) (string, error)
+}
+
+// HTTPSmsProvider sends SMS through an HTTP API that takes
+// {"from", "to", "body"} as JSON, authenticated with a bearer token, and
+// answers with {"id"}.
+type HTTPSmsProvider struct {
+	url    string
+	apiKey string
+	from   string
+	client *http.Client
+}
+
+func NewHTTPSmsProvider(url string, apiKey string, from string, timeout time.Duration) *HTTPSmsProvider {
+	return &HTTPSmsProvider{
+		url:    url,
+		apiKey: apiKey,
+		from:   from,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *HTTPSmsProvider) Send(ctx context.Context, to string, body string) (string, error) {
+	payload, err := json.Marshal(map[string]string{
+		"from": p.from,
+		"to":   to,
+		"body": body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal SMS: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to create SMS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send SMS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read SMS provider response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("SMS provider responded with %d: %s", resp.StatusCode, respBody)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("invalid SMS provider response: %w", err)
+	}
+	return result.ID, nil
+}
+
+// FakeSms is an SMS the FakeSmsProvider pretended to send.
+type FakeSms struct {
+	ID   string
+	To   string
+	Body string
+}
+
+// FakeSmsProvider keeps SMS instead of sending them, for local development.
+// It logs that an SMS was sent, but not its body, which may hold a
+// verification code.
+type FakeSmsProvider struct {
+	mu   sync.Mutex
+	sent []FakeSms
+}
+
+func NewFakeSmsProvider() *FakeSmsProvider {
+	return &FakeSmsProvider{}
+}
+
+func (p *FakeSmsProvider) Send(ctx context.Context, to string, body string) (string, error) {
+	sms := FakeSms{ID: "fake-" + uuid.New().String(), To: to, Body: body}
+	logger.InfoContext(ctx, "Fake SMS", "sms_id", sms.ID, "to", to, "length", len([]rune(body)))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = append(p.sent, sms)
+	return sms.ID, nil
+}
+
+// Sent returns the SMS sent so far.
+func (p *FakeSmsProvider) Sent() []FakeSms {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]FakeSms(nil), p.sent...)
+}
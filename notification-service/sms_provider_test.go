@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPSmsProviderSend(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantID  string
+		wantErr string
+	}{
+		{"accepted", http.StatusOK, `{"id":"sms-1"}`, "sms-1", ""},
+		{"created", http.StatusCreated, `{"id":"sms-2"}`, "sms-2", ""},
+		{"rejected", http.StatusTooManyRequests, `slow down`, "", "SMS provider responded with 429: slow down"},
+		{"server error", http.StatusInternalServerError, ``, "", "SMS provider responded with 500"},
+		{"invalid response", http.StatusOK, `<html>`, "", "invalid SMS provider response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request *http.Request
+			var payload map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				request = r
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					t.Errorf("request body is not JSON: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewHTTPSmsProvider(server.URL, "api-key", "+15550000000", time.Second)
+			id, err := provider.Send(context.Background(), "+4915112345678", "Due soon: Report")
+
+			if request.Method != http.MethodPost || request.Header.Get("Authorization") != "Bearer api-key" || request.Header.Get("Content-Type") != "application/json" {
+				t.Fatalf("request is %s with Authorization %q and Content-Type %q, want a JSON POST with the API key as bearer token",
+					request.Method, request.Header.Get("Authorization"), request.Header.Get("Content-Type"))
+			}
+			if payload["from"] != "+15550000000" || payload["to"] != "+4915112345678" || payload["body"] != "Due soon: Report" {
+				t.Fatalf("request payload = %v, want the sender, recipient and body", payload)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Send() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || id != tt.wantID {
+				t.Fatalf("Send() = %q, %v, want %q", id, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestHTTPSmsProviderSendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	provider := NewHTTPSmsProvider(server.URL, "api-key", "+15550000000", 50*time.Millisecond)
+	if _, err := provider.Send(context.Background(), "+4915112345678", "body"); err == nil {
+		t.Fatalf("Send() to a provider that does not answer succeeded, want an error")
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	commons "sama/go-task-management/commons"
+)
+
+// maxSmsLength is the length SMS bodies are cut to, two SMS segments.
+const maxSmsLength = 320
+
+// redactedSmsBody is stored instead of the body of SMS that carry a phone
+// verification code, so the code is only ever stored hashed.
+const redactedSmsBody = "[phone verification code]"
+
+// SmsNotificationService sends SMS to the verified phone numbers of the
+// recipients of a notification. Every SMS is recorded first, so a
+// redelivered notification does not send it twice, and users who got too
+// many SMS lately get no more until the rate limit window has passed.
+type SmsNotificationService struct {
+	taskRepository            commons.TaskRepositoryInterface
+	taskSystemEventRepository commons.TaskSystemEventRepositoryInterface
+	userRepository            commons.UserRepositoryInterface
+	smsRepository             commons.SmsRepositoryInterface
+	unitOfWork                commons.UnitOfWork
+	provider                  SmsProvider
+	rateLimit                 int
+	rateLimitWindow           time.Duration
+}
+
+func NewSmsNotificationService(
+	taskRepo commons.TaskRepositoryInterface,
+	eventRepo commons.TaskSystemEventRepositoryInterface,
+	userRepo commons.UserRepositoryInterface,
+	smsRepo commons.SmsRepositoryInterface,
+	unitOfWork commons.UnitOfWork,
+	provider SmsProvider,
+	rateLimit int,
+	rateLimitWindow time.Duration,
+) *SmsNotificationService {
+	return &SmsNotificationService{
+		taskRepository:            taskRepo,
+		taskSystemEventRepository: eventRepo,
+		userRepository:            userRepo,
+		smsRepository:             smsRepo,
+		unitOfWork:                unitOfWork,
+		provider:                  provider,
+		rateLimit:                 rateLimit,
+		rateLimitWindow:           rateLimitWindow,
+	}
+}
+
+// Handle sends request to each of its recipients, or to the task's creator
+// and assignee without explicit recipients. Recipients without a verified
+// phone number are skipped; phone verification codes go to the number that
+// is being verified.
+func (s *SmsNotificationService) Handle(ctx context.Context, request NotificationRequest) error {
+	recipients := request.RecipientIDs
+	if len(recipients) == 0 && request.TaskID != "" {
+		task, err := s.taskRepository.GetByID(ctx, request.TaskID)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+		recipients = taskRecipients(task)
+	}
+
+	title, message := request.Title, request.Message
+	if request.TaskID != "" {
+		var err error
+		title, message, err = notificationText(ctx, s.taskRepository, request)
+		if err != nil {
+			return err
+		}
+	}
+	body := smsBody(title, message)
+	if body == "" {
+		return fmt.Errorf("SMS notification requires a title or message")
+	}
+
+	var errs []error
+	for _, userID := range recipients {
+		if err := s.sendTo(ctx, request, userID, body); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send SMS to user %s: %w", userID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *SmsNotificationService) sendTo(ctx context.Context, request NotificationRequest, userID string, body string) error {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.ID == "" || user.PhoneNumber == "" {
+		logger.InfoContext(ctx, "Skipping SMS: no phone number", "recipient_id", userID)
+		return nil
+	}
+	if user.PhoneVerifiedAt == nil && request.Event != commons.NotificationEventPhoneVerification {
+		logger.InfoContext(ctx, "Skipping SMS: phone number not verified", "recipient_id", userID)
+		return nil
+	}
+
+	var taskID *string
+	if request.TaskID != "" {
+		taskID = &request.TaskID
+	}
+
+	storedBody := body
+	if request.Event == commons.NotificationEventPhoneVerification {
+		storedBody = redactedSmsBody
+	}
+
+	// Queueing and the rate limit check are serialised per recipient, so
+	// concurrent notifications cannot both slip under the limit. Once
+	// committed, the queued SMS counts against the limit of later ones.
+	var record commons.SmsMessage
+	var processed, limited bool
+	reason := fmt.Sprintf("rate limit of %d SMS per %s reached", s.rateLimit, s.rateLimitWindow)
+	err = s.unitOfWork.Do(ctx, func(repos commons.Repositories) error {
+		if err := repos.Sms.LockRecipient(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to lock SMS recipient: %w", err)
+		}
+
+		var err error
+		record, err = repos.Sms.Queue(ctx, commons.SmsMessage{
+			UserID:        user.ID,
+			TaskID:        taskID,
+			CorrelationID: request.CorrelationID,
+			PhoneNumber:   user.PhoneNumber,
+			Body:          storedBody,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to store SMS: %w", err)
+		}
+		if record.Status == commons.SmsStatusSent || record.Status == commons.SmsStatusRateLimited {
+			processed = true
+			return nil
+		}
+
+		// The count includes the SMS just queued.
+		count, err := repos.Sms.CountSince(ctx, user.ID, time.Now().Add(-s.rateLimitWindow))
+		if err != nil {
+			return fmt.Errorf("failed to count recent SMS: %w", err)
+		}
+		if count > s.rateLimit {
+			limited = true
+			if err := repos.Sms.MarkFailed(ctx, record.ID, commons.SmsStatusRateLimited, reason); err != nil {
+				return fmt.Errorf("failed to update SMS: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if processed {
+		logger.InfoContext(ctx, "SMS already processed, skipping", "sms_id", record.ID)
+		return nil
+	}
+	if limited {
+		logger.WarnContext(ctx, "Not sending SMS", "sms_id", record.ID, "recipient_id", user.ID, "reason", reason)
+		return s.createSystemEvent(ctx, request, "notification:event:sms-rate-limited", "SMS not sent: rate limit reached", user.ID)
+	}
+
+	messageID, err := s.provider.Send(ctx, record.PhoneNumber, body)
+	if err != nil {
+		if markErr := s.smsRepository.MarkFailed(ctx, record.ID, commons.SmsStatusFailed, err.Error()); markErr != nil {
+			logger.ErrorContext(ctx, "Failed to update SMS", "sms_id", record.ID, "error", markErr)
+		}
+		return err
+	}
+
+	// The SMS is out; failing now would only send it twice.
+	if err := s.smsRepository.MarkSent(context.WithoutCancel(ctx), record.ID, messageID); err != nil {
+		logger.ErrorContext(ctx, "Failed to update SMS", "sms_id", record.ID, "error", err)
+	}
+
+	logger.InfoContext(ctx, "Sent SMS", "sms_id", record.ID, "recipient_id", user.ID)
+	return s.createSystemEvent(ctx, request, "notification:event:sms-sent", "SMS sent", user.ID)
+}
+
+// createSystemEvent records the outcome of an SMS of a task notification.
+func (s *SmsNotificationService) createSystemEvent(ctx context.Context, request NotificationRequest, action, message, userID string) error {
+	if request.TaskID == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]string{"user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.taskSystemEventRepository.Create(ctx, commons.TaskSystemEvent{
+		TaskId:        request.TaskID,
+		CorrelationId: request.CorrelationID,
+		Origin:        "Notification Service",
+		Action:        action,
+		Message:       message,
+		JsonData:      string(data),
+	}, 10)
+	if err != nil {
+		return fmt.Errorf("failed to create system event: %w", err)
+	}
+	return nil
+}
+
+// smsBody joins title and message and cuts them to maxSmsLength.
+func smsBody(title, message string) string {
+	body := strings.TrimSpace(title + "\n" + message)
+	if runes := []rune(body); len(runes) > maxSmsLength {
+		body = string(runes[:maxSmsLength-1]) + "…"
+	}
+	return body
+}
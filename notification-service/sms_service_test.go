@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	commons "sama/go-task-management/commons"
+)
+
+func TestSmsBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		message string
+		want    string
+	}{
+		{"title and message", "Due soon: Report", "Due on Mon", "Due soon: Report\nDue on Mon"},
+		{"message only", "", "Your code is 123456.", "Your code is 123456."},
+		{"title only", "Report", "", "Report"},
+		{"empty", "", "", ""},
+		{"at the limit", "", strings.Repeat("ü", maxSmsLength), strings.Repeat("ü", maxSmsLength)},
+		{"over the limit", "", strings.Repeat("a", maxSmsLength+1), strings.Repeat("a", maxSmsLength-1) + "…"},
+		{"multi-byte over the limit", "Fällig", strings.Repeat("ü", maxSmsLength), "Fällig\n" + strings.Repeat("ü", maxSmsLength-8) + "…"},
+		{"emoji over the limit", "", strings.Repeat("📅", maxSmsLength+5), strings.Repeat("📅", maxSmsLength-1) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := smsBody(tt.title, tt.message)
+			if got != tt.want {
+				t.Fatalf("smsBody() = %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > maxSmsLength {
+				t.Fatalf("smsBody() has %d characters and valid UTF-8 = %v, want at most %d valid ones", utf8.RuneCountInString(got), utf8.ValidString(got), maxSmsLength)
+			}
+		})
+	}
+}
+
+type fakeSmsUserRepository struct {
+	commons.UserRepositoryInterface
+	users map[string]commons.User
+}
+
+func (r *fakeSmsUserRepository) GetByID(ctx context.Context, id string) (commons.User, error) {
+	return r.users[id], nil
+}
+
+// fakeSmsRepository records SMS like the sms_messages table: one per
+// notification and recipient.
+type fakeSmsRepository struct {
+	commons.SmsRepositoryInterface
+	messages []commons.SmsMessage
+}
+
+func (r *fakeSmsRepository) LockRecipient(ctx context.Context, userID string) error {
+	return nil
+}
+
+func (r *fakeSmsRepository) Queue(ctx context.Context, message commons.SmsMessage) (commons.SmsMessage, error) {
+	for _, queued := range r.messages {
+		if queued.CorrelationID == message.CorrelationID && queued.UserID == message.UserID {
+			return queued, nil
+		}
+	}
+	message.ID = fmt.Sprint(len(r.messages) + 1)
+	message.Status = commons.SmsStatusQueued
+	message.CreatedAt = time.Now()
+	r.messages = append(r.messages, message)
+	return message, nil
+}
+
+func (r *fakeSmsRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	count := 0
+	for _, message := range r.messages {
+		if message.UserID == userID && message.Status != commons.SmsStatusRateLimited && message.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeSmsRepository) MarkSent(ctx context.Context, id string, providerMessageID string) error {
+	return r.mark(id, commons.SmsStatusSent)
+}
+
+func (r *fakeSmsRepository) MarkFailed(ctx context.Context, id string, status string, lastError string) error {
+	return r.mark(id, status)
+}
+
+func (r *fakeSmsRepository) mark(id string, status string) error {
+	for i := range r.messages {
+		if r.messages[i].ID == id {
+			r.messages[i].Status = status
+			return nil
+		}
+	}
+	return commons.ErrNotFound
+}
+
+type fakeSmsUnitOfWork struct {
+	sms *fakeSmsRepository
+}
+
+func (u *fakeSmsUnitOfWork) Do(ctx context.Context, fn func(repos commons.Repositories) error) error {
+	return fn(commons.Repositories{Sms: u.sms})
+}
+
+func TestSmsRateLimitPerRecipient(t *testing.T) {
+	verified := time.Now()
+	users := &fakeSmsUserRepository{users: map[string]commons.User{
+		"ada":   {ID: "ada", PhoneNumber: "+4915100000001", PhoneVerifiedAt: &verified},
+		"grace": {ID: "grace", PhoneNumber: "+4915100000002", PhoneVerifiedAt: &verified},
+	}}
+	smsRepo := &fakeSmsRepository{}
+	provider := NewFakeSmsProvider()
+	service := NewSmsNotificationService(nil, nil, users, smsRepo, &fakeSmsUnitOfWork{sms: smsRepo}, provider, 2, time.Hour)
+
+	send := func(correlationID string, recipientIDs ...string) {
+		t.Helper()
+		err := service.Handle(context.Background(), NotificationRequest{CorrelationID: correlationID, RecipientIDs: recipientIDs, Title: "Reminder"})
+		if err != nil {
+			t.Fatalf("Handle(%s) failed: %v", correlationID, err)
+		}
+	}
+	send("1", "ada", "grace")
+	send("2", "ada")
+	send("2", "ada")
+	send("3", "ada")
+	send("4", "grace")
+
+	sent := make(map[string]int)
+	for _, sms := range provider.Sent() {
+		sent[sms.To]++
+	}
+	if sent["+4915100000001"] != 2 || sent["+4915100000002"] != 2 {
+		t.Fatalf("sent %v, want 2 SMS to each number", sent)
+	}
+
+	statuses := make(map[string]string)
+	for _, message := range smsRepo.messages {
+		statuses[message.UserID+"/"+message.CorrelationID] = message.Status
+	}
+	want := map[string]string{
+		"ada/1":   commons.SmsStatusSent,
+		"grace/1": commons.SmsStatusSent,
+		"ada/2":   commons.SmsStatusSent,
+		"ada/3":   commons.SmsStatusRateLimited,
+		"grace/4": commons.SmsStatusSent,
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Fatalf("SMS statuses = %v, want %v", statuses, want)
+	}
+}
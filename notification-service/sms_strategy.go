@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"slices"
+)
+
+type SmsNotificationStrategy struct {
+	smsService *SmsNotificationService
+}
+
+func NewSmsNotificationStrategy(service *SmsNotificationService) *SmsNotificationStrategy {
+	return &SmsNotificationStrategy{smsService: service}
+}
+
+func (s *SmsNotificationStrategy) CanProcess(types []string) bool {
+	return slices.Contains(types, "SMS")
+}
+
+func (s *SmsNotificationStrategy) Type() string {
+	return "SMS"
+}
+
+func (s *SmsNotificationStrategy) Process(ctx context.Context, request NotificationRequest) error {
+	return s.smsService.Handle(ctx, request)
+}
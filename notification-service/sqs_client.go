@@ -3,12 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
+
+	commons "sama/go-task-management/commons"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("sama/go-task-management/notification-service")
+
 type SQSClientInterface interface {
 	SendMessage(ctx context.Context, message string) error
 }
@@ -69,13 +79,44 @@ func getQueueURL(ctx context.Context, client *sqs.Client, queueName string) (*st
 	return result.QueueUrl, nil
 }
 
+// SendMessage sends message to the queue in a producer span. The W3C trace
+// context of the span goes along as message attributes, so that the
+// consumer continues the trace.
 func (c *SQSClient) SendMessage(ctx context.Context, message string) error {
-	_, err := c.client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    c.queueURL,
-		MessageBody: aws.String(message),
+	ctx, span := tracer.Start(ctx, "publish "+c.queueName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "aws_sqs"),
+			attribute.String("messaging.operation.type", "publish"),
+			attribute.String("messaging.destination.name", c.queueName),
+		),
+	)
+	defer span.End()
+
+	attributes := map[string]sqstypes.MessageAttributeValue{}
+	for key, value := range commons.InjectTraceContext(ctx) {
+		attributes[key] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	start := time.Now()
+	result, err := c.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:          c.queueURL,
+		MessageBody:       aws.String(message),
+		MessageAttributes: attributes,
 	})
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	sqsSendDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to send message to SQS: %w", err)
 	}
+	span.SetAttributes(attribute.String("messaging.message.id", aws.ToString(result.MessageId)))
 	return nil
 }
@@ -6,7 +6,24 @@ type NotificationServiceInterface interface {
 	Handle(ctx context.Context) error
 }
 
+// NotificationRequest is the transport independent form of a SendNotificationRequest.
+type NotificationRequest struct {
+	TaskID        string
+	CorrelationID string
+	Types         []string
+	Event         string
+	RecipientIDs  []string
+	Title         string
+	Message       string
+	Link          string
+	// TaskAudience is set when the recipients are the task's creator and
+	// assignee rather than recipients the caller chose.
+	TaskAudience bool
+}
+
 type NotificationStrategy interface {
 	CanProcess(types []string) bool
-	Process(ctx context.Context, taskID string, correlationID string, types []string) error
+	// Type is the notification type the strategy delivers, e.g. "EMAIL".
+	Type() string
+	Process(ctx context.Context, request NotificationRequest) error
 }